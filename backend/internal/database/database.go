@@ -55,6 +55,11 @@ func Init() error {
 				log.Println("🔧 接続プール最適化設定完了")
 			}
 
+			// 全文検索インデックスを保証（既存環境への後付け対応）
+			if err := EnsureFullTextIndexes(DB); err != nil {
+				log.Printf("⚠️ 全文検索インデックス設定失敗: %v", err)
+			}
+
 			return nil
 		}
 
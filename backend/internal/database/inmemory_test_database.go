@@ -106,11 +106,20 @@ func SetupInMemoryTestDB(testName string) (*gorm.DB, error) {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			year INTEGER NOT NULL,
 			month INTEGER NOT NULL,
+			period_type TEXT NOT NULL DEFAULT 'month',
+			period_start DATE,
+			period_end DATE,
+			period_label TEXT NOT NULL DEFAULT '',
 			requester_id INTEGER NOT NULL,
 			payer_id INTEGER NOT NULL,
-			status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'requested', 'paid')),
+			status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'requested', 'acknowledged', 'paid')),
 			request_date DATETIME,
+			require_acknowledgement BOOLEAN NOT NULL DEFAULT FALSE,
+			acknowledged_at DATETIME,
+			acknowledgement_comment TEXT NOT NULL DEFAULT '',
 			payment_date DATETIME,
+			comment TEXT NOT NULL DEFAULT '',
+			tax_rounding TEXT NOT NULL DEFAULT 'floor',
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (requester_id) REFERENCES users(id),
@@ -128,6 +137,10 @@ func SetupInMemoryTestDB(testName string) (*gorm.DB, error) {
 			bill_id INTEGER NOT NULL,
 			item_name TEXT NOT NULL,
 			amount REAL NOT NULL,
+			tax_rate INTEGER NOT NULL DEFAULT 10,
+			tax_exclusive BOOLEAN NOT NULL DEFAULT FALSE,
+			split_rule TEXT NOT NULL DEFAULT 'payer',
+			payer_share INTEGER NOT NULL DEFAULT 100,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (bill_id) REFERENCES monthly_bills(id)
@@ -137,6 +150,30 @@ func SetupInMemoryTestDB(testName string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("インメモリ家計簿項目テーブル作成失敗: %v", err)
 	}
 
+	// 予算・世帯・支払者連携関連テーブル作成（ENUMを含まないためAutoMigrateで作成）
+	err = db.AutoMigrate(
+		&models.Budget{},
+		&models.BudgetAlert{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.HouseholdInvitation{},
+		&models.PartnerInvitation{},
+		&models.PayerRelationship{},
+		&models.PaymentReversal{},
+		&models.BankStatementImport{},
+		&models.BankDeposit{},
+		&models.RefreshToken{},
+		&models.TokenBlacklistEntry{},
+		&models.UserSession{},
+		&models.RecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.PasswordResetToken{},
+		&models.LoginAttempt{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("インメモリ予算・世帯・支払者連携テーブル作成失敗: %v", err)
+	}
+
 	// 管理マップに登録
 	manager.databases[dbName] = db
 
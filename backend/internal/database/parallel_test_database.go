@@ -153,11 +153,33 @@ func createParallelTestDB(dbName string) (*gorm.DB, error) {
 		&models.User{},
 		&models.MonthlyBill{},
 		&models.BillItem{},
+		&models.Budget{},
+		&models.BudgetAlert{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.HouseholdInvitation{},
+		&models.PartnerInvitation{},
+		&models.PayerRelationship{},
+		&models.PaymentReversal{},
+		&models.BankStatementImport{},
+		&models.BankDeposit{},
+		&models.RefreshToken{},
+		&models.TokenBlacklistEntry{},
+		&models.UserSession{},
+		&models.RecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.PasswordResetToken{},
+		&models.LoginAttempt{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("並列テスト用テーブル作成失敗: %v", err)
 	}
 
+	// 検索API用の全文検索インデックスを作成
+	if err := EnsureFullTextIndexes(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
 
@@ -0,0 +1,62 @@
+// ========================================
+// 全文検索インデックス管理
+// MySQLのngramパーサーによるFULLTEXTインデックスを保証する
+// ========================================
+
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// fullTextIndex 全文検索インデックスの定義
+type fullTextIndex struct {
+	Table  string
+	Name   string
+	Column string
+}
+
+// fullTextIndexes 検索APIが利用する全文検索インデックス一覧
+var fullTextIndexes = []fullTextIndex{
+	{Table: "bill_items", Name: "ft_bill_items_item_name", Column: "item_name"},
+	{Table: "monthly_bills", Name: "ft_monthly_bills_comment", Column: "comment"},
+}
+
+// SupportsFullTextSearch データベースがFULLTEXT（ngram）検索に対応しているか判定
+// MySQL以外（SQLiteなど）ではLIKE検索にフォールバックする
+func SupportsFullTextSearch(db *gorm.DB) bool {
+	return db != nil && db.Dialector.Name() == "mysql"
+}
+
+// EnsureFullTextIndexes 全文検索インデックスが存在しない場合は作成する
+// 日本語検索のためngramパーサーを使用する（MySQL以外では何もしない）
+func EnsureFullTextIndexes(db *gorm.DB) error {
+	if !SupportsFullTextSearch(db) {
+		return nil
+	}
+
+	for _, index := range fullTextIndexes {
+		var count int64
+		err := db.Raw(`SELECT COUNT(*) FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`,
+			index.Table, index.Name).Scan(&count).Error
+		if err != nil {
+			return fmt.Errorf("全文検索インデックス確認失敗 %s: %v", index.Name, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		sql := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s) WITH PARSER ngram",
+			index.Name, index.Table, index.Column)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("全文検索インデックス作成失敗 %s: %v", index.Name, err)
+		}
+		log.Printf("🔎 全文検索インデックスを作成しました: %s.%s", index.Table, index.Name)
+	}
+
+	return nil
+}
@@ -92,6 +92,23 @@ func safeAutoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.MonthlyBill{},
 		&models.BillItem{},
+		&models.Budget{},
+		&models.BudgetAlert{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.HouseholdInvitation{},
+		&models.PartnerInvitation{},
+		&models.PayerRelationship{},
+		&models.PaymentReversal{},
+		&models.BankStatementImport{},
+		&models.BankDeposit{},
+		&models.RefreshToken{},
+		&models.TokenBlacklistEntry{},
+		&models.UserSession{},
+		&models.RecoveryCode{},
+		&models.TwoFactorChallenge{},
+		&models.PasswordResetToken{},
+		&models.LoginAttempt{},
 	}
 
 	for _, model := range models {
@@ -100,6 +117,11 @@ func safeAutoMigrate(db *gorm.DB) error {
 		}
 	}
 
+	// 検索API用の全文検索インデックスを作成
+	if err := EnsureFullTextIndexes(db); err != nil {
+		return err
+	}
+
 	log.Printf("✅ 統合テスト用データベース初期化完了")
 	return nil
 }
@@ -112,7 +134,7 @@ func dropTablesIfExistsWithRetry(db *gorm.DB) error {
 	}
 
 	// 外部キー制約の逆順でテーブル削除
-	tables := []string{"bill_items", "monthly_bills", "users"}
+	tables := []string{"login_attempts", "password_reset_tokens", "two_factor_challenges", "recovery_codes", "user_sessions", "token_blacklist", "refresh_tokens", "bank_deposits", "bank_statement_imports", "payment_reversals", "payer_relationships", "partner_invitations", "household_invitations", "household_members", "households", "budget_alerts", "budgets", "bill_items", "monthly_bills", "users"}
 
 	for attempt := 1; attempt <= 3; attempt++ {
 		allDeleted := true
@@ -290,7 +312,7 @@ func CleanupTestDB(db *gorm.DB) error {
 	}
 
 	// テーブル全体のクリーンアップ（TRUNCATE使用で高速化と重複回避）
-	tables := []string{"bill_items", "monthly_bills", "users"}
+	tables := []string{"login_attempts", "password_reset_tokens", "two_factor_challenges", "recovery_codes", "user_sessions", "token_blacklist", "refresh_tokens", "bank_deposits", "bank_statement_imports", "payment_reversals", "payer_relationships", "partner_invitations", "household_invitations", "household_members", "households", "budget_alerts", "budgets", "bill_items", "monthly_bills", "users"}
 
 	for _, table := range tables {
 		// テーブル存在確認（正しい方法）
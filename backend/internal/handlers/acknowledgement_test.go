@@ -0,0 +1,148 @@
+// ========================================
+// 支払者の内容確認ステップの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupAcknowledgementRouter 内容確認・支払い関連のハンドラーを登録したルーターを作成する
+func setupAcknowledgementRouter(db *gorm.DB, userID uint) *gin.Engine {
+	router := setupRouter()
+	router.Use(setUserID(userID))
+	router.POST("/bills", CreateBillHandlerWithDB(db))
+	router.PUT("/bills/:id/request", RequestBillHandlerWithDB(db))
+	router.PUT("/bills/:id/acknowledge", AcknowledgeBillHandlerWithDB(db))
+	router.PUT("/bills/:id/payment", PaymentBillHandlerWithDB(db))
+	router.PUT("/partners/:id", UpdatePartnerSettingsHandlerWithDB(db))
+	return router
+}
+
+// TestAcknowledgeBill_RequiredBeforePayment 内容確認が必須の家計簿は確認後のみ支払えることを検証
+func TestAcknowledgeBill_RequiredBeforePayment(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+
+	requesterRouter := setupAcknowledgementRouter(db, testData.User1.ID)
+	payerRouter := setupAcknowledgementRouter(db, testData.User2.ID)
+
+	// 家計簿ごとに内容確認を必須に設定して作成
+	w := performJSONRequest(requesterRouter, "POST", "/bills", map[string]interface{}{
+		"year": 2024, "month": 10, "payer_id": testData.User2.ID, "require_acknowledgement": true,
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, created.RequireAcknowledgement)
+
+	base := fmt.Sprintf("/bills/%d", created.ID)
+
+	// 請求前は確認できない
+	w = performJSONRequest(payerRouter, "PUT", base+"/acknowledge", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performJSONRequest(requesterRouter, "PUT", base+"/request", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 確認前の支払いは拒否
+	w = performJSONRequest(payerRouter, "PUT", base+"/payment", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 請求者は確認できない
+	w = performJSONRequest(requesterRouter, "PUT", base+"/acknowledge", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 支払者がコメント付きで確認
+	w = performJSONRequest(payerRouter, "PUT", base+"/acknowledge", map[string]interface{}{"comment": " 内容に問題ありません "})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var acknowledged models.MonthlyBill
+	require.NoError(t, db.First(&acknowledged, created.ID).Error)
+	assert.Equal(t, "acknowledged", acknowledged.Status)
+	assert.NotNil(t, acknowledged.AcknowledgedAt)
+	assert.Equal(t, "内容に問題ありません", acknowledged.AcknowledgementComment)
+
+	// 確認後は支払える
+	w = performJSONRequest(payerRouter, "PUT", base+"/payment", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var paid models.MonthlyBill
+	require.NoError(t, db.First(&paid, created.ID).Error)
+	assert.Equal(t, "paid", paid.Status)
+	assert.NotNil(t, paid.AcknowledgedAt)
+}
+
+// TestAcknowledgeBill_OptionalByDefault 設定がない場合は従来どおり請求済みから直接支払え、任意で確認もできることを検証
+func TestAcknowledgeBill_OptionalByDefault(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+
+	requesterRouter := setupAcknowledgementRouter(db, testData.User1.ID)
+	payerRouter := setupAcknowledgementRouter(db, testData.User2.ID)
+
+	for month, acknowledgeFirst := range map[int]bool{11: false, 12: true} {
+		w := performJSONRequest(requesterRouter, "POST", "/bills", map[string]interface{}{"year": 2024, "month": month, "payer_id": testData.User2.ID})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created models.BillResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.False(t, created.RequireAcknowledgement)
+
+		base := fmt.Sprintf("/bills/%d", created.ID)
+		w = performJSONRequest(requesterRouter, "PUT", base+"/request", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		if acknowledgeFirst {
+			w = performJSONRequest(payerRouter, "PUT", base+"/acknowledge", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+
+		w = performJSONRequest(payerRouter, "PUT", base+"/payment", nil)
+		assert.Equal(t, http.StatusOK, w.Code, "month=%d", month)
+	}
+}
+
+// TestAcknowledgeBill_RelationshipSetting 支払者関係の設定が新規家計簿に引き継がれることを検証
+func TestAcknowledgeBill_RelationshipSetting(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("ack_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("ack_payer").MustBuild()
+
+	relationship := models.PayerRelationship{RequesterID: requester.ID, PayerID: payer.ID}
+	require.NoError(t, db.Create(&relationship).Error)
+
+	// 支払者が関係の設定を変更
+	payerRouter := setupAcknowledgementRouter(db, payer.ID)
+	w := performJSONRequest(payerRouter, "PUT", fmt.Sprintf("/partners/%d", relationship.ID), map[string]interface{}{"require_acknowledgement": true})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	requesterRouter := setupAcknowledgementRouter(db, requester.ID)
+	w = performJSONRequest(requesterRouter, "POST", "/bills", map[string]interface{}{"year": 2025, "month": 1, "payer_id": payer.ID})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var inherited models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &inherited))
+	assert.True(t, inherited.RequireAcknowledgement)
+
+	// 家計簿ごとの指定が優先される
+	w = performJSONRequest(requesterRouter, "POST", "/bills", map[string]interface{}{"year": 2025, "month": 2, "payer_id": payer.ID, "require_acknowledgement": false})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var overridden models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &overridden))
+	assert.False(t, overridden.RequireAcknowledgement)
+}
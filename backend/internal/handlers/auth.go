@@ -1,21 +1,25 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"money_management/internal/database"
-	"money_management/internal/middleware"
 	"money_management/internal/models"
+	"money_management/internal/services"
 )
 
 // LoginHandler ログインハンドラー
-// ユーザーの認証情報を確認し、有効な場合はJWTトークンを発行する
+// ユーザーの認証情報を確認し、有効な場合はアクセストークン（JWT）とリフレッシュトークンを発行する
+// 2段階認証が有効なユーザーにはチャレンジトークンを返し、/api/auth/login/2faで認証コードと交換する
 func LoginHandler(c *gin.Context) {
 	LoginHandlerWithDB(database.GetDB())(c)
 }
@@ -27,54 +31,110 @@ func LoginHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 
 		// リクエストボディをバインド（JSONをGoの構造体に変換）
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondBindingError(c, err)
+			return
+		}
+
+		// アカウントIDごとのログイン失敗による待機時間・ロックを確認
+		// 存在しないアカウントIDも同じように扱い、アカウントの有無がわからないようにする
+		attempts := services.NewLoginAttemptService(db)
+		retryAfter, err := attempts.RetryAfter(req.AccountID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ログインに失敗しました"})
+			return
+		}
+		if retryAfter > 0 {
+			respondLoginThrottled(c, retryAfter)
 			return
 		}
 
 		// データベースからユーザーを取得
 		var user models.User
 		if err := db.Where("account_id = ?", req.AccountID).First(&user).Error; err != nil {
+			// 応答時間からアカウントの有無がわからないよう、存在する場合と同じくパスワードを照合する
+			_ = services.CurrentPasswordHasher().ComparePassword(dummyPasswordHash(), req.Password)
 			// セキュリティ上、具体的なエラー内容は返さない
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "認証情報が無効です"})
+			respondLoginFailure(c, attempts, req.AccountID)
 			return
 		}
 
 		// パスワードを検証
-		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "認証情報が無効です"})
+		hasher := services.CurrentPasswordHasher()
+		if err := hasher.ComparePassword(user.PasswordHash, req.Password); err != nil {
+			respondLoginFailure(c, attempts, req.AccountID)
 			return
 		}
+		// 古い形式・設定のハッシュは現在の設定で作り直す（失敗してもログインは続行）
+		if err := services.UpgradePasswordHash(db, hasher, user.ID, user.PasswordHash, req.Password); err != nil {
+			log.Printf("❌ Failed to upgrade password hash: user=%d error=%v", user.ID, err)
+		}
 
-		// JWTトークンを生成
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
-			UserID: user.ID,
-			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24時間有効
-			},
-		})
-
-		// トークンに署名
-		secret, err := middleware.GetJWTSecret()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT秘密鍵の取得に失敗しました"})
+		// 2段階認証が有効な場合は、チャレンジトークンを返して認証コードの確認を待つ
+		// ログイン失敗の記録は認証コードの確認後にリセットする
+		if user.TOTPEnabled {
+			respondTwoFactorChallenge(c, db, user.ID)
 			return
 		}
-		tokenString, err := token.SignedString(secret)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
+
+		if err := attempts.RecordSuccess(user.AccountID); err != nil {
+			log.Printf("❌ Failed to reset login attempts: user=%d error=%v", user.ID, err)
+		}
+		completeLogin(c, db, user, req.InvitationToken)
+	}
+}
+
+// respondLoginFailure ログインの失敗を記録し、認証情報が無効であることを返す
+func respondLoginFailure(c *gin.Context, attempts *services.LoginAttemptService, accountID string) {
+	if err := attempts.RecordFailure(accountID, time.Now()); err != nil {
+		log.Printf("❌ Failed to record login failure: error=%v", err)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "認証情報が無効です"})
+}
+
+// respondLoginThrottled ログインの失敗が続いたアカウントIDに、再試行できるまでの時間を返す
+// 待機時間中とロック中は同じレスポンスにする
+func respondLoginThrottled(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":               "ログインの失敗が続いたため、しばらくしてから再試行してください",
+		"code":                "LOGIN_THROTTLED",
+		"retry_after_seconds": seconds,
+	})
+}
+
+// dummyPasswordHash 存在しないアカウントのパスワード照合に使うハッシュ（初回のみ、現在の設定で生成）
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := services.CurrentPasswordHasher().HashPassword("dummy-password-for-timing")
+	if err != nil {
+		log.Printf("❌ Failed to generate dummy password hash: %v", err)
+	}
+	return hash
+})
+
+// completeLogin 認証済みのユーザーの招待トークンを処理し、トークンを発行してログイン成功レスポンスを返す
+func completeLogin(c *gin.Context, db *gorm.DB, user models.User, invitationToken string) {
+	// 招待トークンが指定されている場合は招待者との支払者関係を作成
+	if invitationToken != "" {
+		if err := redeemPartnerInvitation(db, invitationToken, user.ID); err != nil {
+			respondInvitationError(c, err)
 			return
 		}
+	}
 
-		// ログイン成功レスポンス
-		c.JSON(http.StatusOK, models.LoginResponse{
-			Token: tokenString,
-			User:  user,
-		})
+	// アクセストークンとリフレッシュトークンを発行
+	tokens, err := services.NewRefreshTokenService(db).IssueTokens(user.ID, sessionClient(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
+		return
 	}
+
+	// ログイン成功レスポンス
+	c.JSON(http.StatusOK, newLoginResponse(tokens, user))
 }
 
 // RegisterHandler ユーザー登録ハンドラー
-// 新規ユーザーを登録し、登録完了時にJWTトークンを発行する
+// 新規ユーザーを登録し、登録完了時にアクセストークン（JWT）とリフレッシュトークンを発行する
 func RegisterHandler(c *gin.Context) {
 	RegisterHandlerWithDB(database.GetDB())(c)
 }
@@ -86,13 +146,13 @@ func RegisterHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 
 		// リクエストボディをバインド
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondBindingError(c, err)
 			return
 		}
 
-		// パスワードの長さを検証（最小6文字）
-		if len(req.Password) < 6 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "パスワードは6文字以上で入力してください"})
+		// パスワードポリシーを検証（文字数・文字種・よく使われるパスワード）
+		if err := services.DefaultPasswordPolicy().Validate("password", req.Password); err != nil {
+			respondBillError(c, err, "パスワードが要件を満たしていません")
 			return
 		}
 
@@ -109,8 +169,16 @@ func RegisterHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 招待トークンが指定されている場合はユーザー作成前に有効性を確認
+		if req.InvitationToken != "" {
+			if _, err := findUsablePartnerInvitation(db, req.InvitationToken); err != nil {
+				respondInvitationError(c, err)
+				return
+			}
+		}
+
 		// パスワードをハッシュ化
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := services.CurrentPasswordHasher().HashPassword(req.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの暗号化に失敗しました"})
 			return
@@ -120,40 +188,38 @@ func RegisterHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		user := models.User{
 			Name:         req.Name,
 			AccountID:    req.AccountID,
-			PasswordHash: string(hashedPassword),
+			Email:        req.Email,
+			PasswordHash: hashedPassword,
 		}
 
-		// データベースにユーザーを保存
-		if err := db.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの作成に失敗しました"})
+		// データベースにユーザーを保存（招待トークンの使用と同時に行う）
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if req.InvitationToken == "" {
+				return nil
+			}
+			return redeemPartnerInvitation(tx, req.InvitationToken, user.ID)
+		})
+		if errors.Is(err, errInvitationInvalid) || errors.Is(err, errInvitationSelf) {
+			respondInvitationError(c, err)
 			return
 		}
-
-		// JWTトークンを生成
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
-			UserID: user.ID,
-			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			},
-		})
-
-		// トークンに署名
-		secret, err := middleware.GetJWTSecret()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "JWT秘密鍵の取得に失敗しました"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの作成に失敗しました"})
 			return
 		}
-		tokenString, err := token.SignedString(secret)
+
+		// アクセストークンとリフレッシュトークンを発行
+		tokens, err := services.NewRefreshTokenService(db).IssueTokens(user.ID, sessionClient(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
 			return
 		}
 
 		// 登録成功レスポンス
-		c.JSON(http.StatusCreated, models.LoginResponse{
-			Token: tokenString,
-			User:  user,
-		})
+		c.JSON(http.StatusCreated, newLoginResponse(tokens, user))
 	}
 }
 
@@ -176,13 +242,13 @@ func GetMeHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// ユーザー情報を返す
-		c.JSON(http.StatusOK, user)
+		// ユーザー情報を返す（本人のみのためメールアドレスを含める）
+		c.JSON(http.StatusOK, models.NewSelfUser(user))
 	}
 }
 
 // GetUsersHandler ユーザー一覧取得ハンドラー
-// 家計簿作成時の支払者選択用にユーザー一覧を取得
+// 家計簿作成時の支払者選択用に同じ世帯のユーザーと連携済みの支払者を取得
 func GetUsersHandler(c *gin.Context) {
 	GetUsersHandlerWithDB(database.GetDB())(c)
 }
@@ -190,10 +256,14 @@ func GetUsersHandler(c *gin.Context) {
 // GetUsersHandlerWithDB DB接続を注入可能なユーザー一覧取得ハンドラー
 func GetUsersHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var users []models.User
+		userID := c.GetUint("user_id")
+		users := []models.User{}
 
-		// パスワード情報を除外してユーザー一覧を取得
-		err := db.Select("id, name, account_id, created_at, updated_at").Find(&users).Error
+		// パスワード情報を除外し、同じ世帯のユーザーと連携済みの支払者のみ取得
+		linkedPayers := db.Model(&models.PayerRelationship{}).Select("payer_id").Where("requester_id = ?", userID)
+		err := db.Select("id, name, account_id, created_at, updated_at").
+			Where("id IN (?) OR id IN (?)", householdPeerIDs(db, userID), linkedPayers).
+			Find(&users).Error
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー一覧の取得に失敗しました"})
 			return
@@ -202,3 +272,12 @@ func GetUsersHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"users": users})
 	}
 }
+
+// respondInvitationError 招待トークンのエラーをレスポンスに変換する
+func respondInvitationError(c *gin.Context, err error) {
+	if errors.Is(err, errInvitationInvalid) || errors.Is(err, errInvitationSelf) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "招待リンクの処理に失敗しました"})
+}
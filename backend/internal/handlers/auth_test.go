@@ -17,6 +17,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
 )
 
 // TestLoginHandler_Success 正しい認証情報でログインが成功することを検証（HTTP200とJWTトークンの返却を期待）
@@ -189,7 +190,7 @@ func TestRegisterHandler_Success(t *testing.T) {
 	requestBody := map[string]string{
 		"name":       "新規ユーザー",
 		"account_id": "newuser",
-		"password":   "password123",
+		"password":   "correct-horse-42",
 	}
 	jsonData, _ := json.Marshal(requestBody)
 
@@ -214,7 +215,7 @@ func TestRegisterHandler_Success(t *testing.T) {
 	assert.Equal(t, "新規ユーザー", user.Name)
 }
 
-// TestRegisterHandler_ShortPassword 6文字未満のパスワードで登録を試行した際にバリデーションエラーが返されることを検証（HTTP400とパスワード長エラーメッセージの返却を期待）
+// TestRegisterHandler_ShortPassword 8文字未満のパスワードで登録を試行した際にバリデーションエラーが返されることを検証（HTTP400とパスワード長エラーメッセージの返却を期待）
 func TestRegisterHandler_ShortPassword(t *testing.T) {
 	// ハンドラーテストは並列化を無効にして安定性を重視
 
@@ -245,7 +246,8 @@ func TestRegisterHandler_ShortPassword(t *testing.T) {
 	var response map[string]interface{}
 	err = json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, "パスワードは6文字以上で入力してください", response["error"])
+	assert.Equal(t, "パスワードは8文字以上で入力してください", response["error"])
+	assert.Contains(t, w.Body.String(), `"rule":"password_min_length"`)
 }
 
 // TestRegisterHandler_ShortAccountID 3文字未満のアカウントIDで登録を試行した際にバリデーションエラーが返されることを検証（HTTP400とアカウントID長エラーメッセージの返却を期待）
@@ -265,7 +267,7 @@ func TestRegisterHandler_ShortAccountID(t *testing.T) {
 	requestBody := map[string]string{
 		"name":       "新規ユーザー",
 		"account_id": "ab", // 短すぎるアカウントID
-		"password":   "password123",
+		"password":   "correct-horse-42",
 	}
 	jsonData, _ := json.Marshal(requestBody)
 
@@ -299,7 +301,7 @@ func TestRegisterHandler_LongAccountID(t *testing.T) {
 	requestBody := map[string]string{
 		"name":       "新規ユーザー",
 		"account_id": strings.Repeat("a", 21), // 長すぎるアカウントID
-		"password":   "password123",
+		"password":   "correct-horse-42",
 	}
 	jsonData, _ := json.Marshal(requestBody)
 
@@ -342,7 +344,7 @@ func TestRegisterHandler_DuplicateAccountID(t *testing.T) {
 	requestBody := map[string]string{
 		"name":       "新規ユーザー",
 		"account_id": "existing", // 重複するアカウントID
-		"password":   "password123",
+		"password":   "correct-horse-42",
 	}
 	jsonData, _ := json.Marshal(requestBody)
 
@@ -451,7 +453,7 @@ func TestGetMeHandler_UserNotFound(t *testing.T) {
 	assert.Equal(t, "ユーザーが見つかりません", response["error"])
 }
 
-// TestGetUsersHandler_Success 全ユーザー一覧が正常に取得できることを検証（HTTP200とユーザー一覧の返却を期待）
+// TestGetUsersHandler_Success 同じ世帯のユーザー一覧が正常に取得できることを検証（HTTP200とユーザー一覧の返却を期待）
 func TestGetUsersHandler_Success(t *testing.T) {
 	// ハンドラーテストは並列化を無効にして安定性を重視
 
@@ -469,13 +471,19 @@ func TestGetUsersHandler_Success(t *testing.T) {
 		{Name: "ユーザー3", AccountID: "user3", PasswordHash: "hash3"},
 	}
 
-	for _, user := range users {
-		err = db.Create(&user).Error
+	for i := range users {
+		err = db.Create(&users[i]).Error
 		assert.NoError(t, err)
 	}
 
+	// 全員を同じ世帯に所属させる
+	testfactory.NewTestDataFactory(db).NewHousehold().
+		WithOwner(users[0].ID).
+		WithMembers(users[1].ID, users[2].ID).
+		MustBuild()
+
 	router := setupRouter()
-	router.GET("/users", GetUsersHandlerWithDB(db))
+	router.GET("/users", setUserID(users[0].ID), GetUsersHandlerWithDB(db))
 
 	req := httptest.NewRequest("GET", "/users", nil)
 	w := httptest.NewRecorder()
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// GetBankAccountHandler 口座情報取得ハンドラー
+// ログインユーザーが登録した振込用の口座情報を復号して返す
+func GetBankAccountHandler(c *gin.Context) {
+	GetBankAccountHandlerWithDB(database.GetDB())(c)
+}
+
+// GetBankAccountHandlerWithDB DB接続を注入可能な口座情報取得ハンドラー
+func GetBankAccountHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+
+		account, ok := openUserBankAccount(c, user)
+		if !ok {
+			return
+		}
+		if account == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "口座情報が登録されていません"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"bank_account": account})
+	}
+}
+
+// UpdateBankAccountHandler 口座情報登録ハンドラー
+// 振込ファイルに出力する形式（半角カナ・0埋め）に正規化し、暗号化して保存する
+func UpdateBankAccountHandler(c *gin.Context) {
+	UpdateBankAccountHandlerWithDB(database.GetDB())(c)
+}
+
+// UpdateBankAccountHandlerWithDB DB接続を注入可能な口座情報登録ハンドラー
+func UpdateBankAccountHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var req models.BankAccount
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		account, err := services.NormalizeBankAccount(req)
+		if err != nil {
+			respondBillError(c, err, "口座情報の検証に失敗しました")
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+
+		fieldCipher, err := services.DefaultFieldCipher()
+		if err != nil {
+			log.Printf("❌ Field encryption key unavailable: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の暗号化に失敗しました"})
+			return
+		}
+		if err := services.SealBankAccount(fieldCipher, &user, account); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の暗号化に失敗しました"})
+			return
+		}
+		if err := db.Model(&user).Update("bank_account", user.EncryptedBankAccount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の保存に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"bank_account": account})
+	}
+}
+
+// DeleteBankAccountHandler 口座情報削除ハンドラー
+func DeleteBankAccountHandler(c *gin.Context) {
+	DeleteBankAccountHandlerWithDB(database.GetDB())(c)
+}
+
+// DeleteBankAccountHandlerWithDB DB接続を注入可能な口座情報削除ハンドラー
+func DeleteBankAccountHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		if err := db.Model(&models.User{}).Where("id = ?", userID).Update("bank_account", "").Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の削除に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "口座情報を削除しました"})
+	}
+}
+
+// openUserBankAccount ユーザーの口座情報を復号する（未登録の場合はnil、失敗時はレスポンスを返してfalse）
+func openUserBankAccount(c *gin.Context, user models.User) (*models.BankAccount, bool) {
+	fieldCipher, err := services.DefaultFieldCipher()
+	if err != nil {
+		log.Printf("❌ Field encryption key unavailable: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の復号に失敗しました"})
+		return nil, false
+	}
+	account, err := services.OpenBankAccount(fieldCipher, user)
+	if err != nil {
+		log.Printf("❌ Bank account decryption failed: user=%d error=%v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "口座情報の復号に失敗しました"})
+		return nil, false
+	}
+	return account, true
+}
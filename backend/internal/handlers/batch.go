@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/services"
+)
+
+const maxBatchOperations = 50 // 一括操作で指定できる操作の最大件数
+
+// billBatchAction 一括操作で実行できる操作
+type billBatchAction struct {
+	run      func(svc *services.BillService, billID, userID uint) error
+	message  string // 成功時のメッセージ（個別エンドポイントと同じ）
+	fallback string // ドメインエラー以外のエラー時のメッセージ（個別エンドポイントと同じ）
+}
+
+// billBatchActions 操作名と処理の対応（request: 請求, pay: 支払い, delete: 削除）
+var billBatchActions = map[string]billBatchAction{
+	"request": {run: (*services.BillService).RequestBill, message: "家計簿の請求が確定しました", fallback: "家計簿の更新に失敗しました"},
+	"pay":     {run: (*services.BillService).PayBill, message: "支払いが確定しました", fallback: "家計簿の更新に失敗しました"},
+	"delete":  {run: (*services.BillService).DeleteBill, message: "家計簿を削除しました", fallback: "家計簿の削除に失敗しました"},
+}
+
+// BillBatchOperation 一括操作の1件分のリクエスト
+type BillBatchOperation struct {
+	Action string `json:"action" binding:"required,oneof=request pay delete"` // 操作（request, pay, delete）
+	BillID uint   `json:"bill_id" binding:"required"`                         // 対象の家計簿ID
+}
+
+// BillBatchResult 一括操作の1件分の結果
+type BillBatchResult struct {
+	Index      int    `json:"index"`                 // リクエスト内の順番（0始まり）
+	Action     string `json:"action"`                // 操作
+	BillID     uint   `json:"bill_id"`               // 対象の家計簿ID
+	Status     int    `json:"status"`                // 個別エンドポイントと同じHTTPステータス（未実行の場合は0）
+	Message    string `json:"message,omitempty"`     // 成功時のメッセージ
+	Error      string `json:"error,omitempty"`       // 失敗時のエラーメッセージ
+	RolledBack bool   `json:"rolled_back,omitempty"` // 全件一括モードで他の操作の失敗により取り消された
+	Skipped    bool   `json:"skipped,omitempty"`     // 全件一括モードで先行する操作の失敗により未実行
+}
+
+// BatchBillsHandler 家計簿一括操作ハンドラー
+// 複数の家計簿に対する請求・支払い・削除を1リクエストで実行する
+// atomicがtrueの場合は全件成功時のみ反映し、falseの場合は成功した操作のみ反映する
+func BatchBillsHandler(c *gin.Context) {
+	BatchBillsHandlerWithDB(database.GetDB())(c)
+}
+
+// BatchBillsHandlerWithDB DB接続を注入可能な家計簿一括操作ハンドラー
+func BatchBillsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			Atomic     bool                 `json:"atomic"`                                   // 全件一括（1件でも失敗したら全て取り消す）
+			Operations []BillBatchOperation `json:"operations" binding:"required,min=1,dive"` // 操作一覧（必須）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+		if len(req.Operations) > maxBatchOperations {
+			respondValidationError(c, services.NewValidationError(services.FieldError{
+				Field:   "operations",
+				Rule:    services.RuleMax,
+				Message: "一度に実行できる操作は50件までです",
+			}))
+			return
+		}
+
+		results := make([]BillBatchResult, len(req.Operations))
+		for i, op := range req.Operations {
+			results[i] = BillBatchResult{Index: i, Action: op.Action, BillID: op.BillID}
+		}
+
+		service := newBillService(db)
+		committed := true
+		if req.Atomic {
+			committed = runAtomicBillBatch(service, req.Operations, userID, results)
+		} else {
+			// 操作ごとに実行し、失敗した操作のみ反映しない
+			for i, op := range req.Operations {
+				runBillBatchOperation(service, op, userID, &results[i])
+			}
+		}
+
+		succeeded := 0
+		for _, result := range results {
+			if result.Error == "" && !result.Skipped && !result.RolledBack {
+				succeeded++
+			}
+		}
+
+		log.Printf("📦 Bill batch: user=%d atomic=%t operations=%d succeeded=%d committed=%t",
+			userID, req.Atomic, len(req.Operations), succeeded, committed)
+
+		c.JSON(http.StatusOK, gin.H{
+			"atomic":    req.Atomic,
+			"committed": committed,
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+			"results":   results,
+		})
+	}
+}
+
+// runAtomicBillBatch 全ての操作を1つのトランザクションで実行する
+// 1件でも失敗した場合は全て取り消し、実行済みの操作をrolled_back、未実行の操作をskippedとする
+func runAtomicBillBatch(service *services.BillService, operations []BillBatchOperation, userID uint, results []BillBatchResult) bool {
+	failedIndex := -1
+	err := service.Transaction(func(tx *services.BillService) error {
+		for i, op := range operations {
+			if err := runBillBatchOperation(tx, op, userID, &results[i]); err != nil {
+				failedIndex = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return true
+	}
+
+	for i := range results {
+		switch {
+		case failedIndex < 0:
+			// コミット時のエラー
+			results[i].Status = http.StatusInternalServerError
+			results[i].Message = ""
+			results[i].Error = "家計簿の更新に失敗しました"
+		case i < failedIndex:
+			results[i].RolledBack = true
+		case i > failedIndex:
+			results[i].Skipped = true
+		}
+	}
+	return false
+}
+
+// runBillBatchOperation 1件の操作を実行し、結果を書き込む
+func runBillBatchOperation(service *services.BillService, op BillBatchOperation, userID uint, result *BillBatchResult) error {
+	action := billBatchActions[op.Action]
+	if err := action.run(service, op.BillID, userID); err != nil {
+		result.Status = billErrorStatus(err)
+		result.Error = billErrorMessage(err, action.fallback)
+		return err
+	}
+	result.Status = http.StatusOK
+	result.Message = action.message
+	return nil
+}
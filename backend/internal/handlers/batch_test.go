@@ -0,0 +1,169 @@
+// ========================================
+// 家計簿一括操作ハンドラーの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// batchResponse 一括操作のレスポンス
+type batchResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BillBatchResult `json:"results"`
+}
+
+// setupBatchScenario 作成中・請求済みの家計簿と、請求者・支払者それぞれのルーターを作成する
+func setupBatchScenario(t *testing.T, db *gorm.DB) (pending, requested models.MonthlyBill, requesterRouter, payerRouter *gin.Engine) {
+	t.Helper()
+
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("batch_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("batch_payer").MustBuild()
+
+	pending, _ = factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).
+		WithYearMonth(2024, 10).AddItems(testfactory.Item("家賃", 80000)).MustBuild()
+	requested, _ = factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).
+		WithYearMonth(2024, 11).WithStatus("requested").MustBuild()
+
+	newRouter := func(userID uint) *gin.Engine {
+		router := setupRouter()
+		router.POST("/bills/batch", setUserID(userID), BatchBillsHandlerWithDB(db))
+		return router
+	}
+	return pending, requested, newRouter(requester.ID), newRouter(payer.ID)
+}
+
+// performBatch 一括操作を実行してレスポンスを返す
+func performBatch(t *testing.T, router *gin.Engine, atomic bool, operations ...map[string]interface{}) batchResponse {
+	t.Helper()
+	w := performJSONRequest(router, "POST", "/bills/batch", map[string]interface{}{"atomic": atomic, "operations": operations})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response batchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// billStatus 家計簿の現在の状態を返す（削除済みの場合は空文字）
+func billStatus(db *gorm.DB, billID uint) string {
+	var bill models.MonthlyBill
+	if err := db.First(&bill, billID).Error; err != nil {
+		return ""
+	}
+	return bill.Status
+}
+
+// TestBatchBills_BestEffort 成功した操作のみ反映され、失敗した操作に個別エンドポイントと同じエラーが返ることを検証
+func TestBatchBills_BestEffort(t *testing.T) {
+	db := setupInMemoryDB(t)
+	pending, requested, requesterRouter, _ := setupBatchScenario(t, db)
+
+	response := performBatch(t, requesterRouter, false,
+		map[string]interface{}{"action": "request", "bill_id": pending.ID},
+		map[string]interface{}{"action": "request", "bill_id": requested.ID},
+		map[string]interface{}{"action": "pay", "bill_id": requested.ID},
+		map[string]interface{}{"action": "delete", "bill_id": 99999},
+	)
+
+	assert.True(t, response.Committed)
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 3, response.Failed)
+	require.Len(t, response.Results, 4)
+
+	assert.Equal(t, http.StatusOK, response.Results[0].Status)
+	assert.Equal(t, "家計簿の請求が確定しました", response.Results[0].Message)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(t, "家計簿は既に確定済みです", response.Results[1].Error)
+	// 請求者は支払い処理できない
+	assert.Equal(t, http.StatusNotFound, response.Results[2].Status)
+	assert.Equal(t, http.StatusNotFound, response.Results[3].Status)
+	assert.Equal(t, 3, response.Results[3].Index)
+
+	assert.Equal(t, "requested", billStatus(db, pending.ID))
+}
+
+// TestBatchBills_Atomic 全件一括モードでは1件でも失敗すると全ての操作が取り消されることを検証
+func TestBatchBills_Atomic(t *testing.T) {
+	db := setupInMemoryDB(t)
+	pending, requested, requesterRouter, payerRouter := setupBatchScenario(t, db)
+
+	// 2件目が失敗するため1件目の削除も取り消され、3件目は実行されない
+	response := performBatch(t, requesterRouter, true,
+		map[string]interface{}{"action": "delete", "bill_id": pending.ID},
+		map[string]interface{}{"action": "delete", "bill_id": requested.ID},
+		map[string]interface{}{"action": "request", "bill_id": pending.ID},
+	)
+
+	assert.False(t, response.Committed)
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, 3, response.Failed)
+	assert.True(t, response.Results[0].RolledBack)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(t, "確定済みの家計簿は削除できません", response.Results[1].Error)
+	assert.True(t, response.Results[2].Skipped)
+	assert.Equal(t, 0, response.Results[2].Status)
+
+	assert.Equal(t, "pending", billStatus(db, pending.ID))
+	var itemCount int64
+	db.Model(&models.BillItem{}).Where("bill_id = ?", pending.ID).Count(&itemCount)
+	assert.Equal(t, int64(1), itemCount)
+
+	// 全件成功した場合は反映される（同じ家計簿への連続した操作も順番に実行）
+	response = performBatch(t, requesterRouter, true,
+		map[string]interface{}{"action": "request", "bill_id": pending.ID},
+	)
+	assert.True(t, response.Committed)
+	response = performBatch(t, payerRouter, true,
+		map[string]interface{}{"action": "pay", "bill_id": pending.ID},
+		map[string]interface{}{"action": "pay", "bill_id": requested.ID},
+	)
+	assert.True(t, response.Committed)
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, "paid", billStatus(db, pending.ID))
+	assert.Equal(t, "paid", billStatus(db, requested.ID))
+}
+
+// TestBatchBills_Validation 不正な操作指定がリクエスト全体のエラーになることを検証
+func TestBatchBills_Validation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	pending, _, requesterRouter, _ := setupBatchScenario(t, db)
+
+	tooMany := make([]map[string]interface{}, maxBatchOperations+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]interface{}{"action": "request", "bill_id": pending.ID}
+	}
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"操作なし", map[string]interface{}{"operations": []interface{}{}}},
+		{"未対応の操作", map[string]interface{}{"operations": []interface{}{map[string]interface{}{"action": "acknowledge", "bill_id": pending.ID}}}},
+		{"家計簿ID未指定", map[string]interface{}{"operations": []interface{}{map[string]interface{}{"action": "request"}}}},
+		{"件数超過", map[string]interface{}{"operations": tooMany}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performJSONRequest(requesterRouter, "POST", "/bills/batch", tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	assert.Equal(t, "pending", billStatus(db, pending.ID))
+}
@@ -1,17 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"money_management/internal/database"
 	"money_management/internal/models"
+	"money_management/internal/services"
 )
 
 // GetBillHandler 特定年月の家計簿取得ハンドラー
@@ -28,12 +28,8 @@ func GetBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		month, _ := strconv.Atoi(c.Param("month"))
 		userID := c.GetUint("user_id")
 
-		var bill models.MonthlyBill
-		// 関連データ（請求者・支払者・項目）をプリロードして家計簿を検索
-		// 対象ユーザーが請求者または支払者である家計簿のみ取得
-		err := db.Preload("Requester").Preload("Payer").Preload("Items").
-			Where("year = ? AND month = ? AND (requester_id = ? OR payer_id = ?)", year, month, userID, userID).
-			First(&bill).Error
+		// 対象ユーザーが請求者または支払者である暦月の家計簿を取得
+		bill, err := newBillService(db).GetBillByYearMonth(userID, year, month)
 
 		// 家計簿が見つからない場合はnullを返す
 		if err != nil {
@@ -41,24 +37,54 @@ func GetBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 総金額を計算
-		totalAmount := 0.0
-		for _, item := range bill.Items {
-			totalAmount += item.Amount
-		}
+		c.JSON(http.StatusOK, newBillDetailResponse(db, *bill, userID))
+	}
+}
+
+// GetBillByIDHandler ID指定の家計簿取得ハンドラー
+// 任意期間の家計簿を含め、家計簿IDで家計簿データを取得する
+func GetBillByIDHandler(c *gin.Context) {
+	GetBillByIDHandlerWithDB(database.GetDB())(c)
+}
+
+// GetBillByIDHandlerWithDB DB接続を注入可能なID指定の家計簿取得ハンドラー
+func GetBillByIDHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		billID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
 
-		// レスポンスデータを作成
-		response := models.BillResponse{
-			MonthlyBill: bill,
-			TotalAmount: totalAmount,
+		// 対象ユーザーが請求者または支払者である家計簿のみ取得
+		bill, err := newBillService(db).GetBill(uint(billID), userID)
+		if err != nil {
+			respondBillError(c, err, "家計簿の取得に失敗しました")
+			return
 		}
 
-		c.JSON(http.StatusOK, response)
+		c.JSON(http.StatusOK, newBillDetailResponse(db, *bill, userID))
 	}
 }
 
+// newBillDetailResponse 家計簿詳細のレスポンスを作成する
+// 金額情報に加え、予算消化状況と支払い取り消しの履歴を付与する
+func newBillDetailResponse(db *gorm.DB, bill models.MonthlyBill, userID uint) models.BillResponse {
+	// 金額情報（税抜小計・消費税・税込合計）を計算してレスポンスデータを作成
+	response := newBillResponse(bill)
+
+	// 対象年月の予算消化状況を付与
+	if statuses, err := calculateBudgetStatuses(db, userID, bill.Year, bill.Month); err == nil {
+		response.Budgets = statuses
+	}
+
+	// 支払い取り消しの履歴を付与
+	if reversals, err := loadPaymentReversals(db, bill.ID); err == nil {
+		response.Reversals = reversals
+	}
+
+	return response
+}
+
 // CreateBillHandler 新規家計簿作成ハンドラー
-// 指定された年月の家計簿を新規作成する
+// 指定された年月、または開始日・終了日で指定された任意期間の家計簿を新規作成する
 func CreateBillHandler(c *gin.Context) {
 	CreateBillHandlerWithDB(database.GetDB())(c)
 }
@@ -70,99 +96,44 @@ func CreateBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 
 		// リクエストデータの構造体定義
 		var req struct {
-			Year    int  `json:"year" binding:"required"`     // 対象年（必須）
-			Month   int  `json:"month" binding:"required"`    // 対象月（必須）
-			PayerID uint `json:"payer_id" binding:"required"` // 支払者ID（必須）
+			Year                   int    `json:"year"`                    // 対象年（暦月の場合は必須）
+			Month                  int    `json:"month"`                   // 対象月（暦月の場合は必須）
+			PeriodStart            string `json:"period_start"`            // 任意期間の開始日（YYYY-MM-DD）
+			PeriodEnd              string `json:"period_end"`              // 任意期間の終了日（YYYY-MM-DD）
+			PeriodLabel            string `json:"period_label"`            // 期間のラベル（任意）
+			PayerID                uint   `json:"payer_id"`                // 支払者ID（必須）
+			Comment                string `json:"comment"`                 // コメント（任意）
+			TaxRounding            string `json:"tax_rounding"`            // 消費税の端数処理（任意、省略時は切り捨て）
+			RequireAcknowledgement *bool  `json:"require_acknowledgement"` // 支払前の内容確認を必須とするか（任意、省略時はパートナー設定に従う）
 		}
 
 		// リクエストボディをバインド
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondBindingError(c, err)
 			return
 		}
 
-		// 請求者と支払者が同一ユーザーの場合はエラー
-		if userID == req.PayerID {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "請求者と支払者は異なるユーザーである必要があります"})
-			return
-		}
-
-		// 新しい家計簿を作成
-		bill := models.MonthlyBill{
-			Year:        req.Year,
-			Month:       req.Month,
-			RequesterID: userID,      // 請求者は現在のユーザー
-			PayerID:     req.PayerID, // 支払者は指定されたユーザー
-			Status:      "pending",   // 初期状態は作成中
-		}
-
-		// データベースに保存（デッドロック対応のリトライ機構付き）
-		log.Printf("🔍 About to create bill: Year=%d, Month=%d, RequesterID=%d", bill.Year, bill.Month, bill.RequesterID)
-
-		const (
-			maxRetries         = 3
-			baseBackoffMs      = 100 // ベースバックオフ時間（ミリ秒）
-			backoffIncrementMs = 50  // バックオフ増分（ミリ秒）
-		)
-		var result *gorm.DB
-		var err error
-
-		for i := 0; i < maxRetries; i++ {
-			result = db.Create(&bill)
-			err = result.Error
-
-			if err == nil {
-				break
-			}
-
-			// デッドロックエラーの場合はリトライ
-			if strings.Contains(err.Error(), "Deadlock found when trying to get lock") {
-				log.Printf("🔄 Deadlock detected, retrying... (attempt %d/%d)", i+1, maxRetries)
-				// 穏やかな指数バックオフ: baseTime + incrementTime * attempt^2
-				waitTime := time.Duration(baseBackoffMs+backoffIncrementMs*i*i) * time.Millisecond
-				log.Printf("🕐 Waiting %v before retry", waitTime)
-				time.Sleep(waitTime)
-				continue
-			}
-
-			// デッドロック以外のエラーは即座に終了
-			break
-		}
-
-		log.Printf("🔍 DB Create completed, checking for errors...")
-
+		// 請求者は現在のユーザー、支払者は指定されたユーザー
+		bill, err := newBillService(db).CreateBill(services.CreateBillInput{
+			RequesterID:            userID,
+			PayerID:                req.PayerID,
+			Year:                   req.Year,
+			Month:                  req.Month,
+			PeriodStart:            req.PeriodStart,
+			PeriodEnd:              req.PeriodEnd,
+			PeriodLabel:            req.PeriodLabel,
+			Comment:                req.Comment,
+			TaxRounding:            req.TaxRounding,
+			RequireAcknowledgement: req.RequireAcknowledgement,
+		})
 		if err != nil {
 			log.Printf("🔍 CreateBill Error detected: %s", err.Error())
-
-			// 制約エラー（重複）の場合は409 Conflictを返す
-			errorStr := err.Error()
-			log.Printf("🔍 Checking if error contains 'Duplicate entry': %t", strings.Contains(errorStr, "Duplicate entry"))
-
-			if strings.Contains(errorStr, "Duplicate entry") {
-				log.Printf("🔍 Returning 409 Conflict for duplicate entry")
-				c.JSON(http.StatusConflict, gin.H{
-					"error": "指定された年月の家計簿は既に存在します"})
-				return
-			}
-
-			// その他のエラーの場合は500エラー
-			log.Printf("🔍 Returning 500 for other database error")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿の作成に失敗しました"})
+			respondBillError(c, err, "家計簿の作成に失敗しました")
 			return
 		}
 
-		log.Printf("🔍 Bill created successfully")
-
-		// 作成後、関連データをプリロードして再取得
-		db.Preload("Requester").Preload("Payer").Preload("Items").First(&bill, bill.ID)
-
 		// レスポンスデータを作成（新規作成時は項目がないので金額は0）
-		response := models.BillResponse{
-			MonthlyBill: bill,
-			TotalAmount: 0,
-		}
-
-		c.JSON(http.StatusCreated, response)
+		c.JSON(http.StatusCreated, newBillResponse(*bill))
 	}
 }
 
@@ -179,62 +150,69 @@ func UpdateItemsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		billID, _ := strconv.Atoi(c.Param("id"))
 		userID := c.GetUint("user_id")
 
-		// 対象の家計簿を検索（請求者のみが更新可能）
-		var bill models.MonthlyBill
-		if err := db.Where("id = ? AND requester_id = ?", billID, userID).First(&bill).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "家計簿が見つかりません"})
-			return
-		}
-
-		// pending状態（作成中）の家計簿のみ更新可能
-		if bill.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "確定済みの家計簿の項目は更新できません"})
-			return
-		}
-
 		// リクエストデータの構造体定義
 		var req struct {
 			Items []struct {
-				ID       uint    `json:"id"`        // 項目ID（更新時に使用）
-				ItemName string  `json:"item_name"` // 項目名
-				Amount   float64 `json:"amount"`    // 金額
+				ID           uint    `json:"id"`            // 項目ID（更新時に使用）
+				ItemName     string  `json:"item_name"`     // 項目名
+				Amount       float64 `json:"amount"`        // 金額
+				TaxRate      int     `json:"tax_rate"`      // 消費税率（8または10、省略時は10）
+				TaxExclusive bool    `json:"tax_exclusive"` // 金額が税抜かどうか（省略時は税込）
+				SplitRule    string  `json:"split_rule"`    // 負担ルール（payer, shared, custom、省略時はpayer）
+				PayerShare   *int    `json:"payer_share"`   // 支払者の負担割合（%、customの場合は必須）
 			} `json:"items"`
+			Comment                *string `json:"comment"`                 // コメント（指定時のみ更新）
+			TaxRounding            *string `json:"tax_rounding"`            // 消費税の端数処理（指定時のみ更新）
+			RequireAcknowledgement *bool   `json:"require_acknowledgement"` // 支払前の内容確認を必須とするか（指定時のみ更新）
 		}
 
 		// リクエストボディをバインド
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondBindingError(c, err)
 			return
 		}
 
-		// 既存の項目を全削除
-		db.Where("bill_id = ?", billID).Delete(&models.BillItem{})
-
-		// 新しい項目を追加（名前と金額が有効な項目のみ）
+		input := services.UpdateItemsInput{
+			Comment:                req.Comment,
+			TaxRounding:            req.TaxRounding,
+			RequireAcknowledgement: req.RequireAcknowledgement,
+		}
 		for _, item := range req.Items {
-			if item.ItemName != "" && item.Amount > 0 {
-				billItem := models.BillItem{
-					BillID:   uint(billID),
-					ItemName: item.ItemName,
-					Amount:   item.Amount,
-				}
-				db.Create(&billItem)
-			}
+			input.Items = append(input.Items, services.BillItemInput{
+				ItemName:     item.ItemName,
+				Amount:       item.Amount,
+				TaxRate:      item.TaxRate,
+				TaxExclusive: item.TaxExclusive,
+				SplitRule:    item.SplitRule,
+				PayerShare:   item.PayerShare,
+			})
 		}
 
-		// 更新後のデータを関連データと共に再取得
-		db.Preload("Requester").Preload("Payer").Preload("Items").First(&bill, billID)
+		service := newBillService(db)
 
-		// 総金額を再計算
-		totalAmount := 0.0
-		for _, item := range bill.Items {
-			totalAmount += item.Amount
+		// 予算アラート判定用に更新前の予算消化状況を記録
+		budgetsBefore := map[uint]models.BudgetStatus{}
+		if current, err := service.GetBill(uint(billID), userID); err == nil {
+			budgetsBefore = snapshotBudgetUsage(db, *current)
 		}
 
-		// レスポンスデータを作成
-		response := models.BillResponse{
-			MonthlyBill: bill,
-			TotalAmount: totalAmount,
+		bill, err := service.UpdateItems(uint(billID), userID, input)
+		if err != nil {
+			respondBillError(c, err, "家計簿の更新に失敗しました")
+			return
+		}
+
+		// 金額情報を再計算してレスポンスデータを作成
+		response := newBillResponse(*bill)
+
+		// 閾値を超えた予算のアラートを発生させ、操作ユーザー宛てのものをレスポンスに含める
+		for _, alert := range raiseBudgetAlerts(db, *bill, budgetsBefore) {
+			if alert.UserID == userID {
+				response.BudgetAlerts = append(response.BudgetAlerts, alert)
+			}
+		}
+		if statuses, err := calculateBudgetStatuses(db, userID, bill.Year, bill.Month); err == nil {
+			response.Budgets = statuses
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -254,27 +232,8 @@ func RequestBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		billID, _ := strconv.Atoi(c.Param("id"))
 		userID := c.GetUint("user_id")
 
-		// 対象の家計簿を検索（請求者のみが請求可能）
-		var bill models.MonthlyBill
-		if err := db.Where("id = ? AND requester_id = ?", billID, userID).First(&bill).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "家計簿が見つかりません"})
-			return
-		}
-
-		// pending状態（作成中）の家計簿のみ請求可能
-		if bill.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "家計簿は既に確定済みです"})
-			return
-		}
-
-		// 状態を請求済みに変更し、請求日時を設定
-		now := time.Now()
-		bill.Status = "requested"
-		bill.RequestDate = &now
-
-		// データベースを更新
-		if err := db.Save(&bill).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿の更新に失敗しました"})
+		if err := newBillService(db).RequestBill(uint(billID), userID); err != nil {
+			respondBillError(c, err, "家計簿の更新に失敗しました")
 			return
 		}
 
@@ -283,7 +242,8 @@ func RequestBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 }
 
 // PaymentBillHandler 家計簿支払ハンドラー
-// 家計簿の状態をrequested（請求済み）からpaid（支払済み）に変更する
+// 家計簿の状態をrequested（請求済み）またはacknowledged（支払者確認済み）からpaid（支払済み）に変更する
+// 内容確認が必須の家計簿はacknowledged状態からのみ支払い可能
 func PaymentBillHandler(c *gin.Context) {
 	PaymentBillHandlerWithDB(database.GetDB())(c)
 }
@@ -295,31 +255,45 @@ func PaymentBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		billID, _ := strconv.Atoi(c.Param("id"))
 		userID := c.GetUint("user_id")
 
-		// 対象の家計簿を検索（支払者のみが支払い処理可能）
-		var bill models.MonthlyBill
-		if err := db.Where("id = ? AND payer_id = ?", billID, userID).First(&bill).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "家計簿が見つかりません"})
+		if err := newBillService(db).PayBill(uint(billID), userID); err != nil {
+			respondBillError(c, err, "家計簿の更新に失敗しました")
 			return
 		}
 
-		// requested状態（請求済み）の家計簿のみ支払い処理可能
-		if bill.Status != "requested" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "家計簿が請求中状態ではありません"})
-			return
-		}
+		c.JSON(http.StatusOK, gin.H{"message": "支払いが確定しました"})
+	}
+}
 
-		// 状態を支払済みに変更し、支払日時を設定
-		now := time.Now()
-		bill.Status = "paid"
-		bill.PaymentDate = &now
+// AcknowledgeBillHandler 家計簿内容確認ハンドラー
+// 支払者が請求内容を確認し、家計簿の状態をrequested（請求済み）からacknowledged（支払者確認済み）に変更する
+func AcknowledgeBillHandler(c *gin.Context) {
+	AcknowledgeBillHandlerWithDB(database.GetDB())(c)
+}
+
+// AcknowledgeBillHandlerWithDB DB接続を注入可能な内容確認ハンドラー
+func AcknowledgeBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// URLパラメータから家計簿IDを取得
+		billID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
 
-		// データベースを更新
-		if err := db.Save(&bill).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿の更新に失敗しました"})
+		// 確認時のコメント（任意）
+		var req struct {
+			Comment string `json:"comment"` // 確認時のコメント（任意）
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondBindingError(c, err)
+				return
+			}
+		}
+
+		if err := newBillService(db).AcknowledgeBill(uint(billID), userID, req.Comment); err != nil {
+			respondBillError(c, err, "家計簿の更新に失敗しました")
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "支払いが確定しました"})
+		c.JSON(http.StatusOK, gin.H{"message": "家計簿の内容を確認しました"})
 	}
 }
 
@@ -336,28 +310,8 @@ func DeleteBillHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		billID, _ := strconv.Atoi(c.Param("id"))
 		userID := c.GetUint("user_id")
 
-		// 対象の家計簿を検索（請求者のみが削除可能）
-		var bill models.MonthlyBill
-		if err := db.Where("id = ? AND requester_id = ?", billID, userID).First(&bill).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "家計簿が見つかりません"})
-			return
-		}
-
-		// pending状態（作成中）の家計簿のみ削除可能
-		if bill.Status != "pending" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "確定済みの家計簿は削除できません"})
-			return
-		}
-
-		// 家計簿に関連する項目を先に削除（外部キー制約対応）
-		if err := db.Where("bill_id = ?", billID).Delete(&models.BillItem{}).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿項目の削除に失敗しました"})
-			return
-		}
-
-		// 家計簿本体を削除
-		if err := db.Delete(&bill).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿の削除に失敗しました"})
+		if err := newBillService(db).DeleteBill(uint(billID), userID); err != nil {
+			respondBillError(c, err, "家計簿の削除に失敗しました")
 			return
 		}
 
@@ -376,13 +330,8 @@ func GetBillsListHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.GetUint("user_id")
 
-		var bills []models.MonthlyBill
-		// 関連データと共に家計簿一覧を取得（作成日時の降順）
-		err := db.Preload("Requester").Preload("Payer").Preload("Items").
-			Where("requester_id = ? OR payer_id = ?", userID, userID).
-			Order("year DESC, month DESC").
-			Find(&bills).Error
-
+		// 関連データと共に家計簿一覧を取得（対象期間の降順）
+		bills, err := newBillService(db).ListBills(userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "家計簿一覧の取得に失敗しました"})
 			return
@@ -391,18 +340,56 @@ func GetBillsListHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
 		// 各家計簿の金額情報を計算
 		var billResponses []models.BillResponse
 		for _, bill := range bills {
-			totalAmount := 0.0
-			for _, item := range bill.Items {
-				totalAmount += item.Amount
-			}
-
-			billResponse := models.BillResponse{
-				MonthlyBill: bill,
-				TotalAmount: totalAmount,
-			}
-			billResponses = append(billResponses, billResponse)
+			billResponses = append(billResponses, newBillResponse(bill))
 		}
 
 		c.JSON(http.StatusOK, gin.H{"bills": billResponses})
 	}
 }
+
+// newBillService DB接続から家計簿サービスを作成する
+func newBillService(db *gorm.DB) *services.BillService {
+	return services.NewBillService(services.NewGormBillRepository(db), dbPayerPolicy{db: db})
+}
+
+// respondBillError 家計簿サービスのエラーをHTTPレスポンスに変換する
+// ドメインエラーはそのメッセージを、それ以外のエラーはfallbackのメッセージを返す
+// フィールド単位の検証エラーは検証エラーのレスポンスとして返す
+func respondBillError(c *gin.Context, err error, fallback string) {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		respondValidationError(c, validationErr)
+		return
+	}
+	c.JSON(billErrorStatus(err), gin.H{"error": billErrorMessage(err, fallback)})
+}
+
+// billErrorStatus 家計簿サービスのエラーに対応するHTTPステータスを返す
+func billErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidState), errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// billErrorMessage 家計簿サービスのエラーに対応するメッセージを返す
+// 検証エラーの場合は最初の検証エラーのメッセージを返す
+func billErrorMessage(err error, fallback string) string {
+	var domainErr *services.DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Message
+	}
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Error()
+	}
+	return fallback
+}
@@ -1012,6 +1012,13 @@ func TestCreateBillHandler_FactoryDemo(t *testing.T) {
 		Build()
 	assert.NoError(t, err)
 
+	// 請求者と支払者を同じ世帯に所属させる
+	_, err = factory.NewHousehold().
+		WithOwner(requester.ID).
+		WithMembers(payer.ID).
+		Build()
+	assert.NoError(t, err)
+
 	router := setupRouter()
 	router.POST("/bills", setUserID(requester.ID), CreateBillHandlerWithDB(db))
 
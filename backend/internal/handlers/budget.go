@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+)
+
+// デフォルトのアラート閾値（%）
+const defaultBudgetAlertThreshold = 80
+
+// GetBudgetsHandler 予算一覧取得ハンドラー
+// ログインユーザーが設定した予算の一覧を返す
+func GetBudgetsHandler(c *gin.Context) {
+	GetBudgetsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetBudgetsHandlerWithDB DB接続を注入可能な予算一覧取得ハンドラー
+func GetBudgetsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var budgets []models.Budget
+		if err := db.Where("user_id = ?", userID).Order("category ASC").Find(&budgets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算一覧の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"budgets": budgets})
+	}
+}
+
+// CreateBudgetHandler 予算作成ハンドラー
+// カテゴリ別（または全体）の月次予算を新規作成する
+func CreateBudgetHandler(c *gin.Context) {
+	CreateBudgetHandlerWithDB(database.GetDB())(c)
+}
+
+// CreateBudgetHandlerWithDB DB接続を注入可能な予算作成ハンドラー
+func CreateBudgetHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// リクエストデータの構造体定義
+		var req struct {
+			Category       string  `json:"category"`                         // 対象カテゴリ（省略時は全体予算）
+			MonthlyLimit   float64 `json:"monthly_limit" binding:"required"` // 月次予算上限（必須）
+			AlertThreshold int     `json:"alert_threshold"`                  // アラート閾値（省略時は80%）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		if req.AlertThreshold == 0 {
+			req.AlertThreshold = defaultBudgetAlertThreshold
+		}
+		if msg := validateBudgetValues(req.MonthlyLimit, req.AlertThreshold); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		category := strings.TrimSpace(req.Category)
+
+		// 同一カテゴリの予算が既に存在するかチェック
+		var count int64
+		db.Model(&models.Budget{}).Where("user_id = ? AND category = ?", userID, category).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "指定されたカテゴリの予算は既に存在します"})
+			return
+		}
+
+		budget := models.Budget{
+			UserID:         userID,
+			Category:       category,
+			MonthlyLimit:   req.MonthlyLimit,
+			AlertThreshold: req.AlertThreshold,
+		}
+
+		if err := db.Create(&budget).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算の作成に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, budget)
+	}
+}
+
+// UpdateBudgetHandler 予算更新ハンドラー
+// 予算上限とアラート閾値を更新する
+func UpdateBudgetHandler(c *gin.Context) {
+	UpdateBudgetHandlerWithDB(database.GetDB())(c)
+}
+
+// UpdateBudgetHandlerWithDB DB接続を注入可能な予算更新ハンドラー
+func UpdateBudgetHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budgetID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		// 対象の予算を検索（設定したユーザーのみ更新可能）
+		var budget models.Budget
+		if err := db.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "予算が見つかりません"})
+			return
+		}
+
+		var req struct {
+			MonthlyLimit   float64 `json:"monthly_limit" binding:"required"` // 月次予算上限（必須）
+			AlertThreshold int     `json:"alert_threshold"`                  // アラート閾値（省略時は現在の値を維持）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		if req.AlertThreshold == 0 {
+			req.AlertThreshold = budget.AlertThreshold
+		}
+		if msg := validateBudgetValues(req.MonthlyLimit, req.AlertThreshold); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		budget.MonthlyLimit = req.MonthlyLimit
+		budget.AlertThreshold = req.AlertThreshold
+
+		if err := db.Save(&budget).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算の更新に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, budget)
+	}
+}
+
+// DeleteBudgetHandler 予算削除ハンドラー
+// 予算と関連するアラート履歴を削除する
+func DeleteBudgetHandler(c *gin.Context) {
+	DeleteBudgetHandlerWithDB(database.GetDB())(c)
+}
+
+// DeleteBudgetHandlerWithDB DB接続を注入可能な予算削除ハンドラー
+func DeleteBudgetHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budgetID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		var budget models.Budget
+		if err := db.Where("id = ? AND user_id = ?", budgetID, userID).First(&budget).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "予算が見つかりません"})
+			return
+		}
+
+		// アラート履歴を先に削除（外部キー制約対応）
+		if err := db.Where("budget_id = ?", budget.ID).Delete(&models.BudgetAlert{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算アラートの削除に失敗しました"})
+			return
+		}
+
+		if err := db.Delete(&budget).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算の削除に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "予算を削除しました"})
+	}
+}
+
+// GetBudgetStatusHandler 予算消化状況取得ハンドラー
+// 指定年月（省略時は当月）の予算ごとの使用額・残額・使用率を返す
+func GetBudgetStatusHandler(c *gin.Context) {
+	GetBudgetStatusHandlerWithDB(database.GetDB())(c)
+}
+
+// GetBudgetStatusHandlerWithDB DB接続を注入可能な予算消化状況取得ハンドラー
+func GetBudgetStatusHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// クエリパラメータから年月を取得（省略時は当月）
+		now := time.Now()
+		year, month := now.Year(), int(now.Month())
+		if v := c.Query("year"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "年の指定が無効です"})
+				return
+			}
+			year = parsed
+		}
+		if v := c.Query("month"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > 12 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "月の指定が無効です"})
+				return
+			}
+			month = parsed
+		}
+
+		statuses, err := calculateBudgetStatuses(db, userID, year, month)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算消化状況の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"year":    year,
+			"month":   month,
+			"budgets": statuses,
+		})
+	}
+}
+
+// GetBudgetAlertsHandler 予算アラート一覧取得ハンドラー
+// ログインユーザー宛ての予算アラートを新しい順に返す
+func GetBudgetAlertsHandler(c *gin.Context) {
+	GetBudgetAlertsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetBudgetAlertsHandlerWithDB DB接続を注入可能な予算アラート一覧取得ハンドラー
+func GetBudgetAlertsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var alerts []models.BudgetAlert
+		err := db.Where("user_id = ?", userID).
+			Order("created_at DESC, id DESC").
+			Limit(100).
+			Find(&alerts).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予算アラートの取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+	}
+}
+
+// validateBudgetValues 予算上限とアラート閾値の妥当性を検証する
+// 問題がある場合はエラーメッセージを返す
+func validateBudgetValues(limit float64, threshold int) string {
+	if limit <= 0 {
+		return "予算上限は0より大きい金額を指定してください"
+	}
+	if threshold < 1 || threshold > 100 {
+		return "アラート閾値は1〜100の範囲で指定してください"
+	}
+	return ""
+}
+
+// calculateBudgetStatuses 指定ユーザー・年月の予算消化状況を計算する
+// ユーザーが請求者または支払者である家計簿の項目の税込金額を集計して予算と比較する
+// 税込金額は家計簿の表示と同じく、税率ごとに端数処理した消費税を項目に按分した金額を使用する
+// 家計簿の年月で集計するため、任意期間の家計簿は期間の開始日の月に全額を計上する
+func calculateBudgetStatuses(db *gorm.DB, userID uint, year, month int) ([]models.BudgetStatus, error) {
+	var budgets []models.Budget
+	if err := db.Where("user_id = ?", userID).Order("category ASC").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.BudgetStatus, 0, len(budgets))
+	if len(budgets) == 0 {
+		return statuses, nil
+	}
+
+	var bills []models.MonthlyBill
+	err := db.Preload("Items").
+		Where("year = ? AND month = ?", year, month).
+		Where("requester_id = ? OR payer_id = ?", userID, userID).
+		Find(&bills).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 項目名（カテゴリ）ごとの支出を銭単位で集計
+	var overallSen int64
+	byCategorySen := map[string]int64{}
+	for _, bill := range bills {
+		for _, item := range newBillResponse(bill).Split {
+			amountSen := toSen(item.Amount)
+			byCategorySen[item.ItemName] += amountSen
+			overallSen += amountSen
+		}
+	}
+
+	for _, budget := range budgets {
+		used := fromSen(byCategorySen[budget.Category])
+		if budget.IsOverall() {
+			used = fromSen(overallSen)
+		}
+		statuses = append(statuses, buildBudgetStatus(budget, year, month, used))
+	}
+
+	return statuses, nil
+}
+
+// buildBudgetStatus 予算と使用額から消化状況を組み立てる
+func buildBudgetStatus(budget models.Budget, year, month int, used float64) models.BudgetStatus {
+	percentage := 0.0
+	if budget.MonthlyLimit > 0 {
+		percentage = used / budget.MonthlyLimit * 100
+	}
+
+	return models.BudgetStatus{
+		BudgetID:       budget.ID,
+		Category:       budget.Category,
+		Year:           year,
+		Month:          month,
+		Limit:          budget.MonthlyLimit,
+		Used:           used,
+		Remaining:      budget.MonthlyLimit - used,
+		Percentage:     percentage,
+		AlertThreshold: budget.AlertThreshold,
+		Exceeded:       used > budget.MonthlyLimit,
+	}
+}
+
+// budgetThresholds 予算に対してアラートを発生させる閾値の一覧
+// 設定された閾値に加え、予算上限（100%）到達時にも必ずアラートを発生させる
+func budgetThresholds(budget models.Budget) []int {
+	if budget.AlertThreshold >= 100 {
+		return []int{100}
+	}
+	return []int{budget.AlertThreshold, 100}
+}
+
+// snapshotBudgetUsage 家計簿の当事者全員の予算消化状況を取得する
+// 項目更新前の状態を記録し、閾値の超過判定に使用する
+func snapshotBudgetUsage(db *gorm.DB, bill models.MonthlyBill) map[uint]models.BudgetStatus {
+	snapshot := make(map[uint]models.BudgetStatus)
+	for _, participantID := range []uint{bill.RequesterID, bill.PayerID} {
+		statuses, err := calculateBudgetStatuses(db, participantID, bill.Year, bill.Month)
+		if err != nil {
+			log.Printf("⚠️ 予算消化状況の取得に失敗しました (user=%d): %v", participantID, err)
+			continue
+		}
+		for _, status := range statuses {
+			snapshot[status.BudgetID] = status
+		}
+	}
+	return snapshot
+}
+
+// raiseBudgetAlerts 項目更新前後の予算消化状況を比較し、閾値を超えた予算のアラートを発生させる
+// 同一予算・年月・閾値のアラートは一度だけ記録する
+func raiseBudgetAlerts(db *gorm.DB, bill models.MonthlyBill, before map[uint]models.BudgetStatus) []models.BudgetAlert {
+	var alerts []models.BudgetAlert
+
+	for _, participantID := range []uint{bill.RequesterID, bill.PayerID} {
+		var budgets []models.Budget
+		if err := db.Where("user_id = ?", participantID).Find(&budgets).Error; err != nil {
+			log.Printf("⚠️ 予算の取得に失敗しました (user=%d): %v", participantID, err)
+			continue
+		}
+		if len(budgets) == 0 {
+			continue
+		}
+
+		after, err := calculateBudgetStatuses(db, participantID, bill.Year, bill.Month)
+		if err != nil {
+			log.Printf("⚠️ 予算消化状況の取得に失敗しました (user=%d): %v", participantID, err)
+			continue
+		}
+
+		budgetByID := make(map[uint]models.Budget, len(budgets))
+		for _, budget := range budgets {
+			budgetByID[budget.ID] = budget
+		}
+
+		for _, status := range after {
+			budget := budgetByID[status.BudgetID]
+			previous := before[status.BudgetID].Percentage
+
+			for _, threshold := range budgetThresholds(budget) {
+				// 今回の更新で閾値をまたいだ場合のみアラート
+				if previous >= float64(threshold) || status.Percentage < float64(threshold) {
+					continue
+				}
+
+				var count int64
+				db.Model(&models.BudgetAlert{}).
+					Where("budget_id = ? AND year = ? AND month = ? AND threshold = ?",
+						budget.ID, bill.Year, bill.Month, threshold).
+					Count(&count)
+				if count > 0 {
+					continue
+				}
+
+				alert := models.BudgetAlert{
+					BudgetID:    budget.ID,
+					UserID:      participantID,
+					BillID:      bill.ID,
+					Year:        bill.Year,
+					Month:       bill.Month,
+					Category:    budget.Category,
+					Threshold:   threshold,
+					UsedAmount:  status.Used,
+					LimitAmount: status.Limit,
+				}
+				if err := db.Create(&alert).Error; err != nil {
+					log.Printf("⚠️ 予算アラートの記録に失敗しました (budget=%d): %v", budget.ID, err)
+					continue
+				}
+
+				log.Printf("💰 Budget alert: user=%d budget=%d category=%q %d-%02d threshold=%d%% used=%.2f/%.2f",
+					participantID, budget.ID, budget.Category, bill.Year, bill.Month, threshold, status.Used, status.Limit)
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+
+	return alerts
+}
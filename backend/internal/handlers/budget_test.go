@@ -0,0 +1,316 @@
+// ========================================
+// 予算ハンドラーの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupInMemoryDB テスト用のインメモリDBを作成し、終了時にクリーンアップする
+func setupInMemoryDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.SetupInMemoryTestDB(t.Name())
+	require.NoError(t, err, "インメモリDBの作成に失敗しました")
+	t.Cleanup(func() {
+		database.CleanupInMemoryTestDB(db, t.Name())
+	})
+
+	return db
+}
+
+// setupBudgetScenario 予算テスト用のユーザー2名と空の家計簿を作成する
+func setupBudgetScenario(t *testing.T, db *gorm.DB) (models.User, models.User, models.MonthlyBill) {
+	t.Helper()
+
+	factory := testfactory.NewTestDataFactory(db)
+	requester, err := factory.NewUser().WithName("請求者").WithAccountID("budget_requester").Build()
+	require.NoError(t, err)
+	payer, err := factory.NewUser().WithName("支払者").WithAccountID("budget_payer").Build()
+	require.NoError(t, err)
+
+	bill, _, err := factory.NewBill().
+		WithRequester(requester.ID).
+		WithPayer(payer.ID).
+		WithYearMonth(2024, 5).
+		Build()
+	require.NoError(t, err)
+
+	return requester, payer, bill
+}
+
+// performJSONRequest JSONボディ付きリクエストを実行する
+func performJSONRequest(handler http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// TestCreateBudgetHandler_Success 予算を作成でき、閾値省略時は80%になることを検証
+func TestCreateBudgetHandler_Success(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, _ := setupBudgetScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/budgets", setUserID(requester.ID), CreateBudgetHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/budgets", map[string]interface{}{
+		"category":      "食費",
+		"monthly_limit": 30000,
+	})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var budget models.Budget
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &budget))
+	assert.Equal(t, "食費", budget.Category)
+	assert.Equal(t, 30000.0, budget.MonthlyLimit)
+	assert.Equal(t, 80, budget.AlertThreshold)
+	assert.Equal(t, requester.ID, budget.UserID)
+}
+
+// TestCreateBudgetHandler_Duplicate 同一カテゴリの予算は重複作成できないことを検証
+func TestCreateBudgetHandler_Duplicate(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, _ := setupBudgetScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/budgets", setUserID(requester.ID), CreateBudgetHandlerWithDB(db))
+
+	body := map[string]interface{}{"category": "食費", "monthly_limit": 30000}
+	assert.Equal(t, http.StatusCreated, performJSONRequest(router, "POST", "/budgets", body).Code)
+	assert.Equal(t, http.StatusConflict, performJSONRequest(router, "POST", "/budgets", body).Code)
+}
+
+// TestCreateBudgetHandler_InvalidValues 不正な予算上限・閾値が拒否されることを検証
+func TestCreateBudgetHandler_InvalidValues(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, _ := setupBudgetScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/budgets", setUserID(requester.ID), CreateBudgetHandlerWithDB(db))
+
+	cases := []map[string]interface{}{
+		{"category": "食費", "monthly_limit": -100},
+		{"category": "食費", "monthly_limit": 1000, "alert_threshold": 150},
+	}
+	for _, body := range cases {
+		w := performJSONRequest(router, "POST", "/budgets", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "body=%v", body)
+	}
+}
+
+// TestGetBudgetStatusHandler_Calculation カテゴリ別・全体の使用額と使用率が計算されることを検証
+func TestGetBudgetStatusHandler_Calculation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, bill := setupBudgetScenario(t, db)
+
+	db.Create(&models.BillItem{BillID: bill.ID, ItemName: "食費", Amount: 6000})
+	db.Create(&models.BillItem{BillID: bill.ID, ItemName: "光熱費", Amount: 4000})
+	db.Create(&models.Budget{UserID: requester.ID, Category: "食費", MonthlyLimit: 10000, AlertThreshold: 80})
+	db.Create(&models.Budget{UserID: requester.ID, Category: "", MonthlyLimit: 8000, AlertThreshold: 80})
+
+	router := setupRouter()
+	router.GET("/budgets/status", setUserID(requester.ID), GetBudgetStatusHandlerWithDB(db))
+
+	w := performJSONRequest(router, "GET", "/budgets/status?year=2024&month=5", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Budgets []models.BudgetStatus `json:"budgets"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Budgets, 2)
+
+	statuses := make(map[string]models.BudgetStatus)
+	for _, status := range response.Budgets {
+		statuses[status.Category] = status
+	}
+
+	food := statuses["食費"]
+	assert.Equal(t, 6000.0, food.Used)
+	assert.Equal(t, 4000.0, food.Remaining)
+	assert.InDelta(t, 60.0, food.Percentage, 0.001)
+	assert.False(t, food.Exceeded)
+
+	overall := statuses[""]
+	assert.Equal(t, 10000.0, overall.Used)
+	assert.Equal(t, -2000.0, overall.Remaining)
+	assert.True(t, overall.Exceeded)
+}
+
+// TestGetBudgetStatusHandler_TaxExclusiveItems 税抜の項目は消費税を含めた金額で予算を消化することを検証
+func TestGetBudgetStatusHandler_TaxExclusiveItems(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, bill := setupBudgetScenario(t, db)
+
+	db.Create(&models.BillItem{BillID: bill.ID, ItemName: "食費", Amount: 5000, TaxRate: models.TaxRateReduced, TaxExclusive: true})
+	db.Create(&models.BillItem{BillID: bill.ID, ItemName: "光熱費", Amount: 3000, TaxRate: models.TaxRateStandard})
+	db.Create(&models.Budget{UserID: requester.ID, Category: "食費", MonthlyLimit: 5200, AlertThreshold: 80})
+	db.Create(&models.Budget{UserID: requester.ID, Category: "", MonthlyLimit: 10000, AlertThreshold: 80})
+
+	router := setupRouter()
+	router.GET("/budgets/status", setUserID(requester.ID), GetBudgetStatusHandlerWithDB(db))
+
+	w := performJSONRequest(router, "GET", "/budgets/status?year=2024&month=5", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Budgets []models.BudgetStatus `json:"budgets"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	statuses := make(map[string]models.BudgetStatus)
+	for _, status := range response.Budgets {
+		statuses[status.Category] = status
+	}
+
+	// 税抜5,000円に軽減税率8%の消費税400円を加えた金額
+	food := statuses["食費"]
+	assert.Equal(t, 5400.0, food.Used)
+	assert.True(t, food.Exceeded)
+
+	// 全体の使用額は家計簿の税込合計と一致する
+	require.NoError(t, db.Preload("Items").First(&bill, bill.ID).Error)
+	overall := statuses[""]
+	assert.Equal(t, 8400.0, overall.Used)
+	assert.Equal(t, newBillResponse(bill).GrandTotal, overall.Used)
+}
+
+// TestGetBudgetStatusHandler_InvalidMonth 不正な月指定が拒否されることを検証
+func TestGetBudgetStatusHandler_InvalidMonth(t *testing.T) {
+	db := setupInMemoryDB(t)
+
+	router := setupRouter()
+	router.GET("/budgets/status", setUserID(1), GetBudgetStatusHandlerWithDB(db))
+
+	w := performJSONRequest(router, "GET", "/budgets/status?year=2024&month=13", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestUpdateItemsHandler_RaisesBudgetAlerts 項目保存時に閾値を超えるとアラートが一度だけ発生することを検証
+func TestUpdateItemsHandler_RaisesBudgetAlerts(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, payer, bill := setupBudgetScenario(t, db)
+
+	budget := models.Budget{UserID: requester.ID, Category: "食費", MonthlyLimit: 10000, AlertThreshold: 80}
+	require.NoError(t, db.Create(&budget).Error)
+	payerBudget := models.Budget{UserID: payer.ID, Category: "", MonthlyLimit: 100000, AlertThreshold: 50}
+	require.NoError(t, db.Create(&payerBudget).Error)
+
+	router := setupRouter()
+	router.PUT("/bills/:id/items", setUserID(requester.ID), UpdateItemsHandlerWithDB(db))
+	path := fmt.Sprintf("/bills/%d/items", bill.ID)
+
+	// 80%未満ではアラートなし
+	w := performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "食費", "amount": 7000}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response.BudgetAlerts)
+	require.Len(t, response.Budgets, 1)
+	assert.InDelta(t, 70.0, response.Budgets[0].Percentage, 0.001)
+
+	// 80%を超えるとアラート発生
+	w = performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "食費", "amount": 8500}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	response = models.BillResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.BudgetAlerts, 1)
+	assert.Equal(t, 80, response.BudgetAlerts[0].Threshold)
+	assert.Equal(t, budget.ID, response.BudgetAlerts[0].BudgetID)
+
+	// 100%を超えると上限到達アラートが追加で発生
+	w = performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "食費", "amount": 12000}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	response = models.BillResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.BudgetAlerts, 1)
+	assert.Equal(t, 100, response.BudgetAlerts[0].Threshold)
+	assert.True(t, response.Budgets[0].Exceeded)
+
+	// 一度下回ってから再度超えても同じ閾値のアラートは重複しない
+	performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "食費", "amount": 1000}},
+	})
+	w = performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "食費", "amount": 9000}},
+	})
+	response = models.BillResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response.BudgetAlerts)
+
+	var requesterAlerts int64
+	db.Model(&models.BudgetAlert{}).Where("user_id = ?", requester.ID).Count(&requesterAlerts)
+	assert.Equal(t, int64(2), requesterAlerts)
+
+	// 支払者の予算は閾値未満のためアラートなし
+	var payerAlerts int64
+	db.Model(&models.BudgetAlert{}).Where("user_id = ?", payer.ID).Count(&payerAlerts)
+	assert.Equal(t, int64(0), payerAlerts)
+}
+
+// TestGetBillHandler_IncludesBudgetStatus 家計簿取得レスポンスに予算消化状況が含まれることを検証
+func TestGetBillHandler_IncludesBudgetStatus(t *testing.T) {
+	db := setupInMemoryDB(t)
+	_, payer, bill := setupBudgetScenario(t, db)
+
+	db.Create(&models.BillItem{BillID: bill.ID, ItemName: "食費", Amount: 3000})
+	db.Create(&models.Budget{UserID: payer.ID, Category: "", MonthlyLimit: 10000, AlertThreshold: 80})
+
+	router := setupRouter()
+	router.GET("/bills/:year/:month", setUserID(payer.ID), GetBillHandlerWithDB(db))
+
+	w := performJSONRequest(router, "GET", "/bills/2024/5", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Budgets, 1)
+	assert.Equal(t, 3000.0, response.Budgets[0].Used)
+	assert.Equal(t, 7000.0, response.Budgets[0].Remaining)
+}
+
+// TestDeleteBudgetHandler_OtherUser 他ユーザーの予算は削除できないことを検証
+func TestDeleteBudgetHandler_OtherUser(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, payer, _ := setupBudgetScenario(t, db)
+
+	budget := models.Budget{UserID: requester.ID, Category: "食費", MonthlyLimit: 10000, AlertThreshold: 80}
+	require.NoError(t, db.Create(&budget).Error)
+
+	router := setupRouter()
+	router.DELETE("/budgets/:id", setUserID(payer.ID), DeleteBudgetHandlerWithDB(db))
+
+	w := performJSONRequest(router, "DELETE", fmt.Sprintf("/budgets/%d", budget.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
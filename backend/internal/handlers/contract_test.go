@@ -332,6 +332,34 @@ func (suite *HandlerContractTestSuite) TestCreateBillHandler_DuplicateErrorContr
 	assert.Contains(suite.T(), errorMessage, "指定された年月の家計簿は既に存在します", "適切なエラーメッセージが返されていません")
 }
 
+// TestCreateBillHandler_ValidationErrorContract 家計簿作成の検証エラーが検証エラー契約に適合することを検証
+func (suite *HandlerContractTestSuite) TestCreateBillHandler_ValidationErrorContract() {
+	db := setupInMemoryDB(suite.T())
+	testRouter := setupRouter()
+	testRouter.POST("/bills", setUserID(1), CreateBillHandlerWithDB(db))
+
+	requests := []string{
+		`{"year": 2024, "month": 13, "payer_id": 99999}`, // 範囲外の月・存在しない支払者
+		`{"year": "2024", "month": 3, "payer_id": 2}`,    // 型の不正（バインドエラー）
+		`{"year": 2024`, // JSONとして不正
+	}
+
+	for _, body := range requests {
+		req, _ := http.NewRequest("POST", "/bills", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+
+		err := suite.verifier.ValidateStatusCode(w.Code, testmocks.GetValidationErrorContract().StatusCode)
+		assert.NoError(suite.T(), err, "検証エラーのステータスコード契約違反: %s", body)
+
+		var response map[string]interface{}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		err = testmocks.ValidateValidationErrorResponse(suite.verifier, response)
+		assert.NoError(suite.T(), err, "検証エラーのレスポンス契約違反: %s", body)
+	}
+}
+
 // TestGetBillHandler_ContractCompliance 家計簿詳細ハンドラー契約適合テスト
 func (suite *HandlerContractTestSuite) TestGetBillHandler_ContractCompliance() {
 	contract := suite.contracts["get_bill"]
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+)
+
+// GetHouseholdsHandler 世帯一覧取得ハンドラー
+// ログインユーザーが所属する世帯とそのメンバーを返す
+func GetHouseholdsHandler(c *gin.Context) {
+	GetHouseholdsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetHouseholdsHandlerWithDB DB接続を注入可能な世帯一覧取得ハンドラー
+func GetHouseholdsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		households := []models.Household{}
+		err := db.Preload("Members").Preload("Members.User").
+			Where("id IN (?)", db.Model(&models.HouseholdMember{}).Select("household_id").Where("user_id = ?", userID)).
+			Order("id ASC").
+			Find(&households).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "世帯一覧の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"households": households})
+	}
+}
+
+// CreateHouseholdHandler 世帯作成ハンドラー
+// 新しい世帯を作成し、作成者を世帯管理者として登録する
+func CreateHouseholdHandler(c *gin.Context) {
+	CreateHouseholdHandlerWithDB(database.GetDB())(c)
+}
+
+// CreateHouseholdHandlerWithDB DB接続を注入可能な世帯作成ハンドラー
+func CreateHouseholdHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			Name string `json:"name" binding:"required"` // 世帯名（必須）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" || len([]rune(name)) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "世帯名は1文字以上100文字以下で入力してください"})
+			return
+		}
+
+		household := models.Household{Name: name, CreatedBy: userID}
+
+		// 世帯と管理者メンバーシップを同時に作成
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&household).Error; err != nil {
+				return err
+			}
+			owner := models.HouseholdMember{
+				HouseholdID: household.ID,
+				UserID:      userID,
+				Role:        models.HouseholdRoleOwner,
+			}
+			return tx.Create(&owner).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "世帯の作成に失敗しました"})
+			return
+		}
+
+		db.Preload("Members").Preload("Members.User").First(&household, household.ID)
+
+		c.JSON(http.StatusCreated, household)
+	}
+}
+
+// householdInvitationSentMessage 招待作成時のレスポンスのメッセージ（アカウントの有無にかかわらず同じ）
+const householdInvitationSentMessage = "アカウントが登録されている場合は、招待を送信しました"
+
+// CreateHouseholdInvitationHandler 世帯招待作成ハンドラー
+// 世帯管理者がアカウントIDを指定して既存ユーザーを世帯に招待する
+// アカウントIDの有無を知られないよう、存在しないアカウントでも招待を作成した場合と同じレスポンスを返す
+func CreateHouseholdInvitationHandler(c *gin.Context) {
+	CreateHouseholdInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// CreateHouseholdInvitationHandlerWithDB DB接続を注入可能な世帯招待作成ハンドラー
+func CreateHouseholdInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		householdID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		// 世帯管理者のみ招待可能
+		membership, err := findHouseholdMembership(db, uint(householdID), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "世帯が見つかりません"})
+			return
+		}
+		if !membership.IsOwner() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "世帯への招待は管理者のみ可能です"})
+			return
+		}
+
+		var req struct {
+			AccountID string `json:"account_id" binding:"required"` // 招待するユーザーのアカウントID（必須）
+			Role      string `json:"role"`                          // 参加時の役割（省略時はmember）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		role := req.Role
+		if role == "" {
+			role = models.HouseholdRoleMember
+		}
+		if role != models.HouseholdRoleMember && role != models.HouseholdRoleOwner {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "役割はownerまたはmemberを指定してください"})
+			return
+		}
+
+		// 招待対象のユーザーを検索
+		var invitee models.User
+		if err := db.Where("account_id = ?", req.AccountID).First(&invitee).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "招待の作成に失敗しました"})
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{"message": householdInvitationSentMessage})
+			return
+		}
+
+		// 既にメンバーの場合や回答待ちの招待が既に存在する場合は作成しない
+		// アカウントの有無や招待の状況を推測できないよう、送信時と同じレスポンスを返す
+		if _, err := findHouseholdMembership(db, uint(householdID), invitee.ID); err == nil {
+			c.JSON(http.StatusAccepted, gin.H{"message": householdInvitationSentMessage})
+			return
+		}
+
+		var pending int64
+		if err := db.Model(&models.HouseholdInvitation{}).
+			Where("household_id = ? AND invitee_id = ? AND status = ?", householdID, invitee.ID, models.InvitationStatusPending).
+			Count(&pending).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待の作成に失敗しました"})
+			return
+		}
+		if pending > 0 {
+			c.JSON(http.StatusAccepted, gin.H{"message": householdInvitationSentMessage})
+			return
+		}
+
+		invitation := models.HouseholdInvitation{
+			HouseholdID:      uint(householdID),
+			InviterID:        userID,
+			InviteeID:        invitee.ID,
+			InviteeAccountID: req.AccountID,
+			Role:             role,
+			Status:           models.InvitationStatusPending,
+		}
+		if err := db.Create(&invitation).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待の作成に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": householdInvitationSentMessage})
+	}
+}
+
+// GetHouseholdInvitationsHandler 世帯の招待一覧取得ハンドラー
+// 世帯管理者が送信済みの招待一覧を確認する（招待されたユーザーの情報は含めず、入力したアカウントIDのみ返す）
+func GetHouseholdInvitationsHandler(c *gin.Context) {
+	GetHouseholdInvitationsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetHouseholdInvitationsHandlerWithDB DB接続を注入可能な世帯の招待一覧取得ハンドラー
+func GetHouseholdInvitationsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		householdID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		membership, err := findHouseholdMembership(db, uint(householdID), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "世帯が見つかりません"})
+			return
+		}
+		if !membership.IsOwner() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "招待一覧は管理者のみ確認できます"})
+			return
+		}
+
+		invitations := []models.HouseholdInvitation{}
+		err = db.Preload("Inviter").
+			Where("household_id = ?", householdID).
+			Order("created_at DESC, id DESC").
+			Find(&invitations).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待一覧の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+	}
+}
+
+// RevokeHouseholdInvitationHandler 世帯招待取り消しハンドラー
+// 世帯管理者が回答待ちの招待を取り消す
+func RevokeHouseholdInvitationHandler(c *gin.Context) {
+	RevokeHouseholdInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// RevokeHouseholdInvitationHandlerWithDB DB接続を注入可能な世帯招待取り消しハンドラー
+func RevokeHouseholdInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		householdID, _ := strconv.Atoi(c.Param("id"))
+		invitationID, _ := strconv.Atoi(c.Param("invitation_id"))
+		userID := c.GetUint("user_id")
+
+		membership, err := findHouseholdMembership(db, uint(householdID), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "世帯が見つかりません"})
+			return
+		}
+		if !membership.IsOwner() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "招待の取り消しは管理者のみ可能です"})
+			return
+		}
+
+		var invitation models.HouseholdInvitation
+		if err := db.Where("id = ? AND household_id = ?", invitationID, householdID).First(&invitation).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "招待が見つかりません"})
+			return
+		}
+		if invitation.Status != models.InvitationStatusPending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "回答済みの招待は取り消せません"})
+			return
+		}
+
+		now := time.Now()
+		invitation.Status = models.InvitationStatusRevoked
+		invitation.RespondedAt = &now
+		if err := db.Save(&invitation).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待の更新に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "招待を取り消しました"})
+	}
+}
+
+// GetMyInvitationsHandler 受信招待一覧取得ハンドラー
+// ログインユーザー宛ての回答待ちの招待を返す
+func GetMyInvitationsHandler(c *gin.Context) {
+	GetMyInvitationsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetMyInvitationsHandlerWithDB DB接続を注入可能な受信招待一覧取得ハンドラー
+func GetMyInvitationsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		invitations := []models.HouseholdInvitation{}
+		err := db.Preload("Household").Preload("Inviter").
+			Where("invitee_id = ? AND status = ?", userID, models.InvitationStatusPending).
+			Order("created_at DESC, id DESC").
+			Find(&invitations).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待一覧の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+	}
+}
+
+// AcceptHouseholdInvitationHandler 世帯招待承諾ハンドラー
+// 招待されたユーザーが招待を承諾し、世帯のメンバーになる
+func AcceptHouseholdInvitationHandler(c *gin.Context) {
+	AcceptHouseholdInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// AcceptHouseholdInvitationHandlerWithDB DB接続を注入可能な世帯招待承諾ハンドラー
+func AcceptHouseholdInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return respondHouseholdInvitation(db, models.InvitationStatusAccepted)
+}
+
+// DeclineHouseholdInvitationHandler 世帯招待辞退ハンドラー
+// 招待されたユーザーが招待を辞退する
+func DeclineHouseholdInvitationHandler(c *gin.Context) {
+	DeclineHouseholdInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// DeclineHouseholdInvitationHandlerWithDB DB接続を注入可能な世帯招待辞退ハンドラー
+func DeclineHouseholdInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return respondHouseholdInvitation(db, models.InvitationStatusDeclined)
+}
+
+// respondHouseholdInvitation 招待への回答（承諾・辞退）を処理する共通ハンドラー
+func respondHouseholdInvitation(db *gorm.DB, status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invitationID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		// 招待されたユーザー本人のみ回答可能
+		var invitation models.HouseholdInvitation
+		if err := db.Where("id = ? AND invitee_id = ?", invitationID, userID).First(&invitation).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "招待が見つかりません"})
+			return
+		}
+		if invitation.Status != models.InvitationStatusPending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "この招待は既に回答済みです"})
+			return
+		}
+
+		now := time.Now()
+		invitation.Status = status
+		invitation.RespondedAt = &now
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&invitation).Error; err != nil {
+				return err
+			}
+			if status != models.InvitationStatusAccepted {
+				return nil
+			}
+			// 承諾時はメンバーとして登録（既にメンバーの場合は何もしない）
+			if _, err := findHouseholdMembership(tx, invitation.HouseholdID, userID); err == nil {
+				return nil
+			}
+			member := models.HouseholdMember{
+				HouseholdID: invitation.HouseholdID,
+				UserID:      userID,
+				Role:        invitation.Role,
+			}
+			return tx.Create(&member).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待の更新に失敗しました"})
+			return
+		}
+
+		if status == models.InvitationStatusAccepted {
+			c.JSON(http.StatusOK, gin.H{"message": "世帯に参加しました"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "招待を辞退しました"})
+	}
+}
+
+// RemoveHouseholdMemberHandler 世帯メンバー削除ハンドラー
+// 世帯管理者によるメンバーの削除、またはメンバー本人の脱退を行う
+func RemoveHouseholdMemberHandler(c *gin.Context) {
+	RemoveHouseholdMemberHandlerWithDB(database.GetDB())(c)
+}
+
+// RemoveHouseholdMemberHandlerWithDB DB接続を注入可能な世帯メンバー削除ハンドラー
+func RemoveHouseholdMemberHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		householdID, _ := strconv.Atoi(c.Param("id"))
+		targetUserID, _ := strconv.Atoi(c.Param("user_id"))
+		userID := c.GetUint("user_id")
+
+		membership, err := findHouseholdMembership(db, uint(householdID), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "世帯が見つかりません"})
+			return
+		}
+
+		// 本人の脱退以外は管理者のみ可能
+		if uint(targetUserID) != userID && !membership.IsOwner() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "メンバーの削除は管理者のみ可能です"})
+			return
+		}
+
+		target, err := findHouseholdMembership(db, uint(householdID), uint(targetUserID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "メンバーが見つかりません"})
+			return
+		}
+
+		// 最後の管理者は削除できない（管理者不在の世帯を作らない）
+		if target.IsOwner() {
+			var owners int64
+			db.Model(&models.HouseholdMember{}).
+				Where("household_id = ? AND role = ?", householdID, models.HouseholdRoleOwner).
+				Count(&owners)
+			if owners <= 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "最後の管理者は世帯から削除できません"})
+				return
+			}
+		}
+
+		if err := db.Delete(target).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メンバーの削除に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "メンバーを削除しました"})
+	}
+}
+
+// findHouseholdMembership 指定ユーザーの世帯メンバーシップを取得する
+func findHouseholdMembership(db *gorm.DB, householdID, userID uint) (*models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	if err := db.Where("household_id = ? AND user_id = ?", householdID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// householdPeerIDs 指定ユーザーと世帯を共有するユーザーIDのサブクエリ（本人を含む）
+func householdPeerIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("household_members AS peers").
+		Select("DISTINCT peers.user_id").
+		Joins("JOIN household_members AS mine ON mine.household_id = peers.household_id").
+		Where("mine.user_id = ?", userID)
+}
+
+// sharesHousehold 2人のユーザーが同じ世帯に所属しているか判定する
+func sharesHousehold(db *gorm.DB, userID, otherUserID uint) (bool, error) {
+	var count int64
+	err := db.Table("household_members AS peers").
+		Joins("JOIN household_members AS mine ON mine.household_id = peers.household_id").
+		Where("mine.user_id = ? AND peers.user_id = ?", userID, otherUserID).
+		Count(&count).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,274 @@
+// ========================================
+// 世帯ハンドラーの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupHouseholdScenario 世帯テスト用のユーザー3名と、1人目が管理者・2人目がメンバーの世帯を作成する
+func setupHouseholdScenario(t *testing.T, db *gorm.DB) (models.User, models.User, models.User, models.Household) {
+	t.Helper()
+
+	factory := testfactory.NewTestDataFactory(db)
+	owner := factory.NewUser().WithName("管理者").WithAccountID("household_owner").MustBuild()
+	member := factory.NewUser().WithName("メンバー").WithAccountID("household_member").MustBuild()
+	outsider := factory.NewUser().WithName("部外者").WithAccountID("household_outsider").MustBuild()
+
+	household := factory.NewHousehold().WithName("テスト家").WithOwner(owner.ID).WithMembers(member.ID).MustBuild()
+
+	return owner, member, outsider, household
+}
+
+// TestCreateHouseholdHandler_Success 世帯を作成すると作成者が管理者になることを検証
+func TestCreateHouseholdHandler_Success(t *testing.T) {
+	db := setupInMemoryDB(t)
+	_, _, outsider, _ := setupHouseholdScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/households", setUserID(outsider.ID), CreateHouseholdHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/households", map[string]interface{}{"name": "  新しい世帯 "})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var household models.Household
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &household))
+	assert.Equal(t, "新しい世帯", household.Name)
+	require.Len(t, household.Members, 1)
+	assert.Equal(t, outsider.ID, household.Members[0].UserID)
+	assert.True(t, household.Members[0].IsOwner())
+}
+
+// TestHouseholdInvitation_AcceptFlow 招待を承諾するとメンバーになり、家計簿の支払者に指定できることを検証
+func TestHouseholdInvitation_AcceptFlow(t *testing.T) {
+	db := setupInMemoryDB(t)
+	owner, member, outsider, household := setupHouseholdScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/households/:id/invitations", setUserID(owner.ID), CreateHouseholdInvitationHandlerWithDB(db))
+	router.GET("/households/:id/invitations", setUserID(owner.ID), GetHouseholdInvitationsHandlerWithDB(db))
+	router.GET("/invitations", setUserID(outsider.ID), GetMyInvitationsHandlerWithDB(db))
+	router.PUT("/invitations/:id/accept", setUserID(outsider.ID), AcceptHouseholdInvitationHandlerWithDB(db))
+	router.POST("/bills", setUserID(owner.ID), CreateBillHandlerWithDB(db))
+
+	// 世帯外のユーザーは支払者に指定できない
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": 2024, "month": 6, "payer_id": outsider.ID})
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// 招待を作成
+	w = performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+		map[string]interface{}{"account_id": outsider.AccountID})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	sentBody := w.Body.String()
+
+	// 存在しないアカウントでも同じレスポンス
+	w = performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+		map[string]interface{}{"account_id": "unknown_account"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, sentBody, w.Body.String())
+
+	// 同じユーザーへの2回目の招待・既存メンバーへの招待も同じレスポンス（招待は作成しない）
+	for _, accountID := range []string{outsider.AccountID, member.AccountID} {
+		w = performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+			map[string]interface{}{"account_id": accountID})
+		assert.Equal(t, http.StatusAccepted, w.Code, accountID)
+		assert.Equal(t, sentBody, w.Body.String(), accountID)
+	}
+
+	// 世帯の招待一覧には入力したアカウントIDのみ表示し、招待されたユーザーの情報は含めない
+	w = performJSONRequest(router, "GET", fmt.Sprintf("/households/%d/invitations", household.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), outsider.Name)
+	assert.NotContains(t, w.Body.String(), `"invitee"`)
+	assert.NotContains(t, w.Body.String(), `"invitee_id"`)
+	var householdInvitations struct {
+		Invitations []models.HouseholdInvitation `json:"invitations"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &householdInvitations))
+	require.Len(t, householdInvitations.Invitations, 1)
+	assert.Equal(t, outsider.AccountID, householdInvitations.Invitations[0].InviteeAccountID)
+
+	// 受信した招待一覧に表示される
+	w = performJSONRequest(router, "GET", "/invitations", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listResponse struct {
+		Invitations []models.HouseholdInvitation `json:"invitations"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResponse))
+	require.Len(t, listResponse.Invitations, 1)
+	assert.Equal(t, "テスト家", listResponse.Invitations[0].Household.Name)
+
+	// 招待を承諾
+	w = performJSONRequest(router, "PUT", fmt.Sprintf("/invitations/%d/accept", listResponse.Invitations[0].ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	membership, err := findHouseholdMembership(db, household.ID, outsider.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HouseholdRoleMember, membership.Role)
+
+	// 承諾済みの招待には再回答できない
+	w = performJSONRequest(router, "PUT", fmt.Sprintf("/invitations/%d/accept", listResponse.Invitations[0].ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 世帯メンバーになったので支払者に指定できる
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": 2024, "month": 6, "payer_id": outsider.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestHouseholdInvitation_OwnerOnly 一般メンバーは招待を作成できないことを検証
+func TestHouseholdInvitation_OwnerOnly(t *testing.T) {
+	db := setupInMemoryDB(t)
+	_, member, outsider, household := setupHouseholdScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/households/:id/invitations", setUserID(member.ID), CreateHouseholdInvitationHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+		map[string]interface{}{"account_id": outsider.AccountID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestHouseholdInvitation_DeclineAndRevoke 辞退・取り消しした招待ではメンバーにならないことを検証
+func TestHouseholdInvitation_DeclineAndRevoke(t *testing.T) {
+	db := setupInMemoryDB(t)
+	owner, _, outsider, household := setupHouseholdScenario(t, db)
+
+	router := setupRouter()
+	router.POST("/households/:id/invitations", setUserID(owner.ID), CreateHouseholdInvitationHandlerWithDB(db))
+	router.DELETE("/households/:id/invitations/:invitation_id", setUserID(owner.ID), RevokeHouseholdInvitationHandlerWithDB(db))
+	router.PUT("/invitations/:id/decline", setUserID(outsider.ID), DeclineHouseholdInvitationHandlerWithDB(db))
+	router.PUT("/invitations/:id/accept", setUserID(outsider.ID), AcceptHouseholdInvitationHandlerWithDB(db))
+	router.GET("/invitations", setUserID(outsider.ID), GetMyInvitationsHandlerWithDB(db))
+
+	createInvitation := func() models.HouseholdInvitation {
+		w := performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+			map[string]interface{}{"account_id": outsider.AccountID})
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		// 作成した招待は招待されたユーザーの一覧から取得する
+		w = performJSONRequest(router, "GET", "/invitations", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var listResponse struct {
+			Invitations []models.HouseholdInvitation `json:"invitations"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResponse))
+		require.Len(t, listResponse.Invitations, 1)
+		return listResponse.Invitations[0]
+	}
+
+	// 辞退
+	declined := createInvitation()
+	w := performJSONRequest(router, "PUT", fmt.Sprintf("/invitations/%d/decline", declined.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 取り消し後は承諾できない
+	revoked := createInvitation()
+	w = performJSONRequest(router, "DELETE", fmt.Sprintf("/households/%d/invitations/%d", household.ID, revoked.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = performJSONRequest(router, "PUT", fmt.Sprintf("/invitations/%d/accept", revoked.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, err := findHouseholdMembership(db, household.ID, outsider.ID)
+	assert.Error(t, err)
+}
+
+// TestRemoveHouseholdMemberHandler 管理者によるメンバー削除と最後の管理者の保護を検証
+func TestRemoveHouseholdMemberHandler(t *testing.T) {
+	db := setupInMemoryDB(t)
+	owner, member, _, household := setupHouseholdScenario(t, db)
+
+	ownerRouter := setupRouter()
+	ownerRouter.DELETE("/households/:id/members/:user_id", setUserID(owner.ID), RemoveHouseholdMemberHandlerWithDB(db))
+	memberRouter := setupRouter()
+	memberRouter.DELETE("/households/:id/members/:user_id", setUserID(member.ID), RemoveHouseholdMemberHandlerWithDB(db))
+
+	// 一般メンバーは他人を削除できない
+	w := performJSONRequest(memberRouter, "DELETE", fmt.Sprintf("/households/%d/members/%d", household.ID, owner.ID), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 最後の管理者は脱退できない
+	w = performJSONRequest(ownerRouter, "DELETE", fmt.Sprintf("/households/%d/members/%d", household.ID, owner.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 管理者はメンバーを削除できる
+	w = performJSONRequest(ownerRouter, "DELETE", fmt.Sprintf("/households/%d/members/%d", household.ID, member.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	shared, err := sharesHousehold(db, owner.ID, member.ID)
+	require.NoError(t, err)
+	assert.False(t, shared)
+}
+
+// TestGetUsersHandler_HouseholdScope ユーザー一覧が同じ世帯のメンバーに限定されることを検証
+func TestGetUsersHandler_HouseholdScope(t *testing.T) {
+	db := setupInMemoryDB(t)
+	owner, member, outsider, _ := setupHouseholdScenario(t, db)
+
+	router := setupRouter()
+	router.GET("/users", setUserID(owner.ID), GetUsersHandlerWithDB(db))
+	router.GET("/outsider/users", setUserID(outsider.ID), GetUsersHandlerWithDB(db))
+
+	var response struct {
+		Users []models.User `json:"users"`
+	}
+
+	w := performJSONRequest(router, "GET", "/users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Users, 2)
+	ids := []uint{response.Users[0].ID, response.Users[1].ID}
+	assert.ElementsMatch(t, []uint{owner.ID, member.ID}, ids)
+
+	// 世帯に所属していないユーザーには空の一覧を返す
+	w = performJSONRequest(router, "GET", "/outsider/users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"users":[]}`, w.Body.String())
+}
+
+// TestUserPayloads_ExcludeEmail 家計簿・招待のレスポンスに含まれる相手のユーザー情報にメールアドレスが含まれないことを検証
+func TestUserPayloads_ExcludeEmail(t *testing.T) {
+	db := setupInMemoryDB(t)
+	owner, member, outsider, household := setupHouseholdScenario(t, db)
+	require.NoError(t, db.Model(&models.User{}).Where("id IN ?", []uint{owner.ID, member.ID, outsider.ID}).
+		Update("email", "private@example.com").Error)
+
+	router := setupRouter()
+	router.POST("/households/:id/invitations", setUserID(owner.ID), CreateHouseholdInvitationHandlerWithDB(db))
+	router.GET("/households/:id/invitations", setUserID(owner.ID), GetHouseholdInvitationsHandlerWithDB(db))
+	router.GET("/invitations", setUserID(outsider.ID), GetMyInvitationsHandlerWithDB(db))
+	router.POST("/bills", setUserID(owner.ID), CreateBillHandlerWithDB(db))
+	router.GET("/bills/:year/:month", setUserID(owner.ID), GetBillHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", fmt.Sprintf("/households/%d/invitations", household.ID),
+		map[string]interface{}{"account_id": outsider.AccountID})
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	for _, path := range []string{"/invitations", fmt.Sprintf("/households/%d/invitations", household.ID)} {
+		w = performJSONRequest(router, "GET", path, nil)
+		require.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotContains(t, w.Body.String(), `"email"`, path)
+		assert.NotContains(t, w.Body.String(), "private@example.com", path)
+	}
+
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": 2024, "month": 6, "payer_id": member.ID})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), `"email"`)
+
+	w = performJSONRequest(router, "GET", "/bills/2024/6", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"email"`)
+	assert.NotContains(t, w.Body.String(), "private@example.com")
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"money_management/internal/middleware"
+)
+
+// JWKSHandler JWKS（JWTの検証に使用する公開鍵の一覧）取得ハンドラー
+// RS256・EdDSAの鍵の公開鍵を返し、退役した鍵も猶予期間中は含める（HS256の共有鍵は含めない）
+// 他のサービスがアクセストークンを検証できるよう、認証不要で公開する
+func JWKSHandler(c *gin.Context) {
+	manager, err := middleware.CurrentJWTKeyManager()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "公開鍵を取得できませんでした"})
+		return
+	}
+	set, err := manager.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "公開鍵を取得できませんでした"})
+		return
+	}
+
+	// 鍵のローテーションが反映されるよう、キャッシュは短時間にする
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,83 @@
+// ========================================
+// JWKSとJWT署名鍵のローテーションの自動テスト
+// キーリングの鍵で署名したアクセストークンで認証でき、公開鍵がJWKSで取得できることを検証
+// ========================================
+
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/middleware"
+)
+
+// useEdDSAKeyring EdDSAの鍵を有効な鍵、JWT_SECRETの鍵（kid=default）を退役した鍵とするキーリングを設定
+func useEdDSAKeyring(t *testing.T, secret string) {
+	t.Helper()
+	dir := t.TempDir()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ed25519.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte(secret), 0o600))
+
+	keyring := fmt.Sprintf(`{"active":"ed-1","keys":[
+		{"kid":"ed-1","alg":"EdDSA","private_key_file":"ed25519.pem"},
+		{"kid":"default","alg":"HS256","secret_file":"jwt_secret","retired_at":%q}
+	]}`, time.Now().Format(time.RFC3339))
+	path := filepath.Join(dir, "jwt_keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(keyring), 0o600))
+
+	manager, err := middleware.NewJWTKeyManager(path, time.Hour)
+	require.NoError(t, err)
+	middleware.SetJWTKeyManager(manager)
+	t.Cleanup(func() { middleware.SetJWTKeyManager(nil) })
+}
+
+// TestJWKS_EdDSAKeyring キーリングの鍵で署名したトークンで認証でき、以前の鍵のトークンも猶予期間中は有効であることを検証
+func TestJWKS_EdDSAKeyring(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+	router.GET("/.well-known/jwks.json", JWKSHandler)
+
+	// 切り替え前にJWT_SECRETで発行したトークン（kidなし）
+	legacy, _, err := middleware.GenerateAccessToken(1, 0, "")
+	require.NoError(t, err)
+	useEdDSAKeyring(t, os.Getenv("JWT_SECRET"))
+
+	tokens := loginForTokens(t, router)
+	parsed, _, err := jwt.NewParser().ParseUnverified(tokens.Token, &middleware.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "ed-1", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	w := requestWithToken(router, "GET", "/me", tokens.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = requestWithToken(router, "GET", "/me", legacy, nil)
+	assert.Equal(t, http.StatusOK, w.Code, "token signed with the retired key is valid during the grace period")
+
+	w = performJSONRequest(router, "GET", "/.well-known/jwks.json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var set middleware.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	assert.Equal(t, "ed-1", set.Keys[0].KeyID)
+	assert.Equal(t, "OKP", set.Keys[0].KeyType)
+	assert.NotContains(t, w.Body.String(), os.Getenv("JWT_SECRET"))
+}
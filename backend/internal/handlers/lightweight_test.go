@@ -85,7 +85,7 @@ func TestLightweightMode_RegisterHandler(t *testing.T) {
 	registerRequest := models.RegisterRequest{
 		Name:      "テストユーザー",
 		AccountID: "test_user_light",
-		Password:  "pass123",
+		Password:  "light-pass-42",
 	}
 
 	jsonBytes, _ := json.Marshal(registerRequest)
@@ -0,0 +1,137 @@
+// ========================================
+// ログイン失敗による待機時間・ロックの自動テスト
+// アカウントIDごとの段階的な待機時間、一時的なロックと解除、アカウントの有無が判別できないことを検証
+// ========================================
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+)
+
+// setupLockoutRouter 2回目の失敗から待機時間を設け、4回の失敗でロックするテスト用ルーターを設定
+func setupLockoutRouter(t *testing.T, db *gorm.DB) (*gin.Engine, *recordingResetSender) {
+	t.Helper()
+	t.Setenv("LOGIN_DELAY_AFTER", "2")
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "4")
+	t.Setenv("LOGIN_LOCKOUT_MINUTES", "15")
+	return setupPasswordRouter(t, db)
+}
+
+// attemptLogin アカウントIDとパスワードでログインを試行し、ステータスコードとレスポンスを返す
+func attemptLogin(router *gin.Engine, accountID, password string) (int, string) {
+	w := performJSONRequest(router, "POST", "/login", map[string]string{"account_id": accountID, "password": password})
+	return w.Code, w.Body.String()
+}
+
+// skipLoginDelay 待機時間が過ぎたことにする（最後の失敗日時を過去にずらす）
+func skipLoginDelay(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	require.NoError(t, db.Model(&models.LoginAttempt{}).Where("locked_until IS NULL").
+		UpdateColumn("last_failed_at", time.Now().Add(-time.Minute)).Error)
+}
+
+// TestLoginLockout_ProgressiveDelayThenLock 失敗が続くと待機時間が設けられ、しきい値でロックされることを検証
+func TestLoginLockout_ProgressiveDelayThenLock(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, _ := setupLockoutRouter(t, db)
+
+	for _, accountID := range []string{"refresh_user", "unknown_user"} {
+		// 1回目の失敗の後はすぐに再試行できる
+		code, _ := attemptLogin(router, accountID, "wrong")
+		require.Equal(t, http.StatusUnauthorized, code)
+		code, _ = attemptLogin(router, accountID, "wrong")
+		require.Equal(t, http.StatusUnauthorized, code)
+
+		// 2回目の失敗の後は待機時間中（正しいパスワードでも拒否）
+		code, body := attemptLogin(router, accountID, "password123")
+		require.Equal(t, http.StatusTooManyRequests, code, accountID)
+		assert.Contains(t, body, "LOGIN_THROTTLED")
+
+		skipLoginDelay(t, db)
+		code, _ = attemptLogin(router, accountID, "wrong")
+		require.Equal(t, http.StatusUnauthorized, code)
+		skipLoginDelay(t, db)
+		code, _ = attemptLogin(router, accountID, "wrong")
+		require.Equal(t, http.StatusUnauthorized, code)
+
+		// 4回目の失敗でロック（待機時間が過ぎても解除されない）
+		skipLoginDelay(t, db)
+		code, _ = attemptLogin(router, accountID, "password123")
+		assert.Equal(t, http.StatusTooManyRequests, code, accountID)
+	}
+
+	// 存在するアカウントと存在しないアカウントでロックの状態は区別できない
+	var attempts []models.LoginAttempt
+	require.NoError(t, db.Find(&attempts).Error)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, attempts[0].FailedCount, attempts[1].FailedCount)
+	for _, attempt := range attempts {
+		require.NotNil(t, attempt.LockedUntil)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), *attempt.LockedUntil, time.Minute)
+	}
+
+	// 大文字小文字を変えても同じアカウントIDとして扱う
+	code, _ := attemptLogin(router, "REFRESH_USER", "password123")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+}
+
+// TestLoginLockout_UnlockPaths ロック期間の経過とパスワード再設定でロックが解除されることを検証
+func TestLoginLockout_UnlockPaths(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, sender := setupLockoutRouter(t, db)
+
+	lock := func() {
+		for i := 0; i < 4; i++ {
+			skipLoginDelay(t, db)
+			code, _ := attemptLogin(router, "refresh_user", "wrong")
+			require.Equal(t, http.StatusUnauthorized, code)
+		}
+		code, _ := attemptLogin(router, "refresh_user", "password123")
+		require.Equal(t, http.StatusTooManyRequests, code)
+	}
+
+	// ロック期間の経過で解除
+	lock()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, db.Model(&models.LoginAttempt{}).Where("1 = 1").
+		Updates(map[string]interface{}{"locked_until": past, "last_failed_at": past.Add(-15 * time.Minute)}).Error)
+	loginForTokens(t, router)
+
+	// パスワード再設定で解除
+	lock()
+	w := performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	message := sender.waitForSent(t, 1)[0]
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": message.Token, "new_password": "resetpassword789"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	code, _ := attemptLogin(router, "refresh_user", "resetpassword789")
+	assert.Equal(t, http.StatusOK, code)
+	var remaining int64
+	require.NoError(t, db.Model(&models.LoginAttempt{}).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+}
+
+// TestLoginLockout_SuccessResetsFailures ログインに成功すると失敗の回数がリセットされることを検証
+func TestLoginLockout_SuccessResetsFailures(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, _ := setupLockoutRouter(t, db)
+
+	code, _ := attemptLogin(router, "refresh_user", "wrong")
+	require.Equal(t, http.StatusUnauthorized, code)
+	loginForTokens(t, router)
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.LoginAttempt{}).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+}
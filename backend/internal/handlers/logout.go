@@ -1,143 +1,208 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
 	"money_management/internal/middleware"
+	"money_management/internal/services"
 )
 
 // LogoutHandler ログアウトハンドラー
-// JWTトークンをブラックリストに追加し、セッションを無効化する
+// JWTトークンをブラックリストに追加し、トークンのログインセッションとリフレッシュトークンを無効化する
+// リクエストボディにリフレッシュトークンが指定された場合は、そのログインのリフレッシュトークンも失効させる
 func LogoutHandler(c *gin.Context) {
-	// Authorizationヘッダーからトークンを取得
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "ログアウトにはAuthorizationヘッダーが必要です",
-			"code":  "MISSING_TOKEN",
-		})
-		return
-	}
+	LogoutHandlerWithDB(database.GetDB())(c)
+}
 
-	// "Bearer "プレフィックスを除去
-	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-		tokenString = tokenString[7:]
-	}
+// LogoutHandlerWithDB DB接続を注入可能なログアウトハンドラー
+func LogoutHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Authorizationヘッダーからトークンを取得
+		tokenString := c.GetHeader("Authorization")
+		if tokenString == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "ログアウトにはAuthorizationヘッダーが必要です",
+				"code":  "MISSING_TOKEN",
+			})
+			return
+		}
+
+		// "Bearer "プレフィックスを除去
+		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+			tokenString = tokenString[7:]
+		}
 
-	// JWTトークンを解析してExpiration時刻を取得
-	token, err := jwt.ParseWithClaims(tokenString, &middleware.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		secret, err := middleware.GetJWTSecret()
+		// JWTトークンを解析してExpiration時刻を取得
+		token, err := middleware.ParseToken(tokenString, &middleware.Claims{})
 		if err != nil {
-			return nil, err
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "無効なトークンです",
+				"code":  "INVALID_TOKEN",
+			})
+			return
 		}
-		return secret, nil
-	})
 
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "無効なトークンです",
-			"code":  "INVALID_TOKEN",
-		})
-		return
-	}
+		// クレームからExpiration時刻を取得
+		claims, ok := token.Claims.(*middleware.Claims)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "トークンクレームの解析に失敗しました",
+				"code":  "CLAIMS_PARSE_ERROR",
+			})
+			return
+		}
 
-	// クレームからExpiration時刻を取得
-	claims, ok := token.Claims.(*middleware.Claims)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "トークンクレームの解析に失敗しました",
-			"code":  "CLAIMS_PARSE_ERROR",
-		})
-		return
-	}
+		// トークンをブラックリストに追加
+		expiresAt := claims.ExpiresAt.Time
+		reason := "user_logout"
+		if err := middleware.AddTokenToBlacklist(tokenString, expiresAt, reason); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "ログアウトに失敗しました。時間をおいて再度お試しください",
+				"code":  "TOKEN_BLACKLIST_ERROR",
+			})
+			return
+		}
 
-	// トークンをブラックリストに追加
-	expiresAt := claims.ExpiresAt.Time
-	reason := "user_logout"
-	middleware.AddTokenToBlacklist(tokenString, expiresAt, reason)
+		// ログインセッションを終了し、そのセッションのリフレッシュトークンも失効させる
+		if claims.SessionID != "" {
+			err := services.NewSessionService(db).Revoke(claims.UserID, claims.SessionID, services.RefreshRevokedLogout)
+			if err != nil && !errors.Is(err, services.ErrNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "セッションの終了に失敗しました",
+					"code":  "SESSION_REVOKE_ERROR",
+				})
+				return
+			}
+		}
 
-	// セキュリティ監査用ヘッダーを設定
-	if _, exists := c.Get("user_id"); exists {
-		c.Header("X-User-Logout", "success")
-	}
+		// リフレッシュトークンを失効させる（ボディは任意、無効なトークンは無視する）
+		var req struct {
+			RefreshToken string `json:"refresh_token"` // リフレッシュトークン（任意）
+		}
+		_ = c.ShouldBindJSON(&req)
+		if req.RefreshToken != "" {
+			err := services.NewRefreshTokenService(db).Revoke(req.RefreshToken, claims.UserID, services.RefreshRevokedLogout)
+			if err != nil && !errors.Is(err, services.ErrRefreshTokenInvalid) {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "リフレッシュトークンの無効化に失敗しました",
+					"code":  "REFRESH_TOKEN_REVOKE_ERROR",
+				})
+				return
+			}
+		}
+
+		// セキュリティ監査用ヘッダーを設定
+		if _, exists := c.Get("user_id"); exists {
+			c.Header("X-User-Logout", "success")
+		}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "ログアウトしました",
-		"code":    "LOGOUT_SUCCESS",
-	})
+		c.JSON(http.StatusOK, gin.H{
+			"message": "ログアウトしました",
+			"code":    "LOGOUT_SUCCESS",
+		})
+	}
 }
 
 // LogoutAllHandler 全デバイスからのログアウトハンドラー
-// 特定ユーザーの全トークンを無効化（将来の拡張用）
+// ユーザーのトークン世代を進め、全てのデバイスで発行済みのアクセストークンとリフレッシュトークンを無効化する
 func LogoutAllHandler(c *gin.Context) {
-	// 現在は単一トークンのみ対応
-	// 将来的には、ユーザーIDに基づいて全トークンをブラックリスト化
-	LogoutHandler(c)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "全デバイスからログアウトしました",
-		"code":    "LOGOUT_ALL_SUCCESS",
-		"note":    "現在は単一トークンのみ対応しています",
-	})
+	LogoutAllHandlerWithDB(database.GetDB())(c)
 }
 
-// GetTokenStatusHandler 現在のトークンステータス確認ハンドラー
-func GetTokenStatusHandler(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Authorizationヘッダーが必要です",
-			"code":  "MISSING_TOKEN",
-		})
-		return
-	}
+// LogoutAllHandlerWithDB DB接続を注入可能な全デバイスからのログアウトハンドラー
+func LogoutAllHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
 
-	// "Bearer "プレフィックスを除去
-	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-		tokenString = tokenString[7:]
+		err := services.NewTokenVersionService(db).RevokeAll(userID, services.RefreshRevokedLogoutAll)
+		if err != nil {
+			if errors.Is(err, services.ErrTokenUserNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "全デバイスからのログアウトに失敗しました",
+				"code":  "LOGOUT_ALL_ERROR",
+			})
+			return
+		}
+
+		c.Header("X-User-Logout", "success")
+		c.JSON(http.StatusOK, gin.H{
+			"message": "全デバイスからログアウトしました",
+			"code":    "LOGOUT_ALL_SUCCESS",
+		})
 	}
+}
 
-	// トークンがブラックリストに含まれているかチェック
-	isBlacklisted := middleware.IsTokenBlacklisted(tokenString)
+// GetTokenStatusHandler 現在のトークンステータス確認ハンドラー
+// トークンがログインセッションに属する場合は、そのセッションの情報も返す
+func GetTokenStatusHandler(c *gin.Context) {
+	GetTokenStatusHandlerWithDB(database.GetDB())(c)
+}
 
-	// JWTトークンを解析
-	token, err := jwt.ParseWithClaims(tokenString, &middleware.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		secret, err := middleware.GetJWTSecret()
-		if err != nil {
-			return nil, err
+// GetTokenStatusHandlerWithDB DB接続を注入可能なトークンステータス確認ハンドラー
+func GetTokenStatusHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.GetHeader("Authorization")
+		if tokenString == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Authorizationヘッダーが必要です",
+				"code":  "MISSING_TOKEN",
+			})
+			return
 		}
-		return secret, nil
-	})
-
-	var tokenInfo map[string]interface{}
-	if err != nil {
-		tokenInfo = map[string]interface{}{
-			"valid":       false,
-			"error":       "トークン解析エラー",
-			"blacklisted": isBlacklisted,
-		}
-	} else if claims, ok := token.Claims.(*middleware.Claims); ok {
-		tokenInfo = map[string]interface{}{
-			"valid":       token.Valid && !isBlacklisted,
-			"blacklisted": isBlacklisted,
-			"user_id":     claims.UserID,
-			"expires_at":  claims.ExpiresAt.Time,
-			"issued_at":   claims.IssuedAt.Time,
+
+		// "Bearer "プレフィックスを除去
+		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+			tokenString = tokenString[7:]
 		}
-	} else {
-		tokenInfo = map[string]interface{}{
-			"valid":       false,
-			"error":       "クレーム解析エラー",
-			"blacklisted": isBlacklisted,
+
+		// トークンがブラックリストに含まれているかチェック
+		isBlacklisted := middleware.IsTokenBlacklisted(tokenString)
+
+		// JWTトークンを解析
+		token, err := middleware.ParseToken(tokenString, &middleware.Claims{})
+
+		var tokenInfo map[string]interface{}
+		if err != nil {
+			tokenInfo = map[string]interface{}{
+				"valid":       false,
+				"error":       "トークン解析エラー",
+				"blacklisted": isBlacklisted,
+			}
+		} else if claims, ok := token.Claims.(*middleware.Claims); ok {
+			tokenInfo = map[string]interface{}{
+				"valid":       token.Valid && !isBlacklisted,
+				"blacklisted": isBlacklisted,
+				"user_id":     claims.UserID,
+				"expires_at":  claims.ExpiresAt.Time,
+				"issued_at":   claims.IssuedAt.Time,
+			}
+			if claims.SessionID != "" {
+				tokenInfo["session_id"] = claims.SessionID
+				if session, err := services.NewSessionService(db).GetActive(claims.UserID, claims.SessionID); err == nil {
+					tokenInfo["session"] = newSessionResponse(*session, claims.SessionID)
+				}
+			}
+		} else {
+			tokenInfo = map[string]interface{}{
+				"valid":       false,
+				"error":       "クレーム解析エラー",
+				"blacklisted": isBlacklisted,
+			}
 		}
-	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token_status": tokenInfo,
-	})
+		c.JSON(http.StatusOK, gin.H{
+			"token_status": tokenInfo,
+		})
+	}
 }
 
 // ExtractTokenFromHeader Authorizationヘッダーからトークンを抽出する共通関数
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,9 +10,20 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"money_management/internal/middleware"
 )
 
+// failingBlacklistStore 登録に失敗するブラックリストの保存先
+type failingBlacklistStore struct {
+	middleware.TokenBlacklistStore
+}
+
+// Add 常に失敗する
+func (failingBlacklistStore) Add(string, time.Time, string) error {
+	return errors.New("blacklist store unavailable")
+}
+
 func TestLogoutHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -47,6 +59,23 @@ func TestLogoutHandler(t *testing.T) {
 		assert.True(t, middleware.IsTokenBlacklisted(tokenString))
 	})
 
+	t.Run("ブラックリストへの登録に失敗した場合は500", func(t *testing.T) {
+		middleware.SetTokenBlacklistStore(failingBlacklistStore{TokenBlacklistStore: middleware.NewMemoryTokenBlacklist()})
+		t.Cleanup(func() { middleware.SetTokenBlacklistStore(middleware.NewMemoryTokenBlacklist()) })
+
+		router := gin.New()
+		router.POST("/logout", LogoutHandler)
+
+		req := httptest.NewRequest("POST", "/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "TOKEN_BLACKLIST_ERROR")
+	})
+
 	t.Run("Authorizationヘッダーなし", func(t *testing.T) {
 		router := gin.New()
 		router.POST("/logout", LogoutHandler)
@@ -110,7 +139,7 @@ func TestGetTokenStatusHandler(t *testing.T) {
 
 	t.Run("ブラックリスト化されたトークンのステータス", func(t *testing.T) {
 		// まずトークンをブラックリストに追加
-		middleware.AddTokenToBlacklist(tokenString, time.Now().Add(24*time.Hour), "test")
+		require.NoError(t, middleware.AddTokenToBlacklist(tokenString, time.Now().Add(24*time.Hour), "test"))
 
 		router := gin.New()
 		router.GET("/token-status", GetTokenStatusHandler)
@@ -18,6 +18,7 @@ import (
 
 	"money_management/internal/database"
 	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
 )
 
 // TestLoginHandler_Parallel_Success 並列実行対応ログイン成功テスト
@@ -185,6 +186,7 @@ func TestBillHandler_Parallel_Create(t *testing.T) {
 
 	db.Create(&requester)
 	db.Create(&payer)
+	testfactory.NewTestDataFactory(db).NewHousehold().WithOwner(requester.ID).WithMembers(payer.ID).MustBuild()
 
 	// 家計簿作成リクエスト
 	createRequest := map[string]interface{}{
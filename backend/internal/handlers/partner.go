@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+)
+
+const (
+	defaultInvitationTTLHours = 72      // 招待リンクのデフォルト有効期間（時間）
+	maxInvitationTTLHours     = 24 * 30 // 招待リンクの最大有効期間（時間）
+	invitationTokenBytes      = 32      // 招待トークンの乱数バイト数
+)
+
+var (
+	// errInvitationInvalid 招待トークンが存在しない・使用済み・期限切れ・取り消し済み
+	errInvitationInvalid = errors.New("招待リンクが無効または期限切れです")
+	// errInvitationSelf 自分自身が発行した招待トークン
+	errInvitationSelf = errors.New("自分が発行した招待リンクは使用できません")
+)
+
+// CreatePartnerInvitationHandler 支払者招待リンク作成ハンドラー
+// 一回限り・有効期限付きの招待トークンを発行する（トークンはこのレスポンスでのみ返す）
+func CreatePartnerInvitationHandler(c *gin.Context) {
+	CreatePartnerInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// CreatePartnerInvitationHandlerWithDB DB接続を注入可能な支払者招待リンク作成ハンドラー
+func CreatePartnerInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			ExpiresInHours int `json:"expires_in_hours"` // 有効期間（時間、省略時は72時間）
+		}
+
+		// ボディは省略可能
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondBindingError(c, err)
+				return
+			}
+		}
+
+		ttl := req.ExpiresInHours
+		if ttl == 0 {
+			ttl = defaultInvitationTTLHours
+		}
+		if ttl < 1 || ttl > maxInvitationTTLHours {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "有効期間は1時間以上720時間以下で指定してください"})
+			return
+		}
+
+		token, err := generateInvitationToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待トークンの生成に失敗しました"})
+			return
+		}
+
+		invitation := models.PartnerInvitation{
+			InviterID: userID,
+			TokenHash: hashInvitationToken(token),
+			ExpiresAt: time.Now().Add(time.Duration(ttl) * time.Hour),
+		}
+		if err := db.Create(&invitation).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待リンクの作成に失敗しました"})
+			return
+		}
+		invitation.Status = invitation.CurrentStatus(time.Now())
+
+		c.JSON(http.StatusCreated, gin.H{
+			"token":      token,
+			"invitation": invitation,
+		})
+	}
+}
+
+// GetPartnerInvitationsHandler 支払者招待リンク一覧取得ハンドラー
+// ログインユーザーが発行した招待リンクを状態付きで返す
+func GetPartnerInvitationsHandler(c *gin.Context) {
+	GetPartnerInvitationsHandlerWithDB(database.GetDB())(c)
+}
+
+// GetPartnerInvitationsHandlerWithDB DB接続を注入可能な支払者招待リンク一覧取得ハンドラー
+func GetPartnerInvitationsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		invitations := []models.PartnerInvitation{}
+		err := db.Where("inviter_id = ?", userID).
+			Order("created_at DESC, id DESC").
+			Find(&invitations).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待リンク一覧の取得に失敗しました"})
+			return
+		}
+
+		now := time.Now()
+		for i := range invitations {
+			invitations[i].Status = invitations[i].CurrentStatus(now)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+	}
+}
+
+// RevokePartnerInvitationHandler 支払者招待リンク取り消しハンドラー
+// 未使用の招待リンクを無効化する
+func RevokePartnerInvitationHandler(c *gin.Context) {
+	RevokePartnerInvitationHandlerWithDB(database.GetDB())(c)
+}
+
+// RevokePartnerInvitationHandlerWithDB DB接続を注入可能な支払者招待リンク取り消しハンドラー
+func RevokePartnerInvitationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invitationID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		var invitation models.PartnerInvitation
+		if err := db.Where("id = ? AND inviter_id = ?", invitationID, userID).First(&invitation).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "招待リンクが見つかりません"})
+			return
+		}
+		if invitation.UsedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "使用済みの招待リンクは取り消せません"})
+			return
+		}
+		if invitation.RevokedAt != nil {
+			c.JSON(http.StatusOK, gin.H{"message": "招待リンクを取り消しました"})
+			return
+		}
+
+		if err := db.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "招待リンクの更新に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "招待リンクを取り消しました"})
+	}
+}
+
+// GetPartnersHandler 連携済みパートナー一覧取得ハンドラー
+// ログインユーザーが請求者または支払者となっている支払者関係を返す
+func GetPartnersHandler(c *gin.Context) {
+	GetPartnersHandlerWithDB(database.GetDB())(c)
+}
+
+// GetPartnersHandlerWithDB DB接続を注入可能な連携済みパートナー一覧取得ハンドラー
+func GetPartnersHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		relationships := []models.PayerRelationship{}
+		err := db.Preload("Requester").Preload("Payer").
+			Where("requester_id = ? OR payer_id = ?", userID, userID).
+			Order("created_at DESC, id DESC").
+			Find(&relationships).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パートナー一覧の取得に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"partners": relationships})
+	}
+}
+
+// UpdatePartnerSettingsHandler 支払者関係の設定更新ハンドラー
+// 請求者・支払者のどちらでも、支払前の内容確認を必須とするかを変更できる
+func UpdatePartnerSettingsHandler(c *gin.Context) {
+	UpdatePartnerSettingsHandlerWithDB(database.GetDB())(c)
+}
+
+// UpdatePartnerSettingsHandlerWithDB DB接続を注入可能な支払者関係の設定更新ハンドラー
+func UpdatePartnerSettingsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		relationshipID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			RequireAcknowledgement *bool `json:"require_acknowledgement" binding:"required"` // 支払前の内容確認を必須とするか（必須）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		var relationship models.PayerRelationship
+		if err := db.Where("id = ? AND (requester_id = ? OR payer_id = ?)", relationshipID, userID, userID).First(&relationship).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "パートナーが見つかりません"})
+			return
+		}
+
+		if err := db.Model(&relationship).Update("require_acknowledgement", *req.RequireAcknowledgement).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パートナー設定の更新に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, relationship)
+	}
+}
+
+// findUsablePartnerInvitation 使用可能な招待リンクをトークンから検索する
+func findUsablePartnerInvitation(db *gorm.DB, token string) (*models.PartnerInvitation, error) {
+	var invitation models.PartnerInvitation
+	if err := db.Where("token_hash = ?", hashInvitationToken(token)).First(&invitation).Error; err != nil {
+		return nil, errInvitationInvalid
+	}
+	if invitation.CurrentStatus(time.Now()) != models.PartnerInvitationStatusPending {
+		return nil, errInvitationInvalid
+	}
+	return &invitation, nil
+}
+
+// redeemPartnerInvitation 招待リンクを使用し、招待者を請求者とする支払者関係を作成する
+// 同時使用を防ぐため、未使用の場合のみ使用済みに更新する
+func redeemPartnerInvitation(db *gorm.DB, token string, userID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		invitation, err := findUsablePartnerInvitation(tx, token)
+		if err != nil {
+			return err
+		}
+		if invitation.InviterID == userID {
+			return errInvitationSelf
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.PartnerInvitation{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", invitation.ID).
+			Updates(map[string]interface{}{"used_at": now, "used_by": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationInvalid
+		}
+
+		// 既に関係がある場合は新たに作成しない
+		linked, err := isLinkedPayer(tx, invitation.InviterID, userID)
+		if err != nil || linked {
+			return err
+		}
+		relationship := models.PayerRelationship{
+			RequesterID:  invitation.InviterID,
+			PayerID:      userID,
+			InvitationID: &invitation.ID,
+		}
+		return tx.Create(&relationship).Error
+	})
+}
+
+// isLinkedPayer 招待リンクによる支払者関係があるか判定する
+func isLinkedPayer(db *gorm.DB, requesterID, payerID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.PayerRelationship{}).
+		Where("requester_id = ? AND payer_id = ?", requesterID, payerID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// relationshipRequiresAcknowledgement 支払者関係で内容確認が必須に設定されているか判定する
+func relationshipRequiresAcknowledgement(db *gorm.DB, requesterID, payerID uint) bool {
+	var relationship models.PayerRelationship
+	if err := db.Where("requester_id = ? AND payer_id = ?", requesterID, payerID).First(&relationship).Error; err != nil {
+		return false
+	}
+	return relationship.RequireAcknowledgement
+}
+
+// canBillPayer 請求者が指定ユーザーを支払者にできるか判定する
+// 同じ世帯のメンバー、または招待リンクで連携済みの支払者のみ許可する
+func canBillPayer(db *gorm.DB, requesterID, payerID uint) (bool, error) {
+	linked, err := isLinkedPayer(db, requesterID, payerID)
+	if err != nil || linked {
+		return linked, err
+	}
+	return sharesHousehold(db, requesterID, payerID)
+}
+
+// dbPayerPolicy 世帯・パートナー連携による支払者の判定（services.PayerPolicyの実装）
+type dbPayerPolicy struct {
+	db *gorm.DB
+}
+
+// PayerExists 支払者に指定されたユーザーが存在するか判定する
+func (p dbPayerPolicy) PayerExists(payerID uint) (bool, error) {
+	var count int64
+	if err := p.db.Model(&models.User{}).Where("id = ?", payerID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CanBillPayer 請求者が指定ユーザーを支払者にできるか判定する
+func (p dbPayerPolicy) CanBillPayer(requesterID, payerID uint) (bool, error) {
+	return canBillPayer(p.db, requesterID, payerID)
+}
+
+// RequiresAcknowledgement 支払者関係で内容確認が必須に設定されているか判定する
+func (p dbPayerPolicy) RequiresAcknowledgement(requesterID, payerID uint) bool {
+	return relationshipRequiresAcknowledgement(p.db, requesterID, payerID)
+}
+
+// generateInvitationToken URLに埋め込み可能なランダムな招待トークンを生成する
+func generateInvitationToken() (string, error) {
+	buf := make([]byte, invitationTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashInvitationToken 招待トークンのSHA-256ハッシュ（16進数）を返す
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
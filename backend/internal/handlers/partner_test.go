@@ -0,0 +1,173 @@
+// ========================================
+// 支払者招待リンクハンドラーの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// partnerInvitationResponse 招待リンク作成レスポンスのテスト用構造体
+type partnerInvitationResponse struct {
+	Token      string                   `json:"token"`
+	Invitation models.PartnerInvitation `json:"invitation"`
+}
+
+// issuePartnerInvitation 指定ユーザーとして招待リンクを発行する
+func issuePartnerInvitation(t *testing.T, db *gorm.DB, inviterID uint) partnerInvitationResponse {
+	t.Helper()
+
+	router := setupRouter()
+	router.POST("/partner-invitations", setUserID(inviterID), CreatePartnerInvitationHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/partner-invitations", nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var response partnerInvitationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(t, response.Token)
+	return response
+}
+
+// TestPartnerInvitation_RegisterCreatesRelationship 招待トークン付きの登録で支払者関係が作成されることを検証
+func TestPartnerInvitation_RegisterCreatesRelationship(t *testing.T) {
+	t.Setenv("JWT_SECRET", "partner_invitation_test_secret_key_32chars")
+	db := setupInMemoryDB(t)
+	requester := testfactory.NewTestDataFactory(db).NewUser().WithAccountID("partner_requester").MustBuild()
+
+	issued := issuePartnerInvitation(t, db, requester.ID)
+	assert.Equal(t, models.PartnerInvitationStatusPending, issued.Invitation.Status)
+
+	// トークン本体はDBに保存されない
+	var stored models.PartnerInvitation
+	require.NoError(t, db.First(&stored, issued.Invitation.ID).Error)
+	assert.NotEqual(t, issued.Token, stored.TokenHash)
+
+	router := setupRouter()
+	router.POST("/register", RegisterHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/register", map[string]interface{}{
+		"name":             "招待された支払者",
+		"account_id":       "invited_payer",
+		"password":         "correct-horse-42",
+		"invitation_token": issued.Token,
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var registered models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &registered))
+
+	linked, err := isLinkedPayer(db, requester.ID, registered.User.ID)
+	require.NoError(t, err)
+	assert.True(t, linked)
+
+	// 連携済みの支払者には家計簿を作成できる
+	billRouter := setupRouter()
+	billRouter.POST("/bills", setUserID(requester.ID), CreateBillHandlerWithDB(db))
+	w = performJSONRequest(billRouter, "POST", "/bills", map[string]interface{}{"year": 2024, "month": 7, "payer_id": registered.User.ID})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// 使用済みのトークンでは登録できない（ユーザーも作成されない）
+	w = performJSONRequest(router, "POST", "/register", map[string]interface{}{
+		"name":             "二人目",
+		"account_id":       "second_payer",
+		"password":         "correct-horse-42",
+		"invitation_token": issued.Token,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var count int64
+	db.Model(&models.User{}).Where("account_id = ?", "second_payer").Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// TestPartnerInvitation_LoginCreatesRelationship 招待トークン付きのログインで支払者関係が作成されることを検証
+func TestPartnerInvitation_LoginCreatesRelationship(t *testing.T) {
+	t.Setenv("JWT_SECRET", "partner_invitation_test_secret_key_32chars")
+	db := setupInMemoryDB(t)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("login_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("login_payer").WithPassword(string(hashedPassword)).MustBuild()
+
+	issued := issuePartnerInvitation(t, db, requester.ID)
+
+	router := setupRouter()
+	router.POST("/login", LoginHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/login", map[string]interface{}{
+		"account_id":       payer.AccountID,
+		"password":         "password123",
+		"invitation_token": issued.Token,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	linked, err := isLinkedPayer(db, requester.ID, payer.ID)
+	require.NoError(t, err)
+	assert.True(t, linked)
+
+	// 使用済みとして一覧に表示される
+	listRouter := setupRouter()
+	listRouter.GET("/partner-invitations", setUserID(requester.ID), GetPartnerInvitationsHandlerWithDB(db))
+	w = performJSONRequest(listRouter, "GET", "/partner-invitations", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var listResponse struct {
+		Invitations []models.PartnerInvitation `json:"invitations"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listResponse))
+	require.Len(t, listResponse.Invitations, 1)
+	assert.Equal(t, models.PartnerInvitationStatusUsed, listResponse.Invitations[0].Status)
+	require.NotNil(t, listResponse.Invitations[0].UsedBy)
+	assert.Equal(t, payer.ID, *listResponse.Invitations[0].UsedBy)
+}
+
+// TestPartnerInvitation_RevokedAndExpired 取り消し済み・期限切れ・自分の招待トークンは使用できないことを検証
+func TestPartnerInvitation_RevokedAndExpired(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("revoke_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("revoke_payer").MustBuild()
+
+	// 取り消し
+	revoked := issuePartnerInvitation(t, db, requester.ID)
+	router := setupRouter()
+	router.DELETE("/partner-invitations/:id", setUserID(requester.ID), RevokePartnerInvitationHandlerWithDB(db))
+	w := performJSONRequest(router, "DELETE", fmt.Sprintf("/partner-invitations/%d", revoked.Invitation.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.ErrorIs(t, redeemPartnerInvitation(db, revoked.Token, payer.ID), errInvitationInvalid)
+
+	// 期限切れ
+	expired := issuePartnerInvitation(t, db, requester.ID)
+	db.Model(&models.PartnerInvitation{}).Where("id = ?", expired.Invitation.ID).
+		Update("expires_at", time.Now().Add(-time.Minute))
+	assert.ErrorIs(t, redeemPartnerInvitation(db, expired.Token, payer.ID), errInvitationInvalid)
+
+	// 自分が発行した招待
+	own := issuePartnerInvitation(t, db, requester.ID)
+	assert.ErrorIs(t, redeemPartnerInvitation(db, own.Token, requester.ID), errInvitationSelf)
+
+	// 存在しないトークン
+	assert.ErrorIs(t, redeemPartnerInvitation(db, "unknown-token", payer.ID), errInvitationInvalid)
+
+	linked, err := isLinkedPayer(db, requester.ID, payer.ID)
+	require.NoError(t, err)
+	assert.False(t, linked)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// ChangePasswordHandler パスワード変更ハンドラー
+// 現在のパスワードを確認して新しいパスワードに変更する
+// 変更前に発行した全てのトークンは無効になり、この端末には新しいトークンを発行する
+func ChangePasswordHandler(c *gin.Context) {
+	ChangePasswordHandlerWithDB(database.GetDB())(c)
+}
+
+// ChangePasswordHandlerWithDB DB接続を注入可能なパスワード変更ハンドラー
+func ChangePasswordHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		// パスワードポリシーを検証（文字数・文字種・よく使われるパスワード）
+		if err := services.DefaultPasswordPolicy().Validate("new_password", req.NewPassword); err != nil {
+			respondBillError(c, err, "パスワードが要件を満たしていません")
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+		hasher := services.CurrentPasswordHasher()
+		if err := hasher.ComparePassword(user.PasswordHash, req.CurrentPassword); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "現在のパスワードが正しくありません", "code": "INVALID_PASSWORD"})
+			return
+		}
+		if req.NewPassword == req.CurrentPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "新しいパスワードは現在のパスワードと異なるものを入力してください"})
+			return
+		}
+
+		hashedPassword, err := hasher.HashPassword(req.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの暗号化に失敗しました"})
+			return
+		}
+		if err := services.NewTokenVersionService(db).ChangePasswordHash(userID, hashedPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの変更に失敗しました"})
+			return
+		}
+		log.Printf("🔑 Password changed: user=%d", userID)
+		if err := services.NewLoginAttemptService(db).Unlock(user.AccountID, services.LoginUnlockPasswordChanged); err != nil {
+			log.Printf("❌ Failed to unlock login: user=%d error=%v", userID, err)
+		}
+
+		// 変更前のトークンは全て無効になったため、この端末に新しいトークンを発行する
+		tokens, err := services.NewRefreshTokenService(db).IssueTokens(userID, sessionClient(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
+			return
+		}
+		c.JSON(http.StatusOK, newLoginResponse(tokens, user))
+	}
+}
+
+// UpdateEmailHandler メールアドレス変更ハンドラー
+// パスワード再設定の案内の送信先を変更するため、現在のパスワードの確認が必要
+func UpdateEmailHandler(c *gin.Context) {
+	UpdateEmailHandlerWithDB(database.GetDB())(c)
+}
+
+// UpdateEmailHandlerWithDB DB接続を注入可能なメールアドレス変更ハンドラー
+func UpdateEmailHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.UpdateEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+		if err := services.CurrentPasswordHasher().ComparePassword(user.PasswordHash, req.CurrentPassword); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "現在のパスワードが正しくありません", "code": "INVALID_PASSWORD"})
+			return
+		}
+
+		if err := db.Model(&user).Update("email", req.Email).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "メールアドレスの変更に失敗しました"})
+			return
+		}
+		log.Printf("📧 Email updated: user=%d", userID)
+
+		c.JSON(http.StatusOK, models.NewSelfUser(user))
+	}
+}
+
+// RequestPasswordResetHandler パスワード再設定の依頼ハンドラー
+// アカウントが存在する場合は再設定トークンを発行して案内を送信する
+// アカウントの有無を知られないよう、常に同じレスポンスを返す
+func RequestPasswordResetHandler(c *gin.Context) {
+	RequestPasswordResetHandlerWithDB(database.GetDB())(c)
+}
+
+// RequestPasswordResetHandlerWithDB DB接続を注入可能なパスワード再設定の依頼ハンドラー
+func RequestPasswordResetHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.PasswordResetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		if err := services.NewPasswordResetService(db).RequestReset(req.AccountID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワード再設定の依頼に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "アカウントが登録されている場合は、パスワード再設定の案内を送信しました"})
+	}
+}
+
+// ResetPasswordHandler パスワード再設定ハンドラー
+// 再設定トークンを確認して新しいパスワードを設定し、発行済みの全てのトークンを無効にする
+func ResetPasswordHandler(c *gin.Context) {
+	ResetPasswordHandlerWithDB(database.GetDB())(c)
+}
+
+// ResetPasswordHandlerWithDB DB接続を注入可能なパスワード再設定ハンドラー
+func ResetPasswordHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.PasswordResetConfirmRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		// パスワードポリシーを検証（文字数・文字種・よく使われるパスワード）
+		if err := services.DefaultPasswordPolicy().Validate("new_password", req.NewPassword); err != nil {
+			respondBillError(c, err, "パスワードが要件を満たしていません")
+			return
+		}
+
+		if _, err := services.NewPasswordResetService(db).ResetPassword(req.Token, req.NewPassword); err != nil {
+			if errors.Is(err, services.ErrPasswordResetTokenInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "PASSWORD_RESET_TOKEN_INVALID"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードの再設定に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "パスワードを再設定しました。新しいパスワードでログインしてください"})
+	}
+}
+
+// GetPasswordPolicyHandler パスワードポリシー取得ハンドラー
+// 登録・変更画面で要件を表示できるよう、現在のパスワードの要件を返す（認証不要）
+func GetPasswordPolicyHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"policy": services.DefaultPasswordPolicy()})
+}
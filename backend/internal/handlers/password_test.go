@@ -0,0 +1,315 @@
+// ========================================
+// パスワード変更・再設定の自動テスト
+// 現在のパスワードの確認、再設定トークンの1回限りの使用と、変更前のトークンの失効を検証
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// recordingResetSender 送信した再設定の案内を記録するテスト用の送信方法
+type recordingResetSender struct {
+	mutex    sync.Mutex
+	messages []services.PasswordResetMessage
+}
+
+// Send 再設定の案内を記録する
+func (s *recordingResetSender) Send(message services.PasswordResetMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// sent 記録した案内を返す
+func (s *recordingResetSender) sent() []services.PasswordResetMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]services.PasswordResetMessage(nil), s.messages...)
+}
+
+// waitForSent 再設定の案内がバックグラウンドでcount件送信されるまで待って返す
+func (s *recordingResetSender) waitForSent(t *testing.T, count int) []services.PasswordResetMessage {
+	t.Helper()
+	require.Eventually(t, func() bool { return len(s.sent()) >= count }, 2*time.Second, 5*time.Millisecond)
+	messages := s.sent()
+	require.Len(t, messages, count)
+	return messages
+}
+
+// setupPasswordRouter パスワード変更・再設定のテスト用ルーターを設定
+func setupPasswordRouter(t *testing.T, db *gorm.DB) (*gin.Engine, *recordingResetSender) {
+	t.Helper()
+	router := setupRefreshTokenRouter(t, db)
+	router.PUT("/password", middleware.AuthMiddleware(), ChangePasswordHandlerWithDB(db))
+	router.POST("/password/forgot", RequestPasswordResetHandlerWithDB(db))
+	router.POST("/password/reset", ResetPasswordHandlerWithDB(db))
+	router.PUT("/email", middleware.AuthMiddleware(), UpdateEmailHandlerWithDB(db))
+
+	sender := &recordingResetSender{}
+	services.SetPasswordResetSender(sender)
+	t.Cleanup(func() { services.SetPasswordResetSender(services.LogPasswordResetSender{}) })
+	return router, sender
+}
+
+// loginWithPassword 指定したパスワードでログインする
+func loginWithPassword(router *gin.Engine, password string) int {
+	w := performJSONRequest(router, "POST", "/login", map[string]string{"account_id": "refresh_user", "password": password})
+	return w.Code
+}
+
+// TestChangePassword_RequiresCurrentPassword 現在のパスワードの確認と、変更前のトークンの失効を検証
+func TestChangePassword_RequiresCurrentPassword(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, _ := setupPasswordRouter(t, db)
+	laptop := loginForTokens(t, router)
+	phone := loginForTokens(t, router)
+
+	w := requestWithToken(router, "PUT", "/password", laptop.Token, map[string]string{"current_password": "wrong", "new_password": "newpassword456"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = requestWithToken(router, "PUT", "/password", laptop.Token, map[string]string{"current_password": "password123", "new_password": "short"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = requestWithToken(router, "PUT", "/password", laptop.Token, map[string]string{"current_password": "password123", "new_password": "password123"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = requestWithToken(router, "PUT", "/password", laptop.Token, map[string]string{"current_password": "password123", "new_password": "newpassword456"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// 変更した端末は新しいトークンで利用を続けられ、他の端末のトークンは無効
+	w = requestWithToken(router, "GET", "/me", response.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, old := range []models.LoginResponse{laptop, phone} {
+		w = requestWithToken(router, "GET", "/me", old.Token, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = refreshTokens(router, old.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, loginWithPassword(router, "password123"))
+	assert.Equal(t, http.StatusOK, loginWithPassword(router, "newpassword456"))
+}
+
+// TestPasswordReset_SingleUseToken 再設定トークンで1回だけパスワードを再設定でき、発行済みのトークンが無効になることを検証
+func TestPasswordReset_SingleUseToken(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, sender := setupPasswordRouter(t, db)
+	login := loginForTokens(t, router)
+
+	// 存在しないアカウントでも同じレスポンス
+	w := performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "unknown_user"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	unknownBody := w.Body.String()
+	assert.Empty(t, sender.sent())
+
+	w = performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, unknownBody, w.Body.String())
+	message := sender.waitForSent(t, 1)[0]
+	assert.Equal(t, "refresh_user", message.AccountID)
+	assert.Contains(t, message.ResetURL, "token="+message.Token)
+
+	// トークンはハッシュのみ保存
+	var record models.PasswordResetToken
+	require.NoError(t, db.First(&record).Error)
+	assert.NotEqual(t, message.Token, record.TokenHash)
+
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": message.Token, "new_password": "resetpassword789"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 再設定前のトークンは無効、新しいパスワードでログインできる
+	w = requestWithToken(router, "GET", "/me", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = refreshTokens(router, login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, http.StatusOK, loginWithPassword(router, "resetpassword789"))
+
+	// 同じトークンは再利用できない
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": message.Token, "new_password": "anotherpassword"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "PASSWORD_RESET_TOKEN_INVALID")
+}
+
+// TestPasswordReset_ExpiredAndSupersededTokens 有効期限切れのトークンと、新しい依頼で置き換えられたトークンを拒否することを検証
+func TestPasswordReset_ExpiredAndSupersededTokens(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, sender := setupPasswordRouter(t, db)
+
+	for i := 0; i < 2; i++ {
+		w := performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		sender.waitForSent(t, i+1)
+	}
+	messages := sender.sent()
+
+	w := performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": messages[0].Token, "new_password": "resetpassword789"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	require.NoError(t, db.Model(&models.PasswordResetToken{}).Where("used_at IS NULL").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": messages[1].Token, "new_password": "resetpassword789"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Equal(t, http.StatusOK, loginWithPassword(router, "password123"))
+}
+
+// TestPasswordPolicy_AppliedToChangeAndReset パスワード変更・再設定で共通のポリシーが適用され、ルールのコードが返されることを検証
+func TestPasswordPolicy_AppliedToChangeAndReset(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, sender := setupPasswordRouter(t, db)
+	router.GET("/password-policy", GetPasswordPolicyHandler)
+	tokens := loginForTokens(t, router)
+
+	w := performJSONRequest(router, "GET", "/password-policy", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var policyResponse struct {
+		Policy services.PasswordPolicy `json:"policy"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &policyResponse))
+	assert.Equal(t, services.DefaultPasswordPolicy(), policyResponse.Policy)
+
+	ruleOf := func(w interface{ Bytes() []byte }) string {
+		var response struct {
+			Code   string                `json:"code"`
+			Fields []services.FieldError `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Bytes(), &response))
+		assert.Equal(t, "VALIDATION_ERROR", response.Code)
+		require.NotEmpty(t, response.Fields)
+		assert.Equal(t, "new_password", response.Fields[0].Field)
+		return response.Fields[0].Rule
+	}
+
+	w = requestWithToken(router, "PUT", "/password", tokens.Token, map[string]string{"current_password": "password123", "new_password": "qwertyuiop"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, services.RulePasswordBreached, ruleOf(w.Body))
+
+	w = performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	token := sender.waitForSent(t, 1)[0].Token
+
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": token, "new_password": "abc"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, services.RulePasswordMinLength, ruleOf(w.Body))
+
+	// ポリシー違反では再設定トークンは使用済みにならない
+	w = performJSONRequest(router, "POST", "/password/reset", map[string]string{"token": token, "new_password": "correct-horse-42"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+// TestLogin_UpgradesLegacyPasswordHash 従来のbcryptのハッシュがログイン時にArgon2idで作り直され、発行済みのトークンは有効なままであることを検証
+func TestLogin_UpgradesLegacyPasswordHash(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, _ := setupPasswordRouter(t, db)
+
+	var user models.User
+	require.NoError(t, db.Where("account_id = ?", "refresh_user").First(&user).Error)
+	require.True(t, strings.HasPrefix(user.PasswordHash, "$2a$"), user.PasswordHash)
+
+	// 誤ったパスワードではハッシュは変わらない
+	assert.Equal(t, http.StatusUnauthorized, loginWithPassword(router, "wrong-password"))
+	require.NoError(t, db.First(&user, user.ID).Error)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$2a$"))
+
+	tokens := loginForTokens(t, router)
+	require.NoError(t, db.First(&user, user.ID).Error)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"), user.PasswordHash)
+	assert.False(t, services.CurrentPasswordHasher().NeedsRehash(user.PasswordHash))
+
+	// パスワード自体は変わらないため、作り直し前に発行したトークンも有効
+	w := requestWithToken(router, "GET", "/me", tokens.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, loginWithPassword(router, "password123"))
+
+	// 作り直し済みのハッシュは再度作り直さない
+	upgraded := user.PasswordHash
+	assert.Equal(t, http.StatusOK, loginWithPassword(router, "password123"))
+	require.NoError(t, db.First(&user, user.ID).Error)
+	assert.Equal(t, upgraded, user.PasswordHash)
+}
+
+// blockingResetSender 解放されるまで送信を完了しないテスト用の送信方法
+type blockingResetSender struct {
+	release chan struct{}
+	done    chan services.PasswordResetMessage
+}
+
+// Send 解放されるまで待ってから送信を完了する
+func (s *blockingResetSender) Send(message services.PasswordResetMessage) error {
+	<-s.release
+	s.done <- message
+	return nil
+}
+
+// TestPasswordReset_DeliveryDoesNotDelayResponse 案内の送信を待たずに応答し、アカウントの有無が応答時間に表れないことを検証
+func TestPasswordReset_DeliveryDoesNotDelayResponse(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, _ := setupPasswordRouter(t, db)
+	sender := &blockingResetSender{release: make(chan struct{}), done: make(chan services.PasswordResetMessage, 1)}
+	services.SetPasswordResetSender(sender)
+
+	// 送信が完了していなくても応答する
+	w := performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	close(sender.release)
+	select {
+	case message := <-sender.done:
+		assert.Equal(t, "refresh_user", message.AccountID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("password reset message was not delivered")
+	}
+}
+
+// TestUpdateEmail_ChangesResetAddress 現在のパスワードを確認してメールアドレスを変更し、再設定の案内の送信先になることを検証
+func TestUpdateEmail_ChangesResetAddress(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router, sender := setupPasswordRouter(t, db)
+	tokens := loginForTokens(t, router)
+
+	w := requestWithToken(router, "PUT", "/email", tokens.Token, map[string]string{"current_password": "wrongpassword", "email": "hanako@example.com"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_PASSWORD")
+
+	w = requestWithToken(router, "PUT", "/email", tokens.Token, map[string]string{"current_password": "password123", "email": "not-an-email"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = requestWithToken(router, "PUT", "/email", tokens.Token, map[string]string{"current_password": "password123", "email": "hanako@example.com"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var self models.SelfUser
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &self))
+	assert.Equal(t, "hanako@example.com", self.Email)
+
+	// 本人のユーザー情報にはメールアドレスを含める
+	w = requestWithToken(router, "GET", "/me", tokens.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"hanako@example.com"`)
+
+	w = performJSONRequest(router, "POST", "/password/forgot", map[string]string{"account_id": "refresh_user"})
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "hanako@example.com", sender.waitForSent(t, 1)[0].Email)
+
+	// 空にすると登録を削除する
+	w = requestWithToken(router, "PUT", "/email", tokens.Token, map[string]string{"current_password": "password123", "email": ""})
+	require.Equal(t, http.StatusOK, w.Code)
+	var user models.User
+	require.NoError(t, db.First(&user, self.ID).Error)
+	assert.Empty(t, user.Email)
+}
@@ -0,0 +1,187 @@
+// ========================================
+// 任意期間の家計簿の自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupPeriodRouter 家計簿の作成・取得ハンドラーを登録したルーターを作成する
+func setupPeriodRouter(db *gorm.DB, userID uint) *gin.Engine {
+	router := setupRouter()
+	router.Use(setUserID(userID))
+	router.POST("/bills", CreateBillHandlerWithDB(db))
+	router.GET("/bills", GetBillsListHandlerWithDB(db))
+	router.GET("/bills/:year/:month", GetBillHandlerWithDB(db))
+	router.GET("/bills/detail/:id", GetBillByIDHandlerWithDB(db))
+	return router
+}
+
+// TestCreateBill_CustomPeriod 開始日・終了日を指定した家計簿の作成と重複チェックを検証
+func TestCreateBill_CustomPeriod(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+	router := setupPeriodRouter(db, testData.User1.ID)
+
+	// 給料日（25日）区切りの期間で作成
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"payer_id":     testData.User2.ID,
+		"period_start": "2024-09-25",
+		"period_end":   "2024-10-24",
+		"period_label": "10月分（給料日区切り）",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.BillPeriodCustom, created.PeriodType)
+	assert.Equal(t, "10月分（給料日区切り）", created.PeriodLabel)
+	assert.Equal(t, 2024, created.Year)
+	assert.Equal(t, 9, created.Month)
+	assert.Equal(t, "2024-09-25", created.PeriodStart.Format("2006-01-02"))
+	assert.Equal(t, "2024-10-24", created.PeriodEnd.Format("2006-01-02"))
+
+	// 同じ期間は重複エラー
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"payer_id":     testData.User2.ID,
+		"period_start": "2024-09-25",
+		"period_end":   "2024-10-24",
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "指定された期間の家計簿は既に存在します")
+
+	// 開始年月が同じでも暦月の家計簿は別に作成できる
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"year": 2024, "month": 9, "payer_id": testData.User2.ID,
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var monthly models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &monthly))
+	assert.Equal(t, models.BillPeriodMonth, monthly.PeriodType)
+	assert.Equal(t, "2024-09-01", monthly.PeriodStart.Format("2006-01-02"))
+	assert.Equal(t, "2024-09-30", monthly.PeriodEnd.Format("2006-01-02"))
+}
+
+// TestGetBill_CustomPeriod 年月指定の取得は暦月の家計簿のみを返し、任意期間はID指定で取得できることを検証
+func TestGetBill_CustomPeriod(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+	router := setupPeriodRouter(db, testData.User1.ID)
+
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"payer_id":     testData.User2.ID,
+		"period_start": "2025-01-10",
+		"period_end":   "2025-01-20",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	// 年月指定では任意期間の家計簿は返らない
+	w = performJSONRequest(router, "GET", "/bills/2025/1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"bill": null}`, w.Body.String())
+
+	// 一覧には含まれる
+	w = performJSONRequest(router, "GET", "/bills", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"id":%d`, created.ID))
+
+	// ID指定で取得できる（支払者からも取得可能）
+	w = performJSONRequest(setupPeriodRouter(db, testData.User2.ID), "GET", fmt.Sprintf("/bills/detail/%d", created.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var detail models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, created.ID, detail.ID)
+	assert.Equal(t, models.BillPeriodCustom, detail.PeriodType)
+
+	// 関係のないユーザーには見えない
+	w = performJSONRequest(setupPeriodRouter(db, testData.User3.ID), "GET", fmt.Sprintf("/bills/detail/%d", created.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestCreateBill_PeriodValidation 期間指定の不正な入力が拒否されることを検証
+func TestCreateBill_PeriodValidation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+	router := setupPeriodRouter(db, testData.User1.ID)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"年月も期間もなし", map[string]interface{}{}},
+		{"月が範囲外", map[string]interface{}{"year": 2024, "month": 13}},
+		{"終了日なし", map[string]interface{}{"period_start": "2024-01-01"}},
+		{"日付形式が不正", map[string]interface{}{"period_start": "2024/01/01", "period_end": "2024-01-31"}},
+		{"終了日が開始日より前", map[string]interface{}{"period_start": "2024-02-01", "period_end": "2024-01-31"}},
+		{"期間が長すぎる", map[string]interface{}{"period_start": "2024-01-01", "period_end": "2025-01-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.body["payer_id"] = testData.User2.ID
+			w := performJSONRequest(router, "POST", "/bills", tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	// うるう年を含む366日間は許可
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"payer_id": testData.User2.ID, "period_start": "2024-01-01", "period_end": "2024-12-31",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestBudgetStatus_CustomPeriodCountedInStartMonth 任意期間の家計簿の項目は開始日の月の予算に全額を計上することを検証
+func TestBudgetStatus_CustomPeriodCountedInStartMonth(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+	router := setupPeriodRouter(db, testData.User1.ID)
+	router.GET("/budgets/status", GetBudgetStatusHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{
+		"payer_id":     testData.User2.ID,
+		"period_start": "2024-09-25",
+		"period_end":   "2024-10-24",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NoError(t, db.Create(&models.BillItem{BillID: created.ID, ItemName: "食費", Amount: 30000}).Error)
+	require.NoError(t, db.Create(&models.Budget{UserID: testData.User1.ID, Category: "食費", MonthlyLimit: 50000, AlertThreshold: 80}).Error)
+
+	usedIn := func(year, month int) float64 {
+		w := performJSONRequest(router, "GET", fmt.Sprintf("/budgets/status?year=%d&month=%d", year, month), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Budgets []models.BudgetStatus `json:"budgets"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Budgets, 1)
+		return response.Budgets[0].Used
+	}
+
+	// 期間の大半が10月でも、按分せず開始日の9月に計上する
+	assert.Equal(t, 30000.0, usedIn(2024, 9))
+	assert.Equal(t, 0.0, usedIn(2024, 10))
+}
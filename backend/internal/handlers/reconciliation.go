@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+const maxStatementFileSize = 1024 * 1024 // 入金明細ファイルの最大サイズ（1MB）
+
+// ReconciliationConfirmResult 照合の確定結果（1件の入金と家計簿の組ごと）
+type ReconciliationConfirmResult struct {
+	DepositID uint   `json:"deposit_id"`        // 入金ID
+	BillID    uint   `json:"bill_id"`           // 家計簿ID
+	Status    int    `json:"status"`            // 支払ハンドラーと同じHTTPステータス
+	Message   string `json:"message,omitempty"` // 成功時のメッセージ
+	Error     string `json:"error,omitempty"`   // 失敗時のエラーメッセージ
+}
+
+// ImportBankStatementHandler 入金明細取り込みハンドラー
+// 請求者が銀行の入金明細CSVをアップロードし、請求中の家計簿との照合候補を取得する
+func ImportBankStatementHandler(c *gin.Context) {
+	ImportBankStatementHandlerWithDB(database.GetDB())(c)
+}
+
+// ImportBankStatementHandlerWithDB DB接続を注入可能な入金明細取り込みハンドラー
+func ImportBankStatementHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// multipart/form-dataのfileフィールドで入金明細CSVを受け取る
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respondValidationError(c, services.NewValidationError(services.FieldError{
+				Field: "file", Rule: services.RuleRequired, Message: "入金明細のCSVファイルを指定してください",
+			}))
+			return
+		}
+		if fileHeader.Size > maxStatementFileSize {
+			respondValidationError(c, services.NewValidationError(services.FieldError{
+				Field: "file", Rule: services.RuleMax, Message: "入金明細のファイルは1MB以内にしてください",
+			}))
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の読み取りに失敗しました"})
+			return
+		}
+		defer file.Close()
+
+		parsed, skipped, err := services.ParseBankStatement(file)
+		if err != nil {
+			respondBillError(c, err, "入金明細の読み取りに失敗しました")
+			return
+		}
+		if len(parsed) == 0 {
+			respondValidationError(c, services.NewValidationError(services.FieldError{
+				Field: "file", Rule: services.RuleRequired, Message: "入金明細に入金が含まれていません",
+			}))
+			return
+		}
+
+		// 取り込みと入金を保存
+		statement := models.BankStatementImport{
+			RequesterID:  userID,
+			FileName:     fileHeader.Filename,
+			DepositCount: len(parsed),
+		}
+		for _, deposit := range parsed {
+			statement.Deposits = append(statement.Deposits, models.BankDeposit{
+				LineNumber:  deposit.LineNumber,
+				DepositDate: deposit.Date,
+				Amount:      deposit.Amount,
+				SenderName:  deposit.SenderName,
+			})
+		}
+		if err := db.Create(&statement).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の保存に失敗しました"})
+			return
+		}
+
+		log.Printf("🏦 Bank statement imported: import=%d requester=%d deposits=%d skipped=%d", statement.ID, userID, len(parsed), len(skipped))
+
+		response, err := buildReconciliation(db, statement.ID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の照合に失敗しました"})
+			return
+		}
+		response.SkippedLines = skipped
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetReconciliationHandler 照合結果取得ハンドラー
+// 取り込み済みの入金明細のうち未照合の入金と、請求中の家計簿の照合候補を再計算して返す
+func GetReconciliationHandler(c *gin.Context) {
+	GetReconciliationHandlerWithDB(database.GetDB())(c)
+}
+
+// GetReconciliationHandlerWithDB DB接続を注入可能な照合結果取得ハンドラー
+func GetReconciliationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		importID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		if _, ok := findStatementImport(c, db, uint(importID), userID); !ok {
+			return
+		}
+
+		response, err := buildReconciliation(db, uint(importID), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の照合に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ConfirmReconciliationHandler 照合確定ハンドラー
+// 請求者が確認した入金と家計簿の組ごとに、支払ハンドラーと同じ状態遷移で家計簿をpaid（支払済み）に変更する
+// 組ごとに独立して処理し、失敗した組は結果にエラーを返す
+func ConfirmReconciliationHandler(c *gin.Context) {
+	ConfirmReconciliationHandlerWithDB(database.GetDB())(c)
+}
+
+// ConfirmReconciliationHandlerWithDB DB接続を注入可能な照合確定ハンドラー
+func ConfirmReconciliationHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		importID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			Matches []struct {
+				DepositID uint `json:"deposit_id" binding:"required"` // 入金ID（必須）
+				BillID    uint `json:"bill_id" binding:"required"`    // 家計簿ID（必須）
+			} `json:"matches" binding:"required,min=1,max=100,dive"` // 確定する組（必須）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		if _, ok := findStatementImport(c, db, uint(importID), userID); !ok {
+			return
+		}
+
+		results := make([]ReconciliationConfirmResult, len(req.Matches))
+		for i, match := range req.Matches {
+			results[i] = confirmReconciliationMatch(db, uint(importID), userID, match.DepositID, match.BillID)
+		}
+
+		response, err := buildReconciliation(db, uint(importID), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の照合に失敗しました"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"results": results, "reconciliation": response})
+	}
+}
+
+// confirmReconciliationMatch 入金と家計簿の組を確定する
+// 家計簿の支払済みへの変更と入金の照合済みの記録は同じトランザクションで行う
+func confirmReconciliationMatch(db *gorm.DB, importID, userID, depositID, billID uint) ReconciliationConfirmResult {
+	result := ReconciliationConfirmResult{DepositID: depositID, BillID: billID}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var deposit models.BankDeposit
+		if err := tx.Where("id = ? AND import_id = ?", depositID, importID).First(&deposit).Error; err != nil {
+			return errDepositNotFound
+		}
+		if deposit.MatchedBillID != nil {
+			return errDepositAlreadyMatched
+		}
+
+		// 照合候補として提案されていない組でも確定できる（一致度は記録用に再計算する）
+		service := newBillService(tx)
+		bill, err := service.GetBill(billID, userID)
+		if err != nil {
+			return err
+		}
+		candidate, _ := services.ScoreReconciliation(statementDeposit(deposit), reconciliationBill(*bill))
+
+		// 家計簿を支払済みにする前に入金を確保する（他のリクエストが先に照合した場合は更新されない）
+		claimed := tx.Model(&models.BankDeposit{}).
+			Where("id = ? AND matched_bill_id IS NULL", deposit.ID).
+			Updates(map[string]interface{}{
+				"matched_bill_id": bill.ID,
+				"confidence":      candidate.Confidence,
+				"matched_at":      time.Now(),
+			})
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			return errDepositAlreadyMatched
+		}
+
+		return service.ConfirmPaymentReceived(billID, userID)
+	})
+	if err != nil {
+		result.Status = billErrorStatus(err)
+		result.Error = billErrorMessage(err, "家計簿の更新に失敗しました")
+		return result
+	}
+
+	log.Printf("🏦 Payment reconciled: import=%d deposit=%d bill=%d requester=%d", importID, depositID, billID, userID)
+
+	result.Status = http.StatusOK
+	result.Message = "支払いが確定しました"
+	return result
+}
+
+// 照合の確定に関するエラー
+var (
+	errDepositNotFound       = &services.DomainError{Kind: services.ErrNotFound, Message: "入金が見つかりません"}
+	errDepositAlreadyMatched = &services.DomainError{Kind: services.ErrConflict, Message: "この入金は既に照合済みです"}
+)
+
+// findStatementImport 請求者本人が取り込んだ入金明細を取得する（他のユーザーの取り込みは404）
+func findStatementImport(c *gin.Context, db *gorm.DB, importID, userID uint) (*models.BankStatementImport, bool) {
+	var statement models.BankStatementImport
+	err := db.Where("id = ? AND requester_id = ?", importID, userID).First(&statement).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "入金明細が見つかりません"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "入金明細の取得に失敗しました"})
+		}
+		return nil, false
+	}
+	return &statement, true
+}
+
+// buildReconciliation 入金明細の未照合の入金と請求中の家計簿を照合し、照合結果のレスポンスを作成する
+func buildReconciliation(db *gorm.DB, importID, userID uint) (models.ReconciliationResponse, error) {
+	response := models.ReconciliationResponse{
+		ImportID:          importID,
+		Matches:           []models.ReconciliationMatch{},
+		UnmatchedDeposits: []models.BankDeposit{},
+		UnmatchedBills:    []models.BillResponse{},
+	}
+
+	var deposits []models.BankDeposit
+	if err := db.Where("import_id = ? AND matched_bill_id IS NULL", importID).Order("id").Find(&deposits).Error; err != nil {
+		return response, err
+	}
+	bills, err := newBillService(db).ListAwaitingPayment(userID)
+	if err != nil {
+		return response, err
+	}
+
+	depositsByID := make(map[uint]models.BankDeposit, len(deposits))
+	statementDeposits := make([]services.StatementDeposit, 0, len(deposits))
+	for _, deposit := range deposits {
+		depositsByID[deposit.ID] = deposit
+		statementDeposits = append(statementDeposits, statementDeposit(deposit))
+	}
+	billsByID := make(map[uint]models.BillResponse, len(bills))
+	reconciliationBills := make([]services.ReconciliationBill, 0, len(bills))
+	for _, bill := range bills {
+		billsByID[bill.ID] = newBillResponse(bill)
+		reconciliationBills = append(reconciliationBills, reconciliationBill(bill))
+	}
+
+	result := services.ReconcileDeposits(statementDeposits, reconciliationBills)
+	for _, match := range result.Matches {
+		response.Matches = append(response.Matches, models.ReconciliationMatch{
+			DepositID:  match.DepositID,
+			Deposit:    depositsByID[match.DepositID],
+			Bill:       billsByID[match.BillID],
+			Confidence: match.Confidence,
+			Reasons:    match.Reasons,
+		})
+	}
+	for _, id := range result.UnmatchedDeposits {
+		response.UnmatchedDeposits = append(response.UnmatchedDeposits, depositsByID[id])
+	}
+	for _, id := range result.UnmatchedBills {
+		response.UnmatchedBills = append(response.UnmatchedBills, billsByID[id])
+	}
+	return response, nil
+}
+
+// statementDeposit 保存済みの入金を照合用の入金に変換する
+func statementDeposit(deposit models.BankDeposit) services.StatementDeposit {
+	return services.StatementDeposit{
+		ID:         deposit.ID,
+		LineNumber: deposit.LineNumber,
+		Date:       deposit.DepositDate,
+		Amount:     deposit.Amount,
+		SenderName: deposit.SenderName,
+	}
+}
+
+// reconciliationBill 家計簿を照合用の家計簿に変換する（請求金額は支払者の負担額）
+func reconciliationBill(bill models.MonthlyBill) services.ReconciliationBill {
+	return services.ReconciliationBill{
+		BillID:      bill.ID,
+		Amount:      newBillResponse(bill).TotalAmount,
+		RequestDate: bill.RequestDate,
+		PayerNames:  []string{bill.Payer.Name, bill.Payer.AccountID},
+	}
+}
@@ -0,0 +1,206 @@
+// ========================================
+// 入金明細の照合APIの自動テスト
+// 取り込み・照合候補・確定による支払い済みへの変更を検証
+// ========================================
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// performStatementUpload 入金明細CSVをmultipart/form-dataでアップロードする
+func performStatementUpload(router *gin.Engine, csv string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "statement.csv")
+	part.Write([]byte(csv))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/bills/reconciliations", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// setupReconciliationRouter 照合APIのテスト用ルーターを設定
+func setupReconciliationRouter(db *gorm.DB, userID uint) *gin.Engine {
+	router := setupRouter()
+	router.Use(setUserID(userID))
+	router.POST("/bills/reconciliations", ImportBankStatementHandlerWithDB(db))
+	router.GET("/bills/reconciliations/:id", GetReconciliationHandlerWithDB(db))
+	router.PUT("/bills/reconciliations/:id/confirm", ConfirmReconciliationHandlerWithDB(db))
+	return router
+}
+
+// TestReconciliation_ImportAndConfirm 入金明細の取り込みで照合候補が提案され、確定で支払い済みになることを検証
+func TestReconciliation_ImportAndConfirm(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("recon_requester").MustBuild()
+	payer := factory.NewUser().WithName("やまだ はなこ").WithAccountID("recon_payer").MustBuild()
+	requestDate := time.Date(2024, 10, 1, 9, 0, 0, 0, time.Local)
+
+	matched, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 9).
+		WithStatus("requested").WithRequestDate(requestDate).AddItems(testfactory.Item("家賃", 98000)).MustBuild()
+	unmatched, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 8).
+		WithStatus("requested").WithRequestDate(requestDate).AddItems(testfactory.Item("食費", 30000)).MustBuild()
+	factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 7).
+		WithStatus("pending").AddItems(testfactory.Item("食費", 98000)).MustBuild()
+
+	router := setupReconciliationRouter(db, requester.ID)
+	w := performStatementUpload(router, "取引日,摘要,お引出金額,お預入金額\n"+
+		"2024/10/05,振込 ﾔﾏﾀﾞ ﾊﾅｺ,,98000\n"+
+		"2024/10/06,カード引落,5000,\n"+
+		"2024/10/07,ﾘｿｸ,,12\n")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var response models.ReconciliationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Matches, 1)
+	assert.Equal(t, matched.ID, response.Matches[0].Bill.ID)
+	assert.Equal(t, 1.0, response.Matches[0].Confidence)
+	require.Len(t, response.UnmatchedDeposits, 1)
+	assert.Equal(t, 12.0, response.UnmatchedDeposits[0].Amount)
+	require.Len(t, response.UnmatchedBills, 1)
+	assert.Equal(t, unmatched.ID, response.UnmatchedBills[0].ID)
+	assert.Equal(t, []int{3}, response.SkippedLines)
+
+	// 確定すると支払い済みになり、照合結果から除かれる
+	confirmPath := fmt.Sprintf("/bills/reconciliations/%d/confirm", response.ImportID)
+	depositID := response.Matches[0].DepositID
+	w = performJSONRequest(router, "PUT", confirmPath, map[string]interface{}{
+		"matches": []map[string]interface{}{
+			{"deposit_id": depositID, "bill_id": matched.ID},
+			{"deposit_id": depositID, "bill_id": unmatched.ID},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var confirmed struct {
+		Results        []ReconciliationConfirmResult `json:"results"`
+		Reconciliation models.ReconciliationResponse `json:"reconciliation"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirmed))
+	require.Len(t, confirmed.Results, 2)
+	assert.Equal(t, http.StatusOK, confirmed.Results[0].Status)
+	assert.Equal(t, http.StatusConflict, confirmed.Results[1].Status)
+	assert.Empty(t, confirmed.Reconciliation.Matches)
+
+	var bill models.MonthlyBill
+	require.NoError(t, db.First(&bill, matched.ID).Error)
+	assert.Equal(t, "paid", bill.Status)
+	assert.NotNil(t, bill.PaymentDate)
+
+	var deposit models.BankDeposit
+	require.NoError(t, db.First(&deposit, depositID).Error)
+	require.NotNil(t, deposit.MatchedBillID)
+	assert.Equal(t, matched.ID, *deposit.MatchedBillID)
+	assert.Equal(t, 1.0, deposit.Confidence)
+
+	// 照合結果は再取得できる
+	w = performJSONRequest(router, "GET", fmt.Sprintf("/bills/reconciliations/%d", response.ImportID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var fetched models.ReconciliationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Len(t, fetched.UnmatchedDeposits, 1)
+	assert.Len(t, fetched.UnmatchedBills, 1)
+
+	// 他のユーザーの取り込みは見えない
+	other := setupReconciliationRouter(db, payer.ID)
+	w = performJSONRequest(other, "GET", fmt.Sprintf("/bills/reconciliations/%d", response.ImportID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestReconciliation_ConfirmFollowsPaymentTransition 確定時も支払いと同じ状態遷移の条件が適用されることを検証
+func TestReconciliation_ConfirmFollowsPaymentTransition(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("recon_ack_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("recon_ack_payer").MustBuild()
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 9).
+		WithStatus("requested").WithRequestDate(time.Now()).AddItems(testfactory.Item("家賃", 1000)).MustBuild()
+	require.NoError(t, db.Model(&bill).Update("require_acknowledgement", true).Error)
+
+	router := setupReconciliationRouter(db, requester.ID)
+	w := performStatementUpload(router, "日付,金額\n"+time.Now().Format("2006/01/02")+",1000\n")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response models.ReconciliationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Matches, 1)
+
+	// 内容確認が必須の家計簿は支払者の確認前には支払い済みにできない
+	w = performJSONRequest(router, "PUT", fmt.Sprintf("/bills/reconciliations/%d/confirm", response.ImportID), map[string]interface{}{
+		"matches": []map[string]interface{}{{"deposit_id": response.Matches[0].DepositID, "bill_id": bill.ID}},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "支払前に家計簿の内容確認が必要です")
+
+	// 入金が読み取れないファイルは検証エラー
+	w = performStatementUpload(router, "日付,金額\n2024/10/01,-500\n")
+	decodeValidationError(t, w)
+}
+
+// TestReconciliation_ConfirmRaceWithConcurrentMatch 入金の確認後に他のリクエストが同じ入金を照合した場合、家計簿を支払済みにしないことを検証
+func TestReconciliation_ConfirmRaceWithConcurrentMatch(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("recon_race_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("recon_race_payer").MustBuild()
+	requestDate := time.Now()
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 9).
+		WithStatus("requested").WithRequestDate(requestDate).AddItems(testfactory.Item("家賃", 1000)).MustBuild()
+	other, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 8).
+		WithStatus("requested").WithRequestDate(requestDate).AddItems(testfactory.Item("家賃", 1000)).MustBuild()
+
+	router := setupReconciliationRouter(db, requester.ID)
+	w := performStatementUpload(router, "日付,金額\n"+requestDate.Format("2006/01/02")+",1000\n")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response models.ReconciliationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(t, response.Matches)
+	depositID := response.Matches[0].DepositID
+
+	// 入金の読み込み直後に、他のリクエストが同じ入金を別の家計簿に照合したことにする
+	concurrentMatch := true
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:concurrent_match", func(tx *gorm.DB) {
+		if tx.Statement.Table != "bank_deposits" || !concurrentMatch {
+			return
+		}
+		concurrentMatch = false
+		require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).
+			Exec("UPDATE bank_deposits SET matched_bill_id = ? WHERE id = ?", other.ID, depositID).Error)
+	}))
+
+	w = performJSONRequest(router, "PUT", fmt.Sprintf("/bills/reconciliations/%d/confirm", response.ImportID), map[string]interface{}{
+		"matches": []map[string]interface{}{{"deposit_id": depositID, "bill_id": bill.ID}},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var confirmed struct {
+		Results []ReconciliationConfirmResult `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirmed))
+	require.Len(t, confirmed.Results, 1)
+	assert.Equal(t, http.StatusConflict, confirmed.Results[0].Status)
+
+	var updated models.MonthlyBill
+	require.NoError(t, db.First(&updated, bill.ID).Error)
+	assert.Equal(t, "requested", updated.Status)
+	assert.Nil(t, updated.PaymentDate)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// RefreshTokenHandler トークン再発行ハンドラー
+// リフレッシュトークンを使用済みにして、新しいアクセストークンとリフレッシュトークンを発行する（ローテーション）
+// 使用済みのリフレッシュトークンが再び使われた場合は、そこから続く全てのトークンを失効させる
+func RefreshTokenHandler(c *gin.Context) {
+	RefreshTokenHandlerWithDB(database.GetDB())(c)
+}
+
+// RefreshTokenHandlerWithDB DB接続を注入可能なトークン再発行ハンドラー
+func RefreshTokenHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RefreshRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		userID, tokens, err := services.NewRefreshTokenService(db).Rotate(req.RefreshToken, sessionClient(c))
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "REFRESH_TOKEN_REUSED"})
+			return
+		case errors.Is(err, services.ErrRefreshTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "REFRESH_TOKEN_INVALID"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrRefreshTokenInvalid.Error(), "code": "REFRESH_TOKEN_INVALID"})
+			return
+		}
+
+		c.JSON(http.StatusOK, newLoginResponse(tokens, user))
+	}
+}
+
+// newLoginResponse 発行したトークンとユーザー情報からログインレスポンスを作成する
+func newLoginResponse(tokens *services.SessionTokens, user models.User) models.LoginResponse {
+	return models.LoginResponse{
+		Token:                 tokens.AccessToken,
+		ExpiresAt:             tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		User:                  user,
+	}
+}
+
+// sessionClient リクエストからセッションに記録する端末の情報を取得する
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,232 @@
+// ========================================
+// リフレッシュトークンの自動テスト
+// ローテーション・再利用検出・ログアウト（全デバイス含む）時の失効を検証
+// ========================================
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// setupRefreshTokenRouter ログイン・トークン再発行・ログアウトのテスト用ルーターを設定
+func setupRefreshTokenRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "refresh_token_test_secret_key_32chars")
+	middleware.SetTokenVersionLookup(services.NewTokenVersionService(db).Current)
+	t.Cleanup(func() { middleware.SetTokenVersionLookup(nil) })
+
+	router := setupRouter()
+	router.POST("/login", LoginHandlerWithDB(db))
+	router.POST("/refresh", RefreshTokenHandlerWithDB(db))
+	router.POST("/logout", middleware.AuthMiddleware(), LogoutHandlerWithDB(db))
+	router.POST("/logout-all", middleware.AuthMiddleware(), LogoutAllHandlerWithDB(db))
+	router.GET("/me", middleware.AuthMiddleware(), GetMeHandlerWithDB(db))
+	router.GET("/token-status", middleware.AuthMiddleware(), GetTokenStatusHandlerWithDB(db))
+	router.GET("/sessions", middleware.AuthMiddleware(), ListSessionsHandlerWithDB(db))
+	router.DELETE("/sessions/:id", middleware.AuthMiddleware(), RevokeSessionHandlerWithDB(db))
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.User{Name: "リフレッシュ", AccountID: "refresh_user", PasswordHash: string(hashedPassword)}).Error)
+	return router
+}
+
+// loginForTokens ログインしてトークンを取得する
+func loginForTokens(t *testing.T, router *gin.Engine) models.LoginResponse {
+	t.Helper()
+	return loginWithUserAgent(t, router, "")
+}
+
+// loginWithUserAgent 指定したUser-Agentの端末からログインしてトークンを取得する
+func loginWithUserAgent(t *testing.T, router *gin.Engine, userAgent string) models.LoginResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"account_id": "refresh_user", "password": "password123"})
+	req := httptest.NewRequest("POST", "/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(t, response.Token)
+	require.NotEmpty(t, response.RefreshToken)
+	return response
+}
+
+// refreshTokens リフレッシュトークンでトークンを再発行する
+func refreshTokens(router *gin.Engine, refreshToken string) *httptest.ResponseRecorder {
+	return performJSONRequest(router, "POST", "/refresh", map[string]string{"refresh_token": refreshToken})
+}
+
+// requestWithToken アクセストークン付きでリクエストする
+func requestWithToken(router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestRefreshToken_Rotation リフレッシュトークンが1回限りで、使用するたびに新しいトークンが発行されることを検証
+func TestRefreshToken_Rotation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), login.ExpiresAt, time.Minute)
+	assert.True(t, login.RefreshTokenExpiresAt.After(login.ExpiresAt))
+
+	// DBにはハッシュのみ保存
+	var stored models.RefreshToken
+	require.NoError(t, db.First(&stored).Error)
+	assert.NotEqual(t, login.RefreshToken, stored.TokenHash)
+
+	w := refreshTokens(router, login.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var rotated models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+	assert.NotEqual(t, login.Token, rotated.Token)
+	assert.Equal(t, "refresh_user", rotated.User.AccountID)
+
+	w = requestWithToken(router, "GET", "/me", rotated.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = refreshTokens(router, "unknown-refresh-token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_INVALID")
+}
+
+// TestRefreshToken_ReuseRevokesDescendants 使用済みのリフレッシュトークンの再利用で、そこから続く全てのトークンが失効することを検証
+func TestRefreshToken_ReuseRevokesDescendants(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	var second, third models.LoginResponse
+	w := refreshTokens(router, login.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	w = refreshTokens(router, second.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &third))
+
+	// 別のログインのトークンは影響を受けない
+	other := loginForTokens(t, router)
+
+	// 最初のリフレッシュトークンを再利用
+	w = refreshTokens(router, login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_REUSED")
+
+	// 続くリフレッシュトークンとアクセストークンは全て無効
+	w = refreshTokens(router, third.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = requestWithToken(router, "GET", "/me", third.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = requestWithToken(router, "GET", "/me", second.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var revoked int64
+	db.Model(&models.RefreshToken{}).Where("revoked_reason = ?", "reuse_detected").Count(&revoked)
+	assert.Equal(t, int64(3), revoked)
+
+	w = refreshTokens(router, other.RefreshToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestRefreshToken_LogoutRevokes ログアウトでアクセストークンとリフレッシュトークンの両方が無効になることを検証
+func TestRefreshToken_LogoutRevokes(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	w := requestWithToken(router, "POST", "/logout", login.Token, map[string]string{"refresh_token": login.RefreshToken})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = refreshTokens(router, login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_INVALID")
+	w = requestWithToken(router, "GET", "/me", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// リフレッシュトークンなしのログアウトも従来通り成功する
+	second := loginForTokens(t, router)
+	w = requestWithToken(router, "POST", "/logout", second.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestLogoutAll_RevokesEveryDevice 全デバイスからのログアウトで、他のデバイスのトークンも全て無効になることを検証
+func TestLogoutAll_RevokesEveryDevice(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	phone := loginForTokens(t, router)
+	laptop := loginForTokens(t, router)
+
+	w := requestWithToken(router, "POST", "/logout-all", phone.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// レスポンスは1つのJSONのみ
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "LOGOUT_ALL_SUCCESS", body["code"])
+
+	for _, session := range []models.LoginResponse{phone, laptop} {
+		w = requestWithToken(router, "GET", "/me", session.Token, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "TOKEN_REVOKED")
+
+		w = refreshTokens(router, session.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// 再ログイン後のトークンは有効
+	again := loginForTokens(t, router)
+	w = requestWithToken(router, "GET", "/me", again.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestChangePasswordHash_RevokesTokens パスワード変更で変更前に発行した全てのトークンが無効になることを検証
+func TestChangePasswordHash_RevokesTokens(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("newpassword456"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, services.NewTokenVersionService(db).ChangePasswordHash(login.User.ID, string(hashedPassword)))
+
+	w := requestWithToken(router, "GET", "/me", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TOKEN_REVOKED")
+
+	w = refreshTokens(router, login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "REFRESH_TOKEN_INVALID")
+
+	var stored models.RefreshToken
+	require.NoError(t, db.First(&stored).Error)
+	assert.Equal(t, services.RefreshRevokedPasswordChanged, stored.RevokedReason)
+}
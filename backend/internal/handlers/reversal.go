@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+const maxReversalReasonRunes = 500 // 取り消し理由の最大文字数
+
+// RequestPaymentReversalHandler 支払い取り消し申請ハンドラー
+// 請求者または支払者が、支払済みの家計簿を請求済みに戻す申請を行う（相手の確認後に実行される）
+func RequestPaymentReversalHandler(c *gin.Context) {
+	RequestPaymentReversalHandlerWithDB(database.GetDB())(c)
+}
+
+// RequestPaymentReversalHandlerWithDB DB接続を注入可能な支払い取り消し申請ハンドラー
+func RequestPaymentReversalHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		billID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			Reason string `json:"reason" binding:"required"` // 取り消し理由（必須）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" || utf8.RuneCountInString(reason) > maxReversalReasonRunes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "取り消し理由は1文字以上500文字以下で入力してください"})
+			return
+		}
+
+		// 請求者・支払者のどちらでも申請可能
+		bill, ok := findParticipantBill(c, db, uint(billID), userID)
+		if !ok {
+			return
+		}
+
+		// paid状態（支払済み）の家計簿のみ取り消し可能
+		if bill.Status != "paid" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "支払済みの家計簿のみ取り消しできます"})
+			return
+		}
+
+		// 確認待ちの申請は1件まで
+		var pending int64
+		db.Model(&models.PaymentReversal{}).
+			Where("bill_id = ? AND status = ?", bill.ID, models.ReversalStatusPending).
+			Count(&pending)
+		if pending > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "確認待ちの取り消し申請が既に存在します"})
+			return
+		}
+
+		reversal := models.PaymentReversal{
+			BillID:              bill.ID,
+			InitiatorID:         userID,
+			Reason:              reason,
+			Status:              models.ReversalStatusPending,
+			OriginalPaymentDate: bill.PaymentDate,
+		}
+		if err := db.Create(&reversal).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "取り消し申請の作成に失敗しました"})
+			return
+		}
+
+		log.Printf("↩️ Payment reversal requested: bill=%d initiator=%d reversal=%d", bill.ID, userID, reversal.ID)
+
+		c.JSON(http.StatusCreated, reversal)
+	}
+}
+
+// ConfirmPaymentReversalHandler 支払い取り消し確認ハンドラー
+// 申請者の相手が確認し、家計簿をpaid（支払済み）からrequested（請求済み）に戻す
+func ConfirmPaymentReversalHandler(c *gin.Context) {
+	ConfirmPaymentReversalHandlerWithDB(database.GetDB())(c)
+}
+
+// ConfirmPaymentReversalHandlerWithDB DB接続を注入可能な支払い取り消し確認ハンドラー
+func ConfirmPaymentReversalHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return resolvePaymentReversal(db, models.ReversalStatusConfirmed)
+}
+
+// RejectPaymentReversalHandler 支払い取り消し拒否ハンドラー
+// 申請者の相手が取り消し申請を拒否する
+func RejectPaymentReversalHandler(c *gin.Context) {
+	RejectPaymentReversalHandlerWithDB(database.GetDB())(c)
+}
+
+// RejectPaymentReversalHandlerWithDB DB接続を注入可能な支払い取り消し拒否ハンドラー
+func RejectPaymentReversalHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return resolvePaymentReversal(db, models.ReversalStatusRejected)
+}
+
+// CancelPaymentReversalHandler 支払い取り消し撤回ハンドラー
+// 申請者本人が確認待ちの取り消し申請を撤回する
+func CancelPaymentReversalHandler(c *gin.Context) {
+	CancelPaymentReversalHandlerWithDB(database.GetDB())(c)
+}
+
+// CancelPaymentReversalHandlerWithDB DB接続を注入可能な支払い取り消し撤回ハンドラー
+func CancelPaymentReversalHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return resolvePaymentReversal(db, models.ReversalStatusCancelled)
+}
+
+// resolvePaymentReversal 確認待ちの取り消し申請を確認・拒否・撤回する共通ハンドラー
+// 確認・拒否は申請者の相手のみ、撤回は申請者本人のみ可能
+func resolvePaymentReversal(db *gorm.DB, status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		billID, _ := strconv.Atoi(c.Param("id"))
+		userID := c.GetUint("user_id")
+
+		bill, ok := findParticipantBill(c, db, uint(billID), userID)
+		if !ok {
+			return
+		}
+
+		var reversal models.PaymentReversal
+		if err := db.Where("bill_id = ? AND status = ?", bill.ID, models.ReversalStatusPending).First(&reversal).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "確認待ちの取り消し申請が見つかりません"})
+			return
+		}
+
+		isInitiator := reversal.InitiatorID == userID
+		if status == models.ReversalStatusCancelled && !isInitiator {
+			c.JSON(http.StatusForbidden, gin.H{"error": "取り消し申請の撤回は申請者のみ可能です"})
+			return
+		}
+		if status != models.ReversalStatusCancelled && isInitiator {
+			c.JSON(http.StatusForbidden, gin.H{"error": "取り消し申請の確認は相手のユーザーのみ可能です"})
+			return
+		}
+
+		// 申請後に状態が変わっている場合は取り消しを実行しない
+		if status == models.ReversalStatusConfirmed && bill.Status != "paid" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "家計簿が支払済み状態ではありません"})
+			return
+		}
+
+		updates := map[string]interface{}{"status": status, "resolved_at": time.Now()}
+		if status != models.ReversalStatusCancelled {
+			updates["confirmer_id"] = userID
+		}
+
+		// 確認から更新までの間に他のリクエストで状態が変わった場合に備え、状態を条件にして更新する
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.PaymentReversal{}).
+				Where("id = ? AND status = ?", reversal.ID, models.ReversalStatusPending).
+				Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errReversalAlreadyResolved
+			}
+			if status != models.ReversalStatusConfirmed {
+				return nil
+			}
+			// 請求済みに戻す（取り消し前の支払日時は申請履歴に保持）
+			result = tx.Model(&models.MonthlyBill{}).
+				Where("id = ? AND status = ?", bill.ID, "paid").
+				Updates(map[string]interface{}{"status": "requested", "payment_date": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errReversalBillNotPaid
+			}
+			return nil
+		})
+		if err != nil {
+			respondBillError(c, err, "取り消し申請の更新に失敗しました")
+			return
+		}
+
+		log.Printf("↩️ Payment reversal %s: bill=%d user=%d reversal=%d", status, bill.ID, userID, reversal.ID)
+
+		switch status {
+		case models.ReversalStatusConfirmed:
+			c.JSON(http.StatusOK, gin.H{"message": "支払いを取り消しました"})
+		case models.ReversalStatusRejected:
+			c.JSON(http.StatusOK, gin.H{"message": "取り消し申請を拒否しました"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"message": "取り消し申請を撤回しました"})
+		}
+	}
+}
+
+// 支払い取り消しの更新に関するエラー
+var (
+	errReversalAlreadyResolved = &services.DomainError{Kind: services.ErrConflict, Message: "取り消し申請は既に処理されています"}
+	errReversalBillNotPaid     = &services.DomainError{Kind: services.ErrConflict, Message: "家計簿が支払済み状態ではありません"}
+)
+
+// findParticipantBill 請求者または支払者として参加している家計簿を取得する
+// 見つからない場合は404レスポンスを返してfalseを返す
+func findParticipantBill(c *gin.Context, db *gorm.DB, billID, userID uint) (models.MonthlyBill, bool) {
+	var bill models.MonthlyBill
+	if err := db.Where("id = ? AND (requester_id = ? OR payer_id = ?)", billID, userID, userID).First(&bill).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "家計簿が見つかりません"})
+		return bill, false
+	}
+	return bill, true
+}
+
+// loadPaymentReversals 家計簿の支払い取り消し履歴を新しい順に取得する
+func loadPaymentReversals(db *gorm.DB, billID uint) ([]models.PaymentReversal, error) {
+	reversals := []models.PaymentReversal{}
+	err := db.Preload("Initiator").
+		Where("bill_id = ?", billID).
+		Order("created_at DESC, id DESC").
+		Find(&reversals).Error
+	return reversals, err
+}
@@ -0,0 +1,206 @@
+// ========================================
+// 支払い取り消しハンドラーの自動テスト
+// SQLite in-memoryを使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupReversalScenario 支払済みの家計簿と、請求者・支払者それぞれのルーターを作成する
+func setupReversalScenario(t *testing.T, db *gorm.DB) (models.MonthlyBill, *gin.Engine, *gin.Engine) {
+	t.Helper()
+
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("reversal_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("reversal_payer").MustBuild()
+
+	paidAt := time.Date(2024, 9, 25, 10, 0, 0, 0, time.Local)
+	bill, _ := factory.NewBill().
+		WithRequester(requester.ID).
+		WithPayer(payer.ID).
+		WithYearMonth(2024, 9).
+		WithStatus("paid").
+		WithRequestDate(paidAt.Add(-24 * time.Hour)).
+		WithPaymentDate(paidAt).
+		AddItems(testfactory.Item("家賃", 80000)).
+		MustBuild()
+
+	newRouter := func(userID uint) *gin.Engine {
+		router := setupRouter()
+		router.Use(setUserID(userID))
+		router.GET("/bills/:year/:month", GetBillHandlerWithDB(db))
+		router.POST("/bills/:id/reversal", RequestPaymentReversalHandlerWithDB(db))
+		router.PUT("/bills/:id/reversal/confirm", ConfirmPaymentReversalHandlerWithDB(db))
+		router.PUT("/bills/:id/reversal/reject", RejectPaymentReversalHandlerWithDB(db))
+		router.DELETE("/bills/:id/reversal", CancelPaymentReversalHandlerWithDB(db))
+		router.PUT("/bills/:id/payment", PaymentBillHandlerWithDB(db))
+		return router
+	}
+
+	return bill, newRouter(requester.ID), newRouter(payer.ID)
+}
+
+// TestPaymentReversal_ConfirmFlow 相手の確認で支払済みから請求済みに戻り、元の支払日時が履歴に残ることを検証
+func TestPaymentReversal_ConfirmFlow(t *testing.T) {
+	db := setupInMemoryDB(t)
+	bill, requesterRouter, payerRouter := setupReversalScenario(t, db)
+	base := fmt.Sprintf("/bills/%d/reversal", bill.ID)
+
+	// 支払者が誤って支払済みにしたため取り消しを申請
+	w := performJSONRequest(payerRouter, "POST", base, map[string]interface{}{"reason": "振込が組戻しされたため"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// 申請者本人は確認できない
+	w = performJSONRequest(payerRouter, "PUT", base+"/confirm", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 重複申請は拒否
+	w = performJSONRequest(requesterRouter, "POST", base, map[string]interface{}{"reason": "重複"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 請求者が確認
+	w = performJSONRequest(requesterRouter, "PUT", base+"/confirm", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.MonthlyBill
+	require.NoError(t, db.First(&updated, bill.ID).Error)
+	assert.Equal(t, "requested", updated.Status)
+	assert.Nil(t, updated.PaymentDate)
+	assert.NotNil(t, updated.RequestDate)
+
+	// 家計簿詳細に履歴が含まれる
+	w = performJSONRequest(requesterRouter, "GET", "/bills/2024/9", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Reversals, 1)
+
+	reversal := response.Reversals[0]
+	assert.Equal(t, models.ReversalStatusConfirmed, reversal.Status)
+	assert.Equal(t, "振込が組戻しされたため", reversal.Reason)
+	assert.Equal(t, bill.PayerID, reversal.InitiatorID)
+	require.NotNil(t, reversal.ConfirmerID)
+	assert.Equal(t, bill.RequesterID, *reversal.ConfirmerID)
+	require.NotNil(t, reversal.OriginalPaymentDate)
+	assert.True(t, bill.PaymentDate.Equal(*reversal.OriginalPaymentDate))
+	assert.NotNil(t, reversal.ResolvedAt)
+
+	// 再度支払うと新しい支払日時が設定され、履歴の支払日時は変わらない
+	w = performJSONRequest(payerRouter, "PUT", fmt.Sprintf("/bills/%d/payment", bill.ID), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	reversals, err := loadPaymentReversals(db, bill.ID)
+	require.NoError(t, err)
+	assert.True(t, bill.PaymentDate.Equal(*reversals[0].OriginalPaymentDate))
+}
+
+// TestPaymentReversal_RejectAndCancel 拒否・撤回では家計簿の状態が変わらないことを検証
+func TestPaymentReversal_RejectAndCancel(t *testing.T) {
+	db := setupInMemoryDB(t)
+	bill, requesterRouter, payerRouter := setupReversalScenario(t, db)
+	base := fmt.Sprintf("/bills/%d/reversal", bill.ID)
+
+	// 請求者が申請し、支払者が拒否
+	w := performJSONRequest(requesterRouter, "POST", base, map[string]interface{}{"reason": "金額の誤り"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = performJSONRequest(payerRouter, "PUT", base+"/reject", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 再申請し、相手は撤回できないが申請者は撤回できる
+	w = performJSONRequest(requesterRouter, "POST", base, map[string]interface{}{"reason": "再申請"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = performJSONRequest(payerRouter, "DELETE", base, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performJSONRequest(requesterRouter, "DELETE", base, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.MonthlyBill
+	require.NoError(t, db.First(&updated, bill.ID).Error)
+	assert.Equal(t, "paid", updated.Status)
+	assert.NotNil(t, updated.PaymentDate)
+
+	reversals, err := loadPaymentReversals(db, bill.ID)
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+	assert.Equal(t, models.ReversalStatusCancelled, reversals[0].Status)
+	assert.Nil(t, reversals[0].ConfirmerID)
+	assert.Equal(t, models.ReversalStatusRejected, reversals[1].Status)
+}
+
+// TestPaymentReversal_Validation 支払済み以外の家計簿や理由なしの申請が拒否されることを検証
+func TestPaymentReversal_Validation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	bill, requesterRouter, _ := setupReversalScenario(t, db)
+	base := fmt.Sprintf("/bills/%d/reversal", bill.ID)
+
+	w := performJSONRequest(requesterRouter, "POST", base, map[string]interface{}{"reason": "   "})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	db.Model(&models.MonthlyBill{}).Where("id = ?", bill.ID).Update("status", "requested")
+	w = performJSONRequest(requesterRouter, "POST", base, map[string]interface{}{"reason": "未払い"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 関係のないユーザーには家計簿が見えない
+	outsider := testfactory.NewTestDataFactory(db).NewUser().WithAccountID("reversal_outsider").MustBuild()
+	router := setupRouter()
+	router.POST("/bills/:id/reversal", setUserID(outsider.ID), RequestPaymentReversalHandlerWithDB(db))
+	w = performJSONRequest(router, "POST", base, map[string]interface{}{"reason": "不正"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestPaymentReversal_ConfirmRaceWithConcurrentUpdate 状態の確認後に他のリクエストで状態が変わった場合、取り消しを実行しないことを検証
+func TestPaymentReversal_ConfirmRaceWithConcurrentUpdate(t *testing.T) {
+	db := setupInMemoryDB(t)
+	bill, requesterRouter, payerRouter := setupReversalScenario(t, db)
+	base := fmt.Sprintf("/bills/%d/reversal", bill.ID)
+
+	w := performJSONRequest(payerRouter, "POST", base, map[string]interface{}{"reason": "二重に支払済みにしたため"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// 取り消し申請の読み込み直後に、他のリクエストによる更新を割り込ませる
+	var concurrentUpdate string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:concurrent_update", func(tx *gorm.DB) {
+		if tx.Statement.Table != "payment_reversals" || concurrentUpdate == "" {
+			return
+		}
+		sql := concurrentUpdate
+		concurrentUpdate = ""
+		require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).Exec(sql, bill.ID).Error)
+	}))
+
+	// 家計簿の状態が変わった場合は、申請も確認待ちのまま残る
+	concurrentUpdate = "UPDATE monthly_bills SET status = 'requested' WHERE id = ?"
+	w = performJSONRequest(requesterRouter, "PUT", base+"/confirm", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	reversals, err := loadPaymentReversals(db, bill.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReversalStatusPending, reversals[0].Status)
+	assert.Nil(t, reversals[0].ResolvedAt)
+
+	// 申請が撤回された場合は、家計簿を請求済みに戻さない
+	require.NoError(t, db.Model(&models.MonthlyBill{}).Where("id = ?", bill.ID).Update("status", "paid").Error)
+	concurrentUpdate = "UPDATE payment_reversals SET status = 'cancelled' WHERE bill_id = ?"
+	w = performJSONRequest(requesterRouter, "PUT", base+"/confirm", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var updated models.MonthlyBill
+	require.NoError(t, db.First(&updated, bill.ID).Error)
+	assert.Equal(t, "paid", updated.Status)
+	assert.NotNil(t, updated.PaymentDate)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+)
+
+const (
+	defaultSearchLimit  = 50  // 検索結果のデフォルト件数
+	maxSearchLimit      = 100 // 検索結果の最大件数
+	maxSearchQueryRunes = 100 // 検索語の最大文字数
+	ngramTokenSize      = 2   // MySQL ngramパーサーのトークンサイズ（ngram_token_sizeのデフォルト値）
+)
+
+// billSearcher 家計簿の検索方式の抽象化
+// MySQLではFULLTEXT（ngram）検索、それ以外ではLIKE検索を使用する
+type billSearcher interface {
+	SearchItems(userID uint, query string, limit int) ([]models.SearchResult, error)
+	SearchComments(userID uint, query string, limit int) ([]models.SearchResult, error)
+}
+
+// newBillSearcher DBと検索語に応じた検索方式を選択する
+// ngramのトークンサイズ未満の検索語はFULLTEXTでは一致しないためLIKE検索を使用する
+func newBillSearcher(db *gorm.DB, query string) billSearcher {
+	if database.SupportsFullTextSearch(db) && utf8.RuneCountInString(query) >= ngramTokenSize {
+		return &fullTextBillSearcher{db: db}
+	}
+	return &likeBillSearcher{db: db}
+}
+
+// SearchHandler 家計簿検索ハンドラー
+// ユーザーが閲覧可能な全家計簿から項目名・コメントを検索する
+func SearchHandler(c *gin.Context) {
+	SearchHandlerWithDB(database.GetDB())(c)
+}
+
+// SearchHandlerWithDB DB接続を注入可能な家計簿検索ハンドラー
+func SearchHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// 検索語を取得（前後の空白は除去）
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "検索キーワードを指定してください"})
+			return
+		}
+		if utf8.RuneCountInString(query) > maxSearchQueryRunes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "検索キーワードは100文字以内で指定してください"})
+			return
+		}
+
+		// 取得件数を決定
+		limit := defaultSearchLimit
+		if v := c.Query("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "取得件数の指定が無効です"})
+				return
+			}
+			if parsed > maxSearchLimit {
+				parsed = maxSearchLimit
+			}
+			limit = parsed
+		}
+
+		searcher := newBillSearcher(db, query)
+
+		items, err := searcher.SearchItems(userID, query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "検索に失敗しました"})
+			return
+		}
+		comments, err := searcher.SearchComments(userID, query, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "検索に失敗しました"})
+			return
+		}
+
+		// 新しい年月順に並べ替えて件数を制限
+		results := append(items, comments...)
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Year != results[j].Year {
+				return results[i].Year > results[j].Year
+			}
+			return results[i].Month > results[j].Month
+		})
+		if len(results) > limit {
+			results = results[:limit]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"query":   query,
+			"results": results,
+		})
+	}
+}
+
+// itemSearchQuery 閲覧可能な家計簿の項目検索の共通クエリ
+func itemSearchQuery(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("bill_items").
+		Select(`'item' AS match_type, monthly_bills.id AS bill_id, monthly_bills.year, monthly_bills.month,
+			monthly_bills.status, bill_items.id AS item_id, bill_items.item_name, bill_items.amount`).
+		Joins("JOIN monthly_bills ON monthly_bills.id = bill_items.bill_id").
+		Where("monthly_bills.requester_id = ? OR monthly_bills.payer_id = ?", userID, userID).
+		Order("monthly_bills.year DESC, monthly_bills.month DESC, bill_items.id ASC")
+}
+
+// commentSearchQuery 閲覧可能な家計簿のコメント検索の共通クエリ
+func commentSearchQuery(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("monthly_bills").
+		Select(`'comment' AS match_type, monthly_bills.id AS bill_id, monthly_bills.year, monthly_bills.month,
+			monthly_bills.status, monthly_bills.comment`).
+		Where("monthly_bills.requester_id = ? OR monthly_bills.payer_id = ?", userID, userID).
+		Order("monthly_bills.year DESC, monthly_bills.month DESC")
+}
+
+// fullTextBillSearcher MySQL FULLTEXT（ngramパーサー）による検索
+type fullTextBillSearcher struct {
+	db *gorm.DB
+}
+
+// SearchItems 項目名をFULLTEXT検索する
+func (s *fullTextBillSearcher) SearchItems(userID uint, query string, limit int) ([]models.SearchResult, error) {
+	var results []models.SearchResult
+	err := itemSearchQuery(s.db, userID).
+		Where("MATCH(bill_items.item_name) AGAINST(? IN BOOLEAN MODE)", toBooleanPhrase(query)).
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// SearchComments 家計簿コメントをFULLTEXT検索する
+func (s *fullTextBillSearcher) SearchComments(userID uint, query string, limit int) ([]models.SearchResult, error) {
+	var results []models.SearchResult
+	err := commentSearchQuery(s.db, userID).
+		Where("MATCH(monthly_bills.comment) AGAINST(? IN BOOLEAN MODE)", toBooleanPhrase(query)).
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// toBooleanPhrase 検索語をBOOLEAN MODEのフレーズ検索に変換する
+// 演算子として解釈されないよう二重引用符で囲み、内部の二重引用符は除去する
+func toBooleanPhrase(query string) string {
+	return `"` + strings.ReplaceAll(query, `"`, "") + `"`
+}
+
+// likeBillSearcher LIKEによる部分一致検索（SQLite・短い検索語用のフォールバック）
+type likeBillSearcher struct {
+	db *gorm.DB
+}
+
+// SearchItems 項目名を部分一致検索する
+func (s *likeBillSearcher) SearchItems(userID uint, query string, limit int) ([]models.SearchResult, error) {
+	var results []models.SearchResult
+	err := itemSearchQuery(s.db, userID).
+		Where("bill_items.item_name LIKE ? ESCAPE '!'", toLikePattern(query)).
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// SearchComments 家計簿コメントを部分一致検索する
+func (s *likeBillSearcher) SearchComments(userID uint, query string, limit int) ([]models.SearchResult, error) {
+	var results []models.SearchResult
+	err := commentSearchQuery(s.db, userID).
+		Where("monthly_bills.comment LIKE ? ESCAPE '!'", toLikePattern(query)).
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// toLikePattern 検索語をLIKEの部分一致パターンに変換する（ワイルドカード文字はエスケープ）
+func toLikePattern(query string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + replacer.Replace(query) + "%"
+}
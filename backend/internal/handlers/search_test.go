@@ -0,0 +1,130 @@
+// ========================================
+// 検索ハンドラーの自動テスト
+// SQLite in-memory（LIKEフォールバック）を使用した高速テスト
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// searchResponse 検索レスポンスのテスト用構造体
+type searchResponse struct {
+	Query   string                `json:"query"`
+	Results []models.SearchResult `json:"results"`
+}
+
+// setupSearchScenario 検索テスト用に複数年月の家計簿を作成する
+func setupSearchScenario(t *testing.T, db *gorm.DB) (models.User, models.User, models.User) {
+	t.Helper()
+
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("search_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("search_payer").MustBuild()
+	outsider := factory.NewUser().WithAccountID("search_outsider").MustBuild()
+
+	factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 1).
+		AddItems(testfactory.Item("ガス代", 4500), testfactory.Item("食費", 30000)).MustBuild()
+	factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 3).
+		AddItems(testfactory.Item("ガス代", 3800), testfactory.Item("電気代 100%還元", 5000)).MustBuild()
+
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 2).MustBuild()
+	db.Model(&bill).Update("comment", "ガス会社の請求が遅れたため翌月計上")
+
+	// 関係のないユーザー同士の家計簿
+	other := factory.NewUser().WithAccountID("search_other").MustBuild()
+	factory.NewBill().WithRequester(outsider.ID).WithPayer(other.ID).WithYearMonth(2024, 4).
+		AddItems(testfactory.Item("ガス代", 9999)).MustBuild()
+
+	return requester, payer, outsider
+}
+
+// performSearch 検索リクエストを実行する
+func performSearch(t *testing.T, db *gorm.DB, userID uint, query string) (int, searchResponse) {
+	t.Helper()
+
+	router := setupRouter()
+	router.GET("/search", setUserID(userID), SearchHandlerWithDB(db))
+
+	w := performJSONRequest(router, "GET", "/search?q="+url.QueryEscape(query), nil)
+
+	var response searchResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w.Code, response
+}
+
+// TestSearchHandler_ItemsAndComments 項目名とコメントが新しい年月順に検索されることを検証
+func TestSearchHandler_ItemsAndComments(t *testing.T) {
+	db := setupInMemoryDB(t)
+	_, payer, _ := setupSearchScenario(t, db)
+
+	code, response := performSearch(t, db, payer.ID, "ガス")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Results, 3)
+
+	// 最新の支払いが先頭
+	assert.Equal(t, "item", response.Results[0].MatchType)
+	assert.Equal(t, 3, response.Results[0].Month)
+	assert.Equal(t, "ガス代", response.Results[0].ItemName)
+	assert.Equal(t, 3800.0, response.Results[0].Amount)
+
+	assert.Equal(t, "comment", response.Results[1].MatchType)
+	assert.Equal(t, 2, response.Results[1].Month)
+	assert.Contains(t, response.Results[1].Comment, "ガス会社")
+
+	assert.Equal(t, 1, response.Results[2].Month)
+	assert.Equal(t, 4500.0, response.Results[2].Amount)
+}
+
+// TestSearchHandler_AccessScope 閲覧権限のない家計簿は検索対象外であることを検証
+func TestSearchHandler_AccessScope(t *testing.T) {
+	db := setupInMemoryDB(t)
+	_, _, outsider := setupSearchScenario(t, db)
+
+	code, response := performSearch(t, db, outsider.ID, "ガス代")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, 9999.0, response.Results[0].Amount)
+}
+
+// TestSearchHandler_WildcardEscaping LIKEのワイルドカード文字が文字どおり検索されることを検証
+func TestSearchHandler_WildcardEscaping(t *testing.T) {
+	db := setupInMemoryDB(t)
+	requester, _, _ := setupSearchScenario(t, db)
+
+	code, response := performSearch(t, db, requester.ID, "100%")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "電気代 100%還元", response.Results[0].ItemName)
+
+	code, response = performSearch(t, db, requester.ID, "%")
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response.Results, 1)
+}
+
+// TestSearchHandler_EmptyQuery 検索語が空の場合は400を返すことを検証
+func TestSearchHandler_EmptyQuery(t *testing.T) {
+	db := setupInMemoryDB(t)
+
+	code, _ := performSearch(t, db, 1, "   ")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestToBooleanPhrase BOOLEAN MODE用のフレーズ変換を検証
+func TestToBooleanPhrase(t *testing.T) {
+	assert.Equal(t, `"ガス代"`, toBooleanPhrase("ガス代"))
+	assert.Equal(t, `"ガス代 +電気"`, toBooleanPhrase(`"ガス代 +電気"`))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// sessionResponse ログインセッションのレスポンス
+type sessionResponse struct {
+	ID         string    `json:"id"`           // セッションID
+	UserAgent  string    `json:"user_agent"`   // ログインした端末のUser-Agent
+	IPAddress  string    `json:"ip_address"`   // 最後に確認したIPアドレス
+	CreatedAt  time.Time `json:"created_at"`   // ログイン日時
+	LastSeenAt time.Time `json:"last_seen_at"` // 最終利用日時
+	ExpiresAt  time.Time `json:"expires_at"`   // 有効期限
+	Current    bool      `json:"current"`      // リクエストしたトークンのセッションか
+}
+
+// newSessionResponse セッションのレスポンスを作成する（currentSessionIDはリクエストしたトークンのセッションID）
+func newSessionResponse(session models.UserSession, currentSessionID string) sessionResponse {
+	return sessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+// ListSessionsHandler ログインセッション一覧取得ハンドラー
+// ログインユーザーの有効なセッション（ログイン中の端末）を最終利用日時の新しい順に返す
+func ListSessionsHandler(c *gin.Context) {
+	ListSessionsHandlerWithDB(database.GetDB())(c)
+}
+
+// ListSessionsHandlerWithDB DB接続を注入可能なログインセッション一覧取得ハンドラー
+func ListSessionsHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		sessions, err := services.NewSessionService(db).ListActive(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "セッション一覧の取得に失敗しました"})
+			return
+		}
+
+		currentSessionID := c.GetString("session_id")
+		responses := make([]sessionResponse, 0, len(sessions))
+		for _, session := range sessions {
+			responses = append(responses, newSessionResponse(session, currentSessionID))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"sessions": responses})
+	}
+}
+
+// RevokeSessionHandler ログインセッション終了ハンドラー
+// 指定したセッションのアクセストークンとリフレッシュトークンを無効にする（現在のセッションの場合はログアウト）
+func RevokeSessionHandler(c *gin.Context) {
+	RevokeSessionHandlerWithDB(database.GetDB())(c)
+}
+
+// RevokeSessionHandlerWithDB DB接続を注入可能なログインセッション終了ハンドラー
+func RevokeSessionHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		err := services.NewSessionService(db).Revoke(userID, c.Param("id"), services.RefreshRevokedSessionRevoked)
+		if err != nil {
+			respondBillError(c, err, "セッションの終了に失敗しました")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "セッションを終了しました"})
+	}
+}
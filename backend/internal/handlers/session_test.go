@@ -0,0 +1,151 @@
+// ========================================
+// ログインセッション管理の自動テスト
+// 端末ごとのセッション一覧・終了と最終利用日時の記録を検証
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// listSessions セッション一覧を取得する
+func listSessions(t *testing.T, router *gin.Engine, token string) []sessionResponse {
+	t.Helper()
+	w := requestWithToken(router, "GET", "/sessions", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Sessions []sessionResponse `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Sessions
+}
+
+// TestSessions_ListAndRevoke ログイン中の端末の一覧と、他の端末のセッションの終了を検証
+func TestSessions_ListAndRevoke(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	laptop := loginWithUserAgent(t, router, "Mozilla/5.0 (Macintosh)")
+	phone := loginWithUserAgent(t, router, "Mozilla/5.0 (iPhone)")
+
+	sessions := listSessions(t, router, laptop.Token)
+	require.Len(t, sessions, 2)
+	var laptopSession, phoneSession sessionResponse
+	for _, session := range sessions {
+		if session.Current {
+			laptopSession = session
+		} else {
+			phoneSession = session
+		}
+	}
+	assert.Equal(t, "Mozilla/5.0 (Macintosh)", laptopSession.UserAgent)
+	assert.Equal(t, "Mozilla/5.0 (iPhone)", phoneSession.UserAgent)
+	assert.NotEmpty(t, phoneSession.IPAddress)
+
+	// トークンステータスでセッションを確認できる
+	w := requestWithToken(router, "GET", "/token-status", laptop.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"session_id":"`+laptopSession.ID+`"`)
+
+	// 他の端末のセッションを終了
+	w = requestWithToken(router, "DELETE", "/sessions/"+phoneSession.ID, laptop.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = requestWithToken(router, "GET", "/me", phone.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = refreshTokens(router, phone.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	sessions = listSessions(t, router, laptop.Token)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptopSession.ID, sessions[0].ID)
+
+	// 終了済み・存在しないセッション
+	w = requestWithToken(router, "DELETE", "/sessions/"+phoneSession.ID, laptop.Token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// ローテーション後も同じセッション
+	w = refreshTokens(router, laptop.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	var rotated models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	sessions = listSessions(t, router, rotated.Token)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptopSession.ID, sessions[0].ID)
+	assert.True(t, sessions[0].Current)
+}
+
+// TestSessions_OtherUserCannotRevoke 他のユーザーのセッションは終了できないことを検証
+func TestSessions_OtherUserCannotRevoke(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+	victim := loginForTokens(t, router)
+
+	var session models.UserSession
+	require.NoError(t, db.First(&session).Error)
+
+	attackerToken, _, err := middleware.GenerateAccessToken(999, 0, "")
+	require.NoError(t, err)
+	middleware.SetTokenVersionLookup(nil)
+	w := requestWithToken(router, "DELETE", "/sessions/"+session.ID, attackerToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	middleware.SetTokenVersionLookup(services.NewTokenVersionService(db).Current)
+	w = requestWithToken(router, "GET", "/me", victim.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestSessions_LogoutEndsSession リフレッシュトークンを指定しないログアウトでもセッションが終了することを検証
+func TestSessions_LogoutEndsSession(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	w := requestWithToken(router, "POST", "/logout", login.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = refreshTokens(router, login.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var session models.UserSession
+	require.NoError(t, db.First(&session).Error)
+	require.NotNil(t, session.RevokedAt)
+	assert.Equal(t, services.RefreshRevokedLogout, session.RevokedReason)
+}
+
+// TestSessions_LastSeenFlushedInBatches 認証済みリクエストの最終利用日時がまとめて保存されることを検証
+func TestSessions_LastSeenFlushedInBatches(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRefreshTokenRouter(t, db)
+
+	login := loginForTokens(t, router)
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Model(&models.UserSession{}).Where("1 = 1").UpdateColumn("last_seen_at", past).Error)
+
+	stop := middleware.StartSessionActivityFlusher(services.NewSessionService(db).TouchSessions, time.Hour)
+	for i := 0; i < 3; i++ {
+		w := requestWithToken(router, "GET", "/me", login.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// 保存は一定間隔ごと（ここでは停止時）のみ
+	var session models.UserSession
+	require.NoError(t, db.First(&session).Error)
+	assert.WithinDuration(t, past, session.LastSeenAt, time.Second)
+
+	stop()
+	require.NoError(t, db.First(&session).Error)
+	assert.WithinDuration(t, time.Now(), session.LastSeenAt, 5*time.Second)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"math"
+	"sort"
+
+	"money_management/internal/models"
+)
+
+// calculateBillSplit 家計簿項目の負担ルールから支払者の負担額と項目ごとの内訳を計算する
+// 税率ごとに端数処理した税込合計を各項目の税込金額に按分し（合計が税込合計と一致するよう最大剰余法で調整）、
+// 項目ごとに支払者の負担割合を掛ける（銭未満は四捨五入）
+// 全ての項目が支払者のみの負担の場合、負担額は税込合計と一致する
+func calculateBillSplit(items []models.BillItem, grandTotal float64) (float64, []models.ItemSplit) {
+	split := make([]models.ItemSplit, len(items))
+
+	// 按分の重み: 税込金額（税抜の項目は税率を加算した金額）
+	weights := make([]float64, len(items))
+	var weightSum float64
+	for i, item := range items {
+		rate := item.TaxRate
+		if rate == 0 {
+			rate = models.TaxRateStandard
+		}
+		weights[i] = float64(toSen(item.Amount)) * 100
+		if item.TaxExclusive {
+			weights[i] = float64(toSen(item.Amount)) * float64(100+rate)
+		}
+		weightSum += weights[i]
+	}
+
+	grossSen := allocateSen(toSen(grandTotal), weights, weightSum)
+
+	var owedSen int64
+	for i, item := range items {
+		rule := item.SplitRule
+		if rule == "" {
+			rule = models.SplitRulePayer
+		}
+		share := item.PayerSharePercent()
+		owed := (grossSen[i]*int64(share) + 50) / 100
+
+		split[i] = models.ItemSplit{
+			ItemID:     item.ID,
+			ItemName:   item.ItemName,
+			SplitRule:  rule,
+			PayerShare: share,
+			Amount:     fromSen(grossSen[i]),
+			OwedAmount: fromSen(owed),
+		}
+		owedSen += owed
+	}
+
+	return fromSen(owedSen), split
+}
+
+// allocateSen 銭単位の合計額を重みに比例して按分する
+// 端数は小数部分の大きい順に1銭ずつ配分し、按分後の合計を元の合計額と一致させる
+func allocateSen(totalSen int64, weights []float64, weightSum float64) []int64 {
+	allocated := make([]int64, len(weights))
+	if weightSum <= 0 || totalSen <= 0 {
+		return allocated
+	}
+
+	remainders := make([]float64, len(weights))
+	var assigned int64
+	for i, weight := range weights {
+		exact := float64(totalSen) * weight / weightSum
+		allocated[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(allocated[i])
+		assigned += allocated[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for k := 0; assigned < totalSen; k++ {
+		allocated[order[k%len(order)]]++
+		assigned++
+	}
+
+	return allocated
+}
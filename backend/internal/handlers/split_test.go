@@ -0,0 +1,117 @@
+// ========================================
+// 項目ごとの負担ルールの自動テスト
+// 支払者の負担額の計算と項目更新APIの内訳を検証
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// TestCalculateBillSplit_Rules 支払者のみ・折半・任意の割合が混在する場合の負担額を検証
+func TestCalculateBillSplit_Rules(t *testing.T) {
+	items := []models.BillItem{
+		{ItemName: "家賃", Amount: 80000},
+		{ItemName: "食費", Amount: 30001, SplitRule: models.SplitRuleShared},
+		{ItemName: "光熱費", Amount: 12000, SplitRule: models.SplitRuleCustom, PayerShare: 25},
+		{ItemName: "立替分", Amount: 5000, SplitRule: models.SplitRuleCustom, PayerShare: 0},
+	}
+	_, _, grandTotal := calculateBillTax(items, models.TaxRoundingFloor)
+
+	owed, split := calculateBillSplit(items, grandTotal)
+
+	require.Len(t, split, 4)
+	assert.Equal(t, models.ItemSplit{ItemName: "家賃", SplitRule: models.SplitRulePayer, PayerShare: 100, Amount: 80000, OwedAmount: 80000}, split[0])
+	assert.Equal(t, 50, split[1].PayerShare)
+	assert.Equal(t, 15000.5, split[1].OwedAmount)
+	assert.Equal(t, 3000.0, split[2].OwedAmount)
+	assert.Equal(t, 0.0, split[3].OwedAmount)
+	assert.Equal(t, 98000.5, owed)
+	assert.Equal(t, 127001.0, grandTotal)
+}
+
+// TestCalculateBillSplit_AllPayerMatchesGrandTotal 全て支払者のみの場合は税抜項目を含めても負担額が税込合計と一致することを検証
+func TestCalculateBillSplit_AllPayerMatchesGrandTotal(t *testing.T) {
+	items := []models.BillItem{
+		{ItemName: "パン", Amount: 105, TaxRate: models.TaxRateReduced, TaxExclusive: true},
+		{ItemName: "牛乳", Amount: 105, TaxRate: models.TaxRateReduced, TaxExclusive: true},
+		{ItemName: "卵", Amount: 105, TaxRate: models.TaxRateReduced, TaxExclusive: true},
+		{ItemName: "日用品", Amount: 1100},
+	}
+
+	for _, rounding := range []string{models.TaxRoundingFloor, models.TaxRoundingRound, models.TaxRoundingCeil} {
+		_, _, grandTotal := calculateBillTax(items, rounding)
+		owed, split := calculateBillSplit(items, grandTotal)
+		assert.Equal(t, grandTotal, owed, rounding)
+
+		// 按分後の項目の税込金額の合計も税込合計と一致する
+		var sum float64
+		for _, s := range split {
+			sum += s.Amount
+		}
+		assert.InDelta(t, grandTotal, sum, 0.001, rounding)
+	}
+
+	owed, split := calculateBillSplit(nil, 0)
+	assert.Equal(t, 0.0, owed)
+	assert.Empty(t, split)
+}
+
+// TestUpdateItemsHandler_SplitRules 項目更新で負担ルールが保存され、請求金額に反映されることを検証
+func TestUpdateItemsHandler_SplitRules(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("split_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("split_payer").MustBuild()
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 8).MustBuild()
+
+	router := setupRouter()
+	router.PUT("/bills/:id/items", setUserID(requester.ID), UpdateItemsHandlerWithDB(db))
+	router.GET("/bills/:year/:month", setUserID(payer.ID), GetBillHandlerWithDB(db))
+	path := fmt.Sprintf("/bills/%d/items", bill.ID)
+
+	w := performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"item_name": "家賃", "amount": 80000},
+			{"item_name": "食費", "amount": 30000, "split_rule": "shared"},
+			{"item_name": "光熱費", "amount": 12000, "split_rule": "custom", "payer_share": 25},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 122000.0, response.GrandTotal)
+	assert.Equal(t, 98000.0, response.TotalAmount)
+	require.Len(t, response.Split, 3)
+	assert.Equal(t, models.SplitRuleShared, response.Items[1].SplitRule)
+	assert.Equal(t, 25, response.Items[2].PayerShare)
+
+	// 支払者から見た請求金額も同じ
+	w = performJSONRequest(router, "GET", "/bills/2024/8", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var fetched models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fetched))
+	assert.Equal(t, 98000.0, fetched.TotalAmount)
+
+	// 不正な負担ルール・割合は拒否
+	invalid := []map[string]interface{}{
+		{"item_name": "食費", "amount": 100, "split_rule": "requester"},
+		{"item_name": "食費", "amount": 100, "split_rule": "custom"},
+		{"item_name": "食費", "amount": 100, "split_rule": "custom", "payer_share": 101},
+	}
+	for _, item := range invalid {
+		w = performJSONRequest(router, "PUT", path, map[string]interface{}{"items": []map[string]interface{}{item}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "%v", item)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"sort"
+
+	"money_management/internal/models"
+)
+
+// taxAccumulator 税率ごとの金額集計（誤差を避けるため銭単位の整数で保持）
+type taxAccumulator struct {
+	exclusiveSen int64 // 税抜金額の合計
+	inclusiveSen int64 // 税込金額の合計
+}
+
+// newBillResponse 家計簿から金額情報（税抜小計・税率ごとの消費税・税込合計・支払者の負担額）を計算したレスポンスを作成する
+func newBillResponse(bill models.MonthlyBill) models.BillResponse {
+	subtotal, taxes, grandTotal := calculateBillTax(bill.Items, bill.TaxRounding)
+	owedAmount, split := calculateBillSplit(bill.Items, grandTotal)
+	return models.BillResponse{
+		MonthlyBill: bill,
+		TotalAmount: owedAmount,
+		Subtotal:    subtotal,
+		Taxes:       taxes,
+		GrandTotal:  grandTotal,
+		Split:       split,
+	}
+}
+
+// calculateBillTax 家計簿項目の税抜小計・税率ごとの消費税内訳・税込合計を計算する
+// インボイス制度に従い、項目ごとではなく税率ごとの合計額に対して1回だけ端数処理を行う
+// （同じ税率に税込・税抜の項目が混在する場合は、それぞれの合計額に対して端数処理する）
+func calculateBillTax(items []models.BillItem, rounding string) (float64, []models.TaxBreakdown, float64) {
+	byRate := map[int]*taxAccumulator{}
+	for _, item := range items {
+		rate := item.TaxRate
+		if rate == 0 {
+			rate = models.TaxRateStandard
+		}
+		acc, ok := byRate[rate]
+		if !ok {
+			acc = &taxAccumulator{}
+			byRate[rate] = acc
+		}
+		if item.TaxExclusive {
+			acc.exclusiveSen += toSen(item.Amount)
+		} else {
+			acc.inclusiveSen += toSen(item.Amount)
+		}
+	}
+
+	// 標準税率 → 軽減税率の順に並べる
+	rates := make([]int, 0, len(byRate))
+	for rate := range byRate {
+		rates = append(rates, rate)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(rates)))
+
+	taxes := []models.TaxBreakdown{}
+	var subtotalSen, grandTotalSen int64
+	for _, rate := range rates {
+		acc := byRate[rate]
+		r := int64(rate)
+
+		// 税抜金額の合計に税率を掛けて端数処理
+		exclusiveTax := roundYen(acc.exclusiveSen*r, 100*100, rounding)
+		// 税込金額の合計から税額を割り戻して端数処理
+		inclusiveTax := roundYen(acc.inclusiveSen*r, (100+r)*100, rounding)
+
+		tax := exclusiveTax + inclusiveTax
+		taxableSen := acc.exclusiveSen + acc.inclusiveSen - inclusiveTax*100
+
+		taxes = append(taxes, models.TaxBreakdown{
+			Rate:          rate,
+			TaxableAmount: fromSen(taxableSen),
+			Tax:           float64(tax),
+		})
+		subtotalSen += taxableSen
+		grandTotalSen += taxableSen + tax*100
+	}
+
+	return fromSen(subtotalSen), taxes, fromSen(grandTotalSen)
+}
+
+// roundYen 分数 numerator/denominator（円単位）を指定された方法で整数円に端数処理する
+func roundYen(numerator, denominator int64, rounding string) int64 {
+	if numerator <= 0 {
+		return 0
+	}
+	switch rounding {
+	case models.TaxRoundingCeil:
+		return (numerator + denominator - 1) / denominator
+	case models.TaxRoundingRound:
+		return (2*numerator + denominator) / (2 * denominator)
+	default:
+		return numerator / denominator
+	}
+}
+
+// toSen 円単位の金額を銭単位の整数に変換する
+func toSen(amount float64) int64 {
+	if amount < 0 {
+		return int64(amount*100 - 0.5)
+	}
+	return int64(amount*100 + 0.5)
+}
+
+// fromSen 銭単位の整数を円単位の金額に変換する
+func fromSen(sen int64) float64 {
+	return float64(sen) / 100
+}
@@ -0,0 +1,135 @@
+// ========================================
+// 消費税計算の自動テスト
+// 税率ごとの端数処理と項目更新APIの金額情報を検証
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// TestCalculateBillTax_MixedRates 軽減税率・標準税率、税込・税抜が混在する場合の計算を検証
+func TestCalculateBillTax_MixedRates(t *testing.T) {
+	items := []models.BillItem{
+		{ItemName: "食料品", Amount: 1080, TaxRate: models.TaxRateReduced},
+		{ItemName: "日用品", Amount: 1000, TaxRate: models.TaxRateStandard, TaxExclusive: true},
+		{ItemName: "外食", Amount: 550, TaxRate: models.TaxRateStandard},
+	}
+
+	subtotal, taxes, grandTotal := calculateBillTax(items, models.TaxRoundingFloor)
+
+	require.Len(t, taxes, 2)
+	// 標準税率: 税抜1000円 → 100円、税込550円 → 50円
+	assert.Equal(t, models.TaxBreakdown{Rate: 10, TaxableAmount: 1500, Tax: 150}, taxes[0])
+	// 軽減税率: 税込1080円 → 80円
+	assert.Equal(t, models.TaxBreakdown{Rate: 8, TaxableAmount: 1000, Tax: 80}, taxes[1])
+	assert.Equal(t, 2500.0, subtotal)
+	assert.Equal(t, 2730.0, grandTotal)
+}
+
+// TestCalculateBillTax_RoundingPerRate 端数処理が項目ごとではなく税率ごとに1回行われることを検証
+func TestCalculateBillTax_RoundingPerRate(t *testing.T) {
+	// 税抜105円×2（8%）: 項目ごとなら8円+8円=16円、合計210円に対してなら16.8円
+	items := []models.BillItem{
+		{ItemName: "パン", Amount: 105, TaxRate: models.TaxRateReduced, TaxExclusive: true},
+		{ItemName: "牛乳", Amount: 105, TaxRate: models.TaxRateReduced, TaxExclusive: true},
+	}
+
+	tests := []struct {
+		rounding string
+		tax      float64
+	}{
+		{models.TaxRoundingFloor, 16},
+		{models.TaxRoundingRound, 17},
+		{models.TaxRoundingCeil, 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rounding, func(t *testing.T) {
+			subtotal, taxes, grandTotal := calculateBillTax(items, tt.rounding)
+			require.Len(t, taxes, 1)
+			assert.Equal(t, tt.tax, taxes[0].Tax)
+			assert.Equal(t, 210.0, subtotal)
+			assert.Equal(t, 210+tt.tax, grandTotal)
+		})
+	}
+}
+
+// TestCalculateBillTax_InclusiveKeepsTotal 税込金額のみの場合は税込合計が入力金額の合計と一致することを検証
+func TestCalculateBillTax_InclusiveKeepsTotal(t *testing.T) {
+	items := []models.BillItem{
+		{ItemName: "電気代", Amount: 8000.5},
+		{ItemName: "ガス代", Amount: 4999},
+	}
+
+	subtotal, taxes, grandTotal := calculateBillTax(items, models.TaxRoundingCeil)
+
+	require.Len(t, taxes, 1)
+	assert.Equal(t, 10, taxes[0].Rate)
+	assert.Equal(t, 1182.0, taxes[0].Tax) // 12999.5 × 10/110 = 1181.77… → 切り上げ
+	assert.Equal(t, 11817.5, subtotal)
+	assert.Equal(t, 12999.5, grandTotal)
+
+	_, emptyTaxes, emptyTotal := calculateBillTax(nil, models.TaxRoundingFloor)
+	assert.Empty(t, emptyTaxes)
+	assert.NotNil(t, emptyTaxes)
+	assert.Equal(t, 0.0, emptyTotal)
+}
+
+// TestUpdateItemsHandler_TaxFields 項目更新で税率・税抜フラグ・端数処理が保存され、金額情報が返されることを検証
+func TestUpdateItemsHandler_TaxFields(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("tax_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("tax_payer").MustBuild()
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 8).MustBuild()
+	assert.Equal(t, models.TaxRoundingFloor, bill.TaxRounding)
+
+	router := setupRouter()
+	router.PUT("/bills/:id/items", setUserID(requester.ID), UpdateItemsHandlerWithDB(db))
+	path := fmt.Sprintf("/bills/%d/items", bill.ID)
+
+	w := performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"tax_rounding": "round",
+		"items": []map[string]interface{}{
+			{"item_name": "食料品", "amount": 105, "tax_rate": 8, "tax_exclusive": true},
+			{"item_name": "食料品2", "amount": 105, "tax_rate": 8, "tax_exclusive": true},
+			{"item_name": "日用品", "amount": 1100},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.BillResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.TaxRoundingRound, response.TaxRounding)
+	require.Len(t, response.Items, 3)
+	assert.Equal(t, models.TaxRateStandard, response.Items[2].TaxRate)
+	assert.False(t, response.Items[2].TaxExclusive)
+
+	require.Len(t, response.Taxes, 2)
+	assert.Equal(t, 100.0, response.Taxes[0].Tax)
+	assert.Equal(t, 17.0, response.Taxes[1].Tax)
+	assert.Equal(t, 1210.0, response.Subtotal)
+	assert.Equal(t, 1327.0, response.GrandTotal)
+	assert.Equal(t, response.GrandTotal, response.TotalAmount)
+
+	// 未対応の税率は拒否
+	w = performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{{"item_name": "日用品", "amount": 100, "tax_rate": 5}},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 未対応の端数処理は拒否
+	w = performJSONRequest(router, "PUT", path, map[string]interface{}{"tax_rounding": "bankers"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// GenerateTransferFileHandler 振込ファイル作成ハンドラー
+// 支払者が選択した請求中の家計簿について、請求者の口座への全銀フォーマット（総合振込）の振込ファイルを返す
+// 振込元は支払者自身の口座情報で、振込明細はbill_idsの順に並ぶ（検証エラーのtransfers[i]はbill_ids[i]に対応）
+// ファイルの作成のみで、家計簿の状態は変更しない
+func GenerateTransferFileHandler(c *gin.Context) {
+	GenerateTransferFileHandlerWithDB(database.GetDB())(c)
+}
+
+// GenerateTransferFileHandlerWithDB DB接続を注入可能な振込ファイル作成ハンドラー
+func GenerateTransferFileHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		var req struct {
+			BillIDs      []uint `json:"bill_ids" binding:"required,min=1,max=100,dive,required"` // 振込する家計簿ID（必須）
+			TransferDate string `json:"transfer_date"`                                           // 振込指定日（YYYY-MM-DD、省略時は当日）
+			ClientCode   string `json:"client_code"`                                             // 依頼人コード（任意）
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		transferDate, ok := parseTransferDate(c, req.TransferDate)
+		if !ok {
+			return
+		}
+
+		// 振込元（支払者）の口座情報
+		var payer models.User
+		if err := db.First(&payer, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+		remitter, ok := openUserBankAccount(c, payer)
+		if !ok {
+			return
+		}
+		if remitter == nil {
+			respondValidationError(c, services.NewValidationError(services.FieldError{
+				Field: "remitter", Rule: services.RuleRequired, Message: "振込元の口座情報をプロフィールに登録してください",
+			}))
+			return
+		}
+
+		// 振込先（請求者）の口座情報と振込金額（支払者の負担額）
+		service := newBillService(db)
+		seen := make(map[uint]bool, len(req.BillIDs))
+		transfers := make([]services.ZenginTransfer, 0, len(req.BillIDs))
+		for i, billID := range req.BillIDs {
+			field := fmt.Sprintf("bill_ids[%d]", i)
+			if seen[billID] {
+				respondValidationError(c, services.NewValidationError(services.FieldError{
+					Field: field, Rule: services.RuleFormat, Message: "同じ家計簿が重複して指定されています",
+				}))
+				return
+			}
+			seen[billID] = true
+
+			bill, err := service.GetPayableBill(billID, userID)
+			if err != nil {
+				respondBillError(c, err, "家計簿の取得に失敗しました")
+				return
+			}
+			beneficiary, ok := openUserBankAccount(c, bill.Requester)
+			if !ok {
+				return
+			}
+			if beneficiary == nil {
+				respondValidationError(c, services.NewValidationError(services.FieldError{
+					Field: field, Rule: services.RuleRequired, Message: fmt.Sprintf("請求者「%s」の口座情報が登録されていません", bill.Requester.Name),
+				}))
+				return
+			}
+
+			// 負担額に銭単位の端数がある場合は、入金照合と同じ規則で円単位に丸めて振り込む
+			transfers = append(transfers, services.ZenginTransfer{
+				Beneficiary:  *beneficiary,
+				Amount:       float64(services.RoundOwedAmountYen(newBillResponse(*bill).TotalAmount)),
+				CustomerCode: strconv.FormatUint(uint64(bill.ID), 10),
+			})
+		}
+
+		data, err := services.GenerateZenginTransferFile(services.ZenginTransferFile{
+			ClientCode:   req.ClientCode,
+			Remitter:     *remitter,
+			TransferDate: transferDate,
+			Transfers:    transfers,
+		})
+		if err != nil {
+			respondBillError(c, err, "振込ファイルの作成に失敗しました")
+			return
+		}
+
+		log.Printf("🏦 Zengin transfer file generated: payer=%d bills=%v", userID, req.BillIDs)
+
+		fileName := fmt.Sprintf("zengin_%s.txt", transferDate.Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+		c.Data(http.StatusOK, "text/plain; charset=Shift_JIS", data)
+	}
+}
+
+// parseTransferDate 振込指定日を解析する（省略時は当日、過去の日付は検証エラー）
+func parseTransferDate(c *gin.Context, value string) (time.Time, bool) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if value == "" {
+		return today, true
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		respondValidationError(c, services.NewValidationError(services.FieldError{
+			Field: "transfer_date", Rule: services.RuleFormat, Message: "振込指定日はYYYY-MM-DD形式で入力してください",
+		}))
+		return time.Time{}, false
+	}
+	if date.Before(today) {
+		respondValidationError(c, services.NewValidationError(services.FieldError{
+			Field: "transfer_date", Rule: services.RuleAfter, Message: "振込指定日は今日以降の日付を指定してください",
+		}))
+		return time.Time{}, false
+	}
+	return date, true
+}
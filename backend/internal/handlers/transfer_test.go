@@ -0,0 +1,187 @@
+// ========================================
+// 口座情報と振込ファイル作成APIの自動テスト
+// 口座情報の暗号化保存と、全銀フォーマットの振込ファイルのダウンロードを検証
+// ========================================
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+	testfactory "money_management/internal/testing"
+)
+
+// setupTransferRouter 口座情報・振込ファイルAPIのテスト用ルーターを設定
+func setupTransferRouter(db *gorm.DB, userID uint) *gin.Engine {
+	router := setupRouter()
+	router.Use(setUserID(userID))
+	router.GET("/users/me/bank-account", GetBankAccountHandlerWithDB(db))
+	router.PUT("/users/me/bank-account", UpdateBankAccountHandlerWithDB(db))
+	router.DELETE("/users/me/bank-account", DeleteBankAccountHandlerWithDB(db))
+	router.POST("/bills/transfer-file", GenerateTransferFileHandlerWithDB(db))
+	return router
+}
+
+// TestBankAccount_StoredEncrypted 口座情報が正規化・暗号化して保存され、本人のみ取得できることを検証
+func TestBankAccount_StoredEncrypted(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	user := factory.NewUser().WithAccountID("bank_owner").MustBuild()
+	router := setupTransferRouter(db, user.ID)
+
+	w := performJSONRequest(router, "GET", "/users/me/bank-account", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performJSONRequest(router, "PUT", "/users/me/bank-account", map[string]interface{}{
+		"bank_code": "0001", "bank_name": "みずほ", "branch_code": "001", "branch_name": "トウキョウ",
+		"account_type": "ordinary", "account_number": "1234567", "account_holder": "ヤマダ ハナコ",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = performJSONRequest(router, "GET", "/users/me/bank-account", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		BankAccount models.BankAccount `json:"bank_account"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "ﾐｽﾞﾎ", response.BankAccount.BankName)
+	assert.Equal(t, "ﾔﾏﾀﾞ ﾊﾅｺ", response.BankAccount.AccountHolder)
+
+	// DBには平文で保存されず、ユーザー情報のJSONにも含まれない
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.NotEmpty(t, stored.EncryptedBankAccount)
+	assert.NotContains(t, stored.EncryptedBankAccount, "1234567")
+	userJSON, _ := json.Marshal(stored)
+	assert.NotContains(t, string(userJSON), stored.EncryptedBankAccount)
+
+	// 不正な口座情報はフィールド単位の検証エラー
+	w = performJSONRequest(router, "PUT", "/users/me/bank-account", map[string]interface{}{
+		"bank_code": "1", "bank_name": "みずほ銀行", "branch_code": "001", "branch_name": "トウキョウ",
+		"account_type": "ordinary", "account_number": "1234567", "account_holder": "ヤマダ ハナコ",
+	})
+	fields := decodeValidationError(t, w).Fields
+	assert.Len(t, fields, 2)
+
+	w = performJSONRequest(router, "DELETE", "/users/me/bank-account", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = performJSONRequest(router, "GET", "/users/me/bank-account", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestGenerateTransferFile 支払者が選択した請求中の家計簿の振込ファイルをダウンロードできることを検証
+func TestGenerateTransferFile(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithName("サトウ").WithAccountID("transfer_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("transfer_payer").MustBuild()
+
+	rent, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 9).
+		WithStatus("requested").WithRequestDate(time.Now()).AddItems(testfactory.Item("家賃", 98000)).MustBuild()
+	food, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 10).
+		WithStatus("requested").WithRequestDate(time.Now()).AddItems(testfactory.Item("食費", 1500)).MustBuild()
+	pending, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 11).
+		WithStatus("pending").AddItems(testfactory.Item("食費", 1000)).MustBuild()
+
+	payerRouter := setupTransferRouter(db, payer.ID)
+	request := map[string]interface{}{"bill_ids": []uint{rent.ID, food.ID}}
+
+	// 振込元の口座情報が未登録
+	w := performJSONRequest(payerRouter, "POST", "/bills/transfer-file", request)
+	fields := decodeValidationError(t, w).Fields
+	assert.Equal(t, "remitter", fields[0].Field)
+
+	w = performJSONRequest(payerRouter, "PUT", "/users/me/bank-account", map[string]interface{}{
+		"bank_code": "0001", "bank_name": "ミズホ", "branch_code": "001", "branch_name": "トウキョウ",
+		"account_type": "ordinary", "account_number": "1234567", "account_holder": "ヤマダ ハナコ",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// 振込先（請求者）の口座情報が未登録
+	w = performJSONRequest(payerRouter, "POST", "/bills/transfer-file", request)
+	fields = decodeValidationError(t, w).Fields
+	assert.Equal(t, "bill_ids[0]", fields[0].Field)
+
+	w = performJSONRequest(setupTransferRouter(db, requester.ID), "PUT", "/users/me/bank-account", map[string]interface{}{
+		"bank_code": "0005", "bank_name": "ミツビシユーエフジェイ", "branch_code": "123", "branch_name": "シンジュク",
+		"account_type": "savings", "account_number": "765", "account_holder": "サトウ タロウ",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = performJSONRequest(payerRouter, "POST", "/bills/transfer-file", map[string]interface{}{
+		"bill_ids":      []uint{rent.ID, food.ID},
+		"transfer_date": time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/plain; charset=Shift_JIS", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"zengin_")
+
+	records := bytes.Split(bytes.TrimSuffix(w.Body.Bytes(), []byte("\r\n")), []byte("\r\n"))
+	require.Len(t, records, 5)
+	data, err := japanese.ShiftJIS.NewDecoder().Bytes(records[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ｻﾄｳ ﾀﾛｳ")
+	assert.Equal(t, "8000002000000099500", string(records[3][:19]))
+
+	// ファイルの作成のみで家計簿は請求中のまま
+	var bill models.MonthlyBill
+	require.NoError(t, db.First(&bill, rent.ID).Error)
+	assert.Equal(t, "requested", bill.Status)
+
+	// 請求中でない家計簿・他人の家計簿は指定できない
+	w = performJSONRequest(payerRouter, "POST", "/bills/transfer-file", map[string]interface{}{"bill_ids": []uint{pending.ID}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performJSONRequest(setupTransferRouter(db, requester.ID), "POST", "/bills/transfer-file", map[string]interface{}{"bill_ids": []uint{rent.ID}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 過去の振込指定日・重複した家計簿は検証エラー
+	w = performJSONRequest(payerRouter, "POST", "/bills/transfer-file", map[string]interface{}{
+		"bill_ids": []uint{rent.ID}, "transfer_date": "2020-01-01",
+	})
+	fields = decodeValidationError(t, w).Fields
+	assert.Equal(t, "transfer_date", fields[0].Field)
+	w = performJSONRequest(payerRouter, "POST", "/bills/transfer-file", map[string]interface{}{"bill_ids": []uint{rent.ID, rent.ID}})
+	fields = decodeValidationError(t, w).Fields
+	assert.Equal(t, "bill_ids[1]", fields[0].Field)
+}
+
+// TestGenerateTransferFile_RoundsOwedAmountToYen 折半で負担額に銭単位の端数が出る場合、円単位に丸めた金額で振込ファイルを作成できることを検証
+func TestGenerateTransferFile_RoundsOwedAmountToYen(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("odd_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("odd_payer").MustBuild()
+
+	bill, items := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 10).
+		WithStatus("requested").WithRequestDate(time.Now()).AddItems(testfactory.Item("日用品", 1001)).MustBuild()
+	require.NoError(t, db.Model(&items[0]).Update("split_rule", models.SplitRuleShared).Error)
+
+	for userID, account := range map[uint]map[string]interface{}{
+		payer.ID: {"bank_code": "0001", "bank_name": "ミズホ", "branch_code": "001", "branch_name": "トウキョウ",
+			"account_type": "ordinary", "account_number": "1234567", "account_holder": "ヤマダ ハナコ"},
+		requester.ID: {"bank_code": "0005", "bank_name": "ミツビシユーエフジェイ", "branch_code": "123", "branch_name": "シンジュク",
+			"account_type": "ordinary", "account_number": "7654321", "account_holder": "サトウ タロウ"},
+	} {
+		w := performJSONRequest(setupTransferRouter(db, userID), "PUT", "/users/me/bank-account", account)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// 負担額500.50円は501円として振り込む
+	w := performJSONRequest(setupTransferRouter(db, payer.ID), "POST", "/bills/transfer-file", map[string]interface{}{"bill_ids": []uint{bill.ID}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	records := bytes.Split(bytes.TrimSuffix(w.Body.Bytes(), []byte("\r\n")), []byte("\r\n"))
+	require.Len(t, records, 4)
+	assert.Equal(t, "8000001000000000501", string(records[2][:19]))
+}
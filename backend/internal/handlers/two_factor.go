@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"money_management/internal/database"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// GetTwoFactorStatusHandler 2段階認証の状態取得ハンドラー
+// ログイン中のユーザー本人の2段階認証が有効かを返す
+func GetTwoFactorStatusHandler(c *gin.Context) {
+	GetTwoFactorStatusHandlerWithDB(database.GetDB())(c)
+}
+
+// GetTwoFactorStatusHandlerWithDB DB接続を注入可能な2段階認証の状態取得ハンドラー
+func GetTwoFactorStatusHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := db.Select("id", "totp_enabled").First(&user, c.GetUint("user_id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"two_factor_enabled": user.TOTPEnabled})
+	}
+}
+
+// SetupTwoFactorHandler 2段階認証の設定開始ハンドラー
+// 新しい共有鍵を生成し、認証アプリに登録するotpauth URI（QRコードの内容）と共有鍵を返す
+func SetupTwoFactorHandler(c *gin.Context) {
+	SetupTwoFactorHandlerWithDB(database.GetDB())(c)
+}
+
+// SetupTwoFactorHandlerWithDB DB接続を注入可能な2段階認証の設定開始ハンドラー
+func SetupTwoFactorHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, ok := newTwoFactorService(c, db)
+		if !ok {
+			return
+		}
+
+		enrollment, err := service.BeginEnrollment(c.GetUint("user_id"))
+		if err != nil {
+			respondBillError(c, err, "2段階認証の設定に失敗しました")
+			return
+		}
+
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+// EnableTwoFactorHandler 2段階認証の有効化ハンドラー
+// 認証アプリに表示されたコードを確認して2段階認証を有効にし、リカバリーコードを返す（再表示はできない）
+func EnableTwoFactorHandler(c *gin.Context) {
+	EnableTwoFactorHandlerWithDB(database.GetDB())(c)
+}
+
+// EnableTwoFactorHandlerWithDB DB接続を注入可能な2段階認証の有効化ハンドラー
+func EnableTwoFactorHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.TwoFactorCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		service, ok := newTwoFactorService(c, db)
+		if !ok {
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		codes, err := service.Enable(userID, req.Code)
+		if err != nil {
+			respondBillError(c, err, "2段階認証の有効化に失敗しました")
+			return
+		}
+
+		log.Printf("🔐 Two-factor authentication enabled: user=%d", userID)
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "2段階認証を有効にしました",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// DisableTwoFactorHandler 2段階認証の無効化ハンドラー
+// パスワードと認証コード（またはリカバリーコード）を確認して2段階認証を無効にする
+func DisableTwoFactorHandler(c *gin.Context) {
+	DisableTwoFactorHandlerWithDB(database.GetDB())(c)
+}
+
+// DisableTwoFactorHandlerWithDB DB接続を注入可能な2段階認証の無効化ハンドラー
+func DisableTwoFactorHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.TwoFactorDisableRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+			return
+		}
+		if err := services.CurrentPasswordHasher().ComparePassword(user.PasswordHash, req.Password); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "パスワードが正しくありません", "code": "INVALID_PASSWORD"})
+			return
+		}
+
+		service, ok := newTwoFactorService(c, db)
+		if !ok {
+			return
+		}
+		if err := service.Disable(userID, req.Code); err != nil {
+			respondBillError(c, err, "2段階認証の無効化に失敗しました")
+			return
+		}
+
+		log.Printf("🔓 Two-factor authentication disabled: user=%d", userID)
+		c.JSON(http.StatusOK, gin.H{"message": "2段階認証を無効にしました"})
+	}
+}
+
+// RegenerateRecoveryCodesHandler リカバリーコード再発行ハンドラー
+// 認証アプリのコードを確認してリカバリーコードを作り直す（以前のコードは使用できなくなる）
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	RegenerateRecoveryCodesHandlerWithDB(database.GetDB())(c)
+}
+
+// RegenerateRecoveryCodesHandlerWithDB DB接続を注入可能なリカバリーコード再発行ハンドラー
+func RegenerateRecoveryCodesHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.TwoFactorCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		service, ok := newTwoFactorService(c, db)
+		if !ok {
+			return
+		}
+
+		codes, err := service.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Code)
+		if err != nil {
+			respondBillError(c, err, "リカバリーコードの再発行に失敗しました")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// LoginTwoFactorHandler 2段階認証のログインハンドラー
+// パスワード認証で発行したチャレンジトークンと認証コード（またはリカバリーコード）を確認し、トークンを発行する
+func LoginTwoFactorHandler(c *gin.Context) {
+	LoginTwoFactorHandlerWithDB(database.GetDB())(c)
+}
+
+// LoginTwoFactorHandlerWithDB DB接続を注入可能な2段階認証のログインハンドラー
+func LoginTwoFactorHandlerWithDB(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.TwoFactorLoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondBindingError(c, err)
+			return
+		}
+
+		service, ok := newTwoFactorService(c, db)
+		if !ok {
+			return
+		}
+
+		userID, err := service.VerifyChallenge(req.ChallengeToken, req.Code)
+		switch {
+		case errors.Is(err, services.ErrTwoFactorChallengeInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "TWO_FACTOR_CHALLENGE_INVALID"})
+			return
+		case errors.Is(err, services.ErrTwoFactorCodeInvalid):
+			// 認証コードの誤りもアカウントのログイン失敗として記録する
+			var user models.User
+			if db.Select("account_id").First(&user, userID).Error == nil {
+				if err := services.NewLoginAttemptService(db).RecordFailure(user.AccountID, time.Now()); err != nil {
+					log.Printf("❌ Failed to record login failure: error=%v", err)
+				}
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "TWO_FACTOR_CODE_INVALID"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "認証コードの確認に失敗しました"})
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "認証情報が無効です"})
+			return
+		}
+
+		if err := services.NewLoginAttemptService(db).RecordSuccess(user.AccountID); err != nil {
+			log.Printf("❌ Failed to reset login attempts: user=%d error=%v", user.ID, err)
+		}
+		completeLogin(c, db, user, req.InvitationToken)
+	}
+}
+
+// respondTwoFactorChallenge パスワード認証に成功したユーザーにチャレンジトークンを返す
+func respondTwoFactorChallenge(c *gin.Context, db *gorm.DB, userID uint) {
+	service, ok := newTwoFactorService(c, db)
+	if !ok {
+		return
+	}
+
+	challengeToken, expiresAt, err := service.CreateChallenge(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンを生成できませんでした"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorChallengeResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: expiresAt,
+	})
+}
+
+// newTwoFactorService 2段階認証サービスを作成する（共有鍵の暗号化キーがない場合はレスポンスを返してfalse）
+func newTwoFactorService(c *gin.Context, db *gorm.DB) (*services.TwoFactorService, bool) {
+	fieldCipher, err := services.DefaultFieldCipher()
+	if err != nil {
+		log.Printf("❌ Field encryption key unavailable: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "2段階認証を利用できません"})
+		return nil, false
+	}
+	return services.NewTwoFactorService(db, fieldCipher), true
+}
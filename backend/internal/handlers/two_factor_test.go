@@ -0,0 +1,202 @@
+// ========================================
+// 2段階認証（TOTP）の自動テスト
+// 設定・有効化、チャレンジトークンを使った2段階のログイン、リカバリーコード、無効化を検証
+// ========================================
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+	"money_management/internal/services"
+)
+
+// setupTwoFactorRouter 2段階認証のテスト用ルーターを設定
+func setupTwoFactorRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	t.Helper()
+	router := setupRefreshTokenRouter(t, db)
+	router.POST("/login/2fa", LoginTwoFactorHandlerWithDB(db))
+	router.GET("/2fa/status", middleware.AuthMiddleware(), GetTwoFactorStatusHandlerWithDB(db))
+	router.POST("/2fa/setup", middleware.AuthMiddleware(), SetupTwoFactorHandlerWithDB(db))
+	router.POST("/2fa/enable", middleware.AuthMiddleware(), EnableTwoFactorHandlerWithDB(db))
+	router.POST("/2fa/disable", middleware.AuthMiddleware(), DisableTwoFactorHandlerWithDB(db))
+	router.POST("/2fa/recovery-codes", middleware.AuthMiddleware(), RegenerateRecoveryCodesHandlerWithDB(db))
+	return router
+}
+
+// enableTwoFactor 2段階認証を設定・有効化し、共有鍵とリカバリーコードを返す
+func enableTwoFactor(t *testing.T, router *gin.Engine, token string) (string, []string) {
+	t.Helper()
+	w := requestWithToken(router, "POST", "/2fa/setup", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var enrollment services.TOTPEnrollment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	require.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+
+	// 誤ったコードでは有効化できない
+	w = requestWithToken(router, "POST", "/2fa/enable", token, map[string]string{"code": "000000"})
+	if w.Code == http.StatusOK {
+		t.Skip("generated code happened to be 000000")
+	}
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = requestWithToken(router, "POST", "/2fa/enable", token, map[string]string{"code": totpCodeAt(t, enrollment.Secret, time.Now())})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.RecoveryCodes, 10)
+	return enrollment.Secret, response.RecoveryCodes
+}
+
+// totpCodeAt 指定時刻の認証コードを計算する
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := services.TOTPCode(secret, at)
+	require.NoError(t, err)
+	return code
+}
+
+// startTwoFactorLogin パスワードでログインし、チャレンジトークンを取得する
+func startTwoFactorLogin(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	w := performJSONRequest(router, "POST", "/login", map[string]string{"account_id": "refresh_user", "password": "password123"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var challenge models.TwoFactorChallengeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	require.True(t, challenge.TwoFactorRequired)
+	require.NotEmpty(t, challenge.ChallengeToken)
+	assert.NotContains(t, w.Body.String(), `"token"`)
+	return challenge.ChallengeToken
+}
+
+// completeTwoFactorLogin チャレンジトークンと認証コードでログインを完了する
+func completeTwoFactorLogin(router *gin.Engine, challengeToken, code string) *httptest.ResponseRecorder {
+	return performJSONRequest(router, "POST", "/login/2fa", map[string]string{"challenge_token": challengeToken, "code": code})
+}
+
+// TestTwoFactor_EnrollAndLogin 有効化後のログインが2段階になり、認証コード・リカバリーコードでトークンを取得できることを検証
+func TestTwoFactor_EnrollAndLogin(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupTwoFactorRouter(t, db)
+	login := loginForTokens(t, router)
+	secret, recoveryCodes := enableTwoFactor(t, router, login.Token)
+
+	var user models.User
+	require.NoError(t, db.Where("account_id = ?", "refresh_user").First(&user).Error)
+	assert.True(t, user.TOTPEnabled)
+	assert.NotContains(t, user.EncryptedTOTPSecret, secret)
+
+	// 有効かどうかは本人の状態取得でのみ返し、ユーザー情報には含めない
+	w := requestWithToken(router, "GET", "/2fa/status", login.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"two_factor_enabled":true}`, w.Body.String())
+	w = requestWithToken(router, "GET", "/me", login.Token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "two_factor_enabled")
+
+	// 認証コードでログイン（有効化で使用したステップの次のコード）
+	challenge := startTwoFactorLogin(t, router)
+	code := totpCodeAt(t, secret, time.Now().Add(30*time.Second))
+	w = completeTwoFactorLogin(router, challenge, code)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tokens models.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.NotEmpty(t, tokens.Token)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	// チャレンジトークンは1回限り、使用済みの認証コードも再利用できない
+	w = completeTwoFactorLogin(router, challenge, code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TWO_FACTOR_CHALLENGE_INVALID")
+	w = completeTwoFactorLogin(router, startTwoFactorLogin(t, router), code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TWO_FACTOR_CODE_INVALID")
+
+	// リカバリーコードでログイン（区切り・大文字を許容し、1回限り）
+	challenge = startTwoFactorLogin(t, router)
+	w = completeTwoFactorLogin(router, challenge, recoveryCodes[0])
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = completeTwoFactorLogin(router, startTwoFactorLogin(t, router), recoveryCodes[0])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestTwoFactor_ChallengeAttemptsLimited 誤ったコードを繰り返すとチャレンジトークンが使えなくなることを検証
+func TestTwoFactor_ChallengeAttemptsLimited(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupTwoFactorRouter(t, db)
+	login := loginForTokens(t, router)
+	_, recoveryCodes := enableTwoFactor(t, router, login.Token)
+
+	challenge := startTwoFactorLogin(t, router)
+	for i := 0; i < 5; i++ {
+		w := completeTwoFactorLogin(router, challenge, "aaaaa-aaaaa")
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := completeTwoFactorLogin(router, challenge, recoveryCodes[0])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "TWO_FACTOR_CHALLENGE_INVALID")
+
+	// チャレンジトークンなしではアクセストークンを取得できない
+	w = completeTwoFactorLogin(router, "invalid", recoveryCodes[0])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestTwoFactor_RegenerateAndDisable リカバリーコードの再発行と、パスワード・コードを確認した無効化を検証
+func TestTwoFactor_RegenerateAndDisable(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupTwoFactorRouter(t, db)
+	login := loginForTokens(t, router)
+	secret, oldCodes := enableTwoFactor(t, router, login.Token)
+
+	// 有効化済みの場合は設定をやり直せない
+	w := requestWithToken(router, "POST", "/2fa/setup", login.Token, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 再発行には認証アプリのコードが必要
+	w = requestWithToken(router, "POST", "/2fa/recovery-codes", login.Token, map[string]string{"code": oldCodes[0]})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 再発行すると以前のリカバリーコードは使えない
+	code := totpCodeAt(t, secret, time.Now().Add(30*time.Second))
+	w = requestWithToken(router, "POST", "/2fa/recovery-codes", login.Token, map[string]string{"code": code})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.RecoveryCodes, 10)
+	w = completeTwoFactorLogin(router, startTwoFactorLogin(t, router), oldCodes[1])
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// パスワードが誤っている場合は無効化できない
+	w = requestWithToken(router, "POST", "/2fa/disable", login.Token, map[string]string{"password": "wrong", "code": response.RecoveryCodes[0]})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = requestWithToken(router, "POST", "/2fa/disable", login.Token, map[string]string{"password": "password123", "code": response.RecoveryCodes[0]})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var user models.User
+	require.NoError(t, db.Where("account_id = ?", "refresh_user").First(&user).Error)
+	assert.False(t, user.TOTPEnabled)
+	assert.Empty(t, user.EncryptedTOTPSecret)
+	var remaining int64
+	require.NoError(t, db.Model(&models.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+
+	// 無効化後は通常のログインに戻る
+	loginForTokens(t, router)
+}
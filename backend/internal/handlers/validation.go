@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"money_management/internal/services"
+)
+
+// validationErrorCode 検証エラーのレスポンスのエラーコード
+const validationErrorCode = "VALIDATION_ERROR"
+
+func init() {
+	// バインド時の検証エラーのフィールド名をJSONのキー名にする
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// respondValidationError フィールド単位の検証エラーを400レスポンスとして返す
+// errorには最初の検証エラーのメッセージ、fieldsには全ての検証エラー（フィールド名・ルールコード・メッセージ）を設定する
+func respondValidationError(c *gin.Context, err *services.ValidationError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  err.Error(),
+		"code":   validationErrorCode,
+		"fields": err.Fields,
+	})
+}
+
+// respondBindingError リクエストボディのバインドエラーを検証エラーのレスポンスとして返す
+func respondBindingError(c *gin.Context, err error) {
+	respondValidationError(c, bindingValidationError(err))
+}
+
+// bindingValidationError バインドエラーをフィールド単位の検証エラーに変換する
+func bindingValidationError(err error) *services.ValidationError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]services.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, bindingFieldError(fe))
+		}
+		return services.NewValidationError(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return services.NewValidationError(services.FieldError{
+			Field:   typeErr.Field,
+			Rule:    services.RuleType,
+			Message: fmt.Sprintf("%sの型が不正です", typeErr.Field),
+		})
+	}
+
+	if errors.Is(err, io.EOF) {
+		return services.NewValidationError(services.FieldError{
+			Field:   "body",
+			Rule:    services.RuleRequired,
+			Message: "リクエストボディを指定してください",
+		})
+	}
+
+	return services.NewValidationError(services.FieldError{
+		Field:   "body",
+		Rule:    services.RuleFormat,
+		Message: "リクエストの形式が不正です",
+	})
+}
+
+// bindingFieldError validatorの検証エラーをルールコード付きのフィールドエラーに変換する
+func bindingFieldError(fe validator.FieldError) services.FieldError {
+	// 先頭の構造体名を除いたJSONのキーのパス（items[0].amount等）
+	// 無名構造体の場合は構造体名が付かないため、Goのフィールド名のパスと先頭が一致する場合のみ除く
+	field := fe.Namespace()
+	structPrefix, _, _ := strings.Cut(fe.StructNamespace(), ".")
+	if prefix, rest, ok := strings.Cut(field, "."); ok && prefix == structPrefix {
+		field = rest
+	}
+
+	isString := fe.Kind() == reflect.String
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array || fe.Kind() == reflect.Map
+
+	switch fe.Tag() {
+	case "required":
+		return services.FieldError{Field: field, Rule: services.RuleRequired, Message: fmt.Sprintf("%sを指定してください", field)}
+	case "oneof":
+		values := strings.Join(strings.Fields(fe.Param()), "・")
+		return services.FieldError{Field: field, Rule: services.RuleOneOf, Message: fmt.Sprintf("%sは%sのいずれかを指定してください", field, values)}
+	case "min", "gte", "gt":
+		switch {
+		case isString:
+			return services.FieldError{Field: field, Rule: services.RuleMin, Message: fmt.Sprintf("%sは%s文字以上で入力してください", field, fe.Param())}
+		case isList:
+			return services.FieldError{Field: field, Rule: services.RuleMin, Message: fmt.Sprintf("%sは%s件以上指定してください", field, fe.Param())}
+		case fe.Tag() == "gt":
+			return services.FieldError{Field: field, Rule: services.RuleMin, Message: fmt.Sprintf("%sは%sより大きい値を指定してください", field, fe.Param())}
+		}
+		return services.FieldError{Field: field, Rule: services.RuleMin, Message: fmt.Sprintf("%sは%s以上で指定してください", field, fe.Param())}
+	case "max", "lte", "lt":
+		switch {
+		case isString:
+			return services.FieldError{Field: field, Rule: services.RuleMaxLength, Message: fmt.Sprintf("%sは%s文字以内で入力してください", field, fe.Param())}
+		case isList:
+			return services.FieldError{Field: field, Rule: services.RuleMax, Message: fmt.Sprintf("%sは%s件以内で指定してください", field, fe.Param())}
+		case fe.Tag() == "lt":
+			return services.FieldError{Field: field, Rule: services.RuleMax, Message: fmt.Sprintf("%sは%sより小さい値を指定してください", field, fe.Param())}
+		}
+		return services.FieldError{Field: field, Rule: services.RuleMax, Message: fmt.Sprintf("%sは%s以下で指定してください", field, fe.Param())}
+	default:
+		return services.FieldError{Field: field, Rule: services.RuleFormat, Message: fmt.Sprintf("%sの形式が不正です", field)}
+	}
+}
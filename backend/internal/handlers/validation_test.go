@@ -0,0 +1,145 @@
+// ========================================
+// 入力値検証の自動テスト
+// フィールド単位の検証エラーのレスポンス形式とルールコードを検証
+// ========================================
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/services"
+	testfactory "money_management/internal/testing"
+)
+
+// validationErrorResponse 検証エラーのレスポンス
+type validationErrorResponse struct {
+	Error  string                `json:"error"`
+	Code   string                `json:"code"`
+	Fields []services.FieldError `json:"fields"`
+}
+
+// decodeValidationError 400の検証エラーのレスポンスをデコードする
+func decodeValidationError(t *testing.T, w *httptest.ResponseRecorder) validationErrorResponse {
+	t.Helper()
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	var response validationErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, validationErrorCode, response.Code)
+	require.NotEmpty(t, response.Fields)
+	assert.Equal(t, response.Fields[0].Message, response.Error)
+	return response
+}
+
+// fieldRules フィールド名とルールコードの組を返す
+func fieldRules(fields []services.FieldError) map[string]string {
+	rules := map[string]string{}
+	for _, f := range fields {
+		rules[f.Field] = f.Rule
+	}
+	return rules
+}
+
+// TestCreateBillHandler_FieldValidation 家計簿作成の不正な年月・支払者が全てフィールド単位で報告されることを検証
+func TestCreateBillHandler_FieldValidation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	testData, err := testfactory.NewTestDataFactory(db).CreateLightweightTestScenario()
+	require.NoError(t, err)
+	router := setupPeriodRouter(db, testData.User1.ID)
+
+	// 範囲外の年月と存在しない支払者
+	w := performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": -1, "month": 13, "payer_id": 99999})
+	response := decodeValidationError(t, w)
+	assert.Equal(t, map[string]string{
+		"year":     services.RuleRange,
+		"month":    services.RuleRange,
+		"payer_id": services.RuleNotFound,
+	}, fieldRules(response.Fields))
+
+	// 必須項目なし
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{})
+	response = decodeValidationError(t, w)
+	assert.Equal(t, services.RuleRequired, fieldRules(response.Fields)["payer_id"])
+
+	// 型の不正はバインドエラーとして同じ形式で返す
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": "2024", "month": 1, "payer_id": testData.User2.ID})
+	response = decodeValidationError(t, w)
+	assert.Equal(t, []services.FieldError{{Field: "year", Rule: services.RuleType, Message: "yearの型が不正です"}}, response.Fields)
+
+	// JSONとして不正なボディ
+	req := httptest.NewRequest("POST", "/bills", bytes.NewBufferString("{invalid"))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	response = decodeValidationError(t, w)
+	assert.Equal(t, services.RuleFormat, fieldRules(response.Fields)["body"])
+
+	// 範囲内であれば作成できる
+	w = performJSONRequest(router, "POST", "/bills", map[string]interface{}{"year": 2100, "month": 12, "payer_id": testData.User2.ID})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+// TestUpdateItemsHandler_FieldValidation 不正な項目が保存されず、項目ごとに報告されることを検証
+func TestUpdateItemsHandler_FieldValidation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	factory := testfactory.NewTestDataFactory(db)
+	requester := factory.NewUser().WithAccountID("valid_requester").MustBuild()
+	payer := factory.NewUser().WithAccountID("valid_payer").MustBuild()
+	bill, _ := factory.NewBill().WithRequester(requester.ID).WithPayer(payer.ID).WithYearMonth(2024, 9).
+		AddItems(testfactory.Item("家賃", 80000)).MustBuild()
+
+	router := setupRouter()
+	router.PUT("/bills/:id/items", setUserID(requester.ID), UpdateItemsHandlerWithDB(db))
+	path := fmt.Sprintf("/bills/%d/items", bill.ID)
+
+	longName := string(bytes.Repeat([]byte("あ"), 101))
+	w := performJSONRequest(router, "PUT", path, map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"item_name": "食費", "amount": 30000},
+			{"item_name": "", "amount": 100},
+			{"item_name": longName, "amount": 0},
+			{"item_name": "光熱費", "amount": 100000000, "split_rule": "custom"},
+		},
+	})
+	response := decodeValidationError(t, w)
+	assert.Equal(t, map[string]string{
+		"items[1].item_name":   services.RuleRequired,
+		"items[2].item_name":   services.RuleMaxLength,
+		"items[2].amount":      services.RuleMin,
+		"items[3].amount":      services.RuleMax,
+		"items[3].payer_share": services.RuleRequired,
+	}, fieldRules(response.Fields))
+
+	// 既存の項目はそのまま残る
+	var count int64
+	require.NoError(t, db.Table("bill_items").Where("bill_id = ?", bill.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestBatchBillsHandler_BindingValidation 一括操作のバインドエラーがJSONのキー名で報告されることを検証
+func TestBatchBillsHandler_BindingValidation(t *testing.T) {
+	db := setupInMemoryDB(t)
+	router := setupRouter()
+	router.POST("/bills/batch", setUserID(1), BatchBillsHandlerWithDB(db))
+
+	w := performJSONRequest(router, "POST", "/bills/batch", map[string]interface{}{
+		"operations": []map[string]interface{}{{"action": "archive", "bill_id": 1}, {"action": "pay"}},
+	})
+	response := decodeValidationError(t, w)
+	assert.Equal(t, map[string]string{
+		"operations[0].action":  services.RuleOneOf,
+		"operations[1].bill_id": services.RuleRequired,
+	}, fieldRules(response.Fields))
+
+	w = performJSONRequest(router, "POST", "/bills/batch", map[string]interface{}{"operations": []map[string]interface{}{}})
+	response = decodeValidationError(t, w)
+	assert.Equal(t, map[string]string{"operations": services.RuleMin}, fieldRules(response.Fields))
+}
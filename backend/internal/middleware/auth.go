@@ -1,33 +1,87 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+
+	"money_management/internal/config"
 )
 
 // Claims JWTトークンのクレーム構造体
 // JWTトークンに含まれるユーザー情報を表現
 type Claims struct {
-	UserID               uint `json:"user_id"` // ユーザーID
-	jwt.RegisteredClaims      // JWT標準クレーム（有効期限など）
+	UserID               uint   `json:"user_id"`       // ユーザーID
+	TokenVersion         uint   `json:"ver,omitempty"` // トークン世代（全デバイスログアウト・パスワード変更で進める）
+	SessionID            string `json:"sid,omitempty"` // ログインセッションID（リフレッシュトークンの系列ID）
+	jwt.RegisteredClaims        // JWT標準クレーム（有効期限など）
+}
+
+// AccessTokenTTL アクセストークンの有効期間（ACCESS_TOKEN_TTL_MINUTES、既定15分）
+// 有効期間を過ぎたらリフレッシュトークンで再発行する
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.GetIntEnv("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute
+}
+
+// GenerateAccessToken アクセストークン（キーリングの有効な鍵で署名したJWT）を発行する
+// トークンIDとしてランダムなjtiを設定し、個別の失効に使用する
+// tokenVersionには発行時点のユーザーのトークン世代、sessionIDにはログインセッションID（なしの場合は空）を指定する
+func GenerateAccessToken(userID, tokenVersion uint, sessionID string) (string, *Claims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		SessionID:    sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		},
+	}
+
+	manager, err := CurrentJWTKeyManager()
+	if err != nil {
+		return "", nil, err
+	}
+	tokenString, err := manager.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// newTokenID トークンID（jti）を生成する
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // GetJWTSecret JWTシークレットキーを環境変数またはDocker Secretsから安全に取得する
 // 1. Docker Secrets (/run/secrets/jwt_secret) を優先的に確認
 // 2. JWT_SECRET環境変数をフォールバック
 // 設定されていない場合はエラーを返す（テスト可能）
+// キーリングのファイルがない場合にJWTKeyManagerが読み込み、署名・検証ではキャッシュした鍵を使用する
 func GetJWTSecret() ([]byte, error) {
 	var secret string
 
 	// Docker Secretsから読み込みを試行
-	secretPath := "/run/secrets/jwt_secret"
-	if secretBytes, err := os.ReadFile(secretPath); err == nil {
+	if secretBytes, err := os.ReadFile(jwtSecretFile); err == nil {
 		secret = string(secretBytes)
 		log.Println("📋 JWT Secret loaded from Docker Secrets")
 	} else {
@@ -43,7 +97,7 @@ func GetJWTSecret() ([]byte, error) {
 	secret = strings.TrimSpace(secret)
 
 	// セキュリティのため、最小長をチェック
-	if len(secret) < 32 {
+	if len(secret) < minJWTSecretLength {
 		return nil, fmt.Errorf("JWT_SECRET must be at least 32 characters long for security")
 	}
 
@@ -78,14 +132,8 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// JWTトークンを解析・検証
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			secret, err := GetJWTSecret()
-			if err != nil {
-				return nil, err
-			}
-			return secret, nil
-		})
+		// JWTトークンを解析・検証（トークンのkidに対応するキーリングの鍵を使用）
+		token, err := ParseToken(tokenString, &Claims{})
 
 		// トークンが無効またはエラーが発生した場合
 		if err != nil || !token.Valid {
@@ -102,8 +150,39 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// ユーザーIDをコンテキストに設定（後続のハンドラーで使用可能）
+		// トークンIDで失効したトークン（リフレッシュトークンの再利用検出等）
+		if claims.ID != "" && IsTokenIDBlacklisted(claims.ID) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "このトークンは無効です。再度ログインしてください。",
+				"code":  "TOKEN_BLACKLISTED",
+			})
+			c.Abort()
+			return
+		}
+
+		// トークン世代が古いトークン（全デバイスログアウト・パスワード変更より前に発行）
+		current, err := checkTokenVersion(claims)
+		if err != nil {
+			log.Printf("❌ Token version lookup failed: user=%d error=%v", claims.UserID, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです"})
+			c.Abort()
+			return
+		}
+		if !current {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "このトークンは無効です。再度ログインしてください。",
+				"code":  "TOKEN_REVOKED",
+			})
+			c.Abort()
+			return
+		}
+
+		// ユーザーIDとセッションIDをコンテキストに設定（後続のハンドラーで使用可能）
 		c.Set("user_id", claims.UserID)
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+			recordSessionActivity(claims.SessionID, time.Now())
+		}
 		c.Next()
 	}
 }
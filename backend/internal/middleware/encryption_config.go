@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// GetFieldEncryptionKey 口座情報等の暗号化に使用するキーを環境変数またはDocker Secretsから安全に取得する
+// 1. Docker Secrets (/run/secrets/field_encryption_key) を優先的に確認
+// 2. FIELD_ENCRYPTION_KEY環境変数をフォールバック
+// 3. リリースモード以外では開発用デフォルトキーを使用（警告付き）
+// リリースモードで設定されていない場合はエラーを返す（暗号化済みのデータを読めなくなるため、キーは変更しないこと）
+func GetFieldEncryptionKey() ([]byte, error) {
+	var secret string
+
+	// Docker Secretsから読み込みを試行
+	secretPath := "/run/secrets/field_encryption_key"
+	if secretBytes, err := os.ReadFile(secretPath); err == nil {
+		secret = string(secretBytes)
+		log.Println("🔐 Field encryption key loaded from Docker Secrets")
+	} else {
+		// 環境変数からフォールバック
+		secret = os.Getenv("FIELD_ENCRYPTION_KEY")
+		if secret == "" {
+			if os.Getenv("GIN_MODE") == "release" {
+				return nil, fmt.Errorf("FIELD_ENCRYPTION_KEY environment variable or Docker Secret is required in release mode")
+			}
+			// 開発用デフォルト（セキュリティ警告付き）
+			log.Println("⚠️ WARNING: Using default field encryption key. Set FIELD_ENCRYPTION_KEY environment variable or use Docker Secrets for production")
+			secret = "default-field-encryption-key-change-in-production"
+		} else {
+			log.Println("🔐 Field encryption key loaded from environment variable")
+		}
+	}
+
+	// 改行文字を除去（Docker Secretsファイルに含まれる可能性があるため）
+	secret = strings.TrimSpace(secret)
+
+	// セキュリティのため、最小長をチェック
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("FIELD_ENCRYPTION_KEY must be at least 32 characters long for security")
+	}
+
+	return []byte(secret), nil
+}
@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader 冪等キーを指定するリクエストヘッダー
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 保存済みのレスポンスを再送したことを示すレスポンスヘッダー
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL   = 24 * time.Hour // 冪等キーの保存期間
+	maxIdempotencyKeyLen    = 255            // 冪等キーの最大長
+	maxIdempotencyBodyBytes = 1024 * 1024    // 冪等キー付きリクエストのボディの最大サイズ（比較のためメモリに読み込む）
+)
+
+// idempotencyEntry 冪等キーごとに保存するリクエストとレスポンスの情報
+type idempotencyEntry struct {
+	Fingerprint string // メソッド・パス・ボディのハッシュ
+	Completed   bool   // レスポンスの保存が完了しているか（falseの場合は処理中）
+	Status      int
+	Header      http.Header // ハンドラーが設定したレスポンスヘッダー（Content-Type・Content-Disposition等）
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore ユーザーと冪等キーごとのレスポンス保存
+type IdempotencyStore struct {
+	entries map[string]*idempotencyEntry
+	mutex   sync.Mutex
+	ttl     time.Duration
+}
+
+// NewIdempotencyStore 指定した保存期間の冪等キー保存を作成
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		entries: make(map[string]*idempotencyEntry),
+		ttl:     ttl,
+	}
+}
+
+// グローバルな冪等キー保存インスタンス
+var globalIdempotencyStore = NewIdempotencyStore(defaultIdempotencyTTL)
+
+func init() {
+	// 定期的に有効期限切れのエントリをクリーンアップ（10分間隔）
+	go globalIdempotencyStore.startCleanup()
+}
+
+// begin 冪等キーの処理を開始する
+// 有効なエントリが既に存在する場合はそのエントリを返し、存在しない場合は処理中として登録してnilを返す
+func (s *IdempotencyStore) begin(key, fingerprint string) *idempotencyEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if entry, exists := s.entries[key]; exists && now.Before(entry.ExpiresAt) {
+		copied := *entry
+		return &copied
+	}
+
+	s.entries[key] = &idempotencyEntry{
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	return nil
+}
+
+// complete 処理結果のレスポンスを保存する
+func (s *IdempotencyStore) complete(key string, status int, header http.Header, body []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, exists := s.entries[key]; exists {
+		entry.Completed = true
+		entry.Status = status
+		entry.Header = header
+		entry.Body = body
+	}
+}
+
+// release 処理中のエントリを削除し、同じキーで再実行できるようにする
+func (s *IdempotencyStore) release(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, key)
+}
+
+// startCleanup 定期的に有効期限切れのエントリをクリーンアップ
+func (s *IdempotencyStore) startCleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		s.cleanupExpiredEntries()
+	}
+}
+
+// cleanupExpiredEntries 有効期限切れのエントリをすべてクリーンアップ
+func (s *IdempotencyStore) cleanupExpiredEntries() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// IdempotencyMiddleware 更新系リクエストの冪等キー処理ミドルウェア
+// Idempotency-Keyヘッダー付きのPOST/PUT/PATCH/DELETEについて、最初のレスポンスを保存して再試行時に再送する
+// AuthMiddlewareの後に適用する（ユーザーごとにキーを管理するため）
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return createIdempotencyHandler(globalIdempotencyStore)
+}
+
+// createIdempotencyHandler 冪等キー処理ハンドラーを生成する共通関数
+func createIdempotencyHandler(store *IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency-Keyは255文字以内で指定してください",
+				"code":  "INVALID_IDEMPOTENCY_KEY",
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotencyBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error":    "リクエストサイズが大きすぎます",
+					"code":     "REQUEST_TOO_LARGE",
+					"max_size": maxIdempotencyBodyBytes,
+				})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "リクエストの読み込みに失敗しました"})
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := fmt.Sprintf("%d:%s", c.GetUint("user_id"), key)
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		if entry := store.begin(storeKey, fingerprint); entry != nil {
+			switch {
+			case entry.Fingerprint != fingerprint:
+				// 同じキーで異なるリクエストが送られた
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "このIdempotency-Keyは異なるリクエストで使用済みです",
+					"code":  "IDEMPOTENCY_KEY_REUSED",
+				})
+			case !entry.Completed:
+				// 最初のリクエストがまだ処理中
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "同じIdempotency-Keyのリクエストを処理中です",
+					"code":  "IDEMPOTENCY_REQUEST_IN_PROGRESS",
+				})
+			default:
+				// 保存済みのレスポンスをヘッダーと共に再送
+				for name, values := range entry.Header {
+					c.Writer.Header()[name] = values
+				}
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(entry.Status, entry.Header.Get("Content-Type"), entry.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		// 後続のハンドラーがパニックした場合も、処理中のまま残さず再試行できるよう解放する
+		completed := false
+		defer func() {
+			if !completed {
+				store.release(storeKey)
+			}
+		}()
+
+		// 前段のミドルウェアが設定したヘッダーは再送時にも設定されるため、ハンドラーが設定したものだけを保存する
+		headerBefore := c.Writer.Header().Clone()
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// サーバーエラーとレート制限は再試行できるよう保存しない
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+		store.complete(storeKey, status, changedHeader(headerBefore, writer.Header()), writer.body.Bytes())
+		completed = true
+	}
+}
+
+// idempotencyResponseWriter レスポンスボディを保存用に記録するResponseWriter
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// changedHeader beforeから追加・変更されたレスポンスヘッダーを返す
+func changedHeader(before, after http.Header) http.Header {
+	changed := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			changed[name] = slices.Clone(values)
+		}
+	}
+	return changed
+}
+
+// isMutatingMethod 冪等キーの対象となる更新系メソッドかどうか
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint 同一リクエストか判定するためのハッシュを作成
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupIdempotencyRouter 冪等キー処理と処理回数を数えるハンドラーを登録したルーターを作成
+func setupIdempotencyRouter(store *IdempotencyStore, calls *int, status int) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID == "2" {
+			c.Set("user_id", uint(2))
+		} else {
+			c.Set("user_id", uint(1))
+		}
+		c.Next()
+	})
+	router.Use(createIdempotencyHandler(store))
+
+	handler := func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls})
+	}
+	router.POST("/bills", handler)
+	router.PUT("/bills/:id/payment", handler)
+	router.GET("/bills", handler)
+	return router
+}
+
+func performIdempotentRequest(router *gin.Engine, method, path, key, body, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("同じキーの再試行は保存済みレスポンスを再送する", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusCreated)
+
+		w1 := performIdempotentRequest(router, "POST", "/bills", "key-1", `{"year":2024,"month":10}`, "")
+		w2 := performIdempotentRequest(router, "POST", "/bills", "key-1", `{"year":2024,"month":10}`, "")
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, w2.Code)
+		assert.Equal(t, w1.Body.String(), w2.Body.String())
+		assert.Equal(t, "true", w2.Header().Get(IdempotentReplayedHeader))
+		assert.Empty(t, w1.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("同じキーで異なるボディ・パスは拒否される", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusCreated)
+
+		performIdempotentRequest(router, "POST", "/bills", "key-2", `{"year":2024,"month":10}`, "")
+		w := performIdempotentRequest(router, "POST", "/bills", "key-2", `{"year":2024,"month":11}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+		w = performIdempotentRequest(router, "PUT", "/bills/1/payment", "key-2", `{"year":2024,"month":10}`, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("キーはユーザーごとに管理される", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusOK)
+
+		performIdempotentRequest(router, "PUT", "/bills/1/payment", "shared", "", "1")
+		w := performIdempotentRequest(router, "PUT", "/bills/1/payment", "shared", "", "2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("キーなし・参照系リクエストは対象外", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusOK)
+
+		performIdempotentRequest(router, "POST", "/bills", "", `{}`, "")
+		performIdempotentRequest(router, "POST", "/bills", "", `{}`, "")
+		performIdempotentRequest(router, "GET", "/bills", "key-3", "", "")
+		performIdempotentRequest(router, "GET", "/bills", "key-3", "", "")
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("サーバーエラーは保存せず再実行できる", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusInternalServerError)
+
+		performIdempotentRequest(router, "POST", "/bills", "key-4", `{}`, "")
+		performIdempotentRequest(router, "POST", "/bills", "key-4", `{}`, "")
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("ハンドラーがパニックした場合はキーを解放して再実行できる", func(t *testing.T) {
+		calls := 0
+		router := gin.New()
+		router.Use(gin.Recovery(), createIdempotencyHandler(NewIdempotencyStore(time.Hour)))
+		router.POST("/bills", func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				panic("unexpected failure")
+			}
+			c.JSON(http.StatusCreated, gin.H{"call": calls})
+		})
+
+		w := performIdempotentRequest(router, "POST", "/bills", "key-panic", `{}`, "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+		w = performIdempotentRequest(router, "POST", "/bills", "key-panic", `{}`, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("有効期限切れのキーは再実行される", func(t *testing.T) {
+		calls := 0
+		store := NewIdempotencyStore(time.Millisecond)
+		router := setupIdempotencyRouter(store, &calls, http.StatusCreated)
+
+		performIdempotentRequest(router, "POST", "/bills", "key-5", `{}`, "")
+		time.Sleep(5 * time.Millisecond)
+		performIdempotentRequest(router, "POST", "/bills", "key-5", `{}`, "")
+		assert.Equal(t, 2, calls)
+
+		time.Sleep(5 * time.Millisecond)
+		store.cleanupExpiredEntries()
+		assert.Empty(t, store.entries)
+	})
+
+	t.Run("再送時はハンドラーが設定したレスポンスヘッダーも再送する", func(t *testing.T) {
+		calls := 0
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Header("X-Request-Count", strconv.Itoa(calls))
+			c.Next()
+		})
+		router.Use(createIdempotencyHandler(NewIdempotencyStore(time.Hour)))
+		router.POST("/bills/transfer-file", func(c *gin.Context) {
+			calls++
+			c.Header("Content-Disposition", `attachment; filename="transfer.txt"`)
+			c.Data(http.StatusOK, "text/plain; charset=shift_jis", []byte("transfer"))
+		})
+
+		w1 := performIdempotentRequest(router, "POST", "/bills/transfer-file", "key-file", `{}`, "")
+		w2 := performIdempotentRequest(router, "POST", "/bills/transfer-file", "key-file", `{}`, "")
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "true", w2.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, w1.Body.String(), w2.Body.String())
+		assert.Equal(t, w1.Header().Get("Content-Type"), w2.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="transfer.txt"`, w2.Header().Get("Content-Disposition"))
+		// 前段のミドルウェアが設定したヘッダーは保存済みの値で上書きしない
+		assert.Equal(t, "1", w2.Header().Get("X-Request-Count"))
+	})
+
+	t.Run("大きすぎるボディは拒否される", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusCreated)
+
+		body := `{"comment":"` + strings.Repeat("a", maxIdempotencyBodyBytes) + `"}`
+		w := performIdempotentRequest(router, "POST", "/bills", "key-large", body, "")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "REQUEST_TOO_LARGE")
+		assert.Equal(t, 0, calls)
+
+		// キーは使用済みにならない
+		w = performIdempotentRequest(router, "POST", "/bills", "key-large", `{}`, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("長すぎるキーは拒否される", func(t *testing.T) {
+		calls := 0
+		router := setupIdempotencyRouter(NewIdempotencyStore(time.Hour), &calls, http.StatusCreated)
+
+		w := performIdempotentRequest(router, "POST", "/bills", strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, calls)
+	})
+}
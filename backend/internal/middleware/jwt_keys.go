@@ -0,0 +1,482 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"money_management/internal/config"
+)
+
+// ========================================
+// JWT署名鍵の管理 - kidで識別する複数の鍵（キーリング）をメモリにキャッシュ
+// 有効な鍵で署名し、退役した鍵も猶予期間中は検証に使用する（鍵のローテーション）
+// HS256に加えてRS256・EdDSAに対応し、公開鍵はJWKSとして公開する
+// ========================================
+
+// JWTの署名アルゴリズム
+const (
+	JWTAlgHS256 = "HS256" // HMAC-SHA256（共有鍵）
+	JWTAlgRS256 = "RS256" // RSA-SHA256（公開鍵をJWKSで公開）
+	JWTAlgEdDSA = "EdDSA" // Ed25519（公開鍵をJWKSで公開）
+
+	// DefaultJWTKeyID JWT_SECRETの鍵のkid（kidのない従来のトークンもこのkidの鍵で検証する）
+	DefaultJWTKeyID = "default"
+
+	defaultJWTKeysFile = "/run/secrets/jwt_keys.json" // キーリングの既定のファイル（存在しない場合はJWT_SECRETを使用）
+	jwtSecretFile      = "/run/secrets/jwt_secret"    // JWT_SECRETのDocker Secrets
+	minJWTSecretLength = 32                           // HS256の共有鍵の最小の長さ
+	minRSAKeyBits      = 2048                         // RS256の鍵の最小のビット数
+)
+
+// JWT署名鍵のエラー
+var (
+	ErrJWTKeyNotFound       = errors.New("JWT signing key not found")
+	ErrJWTKeyRetired        = errors.New("JWT signing key is retired")
+	ErrJWTAlgorithmMismatch = errors.New("JWT algorithm does not match the signing key")
+)
+
+// jwtKeyConfig キーリングのファイルの鍵の設定
+type jwtKeyConfig struct {
+	ID             string     `json:"kid"`                        // 鍵の識別子（トークンのヘッダーのkid）
+	Algorithm      string     `json:"alg"`                        // HS256 / RS256 / EdDSA
+	SecretFile     string     `json:"secret_file,omitempty"`      // HS256の共有鍵のファイル
+	PrivateKeyFile string     `json:"private_key_file,omitempty"` // RS256・EdDSAの秘密鍵（PEM）のファイル
+	RetiredAt      *time.Time `json:"retired_at,omitempty"`       // 退役した日時（以降は署名に使用せず、猶予期間のみ検証に使用）
+}
+
+// jwtKeyringConfig キーリングのファイルの形式（JSON）
+type jwtKeyringConfig struct {
+	Active string         `json:"active"` // 署名に使用する鍵のkid
+	Keys   []jwtKeyConfig `json:"keys"`   // 鍵の一覧（退役した鍵を含む）
+}
+
+// JWTKey kidで識別するJWTの署名鍵
+type JWTKey struct {
+	ID         string     // 鍵の識別子（kid）
+	Algorithm  string     // 署名アルゴリズム
+	RetiredAt  *time.Time // 退役した日時（nilの場合は退役していない）
+	method     jwt.SigningMethod
+	signingKey interface{} // 署名に使用する鍵（HS256は共有鍵、それ以外は秘密鍵）
+	verifyKey  interface{} // 検証に使用する鍵（HS256は共有鍵、それ以外は公開鍵）
+}
+
+// verifiableAt 指定した時刻に検証に使用できるか（退役した鍵は猶予期間中のみ）
+func (k *JWTKey) verifiableAt(now time.Time, grace time.Duration) bool {
+	return k.RetiredAt == nil || now.Before(k.RetiredAt.Add(grace))
+}
+
+// fileStamp 変更の検知に使用するファイルの更新日時とサイズ
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// jwtKeyring 読み込んだ鍵の一覧
+type jwtKeyring struct {
+	active    *JWTKey
+	keys      map[string]*JWTKey
+	files     map[string]fileStamp // 読み込んだファイル（変更されたら再読み込みする）
+	fromEnv   bool                 // JWT_SECRET環境変数の鍵か
+	envSecret string               // 読み込んだ時点のJWT_SECRET
+}
+
+// JWTKeyManager JWTの署名鍵のキーリングを管理する
+// 鍵はメモリにキャッシュし、ファイルが変更された場合のみ読み込み直す
+type JWTKeyManager struct {
+	path    string        // キーリングのファイル（空の場合はJWT_SECRETの鍵のみ）
+	grace   time.Duration // 退役した鍵を検証に使用する猶予期間
+	mutex   sync.RWMutex
+	keyring *jwtKeyring
+}
+
+// NewJWTKeyManager キーリングを読み込んで鍵の管理を作成する
+// pathが空の場合はJWT_SECRET（またはDocker Secrets）の鍵をkid=defaultのHS256の鍵として使用する
+func NewJWTKeyManager(path string, grace time.Duration) (*JWTKeyManager, error) {
+	m := &JWTKeyManager{path: path, grace: grace}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewJWTKeyManagerFromEnv 環境変数の設定から鍵の管理を作成する
+// JWT_KEYS_FILE: キーリングのファイル（未設定の場合は/run/secrets/jwt_keys.jsonがあれば使用、なければJWT_SECRET）
+// JWT_KEY_GRACE_MINUTES: 退役した鍵を検証に使用する猶予期間（既定はアクセストークンの有効期間）
+func NewJWTKeyManagerFromEnv() (*JWTKeyManager, error) {
+	path := config.GetStringEnv("JWT_KEYS_FILE", "")
+	if path == "" {
+		if _, err := os.Stat(defaultJWTKeysFile); err == nil {
+			path = defaultJWTKeysFile
+		}
+	}
+	grace := time.Duration(config.GetIntEnv("JWT_KEY_GRACE_MINUTES", int(AccessTokenTTL()/time.Minute))) * time.Minute
+	return NewJWTKeyManager(path, grace)
+}
+
+// Reload キーリングを読み込み直す（失敗した場合はそれまでの鍵を使い続ける）
+func (m *JWTKeyManager) Reload() error {
+	keyring, err := loadJWTKeyring(m.path, m.grace, time.Now())
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	m.keyring = keyring
+	m.mutex.Unlock()
+	log.Printf("🔑 JWT keyring loaded: active=%s alg=%s keys=%d", keyring.active.ID, keyring.active.Algorithm, len(keyring.keys))
+	return nil
+}
+
+// ReloadIfChanged 読み込んだファイルが変更されている場合のみキーリングを読み込み直す
+func (m *JWTKeyManager) ReloadIfChanged() (bool, error) {
+	m.mutex.RLock()
+	files := m.keyring.files
+	m.mutex.RUnlock()
+
+	for path, stamp := range files {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(stamp.modTime) || info.Size() != stamp.size {
+			return true, m.Reload()
+		}
+	}
+	return false, nil
+}
+
+// Watch 定期的にファイルの変更を確認し、変更された場合はキーリングを読み込み直す
+func (m *JWTKeyManager) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if _, err := m.ReloadIfChanged(); err != nil {
+			log.Printf("❌ Failed to reload JWT keyring (keeping previous keys): %v", err)
+		}
+	}
+}
+
+// current 現在のキーリングを取得する
+// JWT_SECRET環境変数の鍵は、環境変数が変更された場合に読み込み直す（メモリ上の比較のみ）
+func (m *JWTKeyManager) current() (*jwtKeyring, error) {
+	m.mutex.RLock()
+	keyring := m.keyring
+	m.mutex.RUnlock()
+
+	if keyring.fromEnv && os.Getenv("JWT_SECRET") != keyring.envSecret {
+		if err := m.Reload(); err != nil {
+			return nil, err
+		}
+		m.mutex.RLock()
+		keyring = m.keyring
+		m.mutex.RUnlock()
+	}
+	return keyring, nil
+}
+
+// ActiveKeyID 署名に使用する鍵のkid
+func (m *JWTKeyManager) ActiveKeyID() (string, error) {
+	keyring, err := m.current()
+	if err != nil {
+		return "", err
+	}
+	return keyring.active.ID, nil
+}
+
+// Sign 有効な鍵でクレームに署名し、ヘッダーにkidを設定したトークンを返す
+func (m *JWTKeyManager) Sign(claims jwt.Claims) (string, error) {
+	keyring, err := m.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(keyring.active.method, claims)
+	token.Header["kid"] = keyring.active.ID
+	return token.SignedString(keyring.active.signingKey)
+}
+
+// Keyfunc トークンのkidから検証に使用する鍵を返す（jwt.ParseWithClaimsに渡す）
+// kidのないトークンはkid=defaultの鍵で検証し、鍵と異なるアルゴリズムのトークンは拒否する
+func (m *JWTKeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	keyring, err := m.current()
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := DefaultJWTKeyID
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		keyID = kid
+	}
+	key, ok := keyring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid=%q", ErrJWTKeyNotFound, keyID)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("%w: kid=%q alg=%s", ErrJWTAlgorithmMismatch, keyID, token.Method.Alg())
+	}
+	if !key.verifiableAt(time.Now(), m.grace) {
+		return nil, fmt.Errorf("%w: kid=%q", ErrJWTKeyRetired, keyID)
+	}
+	return key.verifyKey, nil
+}
+
+// JSONWebKey JWKSの公開鍵（RFC 7517）
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`           // RSA / OKP
+	KeyID     string `json:"kid"`           // 鍵の識別子
+	Use       string `json:"use"`           // 用途（sig）
+	Algorithm string `json:"alg"`           // RS256 / EdDSA
+	N         string `json:"n,omitempty"`   // RSAの法（Base64URL）
+	E         string `json:"e,omitempty"`   // RSAの公開指数（Base64URL）
+	Curve     string `json:"crv,omitempty"` // OKPの曲線（Ed25519）
+	X         string `json:"x,omitempty"`   // Ed25519の公開鍵（Base64URL）
+}
+
+// JSONWebKeySet JWKS（公開鍵の一覧）
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS 検証に使用できる公開鍵の一覧を返す（HS256の共有鍵は含めない）
+// 有効な鍵を先頭に、退役した鍵も猶予期間中は含める
+func (m *JWTKeyManager) JWKS() (JSONWebKeySet, error) {
+	keyring, err := m.current()
+	if err != nil {
+		return JSONWebKeySet{}, err
+	}
+
+	now := time.Now()
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range keyring.sortedKeys() {
+		if !key.verifiableAt(now, m.grace) {
+			continue
+		}
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType: "RSA", KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm,
+				N: base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType: "OKP", KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm,
+				Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	return set, nil
+}
+
+// sortedKeys 有効な鍵を先頭に、残りをkidの順に並べた鍵の一覧
+func (k *jwtKeyring) sortedKeys() []*JWTKey {
+	keys := make([]*JWTKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == k.active) != (keys[j] == k.active) {
+			return keys[i] == k.active
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+// loadJWTKeyring キーリングを読み込む（pathが空の場合はJWT_SECRETの鍵のみ）
+// 猶予期間が過ぎた退役した鍵は読み込まない
+func loadJWTKeyring(path string, grace time.Duration, now time.Time) (*jwtKeyring, error) {
+	if path == "" {
+		return loadSecretKeyring()
+	}
+
+	keyring := &jwtKeyring{keys: make(map[string]*JWTKey), files: make(map[string]fileStamp)}
+	data, err := keyring.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg jwtKeyringConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.ID == "" {
+			return nil, fmt.Errorf("%s: kid is required", path)
+		}
+		if _, exists := keyring.keys[keyCfg.ID]; exists {
+			return nil, fmt.Errorf("%s: duplicate kid %q", path, keyCfg.ID)
+		}
+		if keyCfg.RetiredAt != nil && !now.Before(keyCfg.RetiredAt.Add(grace)) {
+			log.Printf("🔑 JWT key past grace period skipped: kid=%s", keyCfg.ID)
+			continue
+		}
+		key, err := keyring.newKey(keyCfg, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: kid %q: %w", path, keyCfg.ID, err)
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	active, ok := keyring.keys[cfg.Active]
+	if !ok {
+		return nil, fmt.Errorf("%s: active key %q not found", path, cfg.Active)
+	}
+	if active.RetiredAt != nil {
+		return nil, fmt.Errorf("%s: active key %q is retired", path, cfg.Active)
+	}
+	keyring.active = active
+	return keyring, nil
+}
+
+// loadSecretKeyring JWT_SECRET（またはDocker Secrets）の鍵をkid=defaultのHS256の鍵として読み込む
+func loadSecretKeyring() (*jwtKeyring, error) {
+	secret, err := GetJWTSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &JWTKey{ID: DefaultJWTKeyID, Algorithm: JWTAlgHS256, method: jwt.SigningMethodHS256, signingKey: secret, verifyKey: secret}
+	keyring := &jwtKeyring{active: key, keys: map[string]*JWTKey{key.ID: key}, files: make(map[string]fileStamp)}
+	if info, err := os.Stat(jwtSecretFile); err == nil {
+		keyring.files[jwtSecretFile] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	} else {
+		keyring.fromEnv = true
+		keyring.envSecret = os.Getenv("JWT_SECRET")
+	}
+	return keyring, nil
+}
+
+// readFile ファイルを読み込み、変更の検知のために更新日時とサイズを記録する
+func (k *jwtKeyring) readFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k.files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	return data, nil
+}
+
+// newKey 鍵の設定から署名鍵を読み込む（相対パスはキーリングのファイルからの相対パス）
+func (k *jwtKeyring) newKey(cfg jwtKeyConfig, baseDir string) (*JWTKey, error) {
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(baseDir, file)
+	}
+	key := &JWTKey{ID: cfg.ID, Algorithm: cfg.Algorithm, RetiredAt: cfg.RetiredAt}
+
+	switch cfg.Algorithm {
+	case JWTAlgHS256:
+		if cfg.SecretFile == "" {
+			return nil, errors.New("secret_file is required for HS256")
+		}
+		data, err := k.readFile(resolve(cfg.SecretFile))
+		if err != nil {
+			return nil, err
+		}
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) < minJWTSecretLength {
+			return nil, fmt.Errorf("HS256 secret must be at least %d characters long", minJWTSecretLength)
+		}
+		key.method, key.signingKey, key.verifyKey = jwt.SigningMethodHS256, secret, secret
+	case JWTAlgRS256:
+		data, err := k.readPrivateKeyFile(resolve(cfg.PrivateKeyFile))
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if privateKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method, key.signingKey, key.verifyKey = jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey
+	case JWTAlgEdDSA:
+		data, err := k.readPrivateKeyFile(resolve(cfg.PrivateKeyFile))
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA key must be an Ed25519 private key")
+		}
+		key.method, key.signingKey, key.verifyKey = jwt.SigningMethodEdDSA, privateKey, privateKey.Public().(ed25519.PublicKey)
+	default:
+		return nil, fmt.Errorf("unsupported alg %q (%s, %s, %s)", cfg.Algorithm, JWTAlgHS256, JWTAlgRS256, JWTAlgEdDSA)
+	}
+	return key, nil
+}
+
+// readPrivateKeyFile RS256・EdDSAの秘密鍵のファイルを読み込む
+func (k *jwtKeyring) readPrivateKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("private_key_file is required")
+	}
+	return k.readFile(path)
+}
+
+// グローバルな鍵の管理（起動時にSetJWTKeyManagerで設定、未設定の場合は初回の使用時に環境変数から作成）
+var (
+	jwtKeyManager      *JWTKeyManager
+	jwtKeyManagerMutex sync.RWMutex
+)
+
+// SetJWTKeyManager JWTの署名・検証に使用する鍵の管理を設定する
+func SetJWTKeyManager(manager *JWTKeyManager) {
+	jwtKeyManagerMutex.Lock()
+	defer jwtKeyManagerMutex.Unlock()
+	jwtKeyManager = manager
+}
+
+// CurrentJWTKeyManager 現在の鍵の管理を取得する
+func CurrentJWTKeyManager() (*JWTKeyManager, error) {
+	jwtKeyManagerMutex.RLock()
+	manager := jwtKeyManager
+	jwtKeyManagerMutex.RUnlock()
+	if manager != nil {
+		return manager, nil
+	}
+
+	jwtKeyManagerMutex.Lock()
+	defer jwtKeyManagerMutex.Unlock()
+	if jwtKeyManager == nil {
+		created, err := NewJWTKeyManagerFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		jwtKeyManager = created
+	}
+	return jwtKeyManager, nil
+}
+
+// ParseToken トークンをキーリングの鍵で検証してクレームを解析する
+func ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	manager, err := CurrentJWTKeyManager()
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseWithClaims(tokenString, claims, manager.Keyfunc)
+}
@@ -0,0 +1,283 @@
+// ========================================
+// JWT署名鍵の管理の自動テスト
+// kidによる鍵の選択、鍵のローテーションと猶予期間、アルゴリズムの不一致、JWKS、ファイルの再読み込みを確認
+// ========================================
+
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyringDir テスト用のキーリングのディレクトリ
+type keyringDir struct {
+	t    *testing.T
+	dir  string
+	path string
+}
+
+// newKeyringDir テスト用のキーリングのディレクトリを作成
+func newKeyringDir(t *testing.T) *keyringDir {
+	t.Helper()
+	dir := t.TempDir()
+	return &keyringDir{t: t, dir: dir, path: filepath.Join(dir, "jwt_keys.json")}
+}
+
+// writeFile ファイルを書き込み、変更として検知されるよう更新日時を進める
+func (k *keyringDir) writeFile(name string, data []byte) string {
+	k.t.Helper()
+	path := filepath.Join(k.dir, name)
+	require.NoError(k.t, os.WriteFile(path, data, 0o600))
+	later := time.Now().Add(time.Duration(len(data)) * time.Millisecond)
+	require.NoError(k.t, os.Chtimes(path, later, later))
+	return name
+}
+
+// writePrivateKey 秘密鍵をPKCS#8のPEMで書き込む
+func (k *keyringDir) writePrivateKey(name string, key interface{}) string {
+	k.t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(k.t, err)
+	return k.writeFile(name, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// writeKeyring キーリングのファイルを書き込む
+func (k *keyringDir) writeKeyring(cfg jwtKeyringConfig) {
+	k.t.Helper()
+	data, err := json.Marshal(cfg)
+	require.NoError(k.t, err)
+	k.writeFile(filepath.Base(k.path), data)
+}
+
+// parseWith 鍵の管理でトークンを検証する
+func parseWith(manager *JWTKeyManager, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, manager.Keyfunc); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// signTestClaims テスト用のクレームに署名する
+func signTestClaims(t *testing.T, manager *JWTKeyManager, userID uint) string {
+	t.Helper()
+	token, err := manager.Sign(&Claims{
+		UserID:           userID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	})
+	require.NoError(t, err)
+	return token
+}
+
+// TestJWTKeyManager_Rotation 有効な鍵で署名し、退役した鍵のトークンは猶予期間中のみ検証できることを検証
+func TestJWTKeyManager_Rotation(t *testing.T) {
+	keys := newKeyringDir(t)
+	secret := keys.writeFile("hs256.secret", []byte("first-signing-secret-for-rotation-test\n"))
+	keys.writeKeyring(jwtKeyringConfig{Active: "2026-01", Keys: []jwtKeyConfig{
+		{ID: "2026-01", Algorithm: JWTAlgHS256, SecretFile: secret},
+	}})
+	manager, err := NewJWTKeyManager(keys.path, time.Hour)
+	require.NoError(t, err)
+
+	oldToken := signTestClaims(t, manager, 1)
+	parsed, _, err := jwt.NewParser().ParseUnverified(oldToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01", parsed.Header["kid"])
+
+	// EdDSAの鍵に切り替え、以前の鍵は退役させる
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	retiredAt := time.Now()
+	keys.writeKeyring(jwtKeyringConfig{Active: "2026-07", Keys: []jwtKeyConfig{
+		{ID: "2026-07", Algorithm: JWTAlgEdDSA, PrivateKeyFile: keys.writePrivateKey("ed25519.pem", edKey)},
+		{ID: "2026-01", Algorithm: JWTAlgHS256, SecretFile: secret, RetiredAt: &retiredAt},
+	}})
+	reloaded, err := manager.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	newToken := signTestClaims(t, manager, 2)
+	parsed, _, err = jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-07", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	claims, err := parseWith(manager, newToken)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), claims.UserID)
+	claims, err = parseWith(manager, oldToken)
+	require.NoError(t, err, "retired key is accepted during the grace period")
+	assert.Equal(t, uint(1), claims.UserID)
+
+	// 猶予期間が過ぎた鍵のトークンは拒否する
+	expired := *manager.keyring.keys["2026-01"]
+	past := time.Now().Add(-2 * time.Hour)
+	expired.RetiredAt = &past
+	manager.keyring.keys["2026-01"] = &expired
+	_, err = parseWith(manager, oldToken)
+	assert.ErrorIs(t, err, ErrJWTKeyRetired)
+
+	// 変更がない場合は読み込み直さない
+	reloaded, err = manager.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+}
+
+// TestJWTKeyManager_RejectsUnknownKidAndAlgorithmMismatch 不明なkidと、鍵と異なるアルゴリズムのトークンを拒否することを検証
+func TestJWTKeyManager_RejectsUnknownKidAndAlgorithmMismatch(t *testing.T) {
+	keys := newKeyringDir(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys.writeKeyring(jwtKeyringConfig{Active: "rsa-1", Keys: []jwtKeyConfig{
+		{ID: "rsa-1", Algorithm: JWTAlgRS256, PrivateKeyFile: keys.writePrivateKey("rsa.pem", rsaKey)},
+	}})
+	manager, err := NewJWTKeyManager(keys.path, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := parseWith(manager, signTestClaims(t, manager, 7))
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+
+	// RS256の鍵のkidで、公開鍵を共有鍵としてHS256で署名したトークン
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1})
+	forged.Header["kid"] = "rsa-1"
+	forgedString, err := forged.SignedString(publicDER)
+	require.NoError(t, err)
+	_, err = parseWith(manager, forgedString)
+	assert.ErrorIs(t, err, ErrJWTAlgorithmMismatch)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, &Claims{UserID: 1})
+	unknown.Header["kid"] = "rsa-unknown"
+	unknownString, err := unknown.SignedString(rsaKey)
+	require.NoError(t, err)
+	_, err = parseWith(manager, unknownString)
+	assert.ErrorIs(t, err, ErrJWTKeyNotFound)
+
+	// kidのないトークンはkid=defaultの鍵で検証するため、キーリングにない場合は拒否する
+	noKid, err := jwt.NewWithClaims(jwt.SigningMethodRS256, &Claims{UserID: 1}).SignedString(rsaKey)
+	require.NoError(t, err)
+	_, err = parseWith(manager, noKid)
+	assert.ErrorIs(t, err, ErrJWTKeyNotFound)
+}
+
+// TestJWTKeyManager_JWKS 公開鍵の一覧にRS256・EdDSAの鍵を含め、HS256の共有鍵を含めないことを検証
+func TestJWTKeyManager_JWKS(t *testing.T) {
+	keys := newKeyringDir(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	retiredAt := time.Now()
+	keys.writeKeyring(jwtKeyringConfig{Active: "ed-2", Keys: []jwtKeyConfig{
+		{ID: "rsa-1", Algorithm: JWTAlgRS256, PrivateKeyFile: keys.writePrivateKey("rsa.pem", rsaKey), RetiredAt: &retiredAt},
+		{ID: "ed-2", Algorithm: JWTAlgEdDSA, PrivateKeyFile: keys.writePrivateKey("ed25519.pem", edKey)},
+		{ID: "hs-0", Algorithm: JWTAlgHS256, SecretFile: keys.writeFile("hs256.secret", []byte("shared-secret-that-must-not-be-published")), RetiredAt: &retiredAt},
+	}})
+	manager, err := NewJWTKeyManager(keys.path, time.Hour)
+	require.NoError(t, err)
+
+	set, err := manager.JWKS()
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+
+	// 有効な鍵が先頭
+	ed := set.Keys[0]
+	assert.Equal(t, JSONWebKey{KeyType: "OKP", KeyID: "ed-2", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519",
+		X: base64.RawURLEncoding.EncodeToString(edPublic)}, ed)
+
+	rsaJWK := set.Keys[1]
+	assert.Equal(t, "RSA", rsaJWK.KeyType)
+	assert.Equal(t, "rsa-1", rsaJWK.KeyID)
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	require.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(rsaJWK.E)
+	require.NoError(t, err)
+	assert.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaKey.N))
+	assert.Equal(t, int64(rsaKey.E), new(big.Int).SetBytes(e).Int64())
+}
+
+// TestJWTKeyManager_InvalidKeyring 不正なキーリングは読み込まず、再読み込みに失敗した場合はそれまでの鍵を使い続けることを検証
+func TestJWTKeyManager_InvalidKeyring(t *testing.T) {
+	keys := newKeyringDir(t)
+	secret := keys.writeFile("hs256.secret", []byte("valid-signing-secret-with-enough-length"))
+	shortSecret := keys.writeFile("short.secret", []byte("too-short"))
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallRSAFile := keys.writePrivateKey("small-rsa.pem", smallRSA)
+
+	cases := map[string]jwtKeyringConfig{
+		"active not found": {Active: "missing", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: secret}}},
+		"duplicate kid": {Active: "k1", Keys: []jwtKeyConfig{
+			{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: secret},
+			{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: secret},
+		}},
+		"short secret":      {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: shortSecret}}},
+		"small rsa key":     {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgRS256, PrivateKeyFile: smallRSAFile}}},
+		"unsupported alg":   {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: "none", SecretFile: secret}}},
+		"missing key file":  {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgEdDSA}}},
+		"wrong key type":    {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgEdDSA, PrivateKeyFile: smallRSAFile}}},
+		"active is retired": {Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: secret, RetiredAt: new(time.Time)}}},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			keys.writeKeyring(cfg)
+			_, err := NewJWTKeyManager(keys.path, time.Hour)
+			assert.Error(t, err)
+		})
+	}
+
+	keys.writeKeyring(jwtKeyringConfig{Active: "k1", Keys: []jwtKeyConfig{{ID: "k1", Algorithm: JWTAlgHS256, SecretFile: secret}}})
+	manager, err := NewJWTKeyManager(keys.path, time.Hour)
+	require.NoError(t, err)
+	token := signTestClaims(t, manager, 3)
+
+	keys.writeFile(filepath.Base(keys.path), []byte("{not json"))
+	reloaded, err := manager.ReloadIfChanged()
+	assert.True(t, reloaded)
+	assert.Error(t, err)
+	_, err = parseWith(manager, token)
+	assert.NoError(t, err)
+}
+
+// TestJWTKeyManager_SecretFallback キーリングがない場合はJWT_SECRETの鍵を使用し、kidのない従来のトークンも検証できることを検証
+func TestJWTKeyManager_SecretFallback(t *testing.T) {
+	t.Setenv("JWT_SECRET", "fallback-secret-for-key-manager-tests")
+	manager, err := NewJWTKeyManager("", time.Hour)
+	require.NoError(t, err)
+
+	activeID, err := manager.ActiveKeyID()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultJWTKeyID, activeID)
+
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 5}).SignedString([]byte("fallback-secret-for-key-manager-tests"))
+	require.NoError(t, err)
+	claims, err := parseWith(manager, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), claims.UserID)
+
+	// 環境変数を変更した場合は新しい鍵を使用する
+	t.Setenv("JWT_SECRET", "rotated-secret-for-key-manager-tests-2")
+	_, err = parseWith(manager, legacy)
+	assert.True(t, errors.Is(err, jwt.ErrTokenSignatureInvalid))
+	claims, err = parseWith(manager, signTestClaims(t, manager, 6))
+	require.NoError(t, err)
+	assert.Equal(t, uint(6), claims.UserID)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// SessionActivityFlusher セッションごとの最終利用日時をまとめて保存する関数
+type SessionActivityFlusher func(lastSeen map[string]time.Time) error
+
+// sessionActivityBuffer 認証済みリクエストのセッションの最終利用日時を一定間隔でまとめて保存する
+// リクエストごとにはメモリ上の記録のみ行い、データベースの更新はセッションごとに1間隔あたり1回にする
+type sessionActivityBuffer struct {
+	pending map[string]time.Time
+	flusher SessionActivityFlusher
+	mutex   sync.Mutex
+}
+
+// グローバルなセッション利用記録（StartSessionActivityFlusherで保存先を設定するまでは記録しない）
+var globalSessionActivity = &sessionActivityBuffer{
+	pending: make(map[string]time.Time),
+}
+
+// StartSessionActivityFlusher セッションの最終利用日時をinterval間隔で保存する
+// 戻り値の関数で停止し、未保存の記録を保存する
+func StartSessionActivityFlusher(flusher SessionActivityFlusher, interval time.Duration) (stop func()) {
+	globalSessionActivity.mutex.Lock()
+	globalSessionActivity.flusher = flusher
+	globalSessionActivity.mutex.Unlock()
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				globalSessionActivity.flush()
+			case <-done:
+				globalSessionActivity.flush()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+
+			globalSessionActivity.mutex.Lock()
+			globalSessionActivity.flusher = nil
+			globalSessionActivity.mutex.Unlock()
+		})
+	}
+}
+
+// recordSessionActivity セッションの最終利用日時を記録する（保存先が未設定の場合は何もしない）
+func recordSessionActivity(sessionID string, now time.Time) {
+	globalSessionActivity.mutex.Lock()
+	defer globalSessionActivity.mutex.Unlock()
+
+	if globalSessionActivity.flusher == nil {
+		return
+	}
+	if last, exists := globalSessionActivity.pending[sessionID]; !exists || now.After(last) {
+		globalSessionActivity.pending[sessionID] = now
+	}
+}
+
+// flush 記録した最終利用日時を保存する（保存に失敗した記録は次の間隔で再試行する）
+func (b *sessionActivityBuffer) flush() {
+	b.mutex.Lock()
+	if len(b.pending) == 0 || b.flusher == nil {
+		b.mutex.Unlock()
+		return
+	}
+	batch := b.pending
+	flusher := b.flusher
+	b.pending = make(map[string]time.Time)
+	b.mutex.Unlock()
+
+	if err := flusher(batch); err != nil {
+		log.Printf("❌ Session last-seen flush failed: sessions=%d error=%v", len(batch), err)
+
+		b.mutex.Lock()
+		for sessionID, lastSeen := range batch {
+			if current, exists := b.pending[sessionID]; !exists || lastSeen.After(current) {
+				b.pending[sessionID] = lastSeen
+			}
+		}
+		b.mutex.Unlock()
+	}
+}
@@ -0,0 +1,48 @@
+// ========================================
+// セッションの最終利用日時の記録の自動テスト
+// ========================================
+
+package middleware
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSessionActivity_BatchesAndRetries 最終利用日時がセッションごとに最新の1件にまとめられ、保存の失敗時は次回に再試行されることを検証
+func TestSessionActivity_BatchesAndRetries(t *testing.T) {
+	var batches []map[string]time.Time
+	fail := true
+	stop := StartSessionActivityFlusher(func(lastSeen map[string]time.Time) error {
+		if fail {
+			fail = false
+			return errors.New("database unavailable")
+		}
+		batches = append(batches, lastSeen)
+		return nil
+	}, time.Hour)
+
+	base := time.Now()
+	recordSessionActivity("session-a", base)
+	recordSessionActivity("session-a", base.Add(2*time.Second))
+	recordSessionActivity("session-a", base.Add(time.Second))
+	recordSessionActivity("session-b", base)
+
+	globalSessionActivity.flush() // 失敗（記録は保持される）
+	assert.Empty(t, batches)
+
+	stop() // 停止時に再試行
+	if assert.Len(t, batches, 1) {
+		assert.Equal(t, map[string]time.Time{
+			"session-a": base.Add(2 * time.Second),
+			"session-b": base,
+		}, batches[0])
+	}
+
+	// 停止後は記録しない
+	recordSessionActivity("session-c", base)
+	assert.Empty(t, globalSessionActivity.pending)
+}
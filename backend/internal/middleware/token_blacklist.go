@@ -1,136 +1,271 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/config"
 )
 
 // BlacklistedToken ブラックリストに登録されたトークンの情報
+// トークン本体は保存せず、SHA-256のハッシュのみを保持する
 type BlacklistedToken struct {
-	Token     string
-	ExpiresAt time.Time
-	Reason    string
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Reason    string    `json:"reason"`
 }
 
-// TokenBlacklist JWTトークンのブラックリスト管理
-type TokenBlacklist struct {
-	tokens map[string]*BlacklistedToken
-	mutex  sync.RWMutex
+// BlacklistStatus ブラックリストの状況（監視用）
+type BlacklistStatus struct {
+	TotalTokens   int // 登録件数
+	ValidTokens   int // 有効期限内の件数
+	ExpiredTokens int // 有効期限切れ（クリーンアップ待ち）の件数
 }
 
-// グローバルなブラックリストインスタンス
-var globalBlacklist = &TokenBlacklist{
-	tokens: make(map[string]*BlacklistedToken),
+// TokenBlacklistStore トークンのブラックリストの保存先
+// トークンはhashBlacklistKeyでハッシュ化した値で渡される
+type TokenBlacklistStore interface {
+	// Add トークンを有効期限まで登録する（登録済みの場合は上書き）
+	Add(tokenHash string, expiresAt time.Time, reason string) error
+	// Contains トークンが有効期限内で登録されているかチェック
+	Contains(tokenHash string, now time.Time) (bool, error)
+	// CleanupExpired 有効期限切れのトークンを削除し、削除件数を返す
+	CleanupExpired(now time.Time) (int, error)
+	// Status ブラックリストの状況を返す
+	Status(now time.Time) (BlacklistStatus, error)
+	// Backend 保存先の種類（memory, database, file）
+	Backend() string
 }
 
+// ブラックリストの保存先の種類（TOKEN_BLACKLIST_BACKEND）
+const (
+	BlacklistBackendMemory   = "memory"   // メモリ（再起動で消え、レプリカ間で共有されない）
+	BlacklistBackendDatabase = "database" // データベースのテーブル（永続化・レプリカ間で共有）
+	BlacklistBackendFile     = "file"     // ファイル（永続化のみ、単一インスタンス用）
+
+	defaultBlacklistFile = "data/token_blacklist.jsonl" // ファイル保存の既定のパス
+)
+
+// グローバルなブラックリストの保存先（起動時にSetTokenBlacklistStoreで差し替える）
+var (
+	globalBlacklist      TokenBlacklistStore = NewMemoryTokenBlacklist()
+	globalBlacklistMutex sync.RWMutex
+)
+
 func init() {
 	// 定期的にexpiredトークンをクリーンアップ（10分間隔）
-	go globalBlacklist.startCleanup()
+	go startBlacklistCleanup(10 * time.Minute)
 }
 
-// AddToBlacklist トークンをブラックリストに追加
-func (tb *TokenBlacklist) AddToBlacklist(token string, expiresAt time.Time, reason string) {
-	tb.mutex.Lock()
-	defer tb.mutex.Unlock()
+// SetTokenBlacklistStore グローバルなブラックリストの保存先を設定する
+func SetTokenBlacklistStore(store TokenBlacklistStore) {
+	globalBlacklistMutex.Lock()
+	defer globalBlacklistMutex.Unlock()
+	globalBlacklist = store
+}
 
-	tb.tokens[token] = &BlacklistedToken{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		Reason:    reason,
-	}
+// currentBlacklist 現在のブラックリストの保存先を取得する
+func currentBlacklist() TokenBlacklistStore {
+	globalBlacklistMutex.RLock()
+	defer globalBlacklistMutex.RUnlock()
+	return globalBlacklist
 }
 
-// IsBlacklisted トークンがブラックリストに含まれているかチェック
-func (tb *TokenBlacklist) IsBlacklisted(token string) bool {
-	tb.mutex.RLock()
-	defer tb.mutex.RUnlock()
+// NewTokenBlacklistStoreFromEnv 環境変数の設定からブラックリストの保存先を作成する
+// TOKEN_BLACKLIST_BACKEND: database（既定）/ memory / file
+// TOKEN_BLACKLIST_FILE: fileの場合の保存先のパス
+func NewTokenBlacklistStoreFromEnv(db *gorm.DB) (TokenBlacklistStore, error) {
+	backend := strings.ToLower(config.GetStringEnv("TOKEN_BLACKLIST_BACKEND", BlacklistBackendDatabase))
+	switch backend {
+	case BlacklistBackendDatabase:
+		return NewDBTokenBlacklist(db), nil
+	case BlacklistBackendMemory:
+		log.Println("⚠️ Token blacklist is in memory: logged-out tokens become valid again after restart")
+		return NewMemoryTokenBlacklist(), nil
+	case BlacklistBackendFile:
+		return NewFileTokenBlacklist(config.GetStringEnv("TOKEN_BLACKLIST_FILE", defaultBlacklistFile))
+	default:
+		return nil, fmt.Errorf("unknown TOKEN_BLACKLIST_BACKEND: %q (memory, database, file)", backend)
+	}
+}
 
-	blacklistedToken, exists := tb.tokens[token]
-	if !exists {
-		return false
+// startBlacklistCleanup 定期的に有効期限切れのトークンをクリーンアップ
+func startBlacklistCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if _, err := currentBlacklist().CleanupExpired(time.Now()); err != nil {
+			log.Printf("❌ Token blacklist cleanup failed: %v", err)
+		}
 	}
+}
+
+// hashBlacklistKey ブラックリストに保存するキー（トークンのSHA-256ハッシュ、16進数）
+func hashBlacklistKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
 
-	// 有効期限が切れている場合はブラックリストから削除
-	if time.Now().After(blacklistedToken.ExpiresAt) {
-		go tb.removeExpiredToken(token)
-		return false
+// addToBlacklist キーをハッシュ化してブラックリストに登録する
+// 登録に失敗した場合はトークンが有効なまま残るため、エラーを返す
+func addToBlacklist(key string, expiresAt time.Time, reason string) error {
+	store := currentBlacklist()
+	if err := store.Add(hashBlacklistKey(key), expiresAt, reason); err != nil {
+		log.Printf("❌ Token blacklist add failed: backend=%s error=%v", store.Backend(), err)
+		return err
 	}
+	return nil
+}
 
-	return true
+// isBlacklisted キーがブラックリストに含まれているかチェック
+// 保存先の障害時は安全側に倒して、ブラックリストに含まれているものとして扱う
+func isBlacklisted(key string) bool {
+	store := currentBlacklist()
+	blacklisted, err := store.Contains(hashBlacklistKey(key), time.Now())
+	if err != nil {
+		log.Printf("❌ Token blacklist lookup failed: backend=%s error=%v", store.Backend(), err)
+		return true
+	}
+	return blacklisted
 }
 
-// removeExpiredToken 有効期限切れのトークンを削除（非同期実行用）
-func (tb *TokenBlacklist) removeExpiredToken(token string) {
-	tb.mutex.Lock()
-	defer tb.mutex.Unlock()
-	delete(tb.tokens, token)
+// ========================================
+// メモリ上のブラックリスト
+// ========================================
+
+// MemoryTokenBlacklist メモリ上のブラックリスト（テスト・単一インスタンスの開発環境用）
+type MemoryTokenBlacklist struct {
+	tokens map[string]*BlacklistedToken
+	mutex  sync.RWMutex
 }
 
-// startCleanup 定期的に有効期限切れのトークンをクリーンアップ
-func (tb *TokenBlacklist) startCleanup() {
-	ticker := time.NewTicker(10 * time.Minute)
-	for range ticker.C {
-		tb.cleanupExpiredTokens()
+// NewMemoryTokenBlacklist メモリ上のブラックリストを作成
+func NewMemoryTokenBlacklist() *MemoryTokenBlacklist {
+	return &MemoryTokenBlacklist{
+		tokens: make(map[string]*BlacklistedToken),
 	}
 }
 
-// cleanupExpiredTokens 有効期限切れのトークンをすべてクリーンアップ
-func (tb *TokenBlacklist) cleanupExpiredTokens() {
+// Add トークンをブラックリストに追加
+func (tb *MemoryTokenBlacklist) Add(tokenHash string, expiresAt time.Time, reason string) error {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
 
-	now := time.Now()
-	for token, blacklistedToken := range tb.tokens {
-		if now.After(blacklistedToken.ExpiresAt) {
-			delete(tb.tokens, token)
-		}
+	tb.tokens[tokenHash] = &BlacklistedToken{
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
 	}
+	return nil
 }
 
-// GetBlacklistSize ブラックリストのサイズを取得（監視用）
-func (tb *TokenBlacklist) GetBlacklistSize() int {
+// Contains トークンがブラックリストに含まれているかチェック（有効期限切れは含まない）
+func (tb *MemoryTokenBlacklist) Contains(tokenHash string, now time.Time) (bool, error) {
 	tb.mutex.RLock()
 	defer tb.mutex.RUnlock()
-	return len(tb.tokens)
+
+	blacklistedToken, exists := tb.tokens[tokenHash]
+	return exists && now.Before(blacklistedToken.ExpiresAt), nil
+}
+
+// CleanupExpired 有効期限切れのトークンをすべてクリーンアップ
+func (tb *MemoryTokenBlacklist) CleanupExpired(now time.Time) (int, error) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	removed := 0
+	for tokenHash, blacklistedToken := range tb.tokens {
+		if !now.Before(blacklistedToken.ExpiresAt) {
+			delete(tb.tokens, tokenHash)
+			removed++
+		}
+	}
+	return removed, nil
 }
 
-// GetBlacklistStatus ブラックリストの状況を取得（監視用）
-func (tb *TokenBlacklist) GetBlacklistStatus() map[string]interface{} {
+// Status ブラックリストの状況を取得（監視用）
+func (tb *MemoryTokenBlacklist) Status(now time.Time) (BlacklistStatus, error) {
 	tb.mutex.RLock()
 	defer tb.mutex.RUnlock()
 
-	validTokens := 0
-	expiredTokens := 0
-	now := time.Now()
-
+	status := BlacklistStatus{TotalTokens: len(tb.tokens)}
 	for _, blacklistedToken := range tb.tokens {
-		if now.After(blacklistedToken.ExpiresAt) {
-			expiredTokens++
+		if now.Before(blacklistedToken.ExpiresAt) {
+			status.ValidTokens++
 		} else {
-			validTokens++
+			status.ExpiredTokens++
 		}
 	}
+	return status, nil
+}
 
-	return map[string]interface{}{
-		"total_tokens":   len(tb.tokens),
-		"valid_tokens":   validTokens,
-		"expired_tokens": expiredTokens,
+// Backend 保存先の種類
+func (tb *MemoryTokenBlacklist) Backend() string {
+	return BlacklistBackendMemory
+}
+
+// snapshot 有効期限内のトークンの一覧を返す（ファイル保存用）
+func (tb *MemoryTokenBlacklist) snapshot(now time.Time) []BlacklistedToken {
+	tb.mutex.RLock()
+	defer tb.mutex.RUnlock()
+
+	tokens := make([]BlacklistedToken, 0, len(tb.tokens))
+	for _, blacklistedToken := range tb.tokens {
+		if now.Before(blacklistedToken.ExpiresAt) {
+			tokens = append(tokens, *blacklistedToken)
+		}
 	}
+	return tokens
 }
 
 // グローバル関数（外部から簡単にアクセス可能）
 
 // AddTokenToBlacklist トークンをグローバルブラックリストに追加
-func AddTokenToBlacklist(token string, expiresAt time.Time, reason string) {
-	globalBlacklist.AddToBlacklist(token, expiresAt, reason)
+// 保存先への登録に失敗した場合はエラーを返す（ログには記録済みのため、無視する呼び出し元は戻り値を捨ててよい）
+func AddTokenToBlacklist(token string, expiresAt time.Time, reason string) error {
+	return addToBlacklist(token, expiresAt, reason)
 }
 
 // IsTokenBlacklisted トークンがブラックリストに含まれているかチェック
 func IsTokenBlacklisted(token string) bool {
-	return globalBlacklist.IsBlacklisted(token)
+	return isBlacklisted(token)
+}
+
+// tokenIDKeyPrefix トークンIDで登録する場合のキーの接頭辞（トークン文字列と区別する）
+const tokenIDKeyPrefix = "jti:"
+
+// AddTokenIDToBlacklist トークンID（jti）をグローバルブラックリストに追加
+// トークン文字列を保持していない発行済みトークン（リフレッシュで発行したアクセストークン等）の失効に使用する
+func AddTokenIDToBlacklist(tokenID string, expiresAt time.Time, reason string) error {
+	return addToBlacklist(tokenIDKeyPrefix+tokenID, expiresAt, reason)
+}
+
+// IsTokenIDBlacklisted トークンID（jti）がブラックリストに含まれているかチェック
+func IsTokenIDBlacklisted(tokenID string) bool {
+	return isBlacklisted(tokenIDKeyPrefix + tokenID)
 }
 
 // GetTokenBlacklistStatus ブラックリストの状況を取得
 func GetTokenBlacklistStatus() map[string]interface{} {
-	return globalBlacklist.GetBlacklistStatus()
+	store := currentBlacklist()
+	status, err := store.Status(time.Now())
+	if err != nil {
+		return map[string]interface{}{
+			"backend": store.Backend(),
+			"error":   "ブラックリストの状況を取得できません",
+		}
+	}
+
+	return map[string]interface{}{
+		"backend":        store.Backend(),
+		"total_tokens":   status.TotalTokens,
+		"valid_tokens":   status.ValidTokens,
+		"expired_tokens": status.ExpiredTokens,
+	}
 }
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"money_management/internal/models"
+)
+
+// DBTokenBlacklist データベースのテーブルに保存するブラックリスト
+// 再起動後も有効で、同じデータベースを使用する全てのレプリカで共有される
+type DBTokenBlacklist struct {
+	db *gorm.DB
+}
+
+// NewDBTokenBlacklist データベースのブラックリストを作成
+func NewDBTokenBlacklist(db *gorm.DB) *DBTokenBlacklist {
+	return &DBTokenBlacklist{db: db}
+}
+
+// Add トークンをブラックリストに追加（登録済みの場合は有効期限と理由を更新）
+func (tb *DBTokenBlacklist) Add(tokenHash string, expiresAt time.Time, reason string) error {
+	entry := models.TokenBlacklistEntry{
+		TokenHash: tokenHash,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	}
+	return tb.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "expires_at"}),
+	}).Create(&entry).Error
+}
+
+// Contains トークンがブラックリストに含まれているかチェック（有効期限切れは含まない）
+func (tb *DBTokenBlacklist) Contains(tokenHash string, now time.Time) (bool, error) {
+	var count int64
+	err := tb.db.Model(&models.TokenBlacklistEntry{}).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CleanupExpired 有効期限切れのトークンをすべて削除
+func (tb *DBTokenBlacklist) CleanupExpired(now time.Time) (int, error) {
+	result := tb.db.Where("expires_at <= ?", now).Delete(&models.TokenBlacklistEntry{})
+	return int(result.RowsAffected), result.Error
+}
+
+// Status ブラックリストの状況を取得（監視用）
+func (tb *DBTokenBlacklist) Status(now time.Time) (BlacklistStatus, error) {
+	var total, valid int64
+	if err := tb.db.Model(&models.TokenBlacklistEntry{}).Count(&total).Error; err != nil {
+		return BlacklistStatus{}, err
+	}
+	if err := tb.db.Model(&models.TokenBlacklistEntry{}).Where("expires_at > ?", now).Count(&valid).Error; err != nil {
+		return BlacklistStatus{}, err
+	}
+	return BlacklistStatus{
+		TotalTokens:   int(total),
+		ValidTokens:   int(valid),
+		ExpiredTokens: int(total - valid),
+	}, nil
+}
+
+// Backend 保存先の種類
+func (tb *DBTokenBlacklist) Backend() string {
+	return BlacklistBackendDatabase
+}
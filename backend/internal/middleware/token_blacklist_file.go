@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileTokenBlacklist ファイルに保存するブラックリスト（単一インスタンス用）
+// メモリ上のブラックリストに加えて1件ごとにJSON Lines形式で追記し、起動時に読み込む
+// 再起動後も有効だが、レプリカ間では共有されない
+type FileTokenBlacklist struct {
+	*MemoryTokenBlacklist
+	path  string
+	mutex sync.Mutex // ファイルへの書き込みの排他制御
+}
+
+// NewFileTokenBlacklist ファイルのブラックリストを作成し、保存済みのトークンを読み込む
+func NewFileTokenBlacklist(path string) (*FileTokenBlacklist, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	tb := &FileTokenBlacklist{
+		MemoryTokenBlacklist: NewMemoryTokenBlacklist(),
+		path:                 path,
+	}
+	if err := tb.load(); err != nil {
+		return nil, err
+	}
+	return tb, nil
+}
+
+// load ファイルから保存済みのトークンを読み込む（ファイルがない場合は空）
+func (tb *FileTokenBlacklist) load() error {
+	file, err := os.Open(tb.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry BlacklistedToken
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", tb.path, line, err)
+		}
+		_ = tb.MemoryTokenBlacklist.Add(entry.TokenHash, entry.ExpiresAt, entry.Reason)
+	}
+	return scanner.Err()
+}
+
+// Add トークンをブラックリストに追加し、ファイルに追記する
+func (tb *FileTokenBlacklist) Add(tokenHash string, expiresAt time.Time, reason string) error {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	_ = tb.MemoryTokenBlacklist.Add(tokenHash, expiresAt, reason)
+
+	line, err := json.Marshal(BlacklistedToken{TokenHash: tokenHash, ExpiresAt: expiresAt, Reason: reason})
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(tb.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// CleanupExpired 有効期限切れのトークンを削除し、有効なトークンのみでファイルを書き直す
+func (tb *FileTokenBlacklist) CleanupExpired(now time.Time) (int, error) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+
+	removed, _ := tb.MemoryTokenBlacklist.CleanupExpired(now)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// 一時ファイルに書き出してから置き換える（書き込み途中で停止しても元のファイルが残る）
+	tmp, err := os.CreateTemp(filepath.Dir(tb.path), filepath.Base(tb.path)+".tmp*")
+	if err != nil {
+		return removed, err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range tb.snapshot(now) {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return removed, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return removed, err
+	}
+	if err := tmp.Close(); err != nil {
+		return removed, err
+	}
+	return removed, os.Rename(tmp.Name(), tb.path)
+}
+
+// Backend 保存先の種類
+func (tb *FileTokenBlacklist) Backend() string {
+	return BlacklistBackendFile
+}
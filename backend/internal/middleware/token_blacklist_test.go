@@ -0,0 +1,131 @@
+// ========================================
+// トークンのブラックリストの自動テスト
+// メモリ・データベース・ファイルの各保存先で同じ振る舞いになることを検証
+// ========================================
+
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/database"
+)
+
+// blacklistStores テスト対象の全ての保存先を作成する
+func blacklistStores(t *testing.T) map[string]TokenBlacklistStore {
+	t.Helper()
+
+	db, err := database.SetupInMemoryTestDB(t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { database.CleanupInMemoryTestDB(db, t.Name()) })
+
+	fileStore, err := NewFileTokenBlacklist(filepath.Join(t.TempDir(), "blacklist.jsonl"))
+	require.NoError(t, err)
+
+	return map[string]TokenBlacklistStore{
+		BlacklistBackendMemory:   NewMemoryTokenBlacklist(),
+		BlacklistBackendDatabase: NewDBTokenBlacklist(db),
+		BlacklistBackendFile:     fileStore,
+	}
+}
+
+// TestTokenBlacklistStores_AddContainsCleanup 登録・有効期限・クリーンアップが全ての保存先で同じに動作することを検証
+func TestTokenBlacklistStores_AddContainsCleanup(t *testing.T) {
+	now := time.Now()
+	for backend, store := range blacklistStores(t) {
+		t.Run(backend, func(t *testing.T) {
+			assert.Equal(t, backend, store.Backend())
+
+			require.NoError(t, store.Add("active", now.Add(time.Hour), "user_logout"))
+			require.NoError(t, store.Add("expired", now.Add(-time.Minute), "user_logout"))
+
+			contains, err := store.Contains("active", now)
+			require.NoError(t, err)
+			assert.True(t, contains)
+			contains, err = store.Contains("expired", now)
+			require.NoError(t, err)
+			assert.False(t, contains, "有効期限切れのトークンは含まない")
+			contains, err = store.Contains("unknown", now)
+			require.NoError(t, err)
+			assert.False(t, contains)
+
+			// 再登録で有効期限を更新できる
+			require.NoError(t, store.Add("expired", now.Add(time.Hour), "reuse_detected"))
+			contains, err = store.Contains("expired", now)
+			require.NoError(t, err)
+			assert.True(t, contains)
+			require.NoError(t, store.Add("expired", now.Add(-time.Minute), "user_logout"))
+
+			status, err := store.Status(now)
+			require.NoError(t, err)
+			assert.Equal(t, BlacklistStatus{TotalTokens: 2, ValidTokens: 1, ExpiredTokens: 1}, status)
+
+			removed, err := store.CleanupExpired(now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, removed)
+			status, err = store.Status(now)
+			require.NoError(t, err)
+			assert.Equal(t, BlacklistStatus{TotalTokens: 1, ValidTokens: 1}, status)
+		})
+	}
+}
+
+// TestFileTokenBlacklist_Persistence ファイルの保存先が再作成後も登録済みのトークンを保持し、ハッシュのみを保存することを検証
+func TestFileTokenBlacklist_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.jsonl")
+	now := time.Now()
+
+	store, err := NewFileTokenBlacklist(path)
+	require.NoError(t, err)
+	SetTokenBlacklistStore(store)
+	t.Cleanup(func() { SetTokenBlacklistStore(NewMemoryTokenBlacklist()) })
+
+	require.NoError(t, AddTokenToBlacklist("raw.jwt.token", now.Add(time.Hour), "user_logout"))
+	require.NoError(t, AddTokenToBlacklist("old.jwt.token", now.Add(-time.Hour), "user_logout"))
+	assert.True(t, IsTokenBlacklisted("raw.jwt.token"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "raw.jwt.token", "トークン本体は保存しない")
+	assert.Contains(t, string(content), hashBlacklistKey("raw.jwt.token"))
+
+	// 再起動後も有効
+	reopened, err := NewFileTokenBlacklist(path)
+	require.NoError(t, err)
+	contains, err := reopened.Contains(hashBlacklistKey("raw.jwt.token"), now)
+	require.NoError(t, err)
+	assert.True(t, contains)
+
+	// クリーンアップで有効期限切れの行を削除してファイルを書き直す
+	removed, err := reopened.CleanupExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\n"))
+}
+
+// TestNewTokenBlacklistStoreFromEnv 環境変数で保存先を選択できることを検証
+func TestNewTokenBlacklistStoreFromEnv(t *testing.T) {
+	t.Setenv("TOKEN_BLACKLIST_BACKEND", "memory")
+	store, err := NewTokenBlacklistStoreFromEnv(nil)
+	require.NoError(t, err)
+	assert.Equal(t, BlacklistBackendMemory, store.Backend())
+
+	t.Setenv("TOKEN_BLACKLIST_BACKEND", "file")
+	t.Setenv("TOKEN_BLACKLIST_FILE", filepath.Join(t.TempDir(), "nested", "blacklist.jsonl"))
+	store, err = NewTokenBlacklistStoreFromEnv(nil)
+	require.NoError(t, err)
+	assert.Equal(t, BlacklistBackendFile, store.Backend())
+
+	t.Setenv("TOKEN_BLACKLIST_BACKEND", "redis")
+	_, err = NewTokenBlacklistStoreFromEnv(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenVersionLookup ユーザーの現在のトークン世代を返す関数
+// ユーザーが存在しない場合はエラーを返す
+type TokenVersionLookup func(userID uint) (uint, error)
+
+const (
+	// defaultTokenVersionCacheTTL トークン世代をキャッシュする既定の期間
+	defaultTokenVersionCacheTTL = 30 * time.Second
+	// maxTokenVersionCacheEntries キャッシュするユーザー数の上限（超えた場合は期限切れの項目を削除する）
+	maxTokenVersionCacheEntries = 10000
+)
+
+// tokenVersionCacheEntry キャッシュしたトークン世代
+type tokenVersionCacheEntry struct {
+	version   uint
+	expiresAt time.Time
+}
+
+// トークン世代の参照先（起動時にSetTokenVersionLookupで設定する）
+// 認証のたびにDBを参照しないよう、ユーザーごとの世代を短時間キャッシュする
+var (
+	tokenVersionLookup   TokenVersionLookup
+	tokenVersionCacheTTL = defaultTokenVersionCacheTTL
+	tokenVersionCache    = map[uint]tokenVersionCacheEntry{}
+	tokenVersionEpoch    uint64 // キャッシュを破棄するたびに進める（参照中に破棄された古い世代を保存しないため）
+	tokenVersionMutex    sync.RWMutex
+)
+
+// SetTokenVersionLookup AuthMiddlewareが使用するトークン世代の参照先を設定する
+// 未設定の場合はトークン世代を検証しない（nilを指定すると検証を無効にする）
+// 参照先を変更するとキャッシュは破棄する
+func SetTokenVersionLookup(lookup TokenVersionLookup) {
+	tokenVersionMutex.Lock()
+	defer tokenVersionMutex.Unlock()
+	tokenVersionLookup = lookup
+	tokenVersionCache = map[uint]tokenVersionCacheEntry{}
+	tokenVersionEpoch++
+}
+
+// SetTokenVersionCacheTTL トークン世代をキャッシュする期間を設定する（0以下でキャッシュしない）
+// 世代を進めたサーバーではすぐに破棄されるが、複数台構成の他のサーバーではこの期間だけ古い世代が使われる
+func SetTokenVersionCacheTTL(ttl time.Duration) {
+	tokenVersionMutex.Lock()
+	defer tokenVersionMutex.Unlock()
+	tokenVersionCacheTTL = ttl
+	tokenVersionCache = map[uint]tokenVersionCacheEntry{}
+	tokenVersionEpoch++
+}
+
+// InvalidateTokenVersion ユーザーのトークン世代のキャッシュを破棄する（世代を進めた時に呼び出す）
+func InvalidateTokenVersion(userID uint) {
+	tokenVersionMutex.Lock()
+	defer tokenVersionMutex.Unlock()
+	delete(tokenVersionCache, userID)
+	tokenVersionEpoch++
+}
+
+// checkTokenVersion トークンのトークン世代がユーザーの現在の世代と一致するか判定する
+func checkTokenVersion(claims *Claims) (bool, error) {
+	current, ok, err := currentTokenVersion(claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return true, nil
+	}
+	return claims.TokenVersion == current, nil
+}
+
+// currentTokenVersion ユーザーの現在のトークン世代をキャッシュまたは参照先から取得する
+// 参照先が未設定の場合はokにfalseを返す
+func currentTokenVersion(userID uint) (uint, bool, error) {
+	now := time.Now()
+	tokenVersionMutex.RLock()
+	lookup, ttl, epoch := tokenVersionLookup, tokenVersionCacheTTL, tokenVersionEpoch
+	entry, cached := tokenVersionCache[userID]
+	tokenVersionMutex.RUnlock()
+
+	if lookup == nil {
+		return 0, false, nil
+	}
+	if cached && now.Before(entry.expiresAt) {
+		return entry.version, true, nil
+	}
+
+	version, err := lookup(userID)
+	if err != nil {
+		return 0, true, err
+	}
+	if ttl > 0 {
+		tokenVersionMutex.Lock()
+		defer tokenVersionMutex.Unlock()
+		if epoch != tokenVersionEpoch {
+			return version, true, nil
+		}
+		if len(tokenVersionCache) >= maxTokenVersionCacheEntries {
+			for id, e := range tokenVersionCache {
+				if !now.Before(e.expiresAt) {
+					delete(tokenVersionCache, id)
+				}
+			}
+		}
+		if len(tokenVersionCache) < maxTokenVersionCacheEntries {
+			tokenVersionCache[userID] = tokenVersionCacheEntry{version: version, expiresAt: now.Add(ttl)}
+		}
+	}
+	return version, true, nil
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTokenVersionLookup 参照回数を数えるトークン世代の参照先を設定する
+func setupTokenVersionLookup(t *testing.T, versions map[uint]uint, ttl time.Duration) *int {
+	t.Helper()
+	calls := 0
+	SetTokenVersionCacheTTL(ttl)
+	SetTokenVersionLookup(func(userID uint) (uint, error) {
+		calls++
+		return versions[userID], nil
+	})
+	t.Cleanup(func() {
+		SetTokenVersionLookup(nil)
+		SetTokenVersionCacheTTL(defaultTokenVersionCacheTTL)
+	})
+	return &calls
+}
+
+// TestCheckTokenVersion_Cache トークン世代をキャッシュし、破棄・期限切れで参照し直すことを検証
+func TestCheckTokenVersion_Cache(t *testing.T) {
+	versions := map[uint]uint{1: 0}
+	calls := setupTokenVersionLookup(t, versions, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		current, err := checkTokenVersion(&Claims{UserID: 1, TokenVersion: 0})
+		require.NoError(t, err)
+		assert.True(t, current)
+	}
+	assert.Equal(t, 1, *calls)
+
+	// 世代を進めて破棄すると、直後から古いトークンを拒否する
+	versions[1] = 1
+	InvalidateTokenVersion(1)
+	current, err := checkTokenVersion(&Claims{UserID: 1, TokenVersion: 0})
+	require.NoError(t, err)
+	assert.False(t, current)
+	assert.Equal(t, 2, *calls)
+
+	// 期限切れの場合は参照し直す
+	calls = setupTokenVersionLookup(t, versions, time.Millisecond)
+	checkTokenVersion(&Claims{UserID: 1, TokenVersion: 1})
+	time.Sleep(5 * time.Millisecond)
+	checkTokenVersion(&Claims{UserID: 1, TokenVersion: 1})
+	assert.Equal(t, 2, *calls)
+}
+
+// TestCheckTokenVersion_DiscardsLookupRacingInvalidation 参照中に破棄された場合、参照した世代をキャッシュしないことを検証
+func TestCheckTokenVersion_DiscardsLookupRacingInvalidation(t *testing.T) {
+	versions := map[uint]uint{1: 0}
+	calls := 0
+	SetTokenVersionLookup(func(userID uint) (uint, error) {
+		calls++
+		version := versions[userID]
+		if calls == 1 {
+			// 参照した直後に他のリクエストが世代を進めた
+			versions[userID] = 1
+			InvalidateTokenVersion(userID)
+		}
+		return version, nil
+	})
+	t.Cleanup(func() { SetTokenVersionLookup(nil) })
+
+	current, err := checkTokenVersion(&Claims{UserID: 1, TokenVersion: 0})
+	require.NoError(t, err)
+	assert.True(t, current)
+
+	current, err = checkTokenVersion(&Claims{UserID: 1, TokenVersion: 0})
+	require.NoError(t, err)
+	assert.False(t, current)
+	assert.Equal(t, 2, calls)
+}
+
+// TestCheckTokenVersion_NoLookup 参照先が未設定の場合は検証しないことを検証
+func TestCheckTokenVersion_NoLookup(t *testing.T) {
+	SetTokenVersionLookup(nil)
+	current, err := checkTokenVersion(&Claims{UserID: 1, TokenVersion: 5})
+	require.NoError(t, err)
+	assert.True(t, current)
+}
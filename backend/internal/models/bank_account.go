@@ -0,0 +1,21 @@
+package models
+
+// 預金種目
+const (
+	AccountTypeOrdinary = "ordinary" // 普通預金
+	AccountTypeChecking = "checking" // 当座預金
+	AccountTypeSavings  = "savings"  // 貯蓄預金
+)
+
+// BankAccount 振込用の銀行口座情報
+// 全銀フォーマットの振込ファイルの作成に使用し、ユーザーには暗号化して保存する（User.EncryptedBankAccount）
+// 金融機関名・支店名・口座名義は振込ファイルに出力する半角カナで保持する
+type BankAccount struct {
+	BankCode      string `json:"bank_code"`      // 金融機関コード（4桁）
+	BankName      string `json:"bank_name"`      // 金融機関名（半角カナ、15文字以内）
+	BranchCode    string `json:"branch_code"`    // 支店コード（3桁）
+	BranchName    string `json:"branch_name"`    // 支店名（半角カナ、15文字以内）
+	AccountType   string `json:"account_type"`   // 預金種目（ordinary:普通, checking:当座, savings:貯蓄）
+	AccountNumber string `json:"account_number"` // 口座番号（7桁）
+	AccountHolder string `json:"account_holder"` // 口座名義（半角カナ、30文字以内）
+}
@@ -10,35 +10,80 @@ import (
 // MonthlyBill 月次家計簿モデル
 // 特定の月の家計簿情報を表現するモデル
 type MonthlyBill struct {
-	ID          uint       `json:"id" gorm:"primaryKey"`                                                    // 家計簿ID（主キー）
-	Year        int        `json:"year"`                                                                    // 対象年
-	Month       int        `json:"month"`                                                                   // 対象月
-	RequesterID uint       `json:"requester_id"`                                                            // 請求者のユーザーID
-	PayerID     uint       `json:"payer_id"`                                                                // 支払者のユーザーID
-	Status      string     `json:"status" gorm:"type:enum('pending','requested','paid');default:'pending'"` // 状態（pending: 作成中, requested: 請求済み, paid: 支払済み）
-	RequestDate *time.Time `json:"request_date"`                                                            // 請求日時（請求時に設定）
-	PaymentDate *time.Time `json:"payment_date"`                                                            // 支払日時（支払時に設定）
-	Requester   User       `json:"requester" gorm:"foreignKey:RequesterID"`                                 // 請求者のユーザー情報
-	Payer       User       `json:"payer" gorm:"foreignKey:PayerID"`                                         // 支払者のユーザー情報
-	Items       []BillItem `json:"items" gorm:"foreignKey:BillID"`                                          // 家計簿項目リスト
-	CreatedAt   time.Time  `json:"created_at"`                                                              // 作成日時
-	UpdatedAt   time.Time  `json:"updated_at"`                                                              // 更新日時
+	ID                     uint       `json:"id" gorm:"primaryKey"`                                                                   // 家計簿ID（主キー）
+	Year                   int        `json:"year"`                                                                                   // 対象年
+	Month                  int        `json:"month"`                                                                                  // 対象月
+	PeriodType             string     `json:"period_type" gorm:"size:10;default:'month'"`                                             // 期間の種類（month: 暦月, custom: 任意期間）
+	PeriodStart            time.Time  `json:"period_start" gorm:"type:date"`                                                          // 対象期間の開始日
+	PeriodEnd              time.Time  `json:"period_end" gorm:"type:date"`                                                            // 対象期間の終了日（開始日・終了日を含む）
+	PeriodLabel            string     `json:"period_label" gorm:"size:100"`                                                           // 期間のラベル（例: 第2週、沖縄旅行）
+	RequesterID            uint       `json:"requester_id"`                                                                           // 請求者のユーザーID
+	PayerID                uint       `json:"payer_id"`                                                                               // 支払者のユーザーID
+	Status                 string     `json:"status" gorm:"type:enum('pending','requested','acknowledged','paid');default:'pending'"` // 状態（pending: 作成中, requested: 請求済み, acknowledged: 支払者確認済み, paid: 支払済み）
+	RequestDate            *time.Time `json:"request_date"`                                                                           // 請求日時（請求時に設定）
+	RequireAcknowledgement bool       `json:"require_acknowledgement"`                                                                // 支払前に支払者の内容確認を必須とするか
+	AcknowledgedAt         *time.Time `json:"acknowledged_at"`                                                                        // 支払者の内容確認日時
+	AcknowledgementComment string     `json:"acknowledgement_comment" gorm:"size:500"`                                                // 支払者の内容確認時のコメント
+	PaymentDate            *time.Time `json:"payment_date"`                                                                           // 支払日時（支払時に設定）
+	Comment                string     `json:"comment" gorm:"size:1000"`                                                               // コメント（任意のメモ）
+	TaxRounding            string     `json:"tax_rounding" gorm:"size:10;default:'floor'"`                                            // 消費税の端数処理（floor: 切り捨て, round: 四捨五入, ceil: 切り上げ）
+	Requester              User       `json:"requester" gorm:"foreignKey:RequesterID"`                                                // 請求者のユーザー情報
+	Payer                  User       `json:"payer" gorm:"foreignKey:PayerID"`                                                        // 支払者のユーザー情報
+	Items                  []BillItem `json:"items" gorm:"foreignKey:BillID"`                                                         // 家計簿項目リスト
+	CreatedAt              time.Time  `json:"created_at"`                                                                             // 作成日時
+	UpdatedAt              time.Time  `json:"updated_at"`                                                                             // 更新日時
 }
 
+// 家計簿の期間の種類
+const (
+	BillPeriodMonth  = "month"  // 暦月（年・月で指定）
+	BillPeriodCustom = "custom" // 任意期間（開始日・終了日で指定）
+)
+
 // TableName テーブル名を明示的に指定
 func (MonthlyBill) TableName() string {
 	return "monthly_bills"
 }
 
-// BeforeCreate 作成前のフック（ユニーク制約チェック用）
+// IsCustomPeriod 任意期間の家計簿かどうか
+func (bill MonthlyBill) IsCustomPeriod() bool {
+	return bill.PeriodType == BillPeriodCustom
+}
+
+// MonthPeriod 指定年月の初日と末日を返す
+func MonthPeriod(year, month int) (time.Time, time.Time) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 1, -1)
+}
+
+// BeforeCreate 作成前のフック（期間の補完とユニーク制約チェック用）
 func (bill *MonthlyBill) BeforeCreate(tx *gorm.DB) error {
-	// 同じ請求者・年・月の家計簿が既に存在するかチェック
+	// 期間の種類が未指定の場合は暦月として扱い、年月から期間を補完
+	if bill.PeriodType == "" {
+		bill.PeriodType = BillPeriodMonth
+	}
+	if bill.PeriodType == BillPeriodMonth {
+		bill.PeriodStart, bill.PeriodEnd = MonthPeriod(bill.Year, bill.Month)
+	}
+
 	var count int64
-	tx.Model(&MonthlyBill{}).Where("year = ? AND month = ? AND requester_id = ?",
-		bill.Year, bill.Month, bill.RequesterID).Count(&count)
+	if bill.IsCustomPeriod() {
+		// 同じ請求者・同じ期間の任意期間家計簿が既に存在するかチェック
+		tx.Model(&MonthlyBill{}).Where("requester_id = ? AND period_type = ? AND period_start = ? AND period_end = ?",
+			bill.RequesterID, BillPeriodCustom, bill.PeriodStart, bill.PeriodEnd).Count(&count)
+		if count > 0 {
+			return fmt.Errorf("Duplicate entry '%d-%s-%s' for key 'unique_period_requester'",
+				bill.RequesterID, bill.PeriodStart.Format("2006-01-02"), bill.PeriodEnd.Format("2006-01-02"))
+		}
+		return nil
+	}
+
+	// 同じ請求者・年・月の暦月家計簿が既に存在するかチェック
+	tx.Model(&MonthlyBill{}).Where("year = ? AND month = ? AND requester_id = ? AND period_type = ?",
+		bill.Year, bill.Month, bill.RequesterID, BillPeriodMonth).Count(&count)
 
 	if count > 0 {
-		return fmt.Errorf("Duplicate entry '%d-%d-%d' for key 'unique_month_requester'",
+		return fmt.Errorf("Duplicate entry '%d-%d-%d' for key 'unique_period_requester'",
 			bill.Year, bill.Month, bill.RequesterID)
 	}
 	return nil
@@ -47,10 +92,14 @@ func (bill *MonthlyBill) BeforeCreate(tx *gorm.DB) error {
 // BillItem 家計簿項目モデル
 // 家計簿の個々の支出項目を表現するモデル
 type BillItem struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`             // 項目ID（主キー）
-	BillID    uint      `json:"bill_id"`                          // 所属する家計簿のID
-	ItemName  string    `json:"item_name"`                        // 項目名（例: 食費、交通費など）
-	Amount    float64   `json:"amount" gorm:"type:decimal(10,2)"` // 金額（小数点以下2桁まで対応）
-	CreatedAt time.Time `json:"created_at"`                       // 作成日時
-	UpdatedAt time.Time `json:"updated_at"`                       // 更新日時
+	ID           uint      `json:"id" gorm:"primaryKey"`                      // 項目ID（主キー）
+	BillID       uint      `json:"bill_id"`                                   // 所属する家計簿のID
+	ItemName     string    `json:"item_name"`                                 // 項目名（例: 食費、交通費など）
+	Amount       float64   `json:"amount" gorm:"type:decimal(10,2)"`          // 金額（小数点以下2桁まで対応）
+	TaxRate      int       `json:"tax_rate" gorm:"default:10"`                // 消費税率（10: 標準税率, 8: 軽減税率）
+	TaxExclusive bool      `json:"tax_exclusive"`                             // 金額が税抜かどうか（false: 税込金額）
+	SplitRule    string    `json:"split_rule" gorm:"size:10;default:'payer'"` // 負担ルール（payer: 支払者のみ, shared: 折半, custom: 任意の割合）
+	PayerShare   int       `json:"payer_share"`                               // 支払者の負担割合（%）
+	CreatedAt    time.Time `json:"created_at"`                                // 作成日時
+	UpdatedAt    time.Time `json:"updated_at"`                                // 更新日時
 }
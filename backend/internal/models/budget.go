@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Budget 月次予算モデル
+// カテゴリ別（または全体）の月次予算上限とアラート閾値を表現するモデル
+type Budget struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`                    // 予算ID（主キー）
+	UserID         uint      `json:"user_id"`                                 // 予算を設定したユーザーのID
+	Category       string    `json:"category"`                                // 対象カテゴリ（家計簿項目の項目名、空文字は全体予算）
+	MonthlyLimit   float64   `json:"monthly_limit" gorm:"type:decimal(10,2)"` // 月次予算上限
+	AlertThreshold int       `json:"alert_threshold" gorm:"default:80"`       // アラート閾値（予算に対する使用率%）
+	CreatedAt      time.Time `json:"created_at"`                              // 作成日時
+	UpdatedAt      time.Time `json:"updated_at"`                              // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (Budget) TableName() string {
+	return "budgets"
+}
+
+// IsOverall 全体予算（カテゴリ指定なし）かどうか
+func (b Budget) IsOverall() bool {
+	return b.Category == ""
+}
+
+// BudgetAlert 予算アラートモデル
+// 家計簿項目の保存時に予算の閾値を超えたことを記録するイベント
+type BudgetAlert struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`                   // アラートID（主キー）
+	BudgetID    uint      `json:"budget_id"`                              // 対象予算のID
+	UserID      uint      `json:"user_id"`                                // 通知先ユーザーのID
+	BillID      uint      `json:"bill_id"`                                // アラートの契機となった家計簿のID
+	Year        int       `json:"year"`                                   // 対象年
+	Month       int       `json:"month"`                                  // 対象月
+	Category    string    `json:"category"`                               // 対象カテゴリ（空文字は全体予算）
+	Threshold   int       `json:"threshold"`                              // 超過した閾値（%）
+	UsedAmount  float64   `json:"used_amount" gorm:"type:decimal(10,2)"`  // アラート時点の使用額
+	LimitAmount float64   `json:"limit_amount" gorm:"type:decimal(10,2)"` // アラート時点の予算上限
+	CreatedAt   time.Time `json:"created_at"`                             // 作成日時
+}
+
+// TableName テーブル名を明示的に指定
+func (BudgetAlert) TableName() string {
+	return "budget_alerts"
+}
+
+// BudgetStatus 予算消化状況
+// 指定年月における予算の使用額・残額・使用率を表現する（計算値、DBには保存しない）
+type BudgetStatus struct {
+	BudgetID       uint    `json:"budget_id"`       // 予算ID
+	Category       string  `json:"category"`        // 対象カテゴリ（空文字は全体予算）
+	Year           int     `json:"year"`            // 対象年
+	Month          int     `json:"month"`           // 対象月
+	Limit          float64 `json:"limit"`           // 予算上限
+	Used           float64 `json:"used"`            // 使用額
+	Remaining      float64 `json:"remaining"`       // 残額（超過時は負の値）
+	Percentage     float64 `json:"percentage"`      // 使用率（%）
+	AlertThreshold int     `json:"alert_threshold"` // アラート閾値（%）
+	Exceeded       bool    `json:"exceeded"`        // 予算超過しているか
+}
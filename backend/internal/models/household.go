@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// 世帯メンバーの役割
+const (
+	HouseholdRoleOwner  = "owner"  // 世帯管理者（招待・メンバー削除が可能）
+	HouseholdRoleMember = "member" // 一般メンバー
+)
+
+// 世帯招待の状態
+const (
+	InvitationStatusPending  = "pending"  // 回答待ち
+	InvitationStatusAccepted = "accepted" // 承諾済み
+	InvitationStatusDeclined = "declined" // 辞退済み
+	InvitationStatusRevoked  = "revoked"  // 取り消し済み
+)
+
+// Household 世帯モデル
+// 家計簿をやり取りするユーザーのグループを表現するモデル
+type Household struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`                  // 世帯ID（主キー）
+	Name      string            `json:"name"`                                  // 世帯名
+	CreatedBy uint              `json:"created_by"`                            // 作成したユーザーのID
+	Members   []HouseholdMember `json:"members" gorm:"foreignKey:HouseholdID"` // 世帯メンバーリスト
+	CreatedAt time.Time         `json:"created_at"`                            // 作成日時
+	UpdatedAt time.Time         `json:"updated_at"`                            // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (Household) TableName() string {
+	return "households"
+}
+
+// HouseholdMember 世帯メンバーモデル
+// ユーザーの世帯への所属と役割を表現するモデル
+type HouseholdMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`                 // メンバーシップID（主キー）
+	HouseholdID uint      `json:"household_id"`                         // 所属する世帯のID
+	UserID      uint      `json:"user_id"`                              // メンバーのユーザーID
+	Role        string    `json:"role" gorm:"size:20;default:'member'"` // 役割（owner: 管理者, member: 一般メンバー）
+	User        User      `json:"user" gorm:"foreignKey:UserID"`        // メンバーのユーザー情報
+	CreatedAt   time.Time `json:"created_at"`                           // 参加日時
+	UpdatedAt   time.Time `json:"updated_at"`                           // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (HouseholdMember) TableName() string {
+	return "household_members"
+}
+
+// IsOwner 世帯管理者かどうか
+func (m HouseholdMember) IsOwner() bool {
+	return m.Role == HouseholdRoleOwner
+}
+
+// HouseholdInvitation 世帯招待モデル
+// 世帯管理者から既存ユーザーへの参加招待を表現するモデル
+type HouseholdInvitation struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`                                  // 招待ID（主キー）
+	HouseholdID      uint       `json:"household_id"`                                          // 招待先の世帯ID
+	InviterID        uint       `json:"inviter_id"`                                            // 招待したユーザーのID
+	InviteeID        uint       `json:"-"`                                                     // 招待されたユーザーのID（相手を特定できないようJSONには含めない）
+	InviteeAccountID string     `json:"invitee_account_id" gorm:"size:50;not null;default:''"` // 管理者が入力したアカウントID（招待一覧では相手の情報の代わりに表示）
+	Role             string     `json:"role" gorm:"size:20;default:'member'"`                  // 参加時の役割
+	Status           string     `json:"status" gorm:"size:20;default:'pending'"`               // 状態（pending, accepted, declined, revoked）
+	RespondedAt      *time.Time `json:"responded_at"`                                          // 回答日時（承諾・辞退・取り消し時に設定）
+	Household        Household  `json:"household" gorm:"foreignKey:HouseholdID"`               // 招待先の世帯情報
+	Inviter          User       `json:"inviter" gorm:"foreignKey:InviterID"`                   // 招待したユーザーの情報
+	Invitee          User       `json:"-" gorm:"foreignKey:InviteeID"`                         // 招待されたユーザーの情報
+	CreatedAt        time.Time  `json:"created_at"`                                            // 作成日時
+	UpdatedAt        time.Time  `json:"updated_at"`                                            // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (HouseholdInvitation) TableName() string {
+	return "household_invitations"
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LoginAttempt アカウントIDごとのログイン失敗の記録
+// 存在しないアカウントIDも同じように記録し、ロック状態からアカウントの有無がわからないようにする
+type LoginAttempt struct {
+	AccountKey   string     `json:"-" gorm:"primaryKey;size:64"` // 正規化したアカウントIDのSHA-256ハッシュ（16進数）
+	FailedCount  int        `json:"failed_count"`                // 連続したログイン失敗の回数
+	LastFailedAt time.Time  `json:"last_failed_at"`              // 最後にログインに失敗した日時
+	LockedUntil  *time.Time `json:"locked_until"`                // ロックの解除日時（ロック中のみ）
+	UpdatedAt    time.Time  `json:"updated_at"`                  // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
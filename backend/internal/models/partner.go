@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// 招待リンクの状態
+const (
+	PartnerInvitationStatusPending = "pending" // 未使用
+	PartnerInvitationStatusUsed    = "used"    // 使用済み
+	PartnerInvitationStatusExpired = "expired" // 期限切れ
+	PartnerInvitationStatusRevoked = "revoked" // 取り消し済み
+)
+
+// PartnerInvitation 支払者招待リンクモデル
+// 請求者が発行する一回限り・有効期限付きの招待トークンを表現するモデル
+// トークン本体は保存せず、SHA-256ハッシュのみを保持する
+type PartnerInvitation struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`                // 招待ID（主キー）
+	InviterID uint       `json:"inviter_id"`                          // 招待した請求者のID
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`        // 招待トークンのハッシュ（JSONには含めない）
+	ExpiresAt time.Time  `json:"expires_at"`                          // 有効期限
+	UsedAt    *time.Time `json:"used_at"`                             // 使用日時
+	UsedBy    *uint      `json:"used_by"`                             // 使用したユーザーのID
+	RevokedAt *time.Time `json:"revoked_at"`                          // 取り消し日時
+	Status    string     `json:"status" gorm:"-"`                     // 状態（レスポンス用に算出）
+	Inviter   User       `json:"inviter" gorm:"foreignKey:InviterID"` // 招待した請求者の情報
+	CreatedAt time.Time  `json:"created_at"`                          // 作成日時
+	UpdatedAt time.Time  `json:"updated_at"`                          // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (PartnerInvitation) TableName() string {
+	return "partner_invitations"
+}
+
+// CurrentStatus 指定時刻における招待リンクの状態を判定
+func (i PartnerInvitation) CurrentStatus(now time.Time) string {
+	switch {
+	case i.RevokedAt != nil:
+		return PartnerInvitationStatusRevoked
+	case i.UsedAt != nil:
+		return PartnerInvitationStatusUsed
+	case !now.Before(i.ExpiresAt):
+		return PartnerInvitationStatusExpired
+	default:
+		return PartnerInvitationStatusPending
+	}
+}
+
+// PayerRelationship 支払者関係モデル
+// 招待リンクによって結ばれた請求者と支払者の関係を表現するモデル
+type PayerRelationship struct {
+	ID                     uint      `json:"id" gorm:"primaryKey"`                    // 関係ID（主キー）
+	RequesterID            uint      `json:"requester_id"`                            // 請求者のユーザーID
+	PayerID                uint      `json:"payer_id"`                                // 支払者のユーザーID
+	InvitationID           *uint     `json:"invitation_id"`                           // 関係を作成した招待リンクのID
+	RequireAcknowledgement bool      `json:"require_acknowledgement"`                 // この関係で作成する家計簿に支払者の内容確認を必須とするか
+	Requester              User      `json:"requester" gorm:"foreignKey:RequesterID"` // 請求者の情報
+	Payer                  User      `json:"payer" gorm:"foreignKey:PayerID"`         // 支払者の情報
+	CreatedAt              time.Time `json:"created_at"`                              // 作成日時
+}
+
+// TableName テーブル名を明示的に指定
+func (PayerRelationship) TableName() string {
+	return "payer_relationships"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PasswordResetToken パスワード再設定トークンモデル
+// 再設定の依頼ごとに発行し、1回だけ使える（SHA-256のハッシュのみ保存）
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`         // トークンID（主キー）
+	UserID    uint       `json:"user_id" gorm:"index"`         // ユーザーID
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"` // トークンのSHA-256ハッシュ（16進数）
+	ExpiresAt time.Time  `json:"expires_at"`                   // 有効期限
+	UsedAt    *time.Time `json:"used_at"`                      // 使用日時（新しいトークンの発行で無効にした場合も設定）
+	CreatedAt time.Time  `json:"created_at"`                   // 発行日時
+}
+
+// TableName テーブル名を明示的に指定
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
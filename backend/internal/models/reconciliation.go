@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// BankStatementImport 入金明細の取り込みモデル
+// 請求者がアップロードした銀行の入金明細CSV 1ファイル分を表現する
+type BankStatementImport struct {
+	ID           uint          `json:"id" gorm:"primaryKey"`                // 取り込みID（主キー）
+	RequesterID  uint          `json:"requester_id" gorm:"index"`           // 取り込んだ請求者のID
+	FileName     string        `json:"file_name" gorm:"size:255"`           // アップロードされたファイル名
+	DepositCount int           `json:"deposit_count"`                       // 取り込んだ入金の件数
+	Deposits     []BankDeposit `json:"deposits" gorm:"foreignKey:ImportID"` // 取り込んだ入金
+	CreatedAt    time.Time     `json:"created_at"`                          // 取り込み日時
+}
+
+// TableName テーブル名を明示的に指定
+func (BankStatementImport) TableName() string {
+	return "bank_statement_imports"
+}
+
+// BankDeposit 入金明細の1件の入金モデル
+// 家計簿と照合して支払い済みにした場合はMatchedBillIDに家計簿IDを記録する
+type BankDeposit struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`                // 入金ID（主キー）
+	ImportID      uint       `json:"import_id" gorm:"index"`              // 取り込みID
+	LineNumber    int        `json:"line_number"`                         // CSVの行番号（1始まり、ヘッダー行を含む）
+	DepositDate   time.Time  `json:"deposit_date"`                        // 入金日
+	Amount        float64    `json:"amount" gorm:"type:decimal(10,2)"`    // 入金額
+	SenderName    string     `json:"sender_name" gorm:"size:255"`         // 振込依頼人名
+	MatchedBillID *uint      `json:"matched_bill_id" gorm:"index"`        // 照合済みの家計簿ID
+	Confidence    float64    `json:"confidence" gorm:"type:decimal(3,2)"` // 照合時の一致度（0〜1）
+	MatchedAt     *time.Time `json:"matched_at"`                          // 照合日時
+}
+
+// TableName テーブル名を明示的に指定
+func (BankDeposit) TableName() string {
+	return "bank_deposits"
+}
+
+// ReconciliationMatch 入金と家計簿の照合候補
+type ReconciliationMatch struct {
+	DepositID  uint         `json:"deposit_id"` // 入金ID
+	Deposit    BankDeposit  `json:"deposit"`    // 入金
+	Bill       BillResponse `json:"bill"`       // 照合先の家計簿
+	Confidence float64      `json:"confidence"` // 一致度（0〜1、金額・入金日・振込依頼人名から算出）
+	Reasons    []string     `json:"reasons"`    // 一致した条件（amount_exact, date_in_window, sender_name等）
+}
+
+// ReconciliationResponse 入金明細の照合結果のレスポンス
+type ReconciliationResponse struct {
+	ImportID          uint                  `json:"import_id"`          // 取り込みID
+	Matches           []ReconciliationMatch `json:"matches"`            // 照合候補（一致度の高い順）
+	UnmatchedDeposits []BankDeposit         `json:"unmatched_deposits"` // 照合先のない入金
+	UnmatchedBills    []BillResponse        `json:"unmatched_bills"`    // 照合先のない請求中の家計簿
+	SkippedLines      []int                 `json:"skipped_lines"`      // 入金として読み取れなかった行番号（出金・空行等）
+}
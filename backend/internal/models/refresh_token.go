@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RefreshToken リフレッシュトークンモデル
+// トークン本体はSHA-256のハッシュのみ保存し、ローテーションごとに1件ずつ発行する
+// 同じログインから続くトークンは同じFamilyIDを持ち、ParentIDでローテーション元をたどれる
+type RefreshToken struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`                    // リフレッシュトークンID（主キー）
+	UserID          uint       `json:"user_id" gorm:"index"`                    // ユーザーID
+	TokenHash       string     `json:"-" gorm:"size:64;uniqueIndex"`            // トークンのSHA-256ハッシュ（16進数）
+	FamilyID        string     `json:"family_id" gorm:"size:32;index"`          // ログインごとの系列ID
+	ParentID        *uint      `json:"parent_id" gorm:"index"`                  // ローテーション元のリフレッシュトークンID
+	TokenVersion    uint       `json:"-" gorm:"not null;default:0"`             // 発行時点のユーザーのトークン世代
+	AccessTokenID   string     `json:"-" gorm:"column:access_token_id;size:32"` // 同時に発行したアクセストークンのjti
+	AccessExpiresAt time.Time  `json:"-"`                                       // 同時に発行したアクセストークンの有効期限
+	ExpiresAt       time.Time  `json:"expires_at"`                              // 有効期限
+	UsedAt          *time.Time `json:"used_at"`                                 // ローテーションに使用した日時
+	RevokedAt       *time.Time `json:"revoked_at"`                              // 失効日時
+	RevokedReason   string     `json:"revoked_reason,omitempty" gorm:"size:50"` // 失効理由（logout, reuse_detected等）
+	CreatedAt       time.Time  `json:"created_at"`                              // 発行日時
+}
+
+// TableName テーブル名を明示的に指定
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
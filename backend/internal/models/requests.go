@@ -1,30 +1,113 @@
 package models
 
+import "time"
+
 // LoginRequest ログインリクエスト
 // ユーザーのログイン時に送信されるデータ構造
 type LoginRequest struct {
-	AccountID string `json:"account_id" binding:"required"` // アカウントID（必須）
-	Password  string `json:"password" binding:"required"`   // パスワード（必須）
+	AccountID       string `json:"account_id" binding:"required"` // アカウントID（必須）
+	Password        string `json:"password" binding:"required"`   // パスワード（必須）
+	InvitationToken string `json:"invitation_token"`              // 支払者招待トークン（任意）
 }
 
 // RegisterRequest ユーザー登録リクエスト
 // 新規ユーザー登録時に送信されるデータ構造
 type RegisterRequest struct {
-	Name      string `json:"name" binding:"required"`       // ユーザー名（必須）
-	AccountID string `json:"account_id" binding:"required"` // アカウントID（必須）
-	Password  string `json:"password" binding:"required"`   // パスワード（必須）
+	Name            string `json:"name" binding:"required"`         // ユーザー名（必須）
+	AccountID       string `json:"account_id" binding:"required"`   // アカウントID（必須）
+	Password        string `json:"password" binding:"required"`     // パスワード（必須）
+	Email           string `json:"email" binding:"omitempty,email"` // メールアドレス（任意、パスワード再設定の連絡先）
+	InvitationToken string `json:"invitation_token"`                // 支払者招待トークン（任意）
 }
 
-// LoginResponse ログイン・登録レスポンス
+// LoginResponse ログイン・登録・トークン再発行レスポンス
 // ログインまたは登録成功時に返されるデータ構造
 type LoginResponse struct {
-	Token string `json:"token"` // JWTトークン
-	User  User   `json:"user"`  // ユーザー情報
+	Token                 string    `json:"token"`                    // JWTトークン（アクセストークン）
+	ExpiresAt             time.Time `json:"expires_at"`               // アクセストークンの有効期限
+	RefreshToken          string    `json:"refresh_token"`            // リフレッシュトークン（/api/auth/refreshで使用、1回限り）
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"` // リフレッシュトークンの有効期限
+	User                  User      `json:"user"`                     // ユーザー情報
+}
+
+// TwoFactorChallengeResponse 2段階認証が必要な場合のログインレスポンス
+// チャレンジトークンと認証コードを/api/auth/login/2faに送信するとトークンが発行される
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"two_factor_required"`  // 常にtrue
+	ChallengeToken     string    `json:"challenge_token"`      // チャレンジトークン（短時間・1回限り）
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"` // チャレンジトークンの有効期限
+}
+
+// TwoFactorLoginRequest 2段階認証のログインリクエスト
+type TwoFactorLoginRequest struct {
+	ChallengeToken  string `json:"challenge_token" binding:"required"` // パスワード認証で発行したチャレンジトークン（必須）
+	Code            string `json:"code" binding:"required"`            // 認証アプリの6桁のコードまたはリカバリーコード（必須）
+	InvitationToken string `json:"invitation_token"`                   // 支払者招待トークン（任意）
+}
+
+// TwoFactorCodeRequest 認証コードの確認が必要な2段階認証の操作のリクエスト
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // 認証アプリの6桁のコードまたはリカバリーコード（必須）
+}
+
+// TwoFactorDisableRequest 2段階認証の無効化リクエスト
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"` // 現在のパスワード（必須）
+	Code     string `json:"code" binding:"required"`     // 認証アプリの6桁のコードまたはリカバリーコード（必須）
+}
+
+// ChangePasswordRequest パスワード変更リクエスト
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"` // 現在のパスワード（必須）
+	NewPassword     string `json:"new_password" binding:"required"`     // 新しいパスワード（必須）
+}
+
+// UpdateEmailRequest メールアドレス変更リクエスト
+type UpdateEmailRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"` // 現在のパスワード（必須）
+	Email           string `json:"email" binding:"omitempty,email"`     // 新しいメールアドレス（空の場合は登録を削除）
+}
+
+// PasswordResetRequest パスワード再設定の依頼リクエスト
+type PasswordResetRequest struct {
+	AccountID string `json:"account_id" binding:"required"` // アカウントID（必須）
+}
+
+// PasswordResetConfirmRequest パスワード再設定リクエスト
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`        // 再設定トークン（必須）
+	NewPassword string `json:"new_password" binding:"required"` // 新しいパスワード（必須）
+}
+
+// RefreshRequest トークン再発行リクエスト
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // リフレッシュトークン（必須）
 }
 
 // BillResponse 家計簿レスポンス
 // 家計簿データ取得時に返されるデータ構造（計算済みの金額情報を含む）
 type BillResponse struct {
-	MonthlyBill         // 月次家計簿の基本情報
-	TotalAmount float64 `json:"total_amount"` // 総金額（請求金額）
+	MonthlyBill                    // 月次家計簿の基本情報
+	TotalAmount  float64           `json:"total_amount"`            // 請求金額（項目ごとの負担ルールから計算した支払者の負担額）
+	Subtotal     float64           `json:"subtotal"`                // 税抜小計
+	Taxes        []TaxBreakdown    `json:"taxes"`                   // 税率ごとの消費税内訳
+	GrandTotal   float64           `json:"grand_total"`             // 税込合計
+	Split        []ItemSplit       `json:"split"`                   // 項目ごとの負担額の内訳
+	Budgets      []BudgetStatus    `json:"budgets,omitempty"`       // 対象年月の予算消化状況
+	BudgetAlerts []BudgetAlert     `json:"budget_alerts,omitempty"` // 今回の操作で発生した予算アラート
+	Reversals    []PaymentReversal `json:"reversals,omitempty"`     // 支払い取り消しの履歴
+}
+
+// SearchResult 検索結果
+// 家計簿項目名または家計簿コメントに一致した結果を、所属する家計簿の年月と共に表現する
+type SearchResult struct {
+	MatchType string  `json:"match_type"`          // 一致箇所（item: 項目名, comment: 家計簿コメント）
+	BillID    uint    `json:"bill_id"`             // 家計簿ID
+	Year      int     `json:"year"`                // 家計簿の対象年
+	Month     int     `json:"month"`               // 家計簿の対象月
+	Status    string  `json:"status"`              // 家計簿の状態
+	ItemID    uint    `json:"item_id,omitempty"`   // 一致した項目のID（項目一致時のみ）
+	ItemName  string  `json:"item_name,omitempty"` // 一致した項目名（項目一致時のみ）
+	Amount    float64 `json:"amount,omitempty"`    // 一致した項目の金額（項目一致時のみ）
+	Comment   string  `json:"comment,omitempty"`   // 一致したコメント（コメント一致時のみ）
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 支払い取り消しの状態
+const (
+	ReversalStatusPending   = "pending"   // 相手の確認待ち
+	ReversalStatusConfirmed = "confirmed" // 確認済み（取り消し実行済み）
+	ReversalStatusRejected  = "rejected"  // 相手が拒否
+	ReversalStatusCancelled = "cancelled" // 申請者が撤回
+)
+
+// PaymentReversal 支払い取り消しモデル
+// 支払済み（paid）の家計簿を請求済み（requested）に戻す申請と、その監査履歴を表現するモデル
+// 確認されると家計簿の状態を請求済みに戻して支払日時をクリアするため、取り消し前の支払日時はOriginalPaymentDateに保持する
+type PaymentReversal struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`                    // 取り消しID（主キー）
+	BillID              uint       `json:"bill_id" gorm:"index"`                    // 対象の家計簿ID
+	InitiatorID         uint       `json:"initiator_id"`                            // 取り消しを申請したユーザーのID
+	ConfirmerID         *uint      `json:"confirmer_id"`                            // 確認・拒否したユーザーのID
+	Reason              string     `json:"reason" gorm:"size:500"`                  // 取り消し理由
+	Status              string     `json:"status" gorm:"size:20;default:'pending'"` // 状態（pending, confirmed, rejected, cancelled）
+	OriginalPaymentDate *time.Time `json:"original_payment_date"`                   // 取り消し対象の支払日時
+	ResolvedAt          *time.Time `json:"resolved_at"`                             // 確認・拒否・撤回日時
+	Initiator           User       `json:"initiator" gorm:"foreignKey:InitiatorID"` // 申請したユーザーの情報
+	CreatedAt           time.Time  `json:"created_at"`                              // 申請日時
+	UpdatedAt           time.Time  `json:"updated_at"`                              // 更新日時
+}
+
+// TableName テーブル名を明示的に指定
+func (PaymentReversal) TableName() string {
+	return "payment_reversals"
+}
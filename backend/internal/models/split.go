@@ -0,0 +1,40 @@
+package models
+
+// 家計簿項目の負担ルール
+const (
+	SplitRulePayer  = "payer"  // 支払者のみ（支払者が全額負担）
+	SplitRuleShared = "shared" // 折半（支払者が50%負担）
+	SplitRuleCustom = "custom" // 任意の割合（支払者の負担割合を指定）
+)
+
+// IsValidSplitRule 対応している負担ルールかどうか
+func IsValidSplitRule(rule string) bool {
+	switch rule {
+	case SplitRulePayer, SplitRuleShared, SplitRuleCustom:
+		return true
+	}
+	return false
+}
+
+// PayerSharePercent 項目の負担ルールから支払者の負担割合（%）を返す
+// 負担ルール未設定の項目は支払者のみの負担として扱う
+func (item BillItem) PayerSharePercent() int {
+	switch item.SplitRule {
+	case SplitRuleShared:
+		return 50
+	case SplitRuleCustom:
+		return item.PayerShare
+	default:
+		return 100
+	}
+}
+
+// ItemSplit 家計簿項目ごとの負担額の内訳
+type ItemSplit struct {
+	ItemID     uint    `json:"item_id"`     // 項目ID
+	ItemName   string  `json:"item_name"`   // 項目名
+	SplitRule  string  `json:"split_rule"`  // 負担ルール
+	PayerShare int     `json:"payer_share"` // 支払者の負担割合（%）
+	Amount     float64 `json:"amount"`      // 税込金額（税率ごとに端数処理した消費税を項目に按分）
+	OwedAmount float64 `json:"owed_amount"` // 支払者の負担額
+}
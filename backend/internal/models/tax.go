@@ -0,0 +1,36 @@
+package models
+
+// 消費税率（%）
+const (
+	TaxRateStandard = 10 // 標準税率
+	TaxRateReduced  = 8  // 軽減税率（飲食料品など）
+)
+
+// 消費税の端数処理方法
+const (
+	TaxRoundingFloor = "floor" // 切り捨て
+	TaxRoundingRound = "round" // 四捨五入
+	TaxRoundingCeil  = "ceil"  // 切り上げ
+)
+
+// IsValidTaxRate 対応している消費税率かどうか
+func IsValidTaxRate(rate int) bool {
+	return rate == TaxRateStandard || rate == TaxRateReduced
+}
+
+// IsValidTaxRounding 対応している端数処理方法かどうか
+func IsValidTaxRounding(rounding string) bool {
+	switch rounding {
+	case TaxRoundingFloor, TaxRoundingRound, TaxRoundingCeil:
+		return true
+	}
+	return false
+}
+
+// TaxBreakdown 税率ごとの消費税内訳
+// インボイス制度に従い、税率ごとの合計額に対して端数処理した消費税額を表現する
+type TaxBreakdown struct {
+	Rate          int     `json:"rate"`           // 消費税率（%）
+	TaxableAmount float64 `json:"taxable_amount"` // 税抜の対象金額
+	Tax           float64 `json:"tax"`            // 消費税額（円未満は端数処理済み）
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TokenBlacklistEntry トークンのブラックリストモデル
+// ログアウト等で無効化したトークンを有効期限まで保存する（トークン本体はSHA-256のハッシュのみ）
+type TokenBlacklistEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`         // ID（主キー）
+	TokenHash string    `json:"-" gorm:"size:64;uniqueIndex"` // トークンのSHA-256ハッシュ（16進数）
+	Reason    string    `json:"reason" gorm:"size:50"`        // 無効化の理由（user_logout, reuse_detected等）
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`      // トークンの有効期限（以降は削除してよい）
+	CreatedAt time.Time `json:"created_at"`                   // 登録日時
+}
+
+// TableName テーブル名を明示的に指定
+func (TokenBlacklistEntry) TableName() string {
+	return "token_blacklist"
+}
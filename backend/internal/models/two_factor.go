@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RecoveryCode 2段階認証のリカバリーコードモデル
+// 認証アプリを使用できない場合に1回だけ使えるコード（SHA-256のハッシュのみ保存）
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"` // リカバリーコードID（主キー）
+	UserID    uint       `json:"user_id" gorm:"index"` // ユーザーID
+	CodeHash  string     `json:"-" gorm:"size:64"`     // コードのSHA-256ハッシュ（16進数）
+	UsedAt    *time.Time `json:"used_at"`              // 使用日時
+	CreatedAt time.Time  `json:"created_at"`           // 発行日時
+}
+
+// TableName テーブル名を明示的に指定
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// TwoFactorChallenge 2段階認証のチャレンジモデル
+// パスワード認証に成功したログインに発行し、認証コードの確認でトークンと交換する
+type TwoFactorChallenge struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`         // チャレンジID（主キー）
+	UserID    uint       `json:"user_id" gorm:"index"`         // ユーザーID
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"` // チャレンジトークンのSHA-256ハッシュ（16進数）
+	Attempts  int        `json:"attempts"`                     // 認証コードの試行回数
+	ExpiresAt time.Time  `json:"expires_at"`                   // 有効期限
+	UsedAt    *time.Time `json:"used_at"`                      // 使用日時
+	CreatedAt time.Time  `json:"created_at"`                   // 発行日時
+}
+
+// TableName テーブル名を明示的に指定
+func (TwoFactorChallenge) TableName() string {
+	return "two_factor_challenges"
+}
@@ -5,10 +5,35 @@ import "time"
 // User ユーザーモデル
 // 家計簿アプリのユーザー情報を表現するモデル
 type User struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`     // ユーザーID（主キー）
-	Name         string    `json:"name"`                     // ユーザー名
-	AccountID    string    `json:"account_id" gorm:"unique"` // アカウントID（ログイン用、ユニーク制約）
-	PasswordHash string    `json:"-"`                        // パスワードハッシュ（JSONには含めない）
-	CreatedAt    time.Time `json:"created_at"`               // 作成日時
-	UpdatedAt    time.Time `json:"updated_at"`               // 更新日時
+	ID           uint      `json:"id" gorm:"primaryKey"`        // ユーザーID（主キー）
+	Name         string    `json:"name"`                        // ユーザー名
+	AccountID    string    `json:"account_id" gorm:"unique"`    // アカウントID（ログイン用、ユニーク制約）
+	PasswordHash string    `json:"-"`                           // パスワードハッシュ（JSONには含めない）
+	TokenVersion uint      `json:"-" gorm:"not null;default:0"` // トークン世代（進めると発行済みの全トークンが無効になる）
+	CreatedAt    time.Time `json:"created_at"`                  // 作成日時
+	UpdatedAt    time.Time `json:"updated_at"`                  // 更新日時
+
+	// メールアドレス（任意、パスワード再設定の連絡先）
+	// 家計簿・世帯などの相手のユーザー情報にも含まれるため、JSONには含めない（本人にはSelfUserで返す）
+	Email string `json:"-" gorm:"size:255;not null;default:''"`
+
+	// 振込用の口座情報（AES-GCMで暗号化したBankAccount、JSONには含めない）
+	EncryptedBankAccount string `json:"-" gorm:"column:bank_account;type:text"`
+
+	// 2段階認証（TOTP）
+	TOTPEnabled         bool   `json:"-" gorm:"column:totp_enabled;not null;default:false"` // 2段階認証が有効か（本人のみ/api/auth/2fa/statusで取得できる）
+	EncryptedTOTPSecret string `json:"-" gorm:"column:totp_secret;type:text"`               // TOTPの共有鍵（AES-GCMで暗号化、設定中の鍵を含む）
+	TOTPLastStep        int64  `json:"-" gorm:"column:totp_last_step;not null;default:0"`   // 最後に使用したTOTPの時間ステップ（同じコードの再利用防止）
+}
+
+// SelfUser 本人向けのユーザー情報
+// 相手には公開しないメールアドレスを含める（/api/auth/meなど本人のみが取得するレスポンスで使用する）
+type SelfUser struct {
+	User
+	Email string `json:"email"` // メールアドレス（未登録の場合は空）
+}
+
+// NewSelfUser 本人向けのユーザー情報を作成する
+func NewSelfUser(user User) SelfUser {
+	return SelfUser{User: user, Email: user.Email}
 }
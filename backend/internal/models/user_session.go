@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UserSession ログインセッションモデル
+// ログインごとに1件作成し、IDはリフレッシュトークンの系列ID（FamilyID）と同じ値を使う
+// アクセストークンにはsidクレームとしてセッションIDを含める
+type UserSession struct {
+	ID             string     `json:"id" gorm:"primaryKey;size:32"`             // セッションID（リフレッシュトークンの系列ID）
+	UserID         uint       `json:"user_id" gorm:"index"`                     // ユーザーID
+	UserAgent      string     `json:"user_agent" gorm:"size:255"`               // ログインした端末のUser-Agent
+	IPAddress      string     `json:"ip_address" gorm:"size:45"`                // 最後に確認したIPアドレス
+	CurrentTokenID string     `json:"-" gorm:"column:current_token_id;size:32"` // 最後に発行したアクセストークンのjti
+	CreatedAt      time.Time  `json:"created_at"`                               // ログイン日時
+	LastSeenAt     time.Time  `json:"last_seen_at"`                             // 最終利用日時（一定間隔でまとめて更新）
+	ExpiresAt      time.Time  `json:"expires_at"`                               // 有効期限（最新のリフレッシュトークンの有効期限）
+	RevokedAt      *time.Time `json:"revoked_at"`                               // 終了日時（ログアウト・強制終了）
+	RevokedReason  string     `json:"revoked_reason,omitempty" gorm:"size:50"`  // 終了理由
+}
+
+// TableName テーブル名を明示的に指定
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
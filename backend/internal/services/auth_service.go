@@ -7,9 +7,8 @@ package services
 
 import (
 	"errors"
-	"time"
+	"log"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
@@ -60,8 +59,8 @@ func NewAuthService(db testmocks.DBInterface, passwordHasher testmocks.PasswordH
 func NewAuthServiceWithDB(db *gorm.DB) *AuthService {
 	return &AuthService{
 		db:             testmocks.NewGormDBWrapper(db),
-		passwordHasher: &BcryptPasswordHasher{},
-		jwtService:     &JWTService{},
+		passwordHasher: CurrentPasswordHasher(),
+		jwtService:     &JWTService{db: db},
 	}
 }
 
@@ -81,6 +80,16 @@ func (s *AuthService) Login(accountID, password string) (*LoginResult, error) {
 		return nil, errors.New("認証情報が無効です")
 	}
 
+	// 古い形式・設定のハッシュは現在の設定で作り直す（失敗してもログインは続行）
+	if s.passwordHasher.NeedsRehash(user.PasswordHash) {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return UpgradePasswordHash(tx, s.passwordHasher, user.ID, user.PasswordHash, password)
+		})
+		if err != nil {
+			log.Printf("❌ Failed to upgrade password hash: user=%d error=%v", user.ID, err)
+		}
+	}
+
 	// JWTトークンを生成
 	token, err := s.jwtService.GenerateToken(user.ID)
 	if err != nil {
@@ -95,9 +104,9 @@ func (s *AuthService) Login(accountID, password string) (*LoginResult, error) {
 
 // Register ユーザー登録
 func (s *AuthService) Register(name, accountID, password string) (*models.User, error) {
-	// パスワードのバリデーション
-	if len(password) < 8 {
-		return nil, errors.New("パスワードは8文字以上で入力してください")
+	// パスワードのバリデーション（ハンドラーと共通のパスワードポリシー）
+	if err := DefaultPasswordPolicy().Validate("password", password); err != nil {
+		return nil, err
 	}
 
 	if len(accountID) < 3 || len(accountID) > 20 {
@@ -157,12 +166,22 @@ func (s *AuthService) GetAllUsers() ([]models.User, error) {
 // 実際の実装クラス（本番用）
 // ========================================
 
-// BcryptPasswordHasher 実際のbcryptハッシャー
-type BcryptPasswordHasher struct{}
+// BcryptPasswordHasher 実際のbcryptハッシャー（Argon2idのハッシュも照合できる）
+type BcryptPasswordHasher struct {
+	Cost int // コスト（0の場合はbcrypt.DefaultCost）
+}
+
+// cost ハッシュ化に使用するコスト
+func (h *BcryptPasswordHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
 
 // HashPassword パスワードハッシュ化
 func (h *BcryptPasswordHasher) HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
 	if err != nil {
 		return "", err
 	}
@@ -171,43 +190,45 @@ func (h *BcryptPasswordHasher) HashPassword(password string) (string, error) {
 
 // ComparePassword パスワード比較
 func (h *BcryptPasswordHasher) ComparePassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return comparePasswordHash(hashedPassword, password)
+}
+
+// NeedsRehash ハッシュがbcrypt以外か、現在のコストより低い場合にtrueを返す
+func (h *BcryptPasswordHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost < h.cost()
+}
+
+// MaxPasswordBytes ハッシュ化できるパスワードの最大のバイト数（超過分はbcryptに無視される）
+func (h *BcryptPasswordHasher) MaxPasswordBytes() int {
+	return bcryptMaxPasswordBytes
 }
 
 // JWTService 実際のJWTサービス
-type JWTService struct{}
+type JWTService struct {
+	db *gorm.DB // トークン世代の参照先（nilの場合は世代0で発行）
+}
 
-// GenerateToken JWTトークン生成
+// GenerateToken JWTトークン生成（アクセストークン）
 func (j *JWTService) GenerateToken(userID uint) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		},
-	})
-
-	secret, err := middleware.GetJWTSecret()
-	if err != nil {
-		return "", err
+	var tokenVersion uint
+	if j.db != nil {
+		version, err := currentTokenVersion(j.db, userID)
+		if err != nil {
+			return "", err
+		}
+		tokenVersion = version
 	}
-	tokenString, err := token.SignedString(secret)
+	tokenString, _, err := middleware.GenerateAccessToken(userID, tokenVersion, "")
 	if err != nil {
 		return "", err
 	}
-
 	return tokenString, nil
 }
 
 // ValidateToken JWTトークン検証
 func (j *JWTService) ValidateToken(tokenString string) (uint, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &middleware.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		secret, err := middleware.GetJWTSecret()
-		if err != nil {
-			return nil, err
-		}
-		return secret, nil
-	})
-
+	token, err := middleware.ParseToken(tokenString, &middleware.Claims{})
 	if err != nil {
 		return 0, err
 	}
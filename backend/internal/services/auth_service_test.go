@@ -6,10 +6,15 @@
 package services
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
+	"money_management/internal/database"
+	"money_management/internal/models"
 	testmocks "money_management/internal/testing"
 )
 
@@ -121,6 +126,41 @@ func TestAuthService_Login_TokenGenerationError(t *testing.T) {
 	assert.Equal(t, "トークンを生成できませんでした", err.Error())
 }
 
+// TestAuthService_Login_NeedsRehash 古い形式のハッシュでもログインでき、保存済みのハッシュが現在の設定で作り直されることを検証
+// ハッシュの更新はトランザクション内で行うため、モックではなくインメモリDBを使用する
+func TestAuthService_Login_NeedsRehash(t *testing.T) {
+	db, err := database.SetupInMemoryTestDB(t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { database.CleanupInMemoryTestDB(db, t.Name()) })
+
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := models.User{Name: "旧ハッシュ", AccountID: "legacy_user", PasswordHash: string(legacyHash)}
+	require.NoError(t, db.Create(&user).Error)
+
+	hasher := &Argon2idPasswordHasher{Params: Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	deps := testmocks.NewMockTestDependencies()
+	authService := NewAuthService(testmocks.NewGormDBWrapper(db), hasher, deps.JWTService)
+
+	result, err := authService.Login("legacy_user", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy_user", result.User.AccountID)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.NotEqual(t, string(legacyHash), stored.PasswordHash)
+	assert.True(t, strings.HasPrefix(stored.PasswordHash, argon2idHashPrefix))
+	assert.False(t, hasher.NeedsRehash(stored.PasswordHash))
+	assert.NoError(t, hasher.ComparePassword(stored.PasswordHash, "password123"))
+
+	// 作り直した後のログインではハッシュは変わらない
+	_, err = authService.Login("legacy_user", "password123")
+	require.NoError(t, err)
+	var again models.User
+	require.NoError(t, db.First(&again, user.ID).Error)
+	assert.Equal(t, stored.PasswordHash, again.PasswordHash)
+}
+
 // TestAuthService_Register_Success ユーザー登録成功のテスト
 func TestAuthService_Register_Success(t *testing.T) {
 	// ユニットテストは並列化を無効にして安定性を重視
@@ -130,14 +170,14 @@ func TestAuthService_Register_Success(t *testing.T) {
 	authService := NewAuthService(deps.DB, deps.PasswordHasher, deps.JWTService)
 
 	// ユーザー登録実行
-	user, err := authService.Register("新規ユーザー", "new_user", "password123")
+	user, err := authService.Register("新規ユーザー", "new_user", "correct-horse-42")
 
 	// 結果検証
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, "新規ユーザー", user.Name)
 	assert.Equal(t, "new_user", user.AccountID)
-	assert.Equal(t, "mock_hashed_password123", user.PasswordHash)
+	assert.Equal(t, "mock_hashed_correct-horse-42", user.PasswordHash)
 	assert.NotZero(t, user.ID) // モックで自動採番されたID
 }
 
@@ -167,7 +207,7 @@ func TestAuthService_Register_InvalidAccountID(t *testing.T) {
 	authService := NewAuthService(deps.DB, deps.PasswordHasher, deps.JWTService)
 
 	// 短いアカウントIDで登録試行
-	user, err := authService.Register("テストユーザー", "ab", "password123")
+	user, err := authService.Register("テストユーザー", "ab", "correct-horse-42")
 
 	// 結果検証
 	assert.Error(t, err)
@@ -175,7 +215,7 @@ func TestAuthService_Register_InvalidAccountID(t *testing.T) {
 	assert.Equal(t, "アカウントIDは3文字以上20文字以下で入力してください", err.Error())
 
 	// 長いアカウントIDで登録試行
-	user, err = authService.Register("テストユーザー", "this_is_a_very_long_account_id", "password123")
+	user, err = authService.Register("テストユーザー", "this_is_a_very_long_account_id", "correct-horse-42")
 
 	// 結果検証
 	assert.Error(t, err)
@@ -192,7 +232,7 @@ func TestAuthService_Register_DuplicateAccountID(t *testing.T) {
 	authService := NewAuthService(deps.DB, deps.PasswordHasher, deps.JWTService)
 
 	// 最初のユーザーを登録
-	_, err := authService.Register("ユーザー1", "test_user", "password123")
+	_, err := authService.Register("ユーザー1", "test_user", "correct-horse-42")
 	assert.NoError(t, err)
 
 	// 同じアカウントIDで再登録試行
@@ -216,7 +256,7 @@ func TestAuthService_Register_PasswordHashingError(t *testing.T) {
 	deps.PasswordHasher.SetError(true)
 
 	// ユーザー登録試行
-	user, err := authService.Register("テストユーザー", "test_user", "password123")
+	user, err := authService.Register("テストユーザー", "test_user", "correct-horse-42")
 
 	// 結果検証
 	assert.Error(t, err)
@@ -354,7 +394,7 @@ func TestAuthService_ErrorHandling_Comprehensive(t *testing.T) {
 				deps.PasswordHasher.SetError(true)
 			},
 			operation: func(service *AuthService) error {
-				_, err := service.Register("test", "testuser", "password123")
+				_, err := service.Register("test", "testuser", "correct-horse-42")
 				return err
 			},
 			expectedErr: "パスワードの処理に失敗しました",
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+
+	"money_management/internal/models"
+)
+
+// ========================================
+// 振込用の口座情報 - 検証・正規化と暗号化保存
+// ========================================
+
+// 口座情報の各項目の最大文字数（全銀フォーマットの項目長）
+const (
+	maxBankNameLen      = 15
+	maxBranchNameLen    = 15
+	maxAccountHolderLen = 30
+)
+
+// zenginSmallKana 全銀フォーマットで使用できない半角小文字カナの置き換え
+var zenginSmallKana = map[rune]rune{
+	'ｧ': 'ｱ', 'ｨ': 'ｲ', 'ｩ': 'ｳ', 'ｪ': 'ｴ', 'ｫ': 'ｵ',
+	'ｬ': 'ﾔ', 'ｭ': 'ﾕ', 'ｮ': 'ﾖ', 'ｯ': 'ﾂ',
+	'ｰ': '-', '･': '.',
+}
+
+// NormalizeBankAccount 口座情報を検証し、振込ファイルに出力する形式に正規化する
+// 全角の数字・カナ・ひらがなは半角に変換し、口座番号は7桁に0埋めする
+func NormalizeBankAccount(account models.BankAccount) (models.BankAccount, error) {
+	v := &fieldValidator{}
+	normalized := validateBankAccount(v, "", account)
+	if err := v.err(); err != nil {
+		return models.BankAccount{}, err
+	}
+	return normalized, nil
+}
+
+// validateBankAccount 口座情報の各項目を検証して正規化した口座情報を返す（prefixはフィールド名の接頭辞）
+func validateBankAccount(v *fieldValidator, prefix string, account models.BankAccount) models.BankAccount {
+	normalized := models.BankAccount{
+		BankCode:      validateDigits(v, prefix+"bank_code", "金融機関コード", account.BankCode, 4, 4),
+		BankName:      validateZenginName(v, prefix+"bank_name", "金融機関名", account.BankName, maxBankNameLen),
+		BranchCode:    validateDigits(v, prefix+"branch_code", "支店コード", account.BranchCode, 3, 3),
+		BranchName:    validateZenginName(v, prefix+"branch_name", "支店名", account.BranchName, maxBranchNameLen),
+		AccountType:   strings.TrimSpace(account.AccountType),
+		AccountNumber: validateDigits(v, prefix+"account_number", "口座番号", account.AccountNumber, 1, 7),
+		AccountHolder: validateZenginName(v, prefix+"account_holder", "口座名義", account.AccountHolder, maxAccountHolderLen),
+	}
+
+	switch normalized.AccountType {
+	case models.AccountTypeOrdinary, models.AccountTypeChecking, models.AccountTypeSavings:
+	case "":
+		v.add(prefix+"account_type", RuleRequired, "預金種目を指定してください")
+	default:
+		v.add(prefix+"account_type", RuleOneOf, "預金種目はordinary、checking、savingsのいずれかを指定してください")
+	}
+
+	if normalized.AccountNumber != "" {
+		normalized.AccountNumber = fmt.Sprintf("%07s", normalized.AccountNumber)
+	}
+	return normalized
+}
+
+// validateDigits minDigits〜maxDigits桁の数字の項目を検証する（全角数字は半角に変換）
+func validateDigits(v *fieldValidator, field, label, value string, minDigits, maxDigits int) string {
+	value = strings.TrimSpace(norm.NFKC.String(value))
+	if value == "" {
+		v.addf(field, RuleRequired, "%sを入力してください", label)
+		return ""
+	}
+	if !isDigits(value) || len(value) < minDigits || len(value) > maxDigits {
+		if minDigits == maxDigits {
+			v.addf(field, RuleFormat, "%sは%d桁の数字で入力してください", label, maxDigits)
+		} else {
+			v.addf(field, RuleFormat, "%sは%d桁以内の数字で入力してください", label, maxDigits)
+		}
+		return ""
+	}
+	return value
+}
+
+// validateZenginName 金融機関名・口座名義等の項目を全銀フォーマットの半角カナに変換して検証する
+func validateZenginName(v *fieldValidator, field, label, value string, maxLen int) string {
+	converted, ok := ToZenginKana(value)
+	switch {
+	case converted == "":
+		v.addf(field, RuleRequired, "%sを入力してください", label)
+		return ""
+	case !ok:
+		v.addf(field, RuleFormat, "%sはカナ・英数字と記号（()-./,）で入力してください", label)
+		return ""
+	case utf8.RuneCountInString(converted) > maxLen:
+		v.addf(field, RuleMaxLength, "%sは半角%d文字以内で入力してください（濁点・半濁点も1文字）", label, maxLen)
+		return ""
+	}
+	return converted
+}
+
+// ToZenginKana 文字列を全銀フォーマットで使用できる半角文字（半角カナ・英大文字・数字・一部の記号）に変換する
+// ひらがな・全角カナは半角カナに（濁点・半濁点は別の文字）、英小文字は大文字に、小書きのカナは通常のカナに変換する
+// 使用できない文字（漢字等）が含まれる場合は2つ目の戻り値がfalseになる
+func ToZenginKana(value string) (string, bool) {
+	value = norm.NFD.String(norm.NFKC.String(strings.TrimSpace(value)))
+
+	var b strings.Builder
+	ok := true
+	for _, r := range value {
+		switch {
+		case r >= 'ぁ' && r <= 'ゖ':
+			r += 'ァ' - 'ぁ'
+		case r == '\u3099': // 結合用濁点
+			r = 'ﾞ'
+		case r == '\u309A': // 結合用半濁点
+			r = 'ﾟ'
+		}
+		for _, n := range width.Narrow.String(strings.ToUpper(string(r))) {
+			if replaced, found := zenginSmallKana[n]; found {
+				n = replaced
+			}
+			if !isZenginChar(n) {
+				ok = false
+			}
+			b.WriteRune(n)
+		}
+	}
+	return b.String(), ok
+}
+
+// isZenginChar 全銀フォーマットの文字項目で使用できる文字か判定する
+func isZenginChar(r rune) bool {
+	switch {
+	case r >= '0' && r <= '9', r >= 'A' && r <= 'Z':
+		return true
+	case r == 'ｦ', r >= 'ｱ' && r <= 'ﾟ':
+		return true
+	}
+	return strings.ContainsRune(" ()-./,｢｣", r)
+}
+
+// isDigits 文字列が半角数字のみで構成されているか判定する
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// bankAccountAssociatedData 口座情報の暗号文に結び付ける関連データ（他のユーザーへの複製を検出）
+func bankAccountAssociatedData(userID uint) string {
+	return fmt.Sprintf("users.bank_account:%d", userID)
+}
+
+// SealBankAccount 口座情報を暗号化してユーザーに設定する
+func SealBankAccount(fieldCipher *FieldCipher, user *models.User, account models.BankAccount) error {
+	plaintext, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	encrypted, err := fieldCipher.Encrypt(plaintext, bankAccountAssociatedData(user.ID))
+	if err != nil {
+		return err
+	}
+	user.EncryptedBankAccount = encrypted
+	return nil
+}
+
+// OpenBankAccount ユーザーの口座情報を復号する（未登録の場合はnil）
+func OpenBankAccount(fieldCipher *FieldCipher, user models.User) (*models.BankAccount, error) {
+	if user.EncryptedBankAccount == "" {
+		return nil, nil
+	}
+	plaintext, err := fieldCipher.Decrypt(user.EncryptedBankAccount, bankAccountAssociatedData(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	var account models.BankAccount
+	if err := json.Unmarshal(plaintext, &account); err != nil {
+		return nil, ErrFieldDecryption
+	}
+	return &account, nil
+}
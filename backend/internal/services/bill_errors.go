@@ -0,0 +1,40 @@
+package services
+
+import "errors"
+
+// ========================================
+// 家計簿ドメインのエラー定義
+// ========================================
+
+// エラーの種類（errors.Isで判定する）
+var (
+	ErrNotFound     = errors.New("not found")     // 対象が存在しない、または操作権限のあるユーザーから見えない
+	ErrForbidden    = errors.New("forbidden")     // 対象は存在するが操作が許可されていない
+	ErrInvalidState = errors.New("invalid state") // 現在の状態では実行できない操作
+	ErrConflict     = errors.New("conflict")      // 既存データとの重複
+	ErrInvalidInput = errors.New("invalid input") // 入力値の検証エラー
+)
+
+// DomainError 種類と利用者向けメッセージを持つドメインエラー
+type DomainError struct {
+	Kind    error  // エラーの種類（ErrNotFound等）
+	Message string // 利用者向けメッセージ
+}
+
+// Error 利用者向けメッセージを返す
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// Unwrap エラーの種類を返す（errors.Isによる判定用）
+func (e *DomainError) Unwrap() error {
+	return e.Kind
+}
+
+// newDomainError ドメインエラーを作成する
+func newDomainError(kind error, message string) *DomainError {
+	return &DomainError{Kind: kind, Message: message}
+}
+
+// errBillNotFound 家計簿が見つからない場合の共通エラー
+var errBillNotFound = newDomainError(ErrNotFound, "家計簿が見つかりません")
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"money_management/internal/models"
+)
+
+const (
+	billPeriodDateLayout  = "2006-01-02" // 任意期間の日付形式
+	maxBillPeriodDays     = 366          // 任意期間の最大日数
+	maxBillPeriodLabelLen = 100          // 期間ラベルの最大文字数
+	minBillYear           = 2000         // 対象年の下限
+	maxBillYear           = 2100         // 対象年の上限
+)
+
+// billPeriod 家計簿の対象期間
+type billPeriod struct {
+	Year        int
+	Month       int
+	PeriodType  string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	PeriodLabel string
+}
+
+// resolveBillPeriod 家計簿の対象期間を決定する
+// 開始日・終了日が指定された場合は任意期間とし、年月は開始日から決める（一覧・予算集計用）
+// 予算集計では任意期間の家計簿は開始日の月に全額を計上する（複数の月にまたがっても按分しない）
+// 不正な指定はフィールド単位の検証エラーとしてvに追加し、okにfalseを返す
+func resolveBillPeriod(v *fieldValidator, year, month int, start, end, label string) (period billPeriod, ok bool) {
+	errorCount := len(v.fields)
+	if utf8.RuneCountInString(label) > maxBillPeriodLabelLen {
+		v.add("period_label", RuleMaxLength, "期間のラベルは100文字以内で入力してください")
+	}
+
+	// 暦月
+	if start == "" && end == "" {
+		if year == 0 {
+			v.add("year", RuleRequired, "対象の年月、または期間の開始日・終了日を指定してください")
+		} else if year < minBillYear || year > maxBillYear {
+			v.add("year", RuleRange, "年は2000から2100の範囲で指定してください")
+		}
+		if month == 0 {
+			v.add("month", RuleRequired, "対象の年月、または期間の開始日・終了日を指定してください")
+		} else if month < 1 || month > 12 {
+			v.add("month", RuleRange, "月は1から12の範囲で指定してください")
+		}
+		if len(v.fields) > errorCount {
+			return billPeriod{}, false
+		}
+		periodStart, periodEnd := models.MonthPeriod(year, month)
+		return billPeriod{
+			Year:        year,
+			Month:       month,
+			PeriodType:  models.BillPeriodMonth,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+			PeriodLabel: label,
+		}, true
+	}
+
+	// 任意期間
+	periodStart := parsePeriodDate(v, "period_start", start, "開始日")
+	periodEnd := parsePeriodDate(v, "period_end", end, "終了日")
+	if len(v.fields) > errorCount {
+		return billPeriod{}, false
+	}
+	if periodEnd.Before(periodStart) {
+		v.add("period_end", RuleAfter, "終了日は開始日以降の日付を指定してください")
+		return billPeriod{}, false
+	}
+	if periodEnd.Sub(periodStart) >= maxBillPeriodDays*24*time.Hour {
+		v.add("period_end", RuleMax, "期間は366日以内で指定してください")
+		return billPeriod{}, false
+	}
+
+	return billPeriod{
+		Year:        periodStart.Year(),
+		Month:       int(periodStart.Month()),
+		PeriodType:  models.BillPeriodCustom,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		PeriodLabel: label,
+	}, true
+}
+
+// parsePeriodDate 任意期間の日付（YYYY-MM-DD）を解析する
+// 未指定・形式不正・対象年の範囲外の場合は検証エラーを追加する
+func parsePeriodDate(v *fieldValidator, field, value, name string) time.Time {
+	if value == "" {
+		v.add(field, RuleRequired, "期間の開始日と終了日を両方指定してください")
+		return time.Time{}
+	}
+	date, err := time.ParseInLocation(billPeriodDateLayout, value, time.Local)
+	if err != nil {
+		v.addf(field, RuleFormat, "%sはYYYY-MM-DD形式で指定してください", name)
+		return time.Time{}
+	}
+	if date.Year() < minBillYear || date.Year() > maxBillYear {
+		v.addf(field, RuleRange, "%sは2000年から2100年の範囲で指定してください", name)
+		return time.Time{}
+	}
+	return date
+}
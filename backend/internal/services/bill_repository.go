@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+)
+
+// ========================================
+// 家計簿リポジトリのインターフェース定義
+// ========================================
+
+// BillRepository 家計簿データの永続化の抽象化
+// 見つからない場合はErrNotFound、重複の場合はErrConflictをラップしたエラーを返す
+type BillRepository interface {
+	FindByID(billID uint) (*models.MonthlyBill, error)
+	FindWithDetails(billID uint) (*models.MonthlyBill, error)
+	FindByYearMonth(userID uint, year, month int) (*models.MonthlyBill, error)
+	ListByParticipant(userID uint) ([]models.MonthlyBill, error)
+	Create(bill *models.MonthlyBill) error
+	Save(bill *models.MonthlyBill) error
+	UpdateStatus(billID uint, fromStatuses []string, fields map[string]interface{}) error
+	ReplaceItems(billID uint, items []models.BillItem) error
+	Delete(bill *models.MonthlyBill) error
+	Transaction(fn func(repo BillRepository) error) error
+}
+
+// ========================================
+// GORMによる家計簿リポジトリの実装
+// ========================================
+
+// GormBillRepository GORMを使用する家計簿リポジトリ
+type GormBillRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBillRepository GORMを使用する家計簿リポジトリの初期化
+func NewGormBillRepository(db *gorm.DB) *GormBillRepository {
+	return &GormBillRepository{db: db}
+}
+
+// FindByID 家計簿IDで家計簿を取得（関連データなし）
+func (r *GormBillRepository) FindByID(billID uint) (*models.MonthlyBill, error) {
+	var bill models.MonthlyBill
+	if err := r.db.First(&bill, billID).Error; err != nil {
+		return nil, translateRecordError(err)
+	}
+	return &bill, nil
+}
+
+// FindWithDetails 家計簿IDで家計簿を請求者・支払者・項目と共に取得
+func (r *GormBillRepository) FindWithDetails(billID uint) (*models.MonthlyBill, error) {
+	var bill models.MonthlyBill
+	if err := r.withDetails().First(&bill, billID).Error; err != nil {
+		return nil, translateRecordError(err)
+	}
+	return &bill, nil
+}
+
+// FindByYearMonth 対象ユーザーが請求者または支払者である暦月の家計簿を取得
+func (r *GormBillRepository) FindByYearMonth(userID uint, year, month int) (*models.MonthlyBill, error) {
+	var bill models.MonthlyBill
+	err := r.withDetails().
+		Where("year = ? AND month = ? AND period_type = ? AND (requester_id = ? OR payer_id = ?)",
+			year, month, models.BillPeriodMonth, userID, userID).
+		First(&bill).Error
+	if err != nil {
+		return nil, translateRecordError(err)
+	}
+	return &bill, nil
+}
+
+// ListByParticipant 対象ユーザーが請求者または支払者である家計簿を新しい期間順に取得
+func (r *GormBillRepository) ListByParticipant(userID uint) ([]models.MonthlyBill, error) {
+	var bills []models.MonthlyBill
+	err := r.withDetails().
+		Where("requester_id = ? OR payer_id = ?", userID, userID).
+		Order("year DESC, month DESC, period_start DESC").
+		Find(&bills).Error
+	return bills, err
+}
+
+// Create 家計簿を作成（デッドロック時はリトライ）
+func (r *GormBillRepository) Create(bill *models.MonthlyBill) error {
+	const (
+		maxRetries         = 3
+		baseBackoffMs      = 100 // ベースバックオフ時間（ミリ秒）
+		backoffIncrementMs = 50  // バックオフ増分（ミリ秒）
+	)
+
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		err = r.db.Create(bill).Error
+		if err == nil {
+			return nil
+		}
+
+		// デッドロックエラーの場合はリトライ
+		if strings.Contains(err.Error(), "Deadlock found when trying to get lock") {
+			log.Printf("🔄 Deadlock detected, retrying... (attempt %d/%d)", i+1, maxRetries)
+			// 穏やかな指数バックオフ: baseTime + incrementTime * attempt^2
+			waitTime := time.Duration(baseBackoffMs+backoffIncrementMs*i*i) * time.Millisecond
+			log.Printf("🕐 Waiting %v before retry", waitTime)
+			time.Sleep(waitTime)
+			continue
+		}
+
+		// デッドロック以外のエラーは即座に終了
+		break
+	}
+
+	// 制約エラー（重複）
+	if strings.Contains(err.Error(), "Duplicate entry") {
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return err
+}
+
+// Save 家計簿を更新
+func (r *GormBillRepository) Save(bill *models.MonthlyBill) error {
+	return r.db.Save(bill).Error
+}
+
+// UpdateStatus 家計簿がfromStatusesのいずれかの状態の場合のみ項目を更新する（状態遷移用）
+// 読み込み後に他の操作で状態が変わっていた場合は更新せず、ErrInvalidStateをラップしたエラーを返す
+func (r *GormBillRepository) UpdateStatus(billID uint, fromStatuses []string, fields map[string]interface{}) error {
+	result := r.db.Model(&models.MonthlyBill{}).
+		Where("id = ? AND status IN ?", billID, fromStatuses).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: bill %d is not in %v", ErrInvalidState, billID, fromStatuses)
+	}
+	return nil
+}
+
+// ReplaceItems 家計簿の項目を全て置き換える
+func (r *GormBillRepository) ReplaceItems(billID uint, items []models.BillItem) error {
+	if err := r.db.Where("bill_id = ?", billID).Delete(&models.BillItem{}).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].BillID = billID
+		if err := r.db.Create(&items[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete 家計簿と項目を削除（外部キー制約のため項目を先に削除）
+func (r *GormBillRepository) Delete(bill *models.MonthlyBill) error {
+	if err := r.db.Where("bill_id = ?", bill.ID).Delete(&models.BillItem{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(bill).Error
+}
+
+// Transaction トランザクション内で処理を実行（エラー時はロールバック）
+func (r *GormBillRepository) Transaction(fn func(repo BillRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormBillRepository(tx))
+	})
+}
+
+// withDetails 請求者・支払者・項目をプリロードするクエリ
+func (r *GormBillRepository) withDetails() *gorm.DB {
+	return r.db.Preload("Requester").Preload("Payer").Preload("Items")
+}
+
+// translateRecordError レコードが見つからないエラーをErrNotFoundに変換
+func translateRecordError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,519 @@
+// ========================================
+// 家計簿サービス - 家計簿ドメインのビジネスロジック
+// リポジトリの抽象化によりハンドラー・永続化から分離
+// ========================================
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"money_management/internal/models"
+)
+
+const (
+	maxAcknowledgementCommentLen = 500         // 内容確認コメントの最大文字数
+	maxBillCommentLen            = 1000        // 家計簿コメントの最大文字数
+	maxBillItemNameLen           = 100         // 項目名の最大文字数
+	maxBillItemAmount            = 99999999.99 // 項目の金額の上限（decimal(10,2)）
+)
+
+// ========================================
+// 家計簿サービスの依存関係の定義
+// ========================================
+
+// PayerPolicy 支払者の指定に関する判定の抽象化（世帯・パートナー連携）
+type PayerPolicy interface {
+	// PayerExists 支払者に指定されたユーザーが存在するか
+	PayerExists(payerID uint) (bool, error)
+	// CanBillPayer 請求者が指定ユーザーを支払者にできるか
+	CanBillPayer(requesterID, payerID uint) (bool, error)
+	// RequiresAcknowledgement 支払者関係で支払前の内容確認が必須に設定されているか
+	RequiresAcknowledgement(requesterID, payerID uint) bool
+}
+
+// CreateBillInput 家計簿作成の入力
+type CreateBillInput struct {
+	RequesterID            uint
+	PayerID                uint
+	Year                   int    // 対象年（暦月の場合）
+	Month                  int    // 対象月（暦月の場合）
+	PeriodStart            string // 任意期間の開始日（YYYY-MM-DD）
+	PeriodEnd              string // 任意期間の終了日（YYYY-MM-DD）
+	PeriodLabel            string
+	Comment                string
+	TaxRounding            string // 省略時は切り捨て
+	RequireAcknowledgement *bool  // 省略時は支払者関係の設定に従う
+}
+
+// BillItemInput 家計簿項目の入力
+type BillItemInput struct {
+	ItemName     string
+	Amount       float64
+	TaxRate      int // 省略時は標準税率
+	TaxExclusive bool
+	SplitRule    string // 負担ルール（省略時は支払者のみ）
+	PayerShare   *int   // 支払者の負担割合（%、負担ルールがcustomの場合は必須）
+}
+
+// UpdateItemsInput 家計簿項目更新の入力（ポインタのフィールドは指定時のみ更新）
+type UpdateItemsInput struct {
+	Items                  []BillItemInput
+	Comment                *string
+	TaxRounding            *string
+	RequireAcknowledgement *bool
+}
+
+// ========================================
+// 家計簿サービスの実装
+// ========================================
+
+// BillService 家計簿サービス
+type BillService struct {
+	repo   BillRepository
+	policy PayerPolicy
+}
+
+// NewBillService 家計簿サービスの初期化
+func NewBillService(repo BillRepository, policy PayerPolicy) *BillService {
+	return &BillService{repo: repo, policy: policy}
+}
+
+// Transaction 1つのトランザクション内で複数の操作を実行する
+// fnに渡されるサービスの操作は全て同じトランザクションで実行され、エラー時は全てロールバックされる
+func (s *BillService) Transaction(fn func(svc *BillService) error) error {
+	return s.repo.Transaction(func(repo BillRepository) error {
+		return fn(NewBillService(repo, s.policy))
+	})
+}
+
+// GetBill 請求者または支払者として参加している家計簿を取得
+func (s *BillService) GetBill(billID, userID uint) (*models.MonthlyBill, error) {
+	bill, err := s.repo.FindWithDetails(billID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	if bill.RequesterID != userID && bill.PayerID != userID {
+		return nil, errBillNotFound
+	}
+	return bill, nil
+}
+
+// GetBillByYearMonth 請求者または支払者として参加している暦月の家計簿を取得
+func (s *BillService) GetBillByYearMonth(userID uint, year, month int) (*models.MonthlyBill, error) {
+	bill, err := s.repo.FindByYearMonth(userID, year, month)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return bill, nil
+}
+
+// ListBills 請求者または支払者として参加している家計簿の一覧を取得
+func (s *BillService) ListBills(userID uint) ([]models.MonthlyBill, error) {
+	return s.repo.ListByParticipant(userID)
+}
+
+// CreateBill 家計簿を作成（請求者は入力のRequesterID、初期状態はpending）
+func (s *BillService) CreateBill(input CreateBillInput) (*models.MonthlyBill, error) {
+	v := &fieldValidator{}
+
+	// 対象期間を決定（開始日・終了日の指定があれば任意期間、なければ暦月）
+	period, _ := resolveBillPeriod(v, input.Year, input.Month, input.PeriodStart, input.PeriodEnd, input.PeriodLabel)
+
+	// 支払者は請求者以外の存在するユーザー
+	switch {
+	case input.PayerID == 0:
+		v.add("payer_id", RuleRequired, "支払者を指定してください")
+	case input.RequesterID == input.PayerID:
+		v.add("payer_id", RuleNotSelf, "請求者と支払者は異なるユーザーである必要があります")
+	default:
+		exists, err := s.policy.PayerExists(input.PayerID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			v.add("payer_id", RuleNotFound, "指定された支払者が見つかりません")
+		}
+	}
+
+	// 端数処理方法を検証（省略時は切り捨て）
+	taxRounding := input.TaxRounding
+	if taxRounding == "" {
+		taxRounding = models.TaxRoundingFloor
+	}
+	validateTaxRounding(v, taxRounding)
+	validateBillComment(v, input.Comment)
+
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	// 支払者は同じ世帯のメンバーまたは連携済みのパートナーに限定
+	allowed, err := s.policy.CanBillPayer(input.RequesterID, input.PayerID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, newDomainError(ErrForbidden, "支払者は同じ世帯のメンバーまたは連携済みのパートナーである必要があります")
+	}
+
+	bill := models.MonthlyBill{
+		Year:        period.Year,
+		Month:       period.Month,
+		PeriodType:  period.PeriodType,
+		PeriodStart: period.PeriodStart,
+		PeriodEnd:   period.PeriodEnd,
+		PeriodLabel: period.PeriodLabel,
+		RequesterID: input.RequesterID,
+		PayerID:     input.PayerID,
+		Status:      "pending", // 初期状態は作成中
+		Comment:     strings.TrimSpace(input.Comment),
+		TaxRounding: taxRounding,
+	}
+
+	// 内容確認の要否は家計簿ごとの指定を優先し、省略時は支払者関係の設定に従う
+	if input.RequireAcknowledgement != nil {
+		bill.RequireAcknowledgement = *input.RequireAcknowledgement
+	} else {
+		bill.RequireAcknowledgement = s.policy.RequiresAcknowledgement(input.RequesterID, input.PayerID)
+	}
+
+	if err := s.repo.Create(&bill); err != nil {
+		if errors.Is(err, ErrConflict) {
+			if bill.IsCustomPeriod() {
+				return nil, newDomainError(ErrConflict, "指定された期間の家計簿は既に存在します")
+			}
+			return nil, newDomainError(ErrConflict, "指定された年月の家計簿は既に存在します")
+		}
+		return nil, err
+	}
+
+	// 関連データと共に再取得
+	return s.repo.FindWithDetails(bill.ID)
+}
+
+// UpdateItems 家計簿の項目・コメント・端数処理・内容確認の要否を更新（請求者のみ、pending状態のみ）
+// 不正な項目が1つでもあれば保存せず、全ての不正な項目をフィールド単位の検証エラーとして返す
+func (s *BillService) UpdateItems(billID, userID uint, input UpdateItemsInput) (*models.MonthlyBill, error) {
+	v := &fieldValidator{}
+
+	// 項目名・金額・税率・負担ルールを検証（省略された税率は標準税率、負担ルールは支払者のみ）
+	items := make([]models.BillItem, 0, len(input.Items))
+	for i, item := range input.Items {
+		items = append(items, validateBillItem(v, fmt.Sprintf("items[%d]", i), item))
+	}
+	if input.TaxRounding != nil {
+		validateTaxRounding(v, *input.TaxRounding)
+	}
+	if input.Comment != nil {
+		validateBillComment(v, *input.Comment)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.Transaction(func(repo BillRepository) error {
+		bill, err := findBillForRequester(repo, billID, userID)
+		if err != nil {
+			return err
+		}
+
+		// pending状態（作成中）の家計簿のみ更新可能
+		if bill.Status != "pending" {
+			return newDomainError(ErrInvalidState, "確定済みの家計簿の項目は更新できません")
+		}
+
+		if input.TaxRounding != nil {
+			bill.TaxRounding = *input.TaxRounding
+		}
+		if input.RequireAcknowledgement != nil {
+			bill.RequireAcknowledgement = *input.RequireAcknowledgement
+		}
+		if input.Comment != nil {
+			bill.Comment = strings.TrimSpace(*input.Comment)
+		}
+		if err := repo.Save(bill); err != nil {
+			return err
+		}
+
+		return repo.ReplaceItems(bill.ID, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 更新後のデータを関連データと共に再取得
+	return s.repo.FindWithDetails(billID)
+}
+
+// RequestBill 家計簿をpending（作成中）からrequested（請求済み）に変更（請求者のみ）
+func (s *BillService) RequestBill(billID, userID uint) error {
+	bill, err := findBillForRequester(s.repo, billID, userID)
+	if err != nil {
+		return err
+	}
+
+	// pending状態（作成中）の家計簿のみ請求可能
+	if bill.Status != "pending" {
+		return errBillAlreadyRequested
+	}
+
+	// 状態を請求済みに変更し、請求日時を設定
+	return s.transitionStatus(bill.ID, []string{"pending"}, map[string]interface{}{
+		"status":       "requested",
+		"request_date": time.Now(),
+	}, errBillAlreadyRequested)
+}
+
+// AcknowledgeBill 家計簿をrequested（請求済み）からacknowledged（支払者確認済み）に変更（支払者のみ）
+func (s *BillService) AcknowledgeBill(billID, userID uint, comment string) error {
+	comment = strings.TrimSpace(comment)
+	if utf8.RuneCountInString(comment) > maxAcknowledgementCommentLen {
+		return NewValidationError(FieldError{Field: "comment", Rule: RuleMaxLength, Message: "コメントは500文字以内で入力してください"})
+	}
+
+	bill, err := findBillForPayer(s.repo, billID, userID)
+	if err != nil {
+		return err
+	}
+
+	// requested状態（請求済み）の家計簿のみ確認可能
+	if bill.Status != "requested" {
+		return errBillNotRequested
+	}
+
+	// 状態を支払者確認済みに変更し、確認日時とコメントを設定
+	return s.transitionStatus(bill.ID, []string{"requested"}, map[string]interface{}{
+		"status":                  "acknowledged",
+		"acknowledged_at":         time.Now(),
+		"acknowledgement_comment": comment,
+	}, errBillNotRequested)
+}
+
+// PayBill 家計簿をpaid（支払済み）に変更（支払者のみ）
+// requested（請求済み）またはacknowledged（支払者確認済み）から変更でき、内容確認が必須の家計簿はacknowledgedからのみ変更できる
+func (s *BillService) PayBill(billID, userID uint) error {
+	bill, err := findBillForPayer(s.repo, billID, userID)
+	if err != nil {
+		return err
+	}
+	return s.markBillPaid(bill)
+}
+
+// ConfirmPaymentReceived 入金の確認により家計簿をpaid（支払済み）に変更（請求者のみ）
+// 入金明細の照合で確定した支払いに使用し、状態遷移の条件はPayBillと同じ
+func (s *BillService) ConfirmPaymentReceived(billID, userID uint) error {
+	bill, err := findBillForRequester(s.repo, billID, userID)
+	if err != nil {
+		return err
+	}
+	return s.markBillPaid(bill)
+}
+
+// ListAwaitingPayment 請求者として請求中（requested・acknowledged）の家計簿の一覧を取得
+func (s *BillService) ListAwaitingPayment(userID uint) ([]models.MonthlyBill, error) {
+	bills, err := s.repo.ListByParticipant(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	awaiting := make([]models.MonthlyBill, 0, len(bills))
+	for _, bill := range bills {
+		if bill.RequesterID == userID && (bill.Status == "requested" || bill.Status == "acknowledged") {
+			awaiting = append(awaiting, bill)
+		}
+	}
+	return awaiting, nil
+}
+
+// GetPayableBill 支払者として支払い可能な家計簿を請求者・支払者・項目と共に取得
+// 振込ファイルの作成に使用し、支払い可能かの条件はPayBillと同じ
+func (s *BillService) GetPayableBill(billID, userID uint) (*models.MonthlyBill, error) {
+	bill, err := findBillForPayer(s.repo, billID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBillPayable(bill); err != nil {
+		return nil, err
+	}
+	return s.repo.FindWithDetails(billID)
+}
+
+// markBillPaid 家計簿を支払済みに変更する（支払者の支払い操作と請求者の入金確認で共通の状態遷移）
+func (s *BillService) markBillPaid(bill *models.MonthlyBill) error {
+	if err := checkBillPayable(bill); err != nil {
+		return err
+	}
+
+	// 状態を支払済みに変更し、支払日時を設定
+	fromStatuses := []string{"requested", "acknowledged"}
+	if bill.RequireAcknowledgement {
+		fromStatuses = []string{"acknowledged"}
+	}
+	return s.transitionStatus(bill.ID, fromStatuses, map[string]interface{}{
+		"status":       "paid",
+		"payment_date": time.Now(),
+	}, errBillNotRequested)
+}
+
+// transitionStatus 家計簿の状態を変更する
+// 確認した状態（fromStatuses）から他の操作で変わっていた場合は変更せずstateErrを返す
+func (s *BillService) transitionStatus(billID uint, fromStatuses []string, fields map[string]interface{}, stateErr error) error {
+	err := s.repo.UpdateStatus(billID, fromStatuses, fields)
+	if errors.Is(err, ErrInvalidState) {
+		return stateErr
+	}
+	return err
+}
+
+// checkBillPayable 家計簿を支払済みに変更できる状態か確認する
+func checkBillPayable(bill *models.MonthlyBill) error {
+	// 内容確認が必須の家計簿はacknowledged状態（支払者確認済み）のみ支払い処理可能
+	if bill.RequireAcknowledgement && bill.Status != "acknowledged" {
+		if bill.Status == "requested" {
+			return newDomainError(ErrInvalidState, "支払前に家計簿の内容確認が必要です")
+		}
+		return errBillNotRequested
+	}
+
+	// requested状態（請求済み）またはacknowledged状態（支払者確認済み）の家計簿のみ支払い処理可能
+	if bill.Status != "requested" && bill.Status != "acknowledged" {
+		return errBillNotRequested
+	}
+	return nil
+}
+
+// DeleteBill 家計簿と項目を削除（請求者のみ、pending状態のみ）
+func (s *BillService) DeleteBill(billID, userID uint) error {
+	return s.repo.Transaction(func(repo BillRepository) error {
+		bill, err := findBillForRequester(repo, billID, userID)
+		if err != nil {
+			return err
+		}
+
+		// pending状態（作成中）の家計簿のみ削除可能
+		if bill.Status != "pending" {
+			return newDomainError(ErrInvalidState, "確定済みの家計簿は削除できません")
+		}
+
+		return repo.Delete(bill)
+	})
+}
+
+// 家計簿の状態遷移の共通エラー
+var (
+	errBillNotRequested     = newDomainError(ErrInvalidState, "家計簿が請求中状態ではありません") // 請求中でない家計簿への操作
+	errBillAlreadyRequested = newDomainError(ErrInvalidState, "家計簿は既に確定済みです")     // 作成中でない家計簿の請求
+)
+
+// validateBillItem 家計簿項目を検証し、保存する項目を作成する
+// fieldは項目のフィールド名の接頭辞（items[0]等）
+func validateBillItem(v *fieldValidator, field string, input BillItemInput) models.BillItem {
+	name := strings.TrimSpace(input.ItemName)
+	switch {
+	case name == "":
+		v.add(field+".item_name", RuleRequired, "項目名を入力してください")
+	case utf8.RuneCountInString(name) > maxBillItemNameLen:
+		v.add(field+".item_name", RuleMaxLength, "項目名は100文字以内で入力してください")
+	}
+
+	switch {
+	case input.Amount <= 0:
+		v.add(field+".amount", RuleMin, "金額は0より大きい値を指定してください")
+	case input.Amount > maxBillItemAmount:
+		v.add(field+".amount", RuleMax, "金額は99,999,999.99以下で指定してください")
+	}
+
+	taxRate := input.TaxRate
+	if taxRate == 0 {
+		taxRate = models.TaxRateStandard
+	}
+	if !models.IsValidTaxRate(taxRate) {
+		v.add(field+".tax_rate", RuleOneOf, "消費税率は8または10を指定してください")
+	}
+
+	splitRule, payerShare := resolveItemSplit(v, field, input.SplitRule, input.PayerShare)
+
+	return models.BillItem{
+		ItemName:     name,
+		Amount:       input.Amount,
+		TaxRate:      taxRate,
+		TaxExclusive: input.TaxExclusive,
+		SplitRule:    splitRule,
+		PayerShare:   payerShare,
+	}
+}
+
+// resolveItemSplit 項目の負担ルールと支払者の負担割合（%）を決定する（省略時は支払者のみ）
+func resolveItemSplit(v *fieldValidator, field, rule string, payerShare *int) (string, int) {
+	if rule == "" {
+		rule = models.SplitRulePayer
+	}
+	if !models.IsValidSplitRule(rule) {
+		v.add(field+".split_rule", RuleOneOf, "負担ルールはpayer・shared・customのいずれかを指定してください")
+		return rule, 0
+	}
+	if rule != models.SplitRuleCustom {
+		return rule, models.BillItem{SplitRule: rule}.PayerSharePercent()
+	}
+	if payerShare == nil {
+		v.add(field+".payer_share", RuleRequired, "負担ルールがcustomの場合は負担割合を指定してください")
+		return rule, 0
+	}
+	if *payerShare < 0 || *payerShare > 100 {
+		v.add(field+".payer_share", RuleRange, "負担割合は0から100の範囲で指定してください")
+		return rule, 0
+	}
+	return rule, *payerShare
+}
+
+// validateTaxRounding 消費税の端数処理方法を検証する
+func validateTaxRounding(v *fieldValidator, taxRounding string) {
+	if !models.IsValidTaxRounding(taxRounding) {
+		v.add("tax_rounding", RuleOneOf, "端数処理はfloor・round・ceilのいずれかを指定してください")
+	}
+}
+
+// validateBillComment 家計簿のコメントを検証する
+func validateBillComment(v *fieldValidator, comment string) {
+	if utf8.RuneCountInString(strings.TrimSpace(comment)) > maxBillCommentLen {
+		v.add("comment", RuleMaxLength, "コメントは1000文字以内で入力してください")
+	}
+}
+
+// findBillForRequester 請求者として参加している家計簿を取得する
+// 他のユーザーの家計簿は存在を明かさないため見つからない扱いとする
+func findBillForRequester(repo BillRepository, billID, userID uint) (*models.MonthlyBill, error) {
+	bill, err := repo.FindByID(billID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	if bill.RequesterID != userID {
+		return nil, errBillNotFound
+	}
+	return bill, nil
+}
+
+// findBillForPayer 支払者として参加している家計簿を取得する
+func findBillForPayer(repo BillRepository, billID, userID uint) (*models.MonthlyBill, error) {
+	bill, err := repo.FindByID(billID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	if bill.PayerID != userID {
+		return nil, errBillNotFound
+	}
+	return bill, nil
+}
+
+// translateNotFound リポジトリの見つからないエラーを家計簿のドメインエラーに変換する
+func translateNotFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return errBillNotFound
+	}
+	return err
+}
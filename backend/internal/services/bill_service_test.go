@@ -0,0 +1,376 @@
+// ========================================
+// 家計簿サービステスト - リポジトリのスタブによるユニットテスト
+// データベースに依存せず家計簿のビジネスルールを検証
+// ========================================
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/models"
+)
+
+// stubBillRepository メモリ上で家計簿を管理するリポジトリのスタブ
+type stubBillRepository struct {
+	bills  map[uint]*models.MonthlyBill
+	items  map[uint][]models.BillItem
+	nextID uint
+	err    error                       // 設定時は全ての操作でこのエラーを返す
+	stale  map[uint]models.MonthlyBill // 設定時は読み込みでこの家計簿を返す（他の操作で更新される前の古い状態）
+}
+
+func newStubBillRepository() *stubBillRepository {
+	return &stubBillRepository{bills: map[uint]*models.MonthlyBill{}, items: map[uint][]models.BillItem{}}
+}
+
+func (r *stubBillRepository) find(billID uint) (*models.MonthlyBill, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if bill, ok := r.stale[billID]; ok {
+		return &bill, nil
+	}
+	bill, ok := r.bills[billID]
+	if !ok {
+		return nil, fmt.Errorf("%w: bill %d", ErrNotFound, billID)
+	}
+	copied := *bill
+	copied.Items = r.items[billID]
+	return &copied, nil
+}
+
+func (r *stubBillRepository) FindByID(billID uint) (*models.MonthlyBill, error) {
+	return r.find(billID)
+}
+
+func (r *stubBillRepository) FindWithDetails(billID uint) (*models.MonthlyBill, error) {
+	return r.find(billID)
+}
+
+func (r *stubBillRepository) FindByYearMonth(userID uint, year, month int) (*models.MonthlyBill, error) {
+	for id, bill := range r.bills {
+		if bill.Year == year && bill.Month == month && (bill.RequesterID == userID || bill.PayerID == userID) {
+			return r.find(id)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *stubBillRepository) ListByParticipant(userID uint) ([]models.MonthlyBill, error) {
+	var bills []models.MonthlyBill
+	for _, bill := range r.bills {
+		if bill.RequesterID == userID || bill.PayerID == userID {
+			bills = append(bills, *bill)
+		}
+	}
+	return bills, r.err
+}
+
+func (r *stubBillRepository) Create(bill *models.MonthlyBill) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, existing := range r.bills {
+		if existing.RequesterID == bill.RequesterID && existing.Year == bill.Year && existing.Month == bill.Month {
+			return fmt.Errorf("%w: duplicate", ErrConflict)
+		}
+	}
+	r.nextID++
+	bill.ID = r.nextID
+	copied := *bill
+	r.bills[bill.ID] = &copied
+	return nil
+}
+
+func (r *stubBillRepository) Save(bill *models.MonthlyBill) error {
+	if r.err != nil {
+		return r.err
+	}
+	copied := *bill
+	r.bills[bill.ID] = &copied
+	return nil
+}
+
+func (r *stubBillRepository) UpdateStatus(billID uint, fromStatuses []string, fields map[string]interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	bill, ok := r.bills[billID]
+	if !ok || !slices.Contains(fromStatuses, bill.Status) {
+		return fmt.Errorf("%w: bill %d", ErrInvalidState, billID)
+	}
+	for column, value := range fields {
+		switch column {
+		case "status":
+			bill.Status = value.(string)
+		case "request_date":
+			at := value.(time.Time)
+			bill.RequestDate = &at
+		case "acknowledged_at":
+			at := value.(time.Time)
+			bill.AcknowledgedAt = &at
+		case "acknowledgement_comment":
+			bill.AcknowledgementComment = value.(string)
+		case "payment_date":
+			at := value.(time.Time)
+			bill.PaymentDate = &at
+		default:
+			return fmt.Errorf("unsupported column: %s", column)
+		}
+	}
+	return nil
+}
+
+func (r *stubBillRepository) ReplaceItems(billID uint, items []models.BillItem) error {
+	r.items[billID] = items
+	return r.err
+}
+
+func (r *stubBillRepository) Delete(bill *models.MonthlyBill) error {
+	delete(r.bills, bill.ID)
+	delete(r.items, bill.ID)
+	return r.err
+}
+
+func (r *stubBillRepository) Transaction(fn func(repo BillRepository) error) error {
+	return fn(r)
+}
+
+// stubPayerPolicy 支払者の判定のスタブ
+type stubPayerPolicy struct {
+	allowed        bool
+	acknowledgment bool
+	missingPayer   bool // 設定時は支払者が存在しない扱いにする
+	err            error
+}
+
+func (p stubPayerPolicy) PayerExists(payerID uint) (bool, error) {
+	return !p.missingPayer, nil
+}
+
+func (p stubPayerPolicy) CanBillPayer(requesterID, payerID uint) (bool, error) {
+	return p.allowed, p.err
+}
+
+func (p stubPayerPolicy) RequiresAcknowledgement(requesterID, payerID uint) bool {
+	return p.acknowledgment
+}
+
+// TestBillService_CreateBill 作成時の入力検証・支払者判定・重複がドメインエラーになることを検証
+func TestBillService_CreateBill(t *testing.T) {
+	repo := newStubBillRepository()
+	service := NewBillService(repo, stubPayerPolicy{allowed: true, acknowledgment: true})
+
+	bill, err := service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 10, Comment: " 10月分 "})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", bill.Status)
+	assert.Equal(t, "10月分", bill.Comment)
+	assert.Equal(t, models.TaxRoundingFloor, bill.TaxRounding)
+	assert.Equal(t, models.BillPeriodMonth, bill.PeriodType)
+	assert.True(t, bill.RequireAcknowledgement)
+
+	_, err = service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 10})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "指定された年月の家計簿は既に存在します", err.Error())
+
+	_, err = service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 1, Year: 2024, Month: 11})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 11, TaxRounding: "bankers"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, PeriodStart: "2024-11-01"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	missing := NewBillService(repo, stubPayerPolicy{allowed: true, missingPayer: true})
+	_, err = missing.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 99, Year: 2024, Month: 11})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []FieldError{{Field: "payer_id", Rule: RuleNotFound, Message: "指定された支払者が見つかりません"}}, validationErr.Fields)
+
+	denied := NewBillService(repo, stubPayerPolicy{allowed: false})
+	_, err = denied.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 3, Year: 2024, Month: 11})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// 判定時のデータベースエラーはドメインエラーにしない
+	failing := NewBillService(repo, stubPayerPolicy{err: errors.New("connection lost")})
+	_, err = failing.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 11})
+	var domainErr *DomainError
+	assert.False(t, errors.As(err, &domainErr))
+}
+
+// TestBillService_CreateBillValidation 作成時の不正な入力が全てフィールド単位の検証エラーとして返されることを検証
+func TestBillService_CreateBillValidation(t *testing.T) {
+	service := NewBillService(newStubBillRepository(), stubPayerPolicy{allowed: true})
+
+	tests := []struct {
+		name   string
+		input  CreateBillInput
+		fields []FieldError
+	}{
+		{
+			name:  "年月・支払者が全て不正",
+			input: CreateBillInput{RequesterID: 1, PayerID: 1, Year: -1, Month: 13},
+			fields: []FieldError{
+				{Field: "year", Rule: RuleRange, Message: "年は2000から2100の範囲で指定してください"},
+				{Field: "month", Rule: RuleRange, Message: "月は1から12の範囲で指定してください"},
+				{Field: "payer_id", Rule: RuleNotSelf, Message: "請求者と支払者は異なるユーザーである必要があります"},
+			},
+		},
+		{
+			name:  "年月・支払者の指定なし",
+			input: CreateBillInput{RequesterID: 1, TaxRounding: "bankers"},
+			fields: []FieldError{
+				{Field: "year", Rule: RuleRequired, Message: "対象の年月、または期間の開始日・終了日を指定してください"},
+				{Field: "month", Rule: RuleRequired, Message: "対象の年月、または期間の開始日・終了日を指定してください"},
+				{Field: "payer_id", Rule: RuleRequired, Message: "支払者を指定してください"},
+				{Field: "tax_rounding", Rule: RuleOneOf, Message: "端数処理はfloor・round・ceilのいずれかを指定してください"},
+			},
+		},
+		{
+			name:  "任意期間の日付が不正",
+			input: CreateBillInput{RequesterID: 1, PayerID: 2, PeriodStart: "1999-12-01", PeriodEnd: "2024/01/31"},
+			fields: []FieldError{
+				{Field: "period_start", Rule: RuleRange, Message: "開始日は2000年から2100年の範囲で指定してください"},
+				{Field: "period_end", Rule: RuleFormat, Message: "終了日はYYYY-MM-DD形式で指定してください"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateBill(tt.input)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.fields, validationErr.Fields)
+			assert.Equal(t, tt.fields[0].Message, err.Error())
+		})
+	}
+}
+
+// TestBillService_StatusTransitions 請求・確認・支払い・削除の状態遷移と権限を検証
+func TestBillService_StatusTransitions(t *testing.T) {
+	repo := newStubBillRepository()
+	service := NewBillService(repo, stubPayerPolicy{allowed: true})
+	bill, err := service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 10})
+	require.NoError(t, err)
+
+	// 支払者は請求できず、請求者以外には家計簿の存在を明かさない
+	assert.ErrorIs(t, service.RequestBill(bill.ID, 2), ErrNotFound)
+	assert.ErrorIs(t, service.PayBill(bill.ID, 2), ErrInvalidState)
+	assert.ErrorIs(t, service.RequestBill(999, 1), ErrNotFound)
+
+	require.NoError(t, service.RequestBill(bill.ID, 1))
+	assert.ErrorIs(t, service.RequestBill(bill.ID, 1), ErrInvalidState)
+	assert.ErrorIs(t, service.DeleteBill(bill.ID, 1), ErrInvalidState)
+
+	_, err = service.UpdateItems(bill.ID, 1, UpdateItemsInput{})
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	// 請求者は支払い処理できない
+	assert.ErrorIs(t, service.PayBill(bill.ID, 1), ErrNotFound)
+	require.NoError(t, service.AcknowledgeBill(bill.ID, 2, "確認しました"))
+	require.NoError(t, service.PayBill(bill.ID, 2))
+
+	paid, err := service.GetBill(bill.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "paid", paid.Status)
+	assert.NotNil(t, paid.PaymentDate)
+
+	_, err = service.GetBill(bill.ID, 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestBillService_StatusTransitionsWithStaleBill 読み込んだ家計簿が古い状態でも、同じ状態遷移を二重に実行しないことを検証
+func TestBillService_StatusTransitionsWithStaleBill(t *testing.T) {
+	repo := newStubBillRepository()
+	service := NewBillService(repo, stubPayerPolicy{allowed: true})
+	bill, err := service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 10})
+	require.NoError(t, err)
+
+	// 同時に処理された2つのリクエストが、どちらも更新前の家計簿を読み込んだ状態を再現する
+	transitions := []struct {
+		name       string
+		transition func() error
+	}{
+		{"請求", func() error { return service.RequestBill(bill.ID, 1) }},
+		{"内容確認", func() error { return service.AcknowledgeBill(bill.ID, 2, "確認しました") }},
+		{"支払い", func() error { return service.PayBill(bill.ID, 2) }},
+	}
+	for _, tt := range transitions {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.stale = nil
+			stale, err := repo.FindByID(bill.ID)
+			require.NoError(t, err)
+			repo.stale = map[uint]models.MonthlyBill{bill.ID: *stale}
+			defer func() { repo.stale = nil }()
+
+			require.NoError(t, tt.transition())
+			updated := *repo.bills[bill.ID]
+			assert.NotEqual(t, stale.Status, updated.Status)
+
+			// 2回目は古い状態では実行可能に見えるが、既に状態が変わっているため更新しない
+			assert.ErrorIs(t, tt.transition(), ErrInvalidState)
+			assert.Equal(t, updated, *repo.bills[bill.ID])
+		})
+	}
+}
+
+// TestBillService_UpdateItems 項目の検証と、無効な項目がある場合は保存されないことを検証
+func TestBillService_UpdateItems(t *testing.T) {
+	repo := newStubBillRepository()
+	service := NewBillService(repo, stubPayerPolicy{allowed: true})
+	bill, err := service.CreateBill(CreateBillInput{RequesterID: 1, PayerID: 2, Year: 2024, Month: 10})
+	require.NoError(t, err)
+
+	rounding := models.TaxRoundingCeil
+	_, err = service.UpdateItems(bill.ID, 1, UpdateItemsInput{
+		Items: []BillItemInput{
+			{ItemName: "家賃", Amount: 80000},
+			{ItemName: "", Amount: 100},
+			{ItemName: "返金", Amount: -500},
+		},
+		TaxRounding: &rounding,
+	})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Equal(t, []FieldError{
+		{Field: "items[1].item_name", Rule: RuleRequired, Message: "項目名を入力してください"},
+		{Field: "items[2].amount", Rule: RuleMin, Message: "金額は0より大きい値を指定してください"},
+	}, validationErr.Fields)
+	assert.Empty(t, repo.items[bill.ID])
+	assert.Equal(t, models.TaxRoundingFloor, repo.bills[bill.ID].TaxRounding)
+
+	updated, err := service.UpdateItems(bill.ID, 1, UpdateItemsInput{
+		Items: []BillItemInput{
+			{ItemName: " 家賃 ", Amount: 80000},
+			{ItemName: "食料品", Amount: 1080, TaxRate: models.TaxRateReduced},
+		},
+		TaxRounding: &rounding,
+	})
+	require.NoError(t, err)
+	require.Len(t, updated.Items, 2)
+	assert.Equal(t, "家賃", updated.Items[0].ItemName)
+	assert.Equal(t, models.TaxRateStandard, updated.Items[0].TaxRate)
+	assert.Equal(t, models.TaxRoundingCeil, updated.TaxRounding)
+
+	_, err = service.UpdateItems(bill.ID, 1, UpdateItemsInput{Items: []BillItemInput{{ItemName: "日用品", Amount: 100, TaxRate: 5}}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = service.UpdateItems(bill.ID, 2, UpdateItemsInput{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// リポジトリのエラーはそのまま返す
+	repo.err = errors.New("connection lost")
+	_, err = service.UpdateItems(bill.ID, 1, UpdateItemsInput{})
+	assert.EqualError(t, err, "connection lost")
+}
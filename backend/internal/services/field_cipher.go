@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+
+	"money_management/internal/middleware"
+)
+
+// fieldCipherVersion 暗号文の形式のバージョン（キーや方式の変更時に判別するための接頭辞）
+const fieldCipherVersion = "v1:"
+
+// ErrFieldDecryption 暗号化データを復号できない場合のエラー（キーの変更や改ざん）
+var ErrFieldDecryption = errors.New("暗号化データを復号できません")
+
+// FieldCipher DBに保存する項目をAES-256-GCMで暗号化・復号する
+// 暗号文には保存先を表す文字列（ユーザーID等）を関連データとして結び付け、他の行への複製を検出する
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// NewFieldCipher 秘密鍵からFieldCipherを作成する（AESキーは秘密鍵のSHA-256）
+func NewFieldCipher(secret []byte) (*FieldCipher, error) {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldCipher{aead: aead}, nil
+}
+
+var (
+	defaultFieldCipher     *FieldCipher
+	defaultFieldCipherErr  error
+	defaultFieldCipherOnce sync.Once
+)
+
+// DefaultFieldCipher 設定された暗号化キー（FIELD_ENCRYPTION_KEY）のFieldCipherを取得する
+func DefaultFieldCipher() (*FieldCipher, error) {
+	defaultFieldCipherOnce.Do(func() {
+		secret, err := middleware.GetFieldEncryptionKey()
+		if err != nil {
+			defaultFieldCipherErr = err
+			return
+		}
+		defaultFieldCipher, defaultFieldCipherErr = NewFieldCipher(secret)
+	})
+	return defaultFieldCipher, defaultFieldCipherErr
+}
+
+// Encrypt 平文を暗号化し、バージョン接頭辞付きのBase64文字列を返す
+func (c *FieldCipher) Encrypt(plaintext []byte, associated string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, []byte(associated))
+	return fieldCipherVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt Encryptで作成した文字列を復号する（関連データが一致しない場合もErrFieldDecryption）
+func (c *FieldCipher) Decrypt(encoded string, associated string) ([]byte, error) {
+	if !strings.HasPrefix(encoded, fieldCipherVersion) {
+		return nil, ErrFieldDecryption
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, fieldCipherVersion))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, ErrFieldDecryption
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(associated))
+	if err != nil {
+		return nil, ErrFieldDecryption
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"money_management/internal/config"
+	"money_management/internal/models"
+)
+
+// ========================================
+// ログイン失敗の記録 - アカウントIDごとの段階的な待機時間と一時的なロック
+// IPごとのレート制限（AuthRateLimitMiddleware）では防げない、分散した推測を遅らせる
+// ========================================
+
+// ロック解除の理由
+const (
+	LoginUnlockExpired         = "expired"          // ロック期間の経過
+	LoginUnlockPasswordReset   = "password_reset"   // パスワード再設定
+	LoginUnlockPasswordChanged = "password_changed" // パスワード変更
+)
+
+// maxLoginDelay 段階的な待機時間の上限
+const maxLoginDelay = 30 * time.Second
+
+// LoginLockoutPolicy ログイン失敗時の待機時間とロックの設定
+type LoginLockoutPolicy struct {
+	DelayAfter      int           // 待機時間を設け始める失敗回数（以降は失敗ごとに待機時間が倍になる）
+	LockoutAfter    int           // 一時的にロックする失敗回数
+	LockoutDuration time.Duration // ロックの期間（最後の失敗からこの期間が過ぎると失敗回数もリセット）
+}
+
+// DefaultLoginLockoutPolicy 環境変数からログイン失敗時の設定を取得する
+// LOGIN_DELAY_AFTER（既定3回）、LOGIN_LOCKOUT_THRESHOLD（既定10回）、LOGIN_LOCKOUT_MINUTES（既定15分）
+func DefaultLoginLockoutPolicy() LoginLockoutPolicy {
+	return LoginLockoutPolicy{
+		DelayAfter:      config.GetIntEnv("LOGIN_DELAY_AFTER", 3),
+		LockoutAfter:    config.GetIntEnv("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LockoutDuration: time.Duration(config.GetIntEnv("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// retryAfter 失敗の記録から次にログインを試行できるまでの時間を返す（0の場合は試行可能）
+func (p LoginLockoutPolicy) retryAfter(attempt models.LoginAttempt, now time.Time) time.Duration {
+	if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return attempt.LockedUntil.Sub(now)
+	}
+	if attempt.FailedCount < p.DelayAfter {
+		return 0
+	}
+	delay := maxLoginDelay
+	if shift := attempt.FailedCount - p.DelayAfter; shift < 5 {
+		delay = time.Duration(1<<shift) * time.Second
+	}
+	if wait := attempt.LastFailedAt.Add(delay).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// LoginAttemptService アカウントIDごとのログイン失敗の記録とロック
+type LoginAttemptService struct {
+	db     *gorm.DB
+	policy LoginLockoutPolicy
+}
+
+// NewLoginAttemptService ログイン失敗の記録サービスの初期化（環境変数の設定を使用）
+func NewLoginAttemptService(db *gorm.DB) *LoginAttemptService {
+	return &LoginAttemptService{db: db, policy: DefaultLoginLockoutPolicy()}
+}
+
+// RetryAfter アカウントIDで次にログインを試行できるまでの時間を返す（0の場合は試行可能）
+// ロック期間が過ぎた記録はここで削除する
+func (s *LoginAttemptService) RetryAfter(accountID string, now time.Time) (time.Duration, error) {
+	var attempt models.LoginAttempt
+	if err := s.db.Where("account_key = ?", loginAccountKey(accountID)).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if now.After(attempt.LastFailedAt.Add(s.policy.LockoutDuration)) &&
+		(attempt.LockedUntil == nil || !now.Before(*attempt.LockedUntil)) {
+		if attempt.LockedUntil != nil {
+			log.Printf("🔓 Login unlocked: account=%q reason=%s", accountID, LoginUnlockExpired)
+		}
+		return 0, s.db.Delete(&models.LoginAttempt{}, "account_key = ?", attempt.AccountKey).Error
+	}
+	return s.policy.retryAfter(attempt, now), nil
+}
+
+// RecordFailure ログインの失敗を記録し、失敗回数がしきい値に達した場合はロックする
+func (s *LoginAttemptService) RecordFailure(accountID string, now time.Time) error {
+	key := loginAccountKey(accountID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 同時に失敗した場合も回数を取りこぼさないよう、加算はデータベースで行う
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "account_key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_count":   gorm.Expr("failed_count + 1"),
+				"last_failed_at": now,
+				"updated_at":     now,
+			}),
+		}).Create(&models.LoginAttempt{AccountKey: key, FailedCount: 1, LastFailedAt: now}).Error; err != nil {
+			return err
+		}
+
+		var attempt models.LoginAttempt
+		if err := tx.Where("account_key = ?", key).First(&attempt).Error; err != nil {
+			return err
+		}
+		if attempt.FailedCount < s.policy.LockoutAfter || attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			return nil
+		}
+
+		lockedUntil := now.Add(s.policy.LockoutDuration)
+		if err := tx.Model(&attempt).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+		log.Printf("🔒 Login locked: account=%q failures=%d until=%s",
+			accountID, attempt.FailedCount, lockedUntil.Format(time.RFC3339))
+		return nil
+	})
+}
+
+// RecordSuccess ログインの成功で失敗の記録を削除する
+func (s *LoginAttemptService) RecordSuccess(accountID string) error {
+	return s.db.Delete(&models.LoginAttempt{}, "account_key = ?", loginAccountKey(accountID)).Error
+}
+
+// Unlock アカウントIDの失敗の記録とロックを解除する（パスワード再設定・変更時）
+func (s *LoginAttemptService) Unlock(accountID, reason string) error {
+	result := s.db.Delete(&models.LoginAttempt{}, "account_key = ?", loginAccountKey(accountID))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔓 Login unlocked: account=%q reason=%s", accountID, reason)
+	}
+	return nil
+}
+
+// PurgeStale ロック中でなく、最後の失敗からロックの期間が過ぎた記録を削除し、削除件数を返す
+// 存在しないアカウントIDへの試行の記録が残り続けないよう、定期的に実行する
+func (s *LoginAttemptService) PurgeStale(now time.Time) (int64, error) {
+	result := s.db.Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-s.policy.LockoutDuration), now).
+		Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// loginAccountKey アカウントIDを正規化（前後の空白除去・小文字化）したSHA-256ハッシュを返す
+// 照合順序が大文字小文字を区別しないDBでも、表記の違いで記録を分けられないようにする
+func loginAccountKey(accountID string) string {
+	return hashRefreshToken(strings.ToLower(strings.TrimSpace(accountID)))
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"money_management/internal/config"
+	"money_management/internal/models"
+	testmocks "money_management/internal/testing"
+)
+
+// ========================================
+// パスワードハッシュ - アルゴリズムとパラメータを記録した形式でハッシュを保存
+// 新しいハッシュはArgon2id（既定）で作成し、従来のbcryptのハッシュも引き続き照合できる
+// 古い形式・設定のハッシュはログインに成功した時に現在の設定で作り直す
+// ========================================
+
+// パスワードハッシュのアルゴリズム（PASSWORD_HASH_ALGORITHM）
+const (
+	PasswordHashArgon2id = "argon2id" // Argon2id（既定）
+	PasswordHashBcrypt   = "bcrypt"   // bcrypt（従来の形式）
+
+	argon2idHashPrefix = "$argon2id$"
+	// argon2idMaxPasswordBytes Argon2idで受け付ける最大のバイト数（極端に長い入力による負荷を防ぐ）
+	argon2idMaxPasswordBytes = 1024
+)
+
+// パスワードハッシュのエラー
+var (
+	ErrPasswordMismatch        = errors.New("パスワードが一致しません")
+	ErrUnsupportedPasswordHash = errors.New("対応していないパスワードハッシュの形式です")
+)
+
+// Argon2idParams Argon2idのパラメータ（ハッシュに記録され、照合時はハッシュの値を使用する）
+type Argon2idParams struct {
+	Memory      uint32 // メモリ使用量（KiB）
+	Iterations  uint32 // 反復回数
+	Parallelism uint8  // 並列度
+	SaltLength  uint32 // ソルトのバイト数
+	KeyLength   uint32 // ハッシュのバイト数
+}
+
+// defaultArgon2idParams Argon2idの既定のパラメータ（OWASPの推奨値: 19MiB・2回・並列度1）
+var defaultArgon2idParams = Argon2idParams{Memory: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// DefaultArgon2idParams 環境変数からArgon2idのパラメータを取得する
+// PASSWORD_ARGON2_MEMORY_KIB（既定19456）、PASSWORD_ARGON2_ITERATIONS（既定2）、PASSWORD_ARGON2_PARALLELISM（既定1）
+func DefaultArgon2idParams() Argon2idParams {
+	params := defaultArgon2idParams
+	params.Memory = uint32(config.GetIntEnv("PASSWORD_ARGON2_MEMORY_KIB", int(params.Memory)))
+	params.Iterations = uint32(config.GetIntEnv("PASSWORD_ARGON2_ITERATIONS", int(params.Iterations)))
+	params.Parallelism = uint8(config.GetIntEnv("PASSWORD_ARGON2_PARALLELISM", int(params.Parallelism)))
+	return params
+}
+
+// validate パラメータがArgon2idの要件を満たすか検証する
+func (p Argon2idParams) validate() error {
+	if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) {
+		return fmt.Errorf("invalid argon2id parameters: m=%d t=%d p=%d", p.Memory, p.Iterations, p.Parallelism)
+	}
+	if p.SaltLength < 8 || p.KeyLength < 16 {
+		return fmt.Errorf("invalid argon2id salt or key length: salt=%d key=%d", p.SaltLength, p.KeyLength)
+	}
+	return nil
+}
+
+// Argon2idPasswordHasher Argon2idでハッシュ化するハッシャー（bcryptのハッシュも照合できる）
+type Argon2idPasswordHasher struct {
+	Params Argon2idParams
+}
+
+// HashPassword パスワードをArgon2idでハッシュ化し、パラメータを含む形式で返す
+// 形式: $argon2id$v=19$m=<メモリ>,t=<反復回数>,p=<並列度>$<ソルト>$<ハッシュ>（Base64、パディングなし）
+func (h *Argon2idPasswordHasher) HashPassword(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idHashPrefix, argon2.Version,
+		h.Params.Memory, h.Params.Iterations, h.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// ComparePassword パスワードをハッシュと照合する（Argon2idとbcryptの両方に対応）
+func (h *Argon2idPasswordHasher) ComparePassword(hashedPassword, password string) error {
+	return comparePasswordHash(hashedPassword, password)
+}
+
+// NeedsRehash ハッシュがArgon2id以外か、現在のパラメータと異なる場合にtrueを返す
+func (h *Argon2idPasswordHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params != h.Params
+}
+
+// MaxPasswordBytes ハッシュ化できるパスワードの最大のバイト数
+func (h *Argon2idPasswordHasher) MaxPasswordBytes() int {
+	return argon2idMaxPasswordBytes
+}
+
+// comparePasswordHash ハッシュの形式を判別してパスワードを照合する
+func comparePasswordHash(hashedPassword, password string) error {
+	switch {
+	case strings.HasPrefix(hashedPassword, argon2idHashPrefix):
+		params, salt, key, err := decodeArgon2idHash(hashedPassword)
+		if err != nil {
+			return err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	case isBcryptHash(hashedPassword):
+		if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrPasswordMismatch
+			}
+			return err
+		}
+		return nil
+	default:
+		return ErrUnsupportedPasswordHash
+	}
+}
+
+// decodeArgon2idHash Argon2idのハッシュからパラメータ・ソルト・ハッシュを取り出す
+func decodeArgon2idHash(hashedPassword string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	if err := params.validate(); err != nil {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+	return params, salt, key, nil
+}
+
+// isBcryptHash bcryptのハッシュの形式か判定する
+func isBcryptHash(hashedPassword string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hashedPassword, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordByteLimiter ハッシュ化できるパスワードのバイト数に上限があるハッシャー
+type passwordByteLimiter interface {
+	MaxPasswordBytes() int
+}
+
+// グローバルなパスワードハッシャー（起動時にSetPasswordHasherで差し替える）
+var (
+	passwordHasher      testmocks.PasswordHasherInterface = &Argon2idPasswordHasher{Params: defaultArgon2idParams}
+	passwordHasherMutex sync.RWMutex
+)
+
+// SetPasswordHasher パスワードのハッシュ化に使用するハッシャーを設定する
+func SetPasswordHasher(hasher testmocks.PasswordHasherInterface) {
+	passwordHasherMutex.Lock()
+	defer passwordHasherMutex.Unlock()
+	passwordHasher = hasher
+}
+
+// CurrentPasswordHasher 現在のパスワードハッシャーを取得する
+func CurrentPasswordHasher() testmocks.PasswordHasherInterface {
+	passwordHasherMutex.RLock()
+	defer passwordHasherMutex.RUnlock()
+	return passwordHasher
+}
+
+// NewPasswordHasherFromEnv 環境変数の設定からパスワードハッシャーを作成する
+// PASSWORD_HASH_ALGORITHM: argon2id（既定）/ bcrypt
+// どちらの場合も保存済みのArgon2idとbcryptのハッシュは照合でき、ログイン時に設定したアルゴリズムで作り直す
+func NewPasswordHasherFromEnv() (testmocks.PasswordHasherInterface, error) {
+	algorithm := strings.ToLower(config.GetStringEnv("PASSWORD_HASH_ALGORITHM", PasswordHashArgon2id))
+	switch algorithm {
+	case PasswordHashArgon2id:
+		params := DefaultArgon2idParams()
+		if err := params.validate(); err != nil {
+			return nil, err
+		}
+		return &Argon2idPasswordHasher{Params: params}, nil
+	case PasswordHashBcrypt:
+		return &BcryptPasswordHasher{}, nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASH_ALGORITHM: %q (argon2id, bcrypt)", algorithm)
+	}
+}
+
+// UpgradePasswordHash ログインに成功したパスワードのハッシュが古い形式・設定の場合、現在の設定で作り直して保存する
+// パスワード自体は変わらないため、トークン世代は進めない
+// 同時に変更された場合に上書きしないよう、保存済みのハッシュが変わっていない場合のみ更新する
+func UpgradePasswordHash(db *gorm.DB, hasher testmocks.PasswordHasherInterface, userID uint, hashedPassword, password string) error {
+	if !hasher.NeedsRehash(hashedPassword) {
+		return nil
+	}
+	newHash, err := hasher.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", userID, hashedPassword).
+		Update("password_hash", newHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🔐 Password hash upgraded: user=%d", userID)
+	}
+	return nil
+}
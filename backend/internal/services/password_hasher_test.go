@@ -0,0 +1,114 @@
+// ========================================
+// パスワードハッシュの自動テスト
+// Argon2idの形式、従来のbcryptのハッシュの照合と、作り直しが必要かの判定を確認
+// ========================================
+
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams テスト用の軽量なArgon2idのパラメータ
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// TestArgon2idPasswordHasher_RoundTrip パラメータを記録した形式でハッシュ化し、照合できることを検証
+func TestArgon2idPasswordHasher_RoundTrip(t *testing.T) {
+	hasher := &Argon2idPasswordHasher{Params: testArgon2idParams}
+
+	hash, err := hasher.HashPassword("correct-horse-42")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+
+	// 同じパスワードでもソルトが異なるため別のハッシュになる
+	other, err := hasher.HashPassword("correct-horse-42")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	assert.NoError(t, hasher.ComparePassword(hash, "correct-horse-42"))
+	assert.ErrorIs(t, hasher.ComparePassword(hash, "correct-horse-43"), ErrPasswordMismatch)
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+// TestPasswordHasher_LegacyBcrypt 従来のbcryptのハッシュを照合でき、Argon2idへの作り直しが必要と判定されることを検証
+func TestPasswordHasher_LegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	argon := &Argon2idPasswordHasher{Params: testArgon2idParams}
+	assert.NoError(t, argon.ComparePassword(string(legacy), "password123"))
+	assert.ErrorIs(t, argon.ComparePassword(string(legacy), "password124"), ErrPasswordMismatch)
+	assert.True(t, argon.NeedsRehash(string(legacy)))
+
+	// bcryptを選択した場合はコストが低いハッシュとArgon2idのハッシュを作り直す
+	bcryptHasher := &BcryptPasswordHasher{}
+	assert.True(t, bcryptHasher.NeedsRehash(string(legacy)))
+	current, err := bcryptHasher.HashPassword("password123")
+	require.NoError(t, err)
+	assert.False(t, bcryptHasher.NeedsRehash(current))
+
+	argonHash, err := argon.HashPassword("password123")
+	require.NoError(t, err)
+	assert.NoError(t, bcryptHasher.ComparePassword(argonHash, "password123"))
+	assert.True(t, bcryptHasher.NeedsRehash(argonHash))
+}
+
+// TestArgon2idPasswordHasher_NeedsRehashOnParamChange パラメータを変更すると既存のハッシュの作り直しが必要になることを検証
+func TestArgon2idPasswordHasher_NeedsRehashOnParamChange(t *testing.T) {
+	hash, err := (&Argon2idPasswordHasher{Params: testArgon2idParams}).HashPassword("correct-horse-42")
+	require.NoError(t, err)
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	hasher := &Argon2idPasswordHasher{Params: stronger}
+	assert.True(t, hasher.NeedsRehash(hash))
+	// 照合はハッシュに記録されたパラメータで行う
+	assert.NoError(t, hasher.ComparePassword(hash, "correct-horse-42"))
+}
+
+// TestComparePasswordHash_Malformed 不正な形式のハッシュは照合に失敗することを検証
+func TestComparePasswordHash_Malformed(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain-text-password",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5a2V5a2V5a2V5a2V5",
+	} {
+		assert.ErrorIs(t, comparePasswordHash(hash, "password"), ErrUnsupportedPasswordHash, hash)
+	}
+}
+
+// TestNewPasswordHasherFromEnv 環境変数でアルゴリズムとパラメータを選択できることを検証
+func TestNewPasswordHasherFromEnv(t *testing.T) {
+	hasher, err := NewPasswordHasherFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Argon2idPasswordHasher{Params: defaultArgon2idParams}, hasher)
+
+	t.Setenv("PASSWORD_ARGON2_MEMORY_KIB", "65536")
+	t.Setenv("PASSWORD_ARGON2_ITERATIONS", "3")
+	hasher, err = NewPasswordHasherFromEnv()
+	require.NoError(t, err)
+	params := hasher.(*Argon2idPasswordHasher).Params
+	assert.Equal(t, uint32(65536), params.Memory)
+	assert.Equal(t, uint32(3), params.Iterations)
+
+	t.Setenv("PASSWORD_ARGON2_ITERATIONS", "0")
+	_, err = NewPasswordHasherFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	hasher, err = NewPasswordHasherFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &BcryptPasswordHasher{}, hasher)
+
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "md5")
+	_, err = NewPasswordHasherFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"money_management/internal/config"
+)
+
+// ========================================
+// パスワードポリシー - 登録・変更・再設定で共通のパスワードの検証
+// 違反はルールのコード付きのValidationErrorで返し、フロントエンドで表示を切り替えられるようにする
+// ========================================
+
+// パスワードポリシーの違反ルールのコード
+const (
+	RulePasswordMinLength         = "password_min_length"         // 文字数が下限未満
+	RulePasswordMaxBytes          = "password_max_bytes"          // バイト数が上限超過
+	RulePasswordCharacterClasses  = "password_character_classes"  // 文字種の数が不足
+	RulePasswordBreached          = "password_breached"           // よく使われる・漏洩したパスワード
+	RulePasswordInvalidCharacters = "password_invalid_characters" // 制御文字などの使用できない文字を含む
+)
+
+// bcryptMaxPasswordBytes bcryptがハッシュ化に使用する最大のバイト数（超過分は無視される）
+// ハッシャーの上限が不明な場合もこの値を上限にする
+const bcryptMaxPasswordBytes = 72
+
+// commonPasswordsList 同梱のよく使われる・漏洩したパスワードの一覧
+//
+//go:embed data/common_passwords.txt
+var commonPasswordsList string
+
+// PasswordPolicy パスワードの要件
+type PasswordPolicy struct {
+	MinLength           int  `json:"min_length"`            // 最小文字数
+	MaxBytes            int  `json:"max_bytes"`             // 最大バイト数（UTF-8、ハッシュのアルゴリズムの上限以下）
+	MinCharacterClasses int  `json:"min_character_classes"` // 含める文字種（英小文字・英大文字・数字・記号）の最小数
+	RejectBreached      bool `json:"reject_breached"`       // よく使われる・漏洩したパスワードを拒否するか
+}
+
+// defaultPasswordMaxBytes 最大バイト数の既定値（ハッシュのアルゴリズムの上限の方が小さい場合はその値）
+const defaultPasswordMaxBytes = 128
+
+// DefaultPasswordPolicy 環境変数からパスワードポリシーを取得する
+// PASSWORD_MIN_LENGTH（既定8）、PASSWORD_MAX_BYTES（既定128、ハッシュのアルゴリズムの上限を超える値は上限）、
+// PASSWORD_MIN_CHARACTER_CLASSES（既定1）、PASSWORD_REJECT_BREACHED（既定true）
+// 上限はbcryptが72バイト、Argon2idが1024バイト
+func DefaultPasswordPolicy() PasswordPolicy {
+	limit := bcryptMaxPasswordBytes
+	if limiter, ok := CurrentPasswordHasher().(passwordByteLimiter); ok {
+		limit = limiter.MaxPasswordBytes()
+	}
+	policy := PasswordPolicy{
+		MinLength:           config.GetIntEnv("PASSWORD_MIN_LENGTH", 8),
+		MaxBytes:            config.GetIntEnv("PASSWORD_MAX_BYTES", min(defaultPasswordMaxBytes, limit)),
+		MinCharacterClasses: config.GetIntEnv("PASSWORD_MIN_CHARACTER_CLASSES", 1),
+		RejectBreached:      config.GetBoolEnv("PASSWORD_REJECT_BREACHED", true),
+	}
+	if policy.MaxBytes <= 0 || policy.MaxBytes > limit {
+		policy.MaxBytes = limit
+	}
+	return policy
+}
+
+// Validate パスワードがポリシーを満たすか検証する（fieldはエラーに含めるフィールド名）
+// 違反がある場合は全てのルールの違反をまとめたValidationErrorを返す
+func (p PasswordPolicy) Validate(field, password string) error {
+	var v fieldValidator
+
+	if length := utf8.RuneCountInString(password); length < p.MinLength {
+		v.fields = append(v.fields, FieldError{
+			Field: field, Rule: RulePasswordMinLength,
+			Message: fmt.Sprintf("パスワードは%d文字以上で入力してください", p.MinLength),
+			Params:  map[string]interface{}{"min": p.MinLength},
+		})
+	}
+	if len(password) > p.MaxBytes {
+		v.fields = append(v.fields, FieldError{
+			Field: field, Rule: RulePasswordMaxBytes,
+			Message: fmt.Sprintf("パスワードは%dバイト以内で入力してください（全角文字は1文字3バイト）", p.MaxBytes),
+			Params:  map[string]interface{}{"max": p.MaxBytes},
+		})
+	}
+	if strings.IndexFunc(password, unicode.IsControl) >= 0 || !utf8.ValidString(password) {
+		v.add(field, RulePasswordInvalidCharacters, "パスワードに使用できない文字が含まれています")
+	}
+	if classes := passwordCharacterClasses(password); classes < p.MinCharacterClasses {
+		v.fields = append(v.fields, FieldError{
+			Field: field, Rule: RulePasswordCharacterClasses,
+			Message: fmt.Sprintf("パスワードには英小文字・英大文字・数字・記号のうち%d種類以上を含めてください", p.MinCharacterClasses),
+			Params:  map[string]interface{}{"min": p.MinCharacterClasses},
+		})
+	}
+	if p.RejectBreached && IsBreachedPassword(password) {
+		v.add(field, RulePasswordBreached, "このパスワードはよく使われているか漏洩が確認されているため使用できません")
+	}
+
+	return v.err()
+}
+
+// passwordCharacterClasses パスワードに含まれる文字種（英小文字・英大文字・数字・記号）の数を返す
+// 英字以外の文字（ひらがな・漢字など）は記号と同じ種類として数える
+func passwordCharacterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		default:
+			other = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// breachedPasswords 拒否するパスワードの集合（同梱の一覧とPASSWORD_BREACHED_LIST_FILEの一覧、初回のみ読み込み）
+var breachedPasswords = sync.OnceValue(func() map[string]struct{} {
+	set := make(map[string]struct{})
+	addPasswordList(set, bufio.NewScanner(strings.NewReader(commonPasswordsList)))
+
+	if path := config.GetStringEnv("PASSWORD_BREACHED_LIST_FILE", ""); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("❌ Failed to open breached password list: %v", err)
+			return set
+		}
+		defer file.Close()
+		addPasswordList(set, bufio.NewScanner(file))
+		log.Printf("🔐 Breached password list loaded: %d entries", len(set))
+	}
+	return set
+})
+
+// addPasswordList 1行に1つのパスワードの一覧を小文字にして集合に追加する（空行と#で始まる行は無視）
+func addPasswordList(set map[string]struct{}, scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("❌ Failed to read password list: %v", err)
+	}
+}
+
+// IsBreachedPassword よく使われる・漏洩したパスワードの一覧に含まれるか（大文字小文字を区別しない）
+func IsBreachedPassword(password string) bool {
+	_, found := breachedPasswords()[strings.ToLower(password)]
+	return found
+}
@@ -0,0 +1,109 @@
+// ========================================
+// パスワードポリシーの自動テスト
+// 文字数・バイト数・文字種・よく使われるパスワードの各ルールとルールのコードを確認
+// ========================================
+
+package services
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passwordRules 検証エラーに含まれるルールのコードを返す
+func passwordRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	rules := make([]string, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		assert.Equal(t, "password", field.Field)
+		rules = append(rules, field.Rule)
+	}
+	return rules
+}
+
+// TestPasswordPolicy_Rules 各ルールの違反がルールのコードで返されることを検証
+func TestPasswordPolicy_Rules(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxBytes: 72, MinCharacterClasses: 2, RejectBreached: true}
+
+	cases := []struct {
+		name     string
+		password string
+		rules    []string
+	}{
+		{"valid", "correct-horse-42", nil},
+		{"too short", "ab-12", []string{RulePasswordMinLength}},
+		{"single class", "correcthorsebattery", []string{RulePasswordCharacterClasses}},
+		{"breached (case insensitive)", "PassWord123", []string{RulePasswordBreached}},
+		{"short and breached", "1234567", []string{RulePasswordMinLength, RulePasswordCharacterClasses, RulePasswordBreached}},
+		{"too many bytes", strings.Repeat("a1", 37), []string{RulePasswordMaxBytes}},
+		{"control character", "correct\x00horse42", []string{RulePasswordInvalidCharacters}},
+		// 全角文字は文字数で下限を判定し、バイト数で上限を判定する
+		{"japanese counted by runes", "かけいぼ2024", nil},
+		{"japanese over byte limit", strings.Repeat("家", 24) + "1", []string{RulePasswordMaxBytes}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.rules, passwordRules(t, policy.Validate("password", tc.password)))
+		})
+	}
+}
+
+// TestPasswordPolicy_Params フロントエンドの表示用にルールの値が含まれることを検証
+func TestPasswordPolicy_Params(t *testing.T) {
+	err := PasswordPolicy{MinLength: 12, MaxBytes: 72}.Validate("new_password", "short-pass")
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Fields, 1)
+	assert.Equal(t, "new_password", validationErr.Fields[0].Field)
+	assert.Equal(t, 12, validationErr.Fields[0].Params["min"])
+	assert.Equal(t, "パスワードは12文字以上で入力してください", validationErr.Error())
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+}
+
+// TestDefaultPasswordPolicy_Env 環境変数で設定でき、最大バイト数はハッシュのアルゴリズムの上限を超えないことを検証
+func TestDefaultPasswordPolicy_Env(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	assert.Equal(t, PasswordPolicy{MinLength: 8, MaxBytes: 128, MinCharacterClasses: 1, RejectBreached: true}, policy)
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "10")
+	t.Setenv("PASSWORD_MAX_BYTES", "2048")
+	t.Setenv("PASSWORD_MIN_CHARACTER_CLASSES", "3")
+	t.Setenv("PASSWORD_REJECT_BREACHED", "false")
+	policy = DefaultPasswordPolicy()
+	assert.Equal(t, PasswordPolicy{MinLength: 10, MaxBytes: 1024, MinCharacterClasses: 3, RejectBreached: false}, policy)
+	assert.NoError(t, policy.Validate("password", "Password123"))
+
+	// bcryptは72バイトを超える部分を無視するため、72バイトが上限
+	SetPasswordHasher(&BcryptPasswordHasher{})
+	t.Cleanup(func() { SetPasswordHasher(&Argon2idPasswordHasher{Params: defaultArgon2idParams}) })
+	assert.Equal(t, 72, DefaultPasswordPolicy().MaxBytes)
+	t.Setenv("PASSWORD_MAX_BYTES", "")
+	assert.Equal(t, 72, DefaultPasswordPolicy().MaxBytes)
+}
+
+// TestAddPasswordList 追加の一覧ファイルの形式（空行・コメント・大文字小文字）を検証
+func TestAddPasswordList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\n\nHunter2Extra\n  spaced-entry  \n"), 0o600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	set := make(map[string]struct{})
+	addPasswordList(set, bufio.NewScanner(file))
+	assert.Len(t, set, 2)
+	assert.Contains(t, set, "hunter2extra")
+	assert.Contains(t, set, "spaced-entry")
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/config"
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+)
+
+// ========================================
+// パスワード再設定 - 1回限りの再設定トークンと送信方法の切り替え
+// トークンはハッシュのみを保存し、再設定すると発行済みの全てのトークンを無効にする
+// ========================================
+
+// パスワード再設定のエラー
+var (
+	ErrPasswordResetTokenInvalid = errors.New("パスワード再設定トークンが無効または期限切れです")
+	ErrPasswordResetNoAddress    = errors.New("送信先のメールアドレスが登録されていません")
+)
+
+// パスワード再設定の送信方法（PASSWORD_RESET_SENDER）
+const (
+	PasswordResetSenderLog  = "log"  // ログに出力（開発用）
+	PasswordResetSenderSMTP = "smtp" // SMTPでメール送信
+
+	defaultPasswordResetURL = "http://localhost:3000/reset-password" // 再設定画面の既定のURL
+)
+
+// PasswordResetTTL 再設定トークンの有効期間（PASSWORD_RESET_TTL_MINUTES、既定30分）
+func PasswordResetTTL() time.Duration {
+	return time.Duration(config.GetIntEnv("PASSWORD_RESET_TTL_MINUTES", 30)) * time.Minute
+}
+
+// PasswordResetMessage ユーザーに届ける再設定の案内
+type PasswordResetMessage struct {
+	UserID    uint      // ユーザーID
+	AccountID string    // アカウントID
+	Name      string    // ユーザー名
+	Email     string    // 送信先のメールアドレス（未登録の場合は空）
+	Token     string    // 再設定トークン
+	ResetURL  string    // 再設定画面のURL（トークンを含む）
+	ExpiresAt time.Time // 再設定トークンの有効期限
+}
+
+// PasswordResetSender 再設定の案内をユーザーに届ける方法
+type PasswordResetSender interface {
+	Send(message PasswordResetMessage) error
+}
+
+// LogPasswordResetSender 再設定の案内をログに出力する（開発用、トークンがログに残るため本番では使用しない）
+type LogPasswordResetSender struct{}
+
+// Send 再設定のURLをログに出力する
+func (LogPasswordResetSender) Send(message PasswordResetMessage) error {
+	log.Printf("📧 Password reset link: account=%s url=%s expires=%s",
+		message.AccountID, message.ResetURL, message.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// SMTPPasswordResetSender 再設定の案内をSMTPでメール送信する
+type SMTPPasswordResetSender struct {
+	Host     string // SMTPサーバーのホスト名
+	Port     int    // SMTPサーバーのポート
+	Username string // 認証ユーザー名（空の場合は認証しない）
+	Password string // 認証パスワード
+	From     string // 送信元のメールアドレス
+}
+
+// Send 再設定のURLを記載したメールを送信する
+func (s SMTPPasswordResetSender) Send(message PasswordResetMessage) error {
+	if message.Email == "" {
+		return ErrPasswordResetNoAddress
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + s.From,
+		"To: " + message.Email,
+		"Subject: パスワード再設定のご案内",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message.Name + " 様",
+		"",
+		"パスワード再設定の依頼を受け付けました。以下のURLから新しいパスワードを設定してください。",
+		message.ResetURL,
+		"",
+		"有効期限: " + message.ExpiresAt.Format("2006-01-02 15:04"),
+		"心当たりがない場合は、このメールを破棄してください。",
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+	return smtp.SendMail(addr, auth, s.From, []string{message.Email}, []byte(body))
+}
+
+// グローバルな送信方法（起動時にSetPasswordResetSenderで差し替える）
+var (
+	passwordResetSender      PasswordResetSender = LogPasswordResetSender{}
+	passwordResetSenderMutex sync.RWMutex
+)
+
+// SetPasswordResetSender パスワード再設定の送信方法を設定する
+func SetPasswordResetSender(sender PasswordResetSender) {
+	passwordResetSenderMutex.Lock()
+	defer passwordResetSenderMutex.Unlock()
+	passwordResetSender = sender
+}
+
+// currentPasswordResetSender 現在の送信方法を取得する
+func currentPasswordResetSender() PasswordResetSender {
+	passwordResetSenderMutex.RLock()
+	defer passwordResetSenderMutex.RUnlock()
+	return passwordResetSender
+}
+
+// NewPasswordResetSenderFromEnv 環境変数の設定からパスワード再設定の送信方法を作成する
+// PASSWORD_RESET_SENDER: log（既定）/ smtp
+// smtpの場合: SMTP_HOST, SMTP_PORT（既定587）, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+func NewPasswordResetSenderFromEnv() (PasswordResetSender, error) {
+	sender := strings.ToLower(config.GetStringEnv("PASSWORD_RESET_SENDER", PasswordResetSenderLog))
+	switch sender {
+	case PasswordResetSenderLog:
+		if config.GetStringEnv("GIN_MODE", "") == "release" {
+			log.Println("⚠️ Password reset links are written to the log: set PASSWORD_RESET_SENDER=smtp for production")
+		}
+		return LogPasswordResetSender{}, nil
+	case PasswordResetSenderSMTP:
+		smtpSender := SMTPPasswordResetSender{
+			Host:     config.GetStringEnv("SMTP_HOST", ""),
+			Port:     config.GetIntEnv("SMTP_PORT", 587),
+			Username: config.GetStringEnv("SMTP_USERNAME", ""),
+			Password: config.GetStringEnv("SMTP_PASSWORD", ""),
+			From:     config.GetStringEnv("SMTP_FROM", ""),
+		}
+		if smtpSender.Host == "" || smtpSender.From == "" {
+			return nil, errors.New("SMTP_HOST and SMTP_FROM are required for PASSWORD_RESET_SENDER=smtp")
+		}
+		return smtpSender, nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_RESET_SENDER: %q (log, smtp)", sender)
+	}
+}
+
+// PasswordResetService パスワード再設定トークンの発行と再設定
+type PasswordResetService struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetService パスワード再設定サービスの初期化
+func NewPasswordResetService(db *gorm.DB) *PasswordResetService {
+	return &PasswordResetService{db: db}
+}
+
+// RequestReset アカウントが存在する場合、再設定トークンの発行と案内の送信をバックグラウンドで開始する
+// アカウントの有無を知られないよう、存在しないアカウントや発行・送信の失敗はエラーにせず、
+// 応答時間にもトークンの発行やメール送信の時間を含めない
+func (s *PasswordResetService) RequestReset(accountID string) error {
+	var user models.User
+	if err := s.db.Where("account_id = ?", accountID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	go func() {
+		if err := s.issueAndSend(user); err != nil {
+			log.Printf("❌ Password reset delivery failed: user=%d error=%v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// issueAndSend 再設定トークンを発行して案内を送信する
+func (s *PasswordResetService) issueAndSend(user models.User) error {
+	token, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+	record := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(PasswordResetTTL()),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 以前に発行した未使用のトークンは無効にする（最新の案内のみ有効）
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return currentPasswordResetSender().Send(PasswordResetMessage{
+		UserID:    user.ID,
+		AccountID: user.AccountID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Token:     token,
+		ResetURL:  passwordResetURL(token),
+		ExpiresAt: record.ExpiresAt,
+	})
+}
+
+// ResetPassword 再設定トークンを使用済みにしてパスワードを変更し、発行済みの全てのトークンを無効にする
+// ログイン失敗によるロックも解除する
+func (s *PasswordResetService) ResetPassword(token, newPassword string) (uint, error) {
+	passwordHash, err := CurrentPasswordHasher().HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		userID    uint
+		accountID string
+	)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var record models.PasswordResetToken
+		if err := tx.Where("token_hash = ?", hashRefreshToken(token)).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPasswordResetTokenInvalid
+			}
+			return err
+		}
+		if record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+			return ErrPasswordResetTokenInvalid
+		}
+
+		// 同時に使われた場合に1回だけ再設定できるよう、未使用の場合のみ使用済みにする
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetTokenInvalid
+		}
+
+		if err := NewTokenVersionService(tx).ChangePasswordHash(record.UserID, passwordHash); err != nil {
+			if errors.Is(err, ErrTokenUserNotFound) {
+				return ErrPasswordResetTokenInvalid
+			}
+			return err
+		}
+		var user models.User
+		if err := tx.Select("account_id").First(&user, record.UserID).Error; err != nil {
+			return err
+		}
+		userID, accountID = record.UserID, user.AccountID
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// コミット前にキャッシュされた古いトークン世代を破棄する
+	middleware.InvalidateTokenVersion(userID)
+
+	log.Printf("🔑 Password reset completed: user=%d", userID)
+	// ログイン失敗によるロックも解除する
+	if err := NewLoginAttemptService(s.db).Unlock(accountID, LoginUnlockPasswordReset); err != nil {
+		log.Printf("❌ Failed to unlock login: user=%d error=%v", userID, err)
+	}
+	return userID, nil
+}
+
+// passwordResetURL 再設定画面のURL（PASSWORD_RESET_URL）にトークンを付けて返す
+func passwordResetURL(token string) string {
+	base := config.GetStringEnv("PASSWORD_RESET_URL", defaultPasswordResetURL)
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,397 @@
+// ========================================
+// 入金明細の照合 - 銀行の入金明細CSVと請求中の家計簿の突き合わせ
+// 金額・入金日・振込依頼人名から一致度を算出し、照合候補を提案する
+// ========================================
+
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxStatementRows           = 5000 // 入金明細の最大行数
+	maxStatementHeaderScan     = 10   // 見出し行を探す先頭からの行数
+	reconciliationFeeTolerance = 880  // 振込手数料の差し引きとみなす金額差の上限（円）
+	reconciliationOverpayment  = 1000 // 多めの入金とみなす金額差の上限（円）
+	reconciliationEarlyDays    = 3    // 請求日より前の入金を許容する日数
+	reconciliationWindowDays   = 14   // 請求日からの入金期間（この期間内は一致度が高い）
+	reconciliationLateDays     = 45   // 請求日からの入金期間の上限
+	minReconciliationScore     = 0.5  // 照合候補として提案する一致度の下限
+)
+
+// 照合の一致条件（照合候補の理由として返す）
+const (
+	MatchReasonAmountExact       = "amount_exact"        // 金額が請求金額と一致
+	MatchReasonAmountFeeDeducted = "amount_fee_deducted" // 振込手数料を差し引いた金額とみなせる
+	MatchReasonAmountOverpaid    = "amount_overpaid"     // 請求金額より少し多い入金
+	MatchReasonDateInWindow      = "date_in_window"      // 請求日から14日以内の入金
+	MatchReasonDateNearWindow    = "date_near_window"    // 請求日から45日以内の入金
+	MatchReasonSenderName        = "sender_name"         // 振込依頼人名が支払者の名前と一致
+)
+
+// 一致度の配点（合計1.0）
+const (
+	scoreAmountExact       = 0.5
+	scoreAmountFeeDeducted = 0.3
+	scoreAmountOverpaid    = 0.3
+	scoreDateInWindow      = 0.2
+	scoreDateNearWindow    = 0.1
+	scoreSenderName        = 0.3
+)
+
+// 入金明細の見出しの候補（表記ゆれを吸収するため部分一致で判定する）
+var (
+	statementDateHeaders   = []string{"日付", "取引日", "入金日", "年月日", "DATE"}
+	statementAmountHeaders = []string{"入金", "預入", "預り", "DEPOSIT", "CREDIT"}
+	statementTotalHeaders  = []string{"金額", "AMOUNT"}
+	statementSenderHeaders = []string{"振込依頼人", "依頼人", "名義", "摘要", "取引内容", "内容", "NAME", "DESCRIPTION", "MEMO"}
+	statementDateLayouts   = []string{"2006/01/02", "2006-01-02", "2006/1/2", "2006-1-2", "20060102", "2006.01.02", "2006年1月2日"}
+	senderNamePrefixes     = []string{"フリコミ", "振込"}
+)
+
+// StatementDeposit 入金明細から読み取った1件の入金
+type StatementDeposit struct {
+	ID         uint      // 保存後の入金ID（照合結果の識別に使用）
+	LineNumber int       // CSVの行番号（1始まり）
+	Date       time.Time // 入金日
+	Amount     float64   // 入金額
+	SenderName string    // 振込依頼人名
+}
+
+// ReconciliationBill 照合対象の請求中の家計簿
+type ReconciliationBill struct {
+	BillID      uint
+	Amount      float64    // 支払者の負担額（請求金額）
+	RequestDate *time.Time // 請求日時
+	PayerNames  []string   // 振込依頼人名と照合する支払者の名前（氏名・カナ等）
+}
+
+// ReconciliationCandidate 入金と家計簿の照合候補
+type ReconciliationCandidate struct {
+	DepositID  uint
+	BillID     uint
+	Confidence float64  // 一致度（0〜1）
+	Reasons    []string // 一致した条件
+}
+
+// ReconciliationResult 照合結果
+type ReconciliationResult struct {
+	Matches           []ReconciliationCandidate // 照合候補（一致度の高い順、入金・家計簿とも1対1）
+	UnmatchedDeposits []uint                    // 照合先のない入金のID
+	UnmatchedBills    []uint                    // 照合先のない家計簿のID
+}
+
+// ========================================
+// 入金明細CSVの読み取り
+// ========================================
+
+// ParseBankStatement 銀行の入金明細CSVを読み取る
+// 文字コードはUTF-8（BOM付きを含む）またはShift_JISに対応し、先頭の見出し行から日付・入金額・振込依頼人の列を判定する
+// 入金として読み取れない行（出金・空行・合計行等）はskippedに行番号を返す
+func ParseBankStatement(r io.Reader) (deposits []StatementDeposit, skipped []int, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		if data, err = japanese.ShiftJIS.NewDecoder().Bytes(data); err != nil {
+			return nil, nil, errInvalidStatement("入金明細の文字コードはUTF-8またはShift_JISを指定してください")
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, errInvalidStatement("入金明細をCSVとして読み取れません")
+	}
+	if len(rows) > maxStatementRows {
+		return nil, nil, errInvalidStatement("入金明細は5000行以内にしてください")
+	}
+
+	headerIndex, columns, ok := findStatementHeader(rows)
+	if !ok {
+		return nil, nil, errInvalidStatement("入金明細の見出し行（日付・入金額）が見つかりません")
+	}
+
+	for i := headerIndex + 1; i < len(rows); i++ {
+		deposit, ok := parseStatementRow(rows[i], columns)
+		if !ok {
+			skipped = append(skipped, i+1)
+			continue
+		}
+		deposit.LineNumber = i + 1
+		deposits = append(deposits, deposit)
+	}
+	return deposits, skipped, nil
+}
+
+// statementColumns 入金明細の列の位置（-1は該当列なし）
+type statementColumns struct {
+	date   int
+	amount int
+	sender int
+}
+
+// findStatementHeader 先頭の行から見出し行を探し、日付・入金額・振込依頼人の列を判定する
+// 入金額の列は入金専用の列を優先し、なければ金額の列（正の値を入金とみなす）を使う
+func findStatementHeader(rows [][]string) (int, statementColumns, bool) {
+	for i := 0; i < len(rows) && i < maxStatementHeaderScan; i++ {
+		used := map[int]bool{}
+		columns := statementColumns{
+			date: findStatementColumn(rows[i], statementDateHeaders, used),
+		}
+		columns.amount = findStatementColumn(rows[i], statementAmountHeaders, used)
+		if columns.amount < 0 {
+			columns.amount = findStatementColumn(rows[i], statementTotalHeaders, used)
+		}
+		columns.sender = findStatementColumn(rows[i], statementSenderHeaders, used)
+
+		if columns.date >= 0 && columns.amount >= 0 {
+			return i, columns, true
+		}
+	}
+	return 0, statementColumns{}, false
+}
+
+// findStatementColumn 見出しの候補に一致する最初の未使用の列を返す（候補の順に優先）
+func findStatementColumn(header []string, candidates []string, used map[int]bool) int {
+	for _, candidate := range candidates {
+		for i, cell := range header {
+			if !used[i] && strings.Contains(strings.ToUpper(norm.NFKC.String(cell)), candidate) {
+				used[i] = true
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseStatementRow 入金明細の1行を入金として読み取る（入金額が0以下・空の場合は入金ではない）
+func parseStatementRow(row []string, columns statementColumns) (StatementDeposit, bool) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(norm.NFKC.String(row[i]))
+	}
+
+	date, ok := parseStatementDate(cell(columns.date))
+	if !ok {
+		return StatementDeposit{}, false
+	}
+	amount, ok := parseStatementAmount(cell(columns.amount))
+	if !ok || amount <= 0 {
+		return StatementDeposit{}, false
+	}
+	return StatementDeposit{Date: date, Amount: amount, SenderName: cell(columns.sender)}, true
+}
+
+// parseStatementDate 入金日を読み取る（日付のみ、時刻部分は無視する）
+func parseStatementDate(value string) (time.Time, bool) {
+	if i := strings.IndexAny(value, " T"); i > 0 {
+		value = value[:i]
+	}
+	for _, layout := range statementDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseStatementAmount 金額を読み取る（桁区切り・通貨記号を除く）
+func parseStatementAmount(value string) (float64, bool) {
+	value = strings.NewReplacer(",", "", "円", "", "¥", "", "\\", "", " ", "").Replace(value)
+	if value == "" {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, false
+	}
+	return amount, true
+}
+
+// errInvalidStatement 入金明細ファイルの検証エラーを作成する
+func errInvalidStatement(message string) error {
+	return NewValidationError(FieldError{Field: "file", Rule: RuleFormat, Message: message})
+}
+
+// ========================================
+// 入金と家計簿の照合
+// ========================================
+
+// ReconcileDeposits 入金と請求中の家計簿を照合し、照合候補を提案する
+// 金額が一致（または振込手数料の差し引きとみなせる）する組み合わせを候補とし、一致度の高い順に入金・家計簿とも1対1で割り当てる
+func ReconcileDeposits(deposits []StatementDeposit, bills []ReconciliationBill) ReconciliationResult {
+	type scored struct {
+		candidate ReconciliationCandidate
+		dayGap    float64
+	}
+
+	var candidates []scored
+	for _, deposit := range deposits {
+		for _, bill := range bills {
+			candidate, ok := ScoreReconciliation(deposit, bill)
+			if !ok || candidate.Confidence < minReconciliationScore {
+				continue
+			}
+			gap := math.MaxFloat64
+			if bill.RequestDate != nil {
+				gap = math.Abs(deposit.Date.Sub(*bill.RequestDate).Hours())
+			}
+			candidates = append(candidates, scored{candidate: candidate, dayGap: gap})
+		}
+	}
+
+	// 一致度の高い順（同じ場合は請求日に近い入金、入金ID・家計簿IDの順）
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.candidate.Confidence != b.candidate.Confidence {
+			return a.candidate.Confidence > b.candidate.Confidence
+		}
+		if a.dayGap != b.dayGap {
+			return a.dayGap < b.dayGap
+		}
+		if a.candidate.DepositID != b.candidate.DepositID {
+			return a.candidate.DepositID < b.candidate.DepositID
+		}
+		return a.candidate.BillID < b.candidate.BillID
+	})
+
+	result := ReconciliationResult{}
+	matchedDeposits := map[uint]bool{}
+	matchedBills := map[uint]bool{}
+	for _, c := range candidates {
+		if matchedDeposits[c.candidate.DepositID] || matchedBills[c.candidate.BillID] {
+			continue
+		}
+		matchedDeposits[c.candidate.DepositID] = true
+		matchedBills[c.candidate.BillID] = true
+		result.Matches = append(result.Matches, c.candidate)
+	}
+
+	for _, deposit := range deposits {
+		if !matchedDeposits[deposit.ID] {
+			result.UnmatchedDeposits = append(result.UnmatchedDeposits, deposit.ID)
+		}
+	}
+	for _, bill := range bills {
+		if !matchedBills[bill.BillID] {
+			result.UnmatchedBills = append(result.UnmatchedBills, bill.BillID)
+		}
+	}
+	return result
+}
+
+// RoundOwedAmountYen 支払者の負担額（銭単位の端数を含む）を円単位に丸める（50銭以上は切り上げ）
+// 振込は円単位のため、照合と全銀ファイルの振込金額で同じ丸め方を使用する
+func RoundOwedAmountYen(amount float64) int64 {
+	sen := int64(math.Round(amount * 100))
+	if sen < 0 {
+		return -((-sen + 50) / 100)
+	}
+	return (sen + 50) / 100
+}
+
+// ScoreReconciliation 入金と家計簿の一致度を算出する
+// 金額が一致しない（振込手数料の差し引きや少し多めの入金ともみなせない）場合はokにfalseを返す
+func ScoreReconciliation(deposit StatementDeposit, bill ReconciliationBill) (ReconciliationCandidate, bool) {
+	candidate := ReconciliationCandidate{DepositID: deposit.ID, BillID: bill.BillID, Reasons: []string{}}
+	var score float64
+
+	// 金額（円単位に丸めて比較）
+	shortfall := RoundOwedAmountYen(bill.Amount) - RoundOwedAmountYen(deposit.Amount)
+	switch {
+	case shortfall == 0:
+		score += scoreAmountExact
+		candidate.Reasons = append(candidate.Reasons, MatchReasonAmountExact)
+	case shortfall > 0 && shortfall <= reconciliationFeeTolerance:
+		score += scoreAmountFeeDeducted
+		candidate.Reasons = append(candidate.Reasons, MatchReasonAmountFeeDeducted)
+	case shortfall < 0 && -shortfall <= reconciliationOverpayment:
+		score += scoreAmountOverpaid
+		candidate.Reasons = append(candidate.Reasons, MatchReasonAmountOverpaid)
+	default:
+		return ReconciliationCandidate{}, false
+	}
+
+	// 入金日（請求日からの日数）
+	if bill.RequestDate != nil {
+		requested := bill.RequestDate.In(time.Local)
+		requestDay := time.Date(requested.Year(), requested.Month(), requested.Day(), 0, 0, 0, 0, time.Local)
+		days := int(math.Round(deposit.Date.Sub(requestDay).Hours() / 24))
+		switch {
+		case days >= -reconciliationEarlyDays && days <= reconciliationWindowDays:
+			score += scoreDateInWindow
+			candidate.Reasons = append(candidate.Reasons, MatchReasonDateInWindow)
+		case days > reconciliationWindowDays && days <= reconciliationLateDays:
+			score += scoreDateNearWindow
+			candidate.Reasons = append(candidate.Reasons, MatchReasonDateNearWindow)
+		}
+	}
+
+	// 振込依頼人名
+	if senderMatchesPayer(deposit.SenderName, bill.PayerNames) {
+		score += scoreSenderName
+		candidate.Reasons = append(candidate.Reasons, MatchReasonSenderName)
+	}
+
+	candidate.Confidence = math.Round(score*100) / 100
+	return candidate, true
+}
+
+// senderMatchesPayer 振込依頼人名が支払者の名前のいずれかと一致するか（正規化して部分一致で判定）
+func senderMatchesPayer(sender string, payerNames []string) bool {
+	sender = normalizeSenderName(sender)
+	if sender == "" {
+		return false
+	}
+	for _, name := range payerNames {
+		name = normalizeSenderName(name)
+		if utf8.RuneCountInString(name) < 2 {
+			continue
+		}
+		if strings.Contains(sender, name) || strings.Contains(name, sender) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSenderName 振込依頼人名を比較用に正規化する
+// 半角カナ・全角英数字をNFKCで統一し、ひらがなをカタカナに、英字を大文字にして、空白・記号と振込の接頭辞を除く
+func normalizeSenderName(name string) string {
+	name = strings.TrimSpace(norm.NFKC.String(name))
+	for _, prefix := range senderNamePrefixes {
+		name = strings.TrimSpace(strings.TrimPrefix(name, prefix))
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'ぁ' && r <= 'ゖ':
+			b.WriteRune(r + ('ァ' - 'ぁ'))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == 'ー':
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+// ========================================
+// 入金明細の照合テスト
+// CSVの読み取りと、入金と家計簿の一致度・割り当てを検証
+// ========================================
+
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// TestParseBankStatement_UTF8 前置きの行・BOM・出金行を含むUTF-8の入金明細を読み取れることを検証
+func TestParseBankStatement_UTF8(t *testing.T) {
+	csv := "\xef\xbb\xbf口座番号,1234567\n" +
+		"取引日,摘要,お引出金額,お預入金額,残高\n" +
+		"2024/10/05,振込 ﾔﾏﾀﾞ ﾊﾅｺ,,\"98,000\",200000\n" +
+		"2024/10/06,カード引落,5000,,195000\n" +
+		"2024-10-07,ﾌﾘｺﾐ ｻﾄｳ ｼﾞﾛｳ,,１２，０００円,207000\n" +
+		"合計,,5000,110000,\n"
+
+	deposits, skipped, err := ParseBankStatement(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, deposits, 2)
+	assert.Equal(t, 3, deposits[0].LineNumber)
+	assert.Equal(t, 98000.0, deposits[0].Amount)
+	assert.Equal(t, "振込 ヤマダ ハナコ", deposits[0].SenderName)
+	assert.Equal(t, time.Date(2024, 10, 5, 0, 0, 0, 0, time.Local), deposits[0].Date)
+	assert.Equal(t, 12000.0, deposits[1].Amount)
+	assert.Equal(t, []int{4, 6}, skipped)
+}
+
+// TestParseBankStatement_ShiftJIS Shift_JISの入金明細と金額列のみの形式を読み取れることを検証
+func TestParseBankStatement_ShiftJIS(t *testing.T) {
+	csv, err := japanese.ShiftJIS.NewEncoder().String("日付,内容,金額\n20241005,ヤマダ タロウ,50000\n20241006,家賃,-80000\n")
+	require.NoError(t, err)
+
+	deposits, skipped, err := ParseBankStatement(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	assert.Equal(t, "ヤマダ タロウ", deposits[0].SenderName)
+	assert.Equal(t, []int{3}, skipped)
+
+	// 見出し行がない場合は検証エラー
+	_, _, err = ParseBankStatement(strings.NewReader("2024/10/05,100\n"))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "file", validationErr.Fields[0].Field)
+}
+
+// TestScoreReconciliation 金額・入金日・振込依頼人名による一致度を検証
+func TestScoreReconciliation(t *testing.T) {
+	requested := time.Date(2024, 10, 1, 21, 0, 0, 0, time.Local)
+	bill := ReconciliationBill{BillID: 1, Amount: 98000, RequestDate: &requested, PayerNames: []string{"やまだ はなこ", "hanako"}}
+
+	tests := []struct {
+		name       string
+		deposit    StatementDeposit
+		ok         bool
+		confidence float64
+		reasons    []string
+	}{
+		{"全て一致", StatementDeposit{Date: time.Date(2024, 10, 5, 0, 0, 0, 0, time.Local), Amount: 98000, SenderName: "振込 ヤマダ ハナコ"}, true, 1.0,
+			[]string{MatchReasonAmountExact, MatchReasonDateInWindow, MatchReasonSenderName}},
+		{"手数料差し引き・期間後半", StatementDeposit{Date: time.Date(2024, 11, 1, 0, 0, 0, 0, time.Local), Amount: 97560, SenderName: "HANAKO Y"}, true, 0.7,
+			[]string{MatchReasonAmountFeeDeducted, MatchReasonDateNearWindow, MatchReasonSenderName}},
+		{"金額のみ一致", StatementDeposit{Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), Amount: 98000, SenderName: "スズキ"}, true, 0.5,
+			[]string{MatchReasonAmountExact}},
+		{"少し多めの入金", StatementDeposit{Date: time.Date(2024, 10, 5, 0, 0, 0, 0, time.Local), Amount: 98500}, true, 0.5,
+			[]string{MatchReasonAmountOverpaid, MatchReasonDateInWindow}},
+		{"多すぎる入金", StatementDeposit{Date: time.Date(2024, 10, 5, 0, 0, 0, 0, time.Local), Amount: 99001}, false, 0, nil},
+		{"差額が手数料を超える", StatementDeposit{Date: time.Date(2024, 10, 5, 0, 0, 0, 0, time.Local), Amount: 97000}, false, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate, ok := ScoreReconciliation(tt.deposit, bill)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.confidence, candidate.Confidence)
+				assert.Equal(t, tt.reasons, candidate.Reasons)
+			}
+		})
+	}
+}
+
+// TestScoreReconciliation_FractionalOwedAmount 負担額に銭単位の端数がある場合も、円単位に丸めた金額の入金と一致することを検証
+func TestScoreReconciliation_FractionalOwedAmount(t *testing.T) {
+	requested := time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)
+	depositDate := time.Date(2024, 10, 3, 0, 0, 0, 0, time.Local)
+	// 1,001円の共同の品目を折半した負担額
+	bill := ReconciliationBill{BillID: 1, Amount: 500.50, RequestDate: &requested}
+
+	assert.Equal(t, int64(501), RoundOwedAmountYen(bill.Amount))
+	assert.Equal(t, int64(500), RoundOwedAmountYen(500.49))
+
+	candidate, ok := ScoreReconciliation(StatementDeposit{Date: depositDate, Amount: 501}, bill)
+	require.True(t, ok)
+	assert.Equal(t, []string{MatchReasonAmountExact, MatchReasonDateInWindow}, candidate.Reasons)
+
+	candidate, ok = ScoreReconciliation(StatementDeposit{Date: depositDate, Amount: 500}, bill)
+	require.True(t, ok)
+	assert.Equal(t, []string{MatchReasonAmountFeeDeducted, MatchReasonDateInWindow}, candidate.Reasons)
+
+	candidate, ok = ScoreReconciliation(StatementDeposit{Date: depositDate, Amount: 600}, bill)
+	require.True(t, ok)
+	assert.Equal(t, []string{MatchReasonAmountOverpaid, MatchReasonDateInWindow}, candidate.Reasons)
+}
+
+// TestReconcileDeposits 一致度の高い組から1対1で割り当て、照合先のない入金・家計簿を分けることを検証
+func TestReconcileDeposits(t *testing.T) {
+	requested := time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)
+	depositDate := time.Date(2024, 10, 3, 0, 0, 0, 0, time.Local)
+
+	bills := []ReconciliationBill{
+		{BillID: 10, Amount: 50000, RequestDate: &requested, PayerNames: []string{"ヤマダ"}},
+		{BillID: 11, Amount: 50000, RequestDate: &requested, PayerNames: []string{"サトウ"}},
+		{BillID: 12, Amount: 30000, RequestDate: &requested, PayerNames: []string{"スズキ"}},
+	}
+	deposits := []StatementDeposit{
+		{ID: 1, Date: depositDate, Amount: 50000, SenderName: "ｻﾄｳ ｼﾞﾛｳ"},
+		{ID: 2, Date: depositDate, Amount: 50000, SenderName: "ﾔﾏﾀﾞ ﾊﾅｺ"},
+		{ID: 3, Date: depositDate, Amount: 1000, SenderName: "ﾘｿｸ"},
+	}
+
+	result := ReconcileDeposits(deposits, bills)
+	require.Len(t, result.Matches, 2)
+	assert.Equal(t, uint(1), result.Matches[0].DepositID)
+	assert.Equal(t, uint(11), result.Matches[0].BillID)
+	assert.Equal(t, uint(2), result.Matches[1].DepositID)
+	assert.Equal(t, uint(10), result.Matches[1].BillID)
+	assert.Equal(t, []uint{3}, result.UnmatchedDeposits)
+	assert.Equal(t, []uint{12}, result.UnmatchedBills)
+
+	// 同じ金額の入金が1件のみの場合は一致度の高い家計簿に割り当てる
+	result = ReconcileDeposits(deposits[1:2], bills[:2])
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, uint(10), result.Matches[0].BillID)
+	assert.Equal(t, []uint{11}, result.UnmatchedBills)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/config"
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+)
+
+// ========================================
+// リフレッシュトークン - ローテーションと再利用検出
+// ========================================
+
+// リフレッシュトークンの失効理由
+const (
+	RefreshRevokedLogout          = "logout"           // ログアウト
+	RefreshRevokedLogoutAll       = "logout_all"       // 全デバイスからのログアウト
+	RefreshRevokedPasswordChanged = "password_changed" // パスワード変更
+	RefreshRevokedSessionRevoked  = "session_revoked"  // セッション一覧からの終了
+	RefreshRevokedReuseDetected   = "reuse_detected"   // 使用済みトークンの再利用を検出
+)
+
+// リフレッシュトークンのエラー
+var (
+	ErrRefreshTokenInvalid = errors.New("リフレッシュトークンが無効です")
+	ErrRefreshTokenReused  = errors.New("リフレッシュトークンが再利用されたため、このログインの全てのトークンを無効にしました")
+)
+
+// RefreshTokenTTL リフレッシュトークンの有効期間（REFRESH_TOKEN_TTL_HOURS、既定30日）
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.GetIntEnv("REFRESH_TOKEN_TTL_HOURS", 24*30)) * time.Hour
+}
+
+// SessionTokens 発行したアクセストークンとリフレッシュトークン
+type SessionTokens struct {
+	AccessToken           string    // アクセストークン（JWT）
+	AccessTokenExpiresAt  time.Time // アクセストークンの有効期限
+	RefreshToken          string    // リフレッシュトークン（ランダムな文字列、DBにはハッシュのみ保存）
+	RefreshTokenExpiresAt time.Time // リフレッシュトークンの有効期限
+}
+
+// RefreshTokenService アクセストークンとリフレッシュトークンの発行・ローテーション・失効
+type RefreshTokenService struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenService リフレッシュトークンサービスの初期化
+func NewRefreshTokenService(db *gorm.DB) *RefreshTokenService {
+	return &RefreshTokenService{db: db}
+}
+
+// IssueTokens ログイン・登録時に新しいセッションを作成し、アクセストークンとリフレッシュトークンを発行する
+func (s *RefreshTokenService) IssueTokens(userID uint, client SessionClient) (*SessionTokens, error) {
+	var tokens *SessionTokens
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tokenVersion, err := currentTokenVersion(tx, userID)
+		if err != nil {
+			return err
+		}
+		session, err := createSession(tx, userID, client)
+		if err != nil {
+			return err
+		}
+		tokens, err = issueSessionTokens(tx, userID, tokenVersion, session.ID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Rotate リフレッシュトークンを使用済みにして、新しいアクセストークンとリフレッシュトークンを発行する
+// 使用済みのトークンが再び使われた場合は、そのトークンから続く全てのトークンを失効させてErrRefreshTokenReusedを返す
+// セッションの最終利用日時とIPアドレスはclientの情報で更新する
+func (s *RefreshTokenService) Rotate(refreshToken string, client SessionClient) (uint, *SessionTokens, error) {
+	var (
+		userID uint
+		tokens *SessionTokens
+		reused *models.RefreshToken
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashRefreshToken(refreshToken)).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.UsedAt != nil {
+			reused = &current
+			return ErrRefreshTokenReused
+		}
+		if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+			return ErrRefreshTokenInvalid
+		}
+
+		// 発行後に全デバイスからのログアウト・パスワード変更があったトークン
+		tokenVersion, err := currentTokenVersion(tx, current.UserID)
+		if err != nil {
+			if errors.Is(err, ErrTokenUserNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+		if tokenVersion != current.TokenVersion {
+			return ErrRefreshTokenInvalid
+		}
+
+		// 同時に使われた場合に1つだけがローテーションできるよう、未使用の場合のみ使用済みにする
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", current.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = &current
+			return ErrRefreshTokenReused
+		}
+
+		issued, err := issueSessionTokens(tx, current.UserID, tokenVersion, current.FamilyID, &current.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.UserSession{}).Where("id = ?", current.FamilyID).
+			Updates(map[string]interface{}{"last_seen_at": now, "ip_address": client.IPAddress}).Error; err != nil {
+			return err
+		}
+		userID, tokens = current.UserID, issued
+		return nil
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) && reused != nil {
+		log.Printf("🚨 Refresh token reuse detected: user=%d family=%s token=%d", reused.UserID, reused.FamilyID, reused.ID)
+		if revokeErr := s.revokeDescendants(reused.ID, RefreshRevokedReuseDetected); revokeErr != nil {
+			return 0, nil, revokeErr
+		}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return userID, tokens, nil
+}
+
+// Revoke ユーザーのリフレッシュトークンと、同じ系列の全てのトークンを失効させる（ログアウト）
+func (s *RefreshTokenService) Revoke(refreshToken string, userID uint, reason string) error {
+	var token models.RefreshToken
+	err := s.db.Where("token_hash = ? AND user_id = ?", hashRefreshToken(refreshToken), userID).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	var family []models.RefreshToken
+	if err := s.db.Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).Find(&family).Error; err != nil {
+		return err
+	}
+	return s.revokeTokens(family, reason)
+}
+
+// revokeDescendants リフレッシュトークンとローテーションで続く全てのトークンを失効させる
+// 続くトークンと同時に発行したアクセストークンもトークンIDで失効させる
+func (s *RefreshTokenService) revokeDescendants(rootID uint, reason string) error {
+	var root models.RefreshToken
+	if err := s.db.First(&root, rootID).Error; err != nil {
+		return err
+	}
+
+	targets := []models.RefreshToken{root}
+	parentIDs := []uint{root.ID}
+	for len(parentIDs) > 0 {
+		var children []models.RefreshToken
+		if err := s.db.Where("parent_id IN ?", parentIDs).Find(&children).Error; err != nil {
+			return err
+		}
+		parentIDs = parentIDs[:0]
+		for _, child := range children {
+			targets = append(targets, child)
+			parentIDs = append(parentIDs, child.ID)
+		}
+	}
+	return s.revokeTokens(targets, reason)
+}
+
+// revokeTokens リフレッシュトークンと同時に発行したアクセストークンを失効させ、そのセッションを終了する
+// アクセストークンのブラックリストへの登録に失敗した場合も、リフレッシュトークンとセッションは失効させてからエラーを返す
+func (s *RefreshTokenService) revokeTokens(tokens []models.RefreshToken, reason string) error {
+	now := time.Now()
+	var blacklistErr error
+	ids := make([]uint, 0, len(tokens))
+	familyIDs := make([]string, 0, 1)
+	for _, token := range tokens {
+		ids = append(ids, token.ID)
+		if len(familyIDs) == 0 || familyIDs[len(familyIDs)-1] != token.FamilyID {
+			familyIDs = append(familyIDs, token.FamilyID)
+		}
+		if token.AccessTokenID != "" && now.Before(token.AccessExpiresAt) {
+			if err := middleware.AddTokenIDToBlacklist(token.AccessTokenID, token.AccessExpiresAt, reason); err != nil && blacklistErr == nil {
+				blacklistErr = err
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return blacklistErr
+	}
+	if err := s.db.Model(&models.RefreshToken{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+		return err
+	}
+	if err := revokeSessions(s.db.Where("id IN ?", familyIDs), reason); err != nil {
+		return err
+	}
+	return blacklistErr
+}
+
+// issueSessionTokens アクセストークンを発行し、同じ系列（セッション）のリフレッシュトークンを保存する
+// セッションには発行したアクセストークンのjtiと、リフレッシュトークンの有効期限を記録する
+func issueSessionTokens(db *gorm.DB, userID, tokenVersion uint, familyID string, parentID *uint) (*SessionTokens, error) {
+	accessToken, claims, err := middleware.GenerateAccessToken(userID, tokenVersion, familyID)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.RefreshToken{
+		UserID:          userID,
+		TokenHash:       hashRefreshToken(refreshToken),
+		FamilyID:        familyID,
+		ParentID:        parentID,
+		TokenVersion:    tokenVersion,
+		AccessTokenID:   claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.UserSession{}).Where("id = ?", familyID).
+		Updates(map[string]interface{}{"current_token_id": claims.ID, "expires_at": record.ExpiresAt}).Error; err != nil {
+		return nil, err
+	}
+
+	return &SessionTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// hashRefreshToken リフレッシュトークンのSHA-256ハッシュ（16進数）を返す
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex nバイトの乱数を16進数の文字列で返す
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
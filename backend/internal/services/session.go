@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/models"
+)
+
+// ========================================
+// ログインセッション - 端末ごとのログイン状況の確認と終了
+// セッションはリフレッシュトークンの系列と1対1で、IDも同じ値を使う
+// ========================================
+
+// maxUserAgentLen 保存するUser-Agentの最大文字数
+const maxUserAgentLen = 255
+
+// errSessionNotFound セッションが見つからない（他のユーザーのセッション・終了済みを含む）
+var errSessionNotFound = newDomainError(ErrNotFound, "セッションが見つかりません")
+
+// SessionClient ログイン・トークン再発行を行った端末の情報
+type SessionClient struct {
+	UserAgent string // User-Agentヘッダー
+	IPAddress string // クライアントのIPアドレス
+}
+
+// SessionService ログインセッションの一覧・終了・最終利用日時の更新
+type SessionService struct {
+	db *gorm.DB
+}
+
+// NewSessionService セッションサービスの初期化
+func NewSessionService(db *gorm.DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// ListActive ユーザーの有効なセッションを最終利用日時の新しい順に返す
+func (s *SessionService) ListActive(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// GetActive ユーザーの有効なセッションを返す
+func (s *SessionService) GetActive(userID uint, sessionID string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Revoke ユーザーのセッションを終了し、そのセッションのリフレッシュトークンとアクセストークンを無効にする
+func (s *SessionService) Revoke(userID uint, sessionID, reason string) error {
+	if _, err := s.GetActive(userID, sessionID); err != nil {
+		return err
+	}
+
+	var family []models.RefreshToken
+	if err := s.db.Where("family_id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).Find(&family).Error; err != nil {
+		return err
+	}
+	if err := NewRefreshTokenService(s.db).revokeTokens(family, reason); err != nil {
+		return err
+	}
+	return revokeSessions(s.db.Where("id = ?", sessionID), reason)
+}
+
+// TouchSessions セッションの最終利用日時をまとめて更新する（middleware.SessionActivityFlusherとして使用する）
+// 保存済みの日時より新しい場合のみ更新する
+func (s *SessionService) TouchSessions(lastSeen map[string]time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for sessionID, seenAt := range lastSeen {
+			if err := tx.Model(&models.UserSession{}).
+				Where("id = ? AND last_seen_at < ?", sessionID, seenAt).
+				UpdateColumn("last_seen_at", seenAt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// createSession ログイン時に新しいセッションを作成する
+func createSession(db *gorm.DB, userID uint, client SessionClient) (*models.UserSession, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  truncateRunes(client.UserAgent, maxUserAgentLen),
+		IPAddress:  client.IPAddress,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL()),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// revokeSessions 条件に一致する有効なセッションを終了済みにする
+func revokeSessions(query *gorm.DB, reason string) error {
+	return query.Model(&models.UserSession{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// truncateRunes 文字列を最大文字数で切り詰める
+func truncateRunes(value string, maxLen int) string {
+	if utf8.RuneCountInString(value) <= maxLen {
+		return value
+	}
+	return string([]rune(value)[:maxLen])
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/middleware"
+	"money_management/internal/models"
+)
+
+// ========================================
+// トークン世代 - 全デバイスからのログアウト
+// アクセストークンには発行時点のユーザーのトークン世代を含め、
+// 世代を進めると発行済みの全てのアクセストークンとリフレッシュトークンが無効になる
+// ========================================
+
+// ErrTokenUserNotFound トークンのユーザーが存在しない
+var ErrTokenUserNotFound = errors.New("トークンのユーザーが見つかりません")
+
+// TokenVersionService ユーザーのトークン世代の参照と更新
+type TokenVersionService struct {
+	db *gorm.DB
+}
+
+// NewTokenVersionService トークン世代サービスの初期化
+func NewTokenVersionService(db *gorm.DB) *TokenVersionService {
+	return &TokenVersionService{db: db}
+}
+
+// Current ユーザーの現在のトークン世代を返す（middleware.TokenVersionLookupとして使用する）
+func (s *TokenVersionService) Current(userID uint) (uint, error) {
+	return currentTokenVersion(s.db, userID)
+}
+
+// RevokeAll ユーザーのトークン世代を進め、全てのデバイスの発行済みトークンを無効にする
+func (s *TokenVersionService) RevokeAll(userID uint, reason string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return revokeAllUserTokens(tx, userID, reason)
+	})
+	middleware.InvalidateTokenVersion(userID)
+	return err
+}
+
+// ChangePasswordHash パスワードハッシュを更新し、変更前に発行した全てのトークンを無効にする
+func (s *TokenVersionService) ChangePasswordHash(userID uint, passwordHash string) error {
+	defer middleware.InvalidateTokenVersion(userID)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", passwordHash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenUserNotFound
+		}
+		return revokeAllUserTokens(tx, userID, RefreshRevokedPasswordChanged)
+	})
+}
+
+// revokeAllUserTokens トークン世代を進め、ユーザーの有効なリフレッシュトークンとセッションを全て失効させる
+// アクセストークンはAuthMiddlewareのトークン世代の検証で無効になる
+// AuthMiddlewareがキャッシュしたトークン世代は破棄する（コミット前に古い世代が再びキャッシュされないよう、
+// 呼び出し元もトランザクションの終了後に破棄する）
+func revokeAllUserTokens(tx *gorm.DB, userID uint, reason string) error {
+	result := tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenUserNotFound
+	}
+	middleware.InvalidateTokenVersion(userID)
+
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error; err != nil {
+		return err
+	}
+	if err := revokeSessions(tx.Where("user_id = ?", userID), reason); err != nil {
+		return err
+	}
+
+	log.Printf("🔒 All tokens revoked: user=%d reason=%s", userID, reason)
+	return nil
+}
+
+// currentTokenVersion ユーザーの現在のトークン世代を返す
+func currentTokenVersion(db *gorm.DB, userID uint) (uint, error) {
+	var user models.User
+	if err := db.Select("id", "token_version").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrTokenUserNotFound
+		}
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ========================================
+// TOTP（RFC 6238） - 認証アプリの時間ベースのワンタイムパスワード
+// HMAC-SHA1・6桁・30秒間隔（Google Authenticator等の既定値）
+// ========================================
+
+// TOTPの設定値
+const (
+	totpDigits     = 6  // コードの桁数
+	totpPeriod     = 30 // 時間ステップの秒数
+	totpSkew       = 1  // 前後に許容する時間ステップ数（端末の時刻のずれ）
+	totpSecretSize = 20 // 共有鍵のバイト数（RFC 4226の推奨値160ビット）
+)
+
+// totpEncoding 共有鍵のBase32エンコーディング（認証アプリの入力形式、パディングなし）
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret 新しい共有鍵を生成し、Base32の文字列で返す
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI 認証アプリに登録するotpauth URI（QRコードの内容）を返す
+func TOTPProvisioningURI(secret, issuer, accountName string) string {
+	label := url.PathEscape(issuer + ":" + accountName)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode 共有鍵と時刻からコードを計算する
+func TOTPCode(secret string, at time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpStep(at), totpDigits), nil
+}
+
+// verifyTOTP コードを検証し、一致した時間ステップを返す
+// afterStep以前の時間ステップのコードは使用済みとして一致させない
+func verifyTOTP(secret, code string, now time.Time, afterStep int64) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits || !isDigits(code) {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= afterStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step, totpDigits)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpStep 時刻に対応する時間ステップを返す
+func totpStep(at time.Time) int64 {
+	return at.Unix() / totpPeriod
+}
+
+// hotp RFC 4226のHOTP値を指定の桁数で返す
+func hotp(key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// decodeTOTPSecret Base32の共有鍵をバイト列に変換する（空白・小文字・パディングを許容）
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return totpEncoding.DecodeString(strings.TrimRight(normalized, "="))
+}
@@ -0,0 +1,76 @@
+// ========================================
+// TOTP（RFC 6238）の自動テスト
+// RFC 6238 付録Bのテストベクターと、時刻のずれ・使用済みコードの扱いを確認
+// ========================================
+
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret RFC 6238 付録BのSHA1用の共有鍵 "12345678901234567890" のBase32表記
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCode_RFC6238Vectors RFC 6238のテストベクター（8桁の下6桁）と一致することを検証
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		code, err := TOTPCode(rfc6238Secret, time.Unix(v.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "T=%d", v.unix)
+	}
+}
+
+// TestVerifyTOTP_SkewAndReplay 前後1ステップのずれを許容し、使用済みのステップは拒否することを検証
+func TestVerifyTOTP_SkewAndReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := TOTPCode(rfc6238Secret, now.Add(-totpPeriod*time.Second))
+	require.NoError(t, err)
+
+	step, ok := verifyTOTP(rfc6238Secret, previous, now, 0)
+	require.True(t, ok)
+	assert.Equal(t, totpStep(now)-1, step)
+
+	// 同じステップ以前のコードは使用済み
+	_, ok = verifyTOTP(rfc6238Secret, previous, now, step)
+	assert.False(t, ok)
+
+	// 許容範囲外のずれ・形式の誤り
+	old, err := TOTPCode(rfc6238Secret, now.Add(-2*totpPeriod*time.Second))
+	require.NoError(t, err)
+	_, ok = verifyTOTP(rfc6238Secret, old, now, 0)
+	assert.False(t, ok)
+	_, ok = verifyTOTP(rfc6238Secret, "12345a", now, 0)
+	assert.False(t, ok)
+}
+
+// TestGenerateTOTPSecret_ProvisioningURI 生成した共有鍵と認証アプリ登録用URIの形式を検証
+func TestGenerateTOTPSecret_ProvisioningURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+	_, err = TOTPCode(strings.ToLower(secret), time.Now())
+	require.NoError(t, err)
+
+	uri := TOTPProvisioningURI(secret, "Money Management", "alice")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Money%20Management:alice?"), uri)
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Money+Management")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
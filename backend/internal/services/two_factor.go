@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"money_management/internal/config"
+	"money_management/internal/models"
+)
+
+// ========================================
+// 2段階認証 - TOTPの登録・無効化、リカバリーコード、ログイン時のチャレンジ
+// ========================================
+
+// 2段階認証の設定値
+const (
+	recoveryCodeCount          = 10              // 発行するリカバリーコードの数
+	twoFactorChallengeTTL      = 5 * time.Minute // チャレンジトークンの有効期間
+	twoFactorChallengeMaxTries = 5               // チャレンジごとの認証コードの最大試行回数
+)
+
+// 2段階認証のエラー
+var (
+	errTwoFactorAlreadyEnabled = newDomainError(ErrConflict, "2段階認証は既に有効です")
+	errTwoFactorNotEnabled     = newDomainError(ErrInvalidState, "2段階認証が有効になっていません")
+	errTwoFactorNotEnrolled    = newDomainError(ErrInvalidState, "先に2段階認証の設定を開始してください")
+
+	// ErrTwoFactorCodeInvalid 認証コード・リカバリーコードが正しくない（使用済みを含む）
+	ErrTwoFactorCodeInvalid = newDomainError(ErrInvalidInput, "認証コードが正しくありません")
+	// ErrTwoFactorChallengeInvalid チャレンジトークンが無効（期限切れ・使用済み・試行回数超過）
+	ErrTwoFactorChallengeInvalid = errors.New("2段階認証の有効期限が切れました。もう一度ログインしてください")
+)
+
+// TOTPEnrollment 2段階認証の設定開始時に返す認証アプリの登録情報
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`           // 共有鍵（Base32、手入力用）
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth URI（QRコードの内容）
+}
+
+// TwoFactorService 2段階認証の設定とログイン時の認証コードの確認
+type TwoFactorService struct {
+	db     *gorm.DB
+	cipher *FieldCipher
+}
+
+// NewTwoFactorService 2段階認証サービスの初期化（共有鍵はcipherで暗号化して保存する）
+func NewTwoFactorService(db *gorm.DB, cipher *FieldCipher) *TwoFactorService {
+	return &TwoFactorService{db: db, cipher: cipher}
+}
+
+// BeginEnrollment 新しい共有鍵を生成して保存し、認証アプリの登録情報を返す
+// Enableで認証コードを確認するまで2段階認証は有効にならない（再実行すると共有鍵を作り直す）
+func (s *TwoFactorService) BeginEnrollment(userID uint) (*TOTPEnrollment, error) {
+	user, err := s.loadUser(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errTwoFactorAlreadyEnabled
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt([]byte(secret), totpAssociatedData(userID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(user).Updates(map[string]interface{}{"totp_secret": encrypted, "totp_last_step": 0}).Error; err != nil {
+		return nil, err
+	}
+
+	issuer := config.GetStringEnv("TOTP_ISSUER", "Money Management")
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(secret, issuer, user.AccountID),
+	}, nil
+}
+
+// Enable 設定中の共有鍵の認証コードを確認して2段階認証を有効にし、リカバリーコードを発行する
+// リカバリーコードはこの戻り値でのみ確認できる
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.loadUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabled {
+			return errTwoFactorAlreadyEnabled
+		}
+		if user.EncryptedTOTPSecret == "" {
+			return errTwoFactorNotEnrolled
+		}
+		if err := s.verifyTOTPCode(tx, user, normalizeTwoFactorCode(code)); err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 認証コードまたはリカバリーコードを確認して2段階認証を無効にする
+// パスワードの確認は呼び出し側で行う
+func (s *TwoFactorService) Disable(userID uint, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.loadUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TOTPEnabled {
+			return errTwoFactorNotEnabled
+		}
+		if err := s.verifyCode(tx, user, code); err != nil {
+			return err
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled": false, "totp_secret": "", "totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes 認証コードを確認してリカバリーコードを作り直す（以前のコードは使用できなくなる）
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.loadUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TOTPEnabled {
+			return errTwoFactorNotEnabled
+		}
+		if err := s.verifyTOTPCode(tx, user, normalizeTwoFactorCode(code)); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CreateChallenge パスワード認証に成功したユーザーのチャレンジトークンを発行する
+// 以前に発行した未使用のチャレンジは無効にする（複数のチャレンジで試行回数の上限を回避できないようにする）
+func (s *TwoFactorService) CreateChallenge(userID uint) (string, time.Time, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	challenge := models.TwoFactorChallenge{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TwoFactorChallenge{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			UpdateColumn("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&challenge).Error
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, challenge.ExpiresAt, nil
+}
+
+// VerifyChallenge チャレンジトークンと認証コード（またはリカバリーコード）を確認し、ユーザーIDを返す
+// 認証コードの試行はチャレンジごとに上限回数までで、上限に達したチャレンジは無効になる
+// 認証コードが誤っている場合もErrTwoFactorCodeInvalidとともにユーザーIDを返す（ログイン失敗の記録用）
+func (s *TwoFactorService) VerifyChallenge(challengeToken, code string) (uint, error) {
+	var challenge models.TwoFactorChallenge
+	if err := s.db.Where("token_hash = ?", hashRefreshToken(challengeToken)).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrTwoFactorChallengeInvalid
+		}
+		return 0, err
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return 0, ErrTwoFactorChallengeInvalid
+	}
+
+	// 同時に試行された場合も上限回数を超えないよう、検証の前に試行回数を加算する
+	result := s.db.Model(&models.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", challenge.ID, twoFactorChallengeMaxTries).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrTwoFactorChallengeInvalid
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.loadUser(tx, challenge.UserID)
+		if err != nil {
+			return err
+		}
+		if !user.TOTPEnabled {
+			return ErrTwoFactorChallengeInvalid
+		}
+		if err := s.verifyCode(tx, user, code); err != nil {
+			return err
+		}
+
+		used := tx.Model(&models.TwoFactorChallenge{}).
+			Where("id = ? AND used_at IS NULL", challenge.ID).
+			UpdateColumn("used_at", time.Now())
+		if used.Error != nil {
+			return used.Error
+		}
+		if used.RowsAffected == 0 {
+			return ErrTwoFactorChallengeInvalid
+		}
+		return nil
+	})
+	if errors.Is(err, ErrTwoFactorCodeInvalid) {
+		return challenge.UserID, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return challenge.UserID, nil
+}
+
+// verifyCode 6桁の数字は認証アプリのコード、それ以外はリカバリーコードとして確認する
+func (s *TwoFactorService) verifyCode(tx *gorm.DB, user *models.User, code string) error {
+	normalized := normalizeTwoFactorCode(code)
+	if len(normalized) == totpDigits && isDigits(normalized) {
+		return s.verifyTOTPCode(tx, user, normalized)
+	}
+	return useRecoveryCode(tx, user.ID, normalized)
+}
+
+// verifyTOTPCode 認証アプリのコードを確認し、使用した時間ステップを記録する（同じコードは再利用できない）
+func (s *TwoFactorService) verifyTOTPCode(tx *gorm.DB, user *models.User, code string) error {
+	plaintext, err := s.cipher.Decrypt(user.EncryptedTOTPSecret, totpAssociatedData(user.ID))
+	if err != nil {
+		return err
+	}
+	step, ok := verifyTOTP(string(plaintext), code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorCodeInvalid
+	}
+	return nil
+}
+
+// loadUser 2段階認証の操作対象のユーザーを取得する
+func (s *TwoFactorService) loadUser(db *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newDomainError(ErrNotFound, "ユーザーが見つかりません")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// useRecoveryCode 未使用のリカバリーコードを使用済みにする
+func useRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	if code == "" {
+		return ErrTwoFactorCodeInvalid
+	}
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRefreshToken(code)).
+		UpdateColumn("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorCodeInvalid
+	}
+	return nil
+}
+
+// replaceRecoveryCodes ユーザーのリカバリーコードを新しいコードに置き換え、表示用のコードを返す
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		value, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, value[:5]+"-"+value[5:])
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: hashRefreshToken(value)})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeTwoFactorCode 入力されたコードの空白・ハイフンを除き、英字を小文字にする
+func normalizeTwoFactorCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// totpAssociatedData 共有鍵の暗号文に結び付ける関連データ（他のユーザーへの複製を検出）
+func totpAssociatedData(userID uint) string {
+	return fmt.Sprintf("users.totp_secret:%d", userID)
+}
@@ -0,0 +1,78 @@
+package services
+
+import "fmt"
+
+// ========================================
+// 入力値検証 - フィールド単位の検証エラー
+// ========================================
+
+// 検証ルールのコード（クライアントがエラー内容を判定するために使用）
+const (
+	RuleRequired  = "required"   // 必須項目が指定されていない
+	RuleRange     = "range"      // 数値が許可された範囲外
+	RuleMin       = "min"        // 数値が下限未満
+	RuleMax       = "max"        // 数値が上限超過
+	RuleMaxLength = "max_length" // 文字数が上限超過
+	RuleOneOf     = "one_of"     // 許可された値のいずれでもない
+	RuleFormat    = "format"     // 形式が不正
+	RuleType      = "type"       // 型が不正
+	RuleNotFound  = "not_found"  // 参照先が存在しない
+	RuleNotSelf   = "not_self"   // 自分自身は指定できない
+	RuleAfter     = "after"      // 日付の前後関係が不正
+)
+
+// FieldError 1つのフィールドの検証エラー
+type FieldError struct {
+	Field   string                 `json:"field"`            // フィールド名（配列要素は items[0].amount の形式）
+	Rule    string                 `json:"rule"`             // 違反した検証ルールのコード
+	Message string                 `json:"message"`          // 利用者向けメッセージ
+	Params  map[string]interface{} `json:"params,omitempty"` // メッセージの組み立てに使う値（フロントエンドでの表示用、例: {"min": 8}）
+}
+
+// ValidationError フィールド単位の検証エラーの集合
+// errors.Is(err, ErrInvalidInput)で判定できる
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error 最初の検証エラーのメッセージを返す
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return "入力内容に誤りがあります"
+	}
+	return e.Fields[0].Message
+}
+
+// Unwrap エラーの種類を返す（errors.Isによる判定用）
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// NewValidationError 検証エラーを作成する
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// fieldValidator 検証エラーを収集する
+// 全てのフィールドを検証してから、まとめて1つのエラーとして返す
+type fieldValidator struct {
+	fields []FieldError
+}
+
+// add 検証エラーを追加する
+func (v *fieldValidator) add(field, rule, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Rule: rule, Message: message})
+}
+
+// addf 書式指定したメッセージで検証エラーを追加する
+func (v *fieldValidator) addf(field, rule, format string, args ...interface{}) {
+	v.add(field, rule, fmt.Sprintf(format, args...))
+}
+
+// err 検証エラーがあればValidationErrorを、なければnilを返す
+func (v *fieldValidator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+
+	"money_management/internal/models"
+)
+
+// ========================================
+// 全銀フォーマット（総合振込）の振込ファイル作成
+// 1レコード120バイトの固定長、Shift_JIS、レコードごとにCRLFで区切る
+// ヘッダー(1)・データ(2)・トレーラー(8)・エンド(9)の4種類のレコードで構成する
+// ========================================
+
+// 全銀フォーマットの制限値
+const (
+	zenginRecordLength   = 120          // 1レコードのバイト数
+	zenginMaxTransfers   = 999999       // データレコードの最大件数（トレーラーの合計件数6桁）
+	zenginMaxAmount      = 9999999999   // 1件の振込金額の上限（10桁）
+	zenginMaxTotalAmount = 999999999999 // 合計金額の上限（12桁）
+	zenginTransferType   = "21"         // 種別コード（総合振込）
+	zenginCodeShiftJIS   = "0"          // コード区分（JIS・SJIS）
+	zenginTransferMethod = "7"          // 振込指定区分（電信振込）
+	zenginDefaultClient  = "0000000000" // 依頼人コードの既定値（銀行との契約がない個人の場合）
+	zenginRecordSep      = "\r\n"       // レコードの区切り
+)
+
+// ZenginTransferFile 全銀フォーマットの振込ファイルの内容
+type ZenginTransferFile struct {
+	ClientCode   string             // 依頼人コード（10桁以内の数字、空の場合は0埋め）
+	Remitter     models.BankAccount // 振込元（依頼人）の口座
+	TransferDate time.Time          // 振込指定日（取組日）
+	Transfers    []ZenginTransfer   // 振込明細
+}
+
+// ZenginTransfer 振込ファイルの1件の振込
+type ZenginTransfer struct {
+	Beneficiary  models.BankAccount // 振込先（受取人）の口座
+	Amount       float64            // 振込金額（円、1円未満の端数は不可）
+	CustomerCode string             // 顧客コード1（10桁以内の数字、家計簿ID等）
+}
+
+// GenerateZenginTransferFile 全銀フォーマット（総合振込）の振込ファイルを作成する
+// 全ての項目を検証し、項目長や使用できる文字に違反する場合はフィールド単位の検証エラーを返す
+// （振込明細のフィールド名は transfers[0].amount の形式）
+func GenerateZenginTransferFile(file ZenginTransferFile) ([]byte, error) {
+	v := &fieldValidator{}
+
+	clientCode := strings.TrimSpace(file.ClientCode)
+	if clientCode == "" {
+		clientCode = zenginDefaultClient
+	} else if !isDigits(clientCode) || len(clientCode) > 10 {
+		v.add("client_code", RuleFormat, "依頼人コードは10桁以内の数字で入力してください")
+	}
+	if file.TransferDate.IsZero() {
+		v.add("transfer_date", RuleRequired, "振込指定日を指定してください")
+	}
+
+	remitter := validateBankAccount(v, "remitter.", file.Remitter)
+	if remitter.AccountType == models.AccountTypeSavings {
+		v.add("remitter.account_type", RuleOneOf, "振込元の口座に貯蓄預金は指定できません")
+	}
+
+	switch {
+	case len(file.Transfers) == 0:
+		v.add("transfers", RuleRequired, "振込明細を1件以上指定してください")
+	case len(file.Transfers) > zenginMaxTransfers:
+		v.addf("transfers", RuleMax, "振込明細は%d件以内にしてください", zenginMaxTransfers)
+	}
+
+	beneficiaries := make([]models.BankAccount, len(file.Transfers))
+	amounts := make([]int64, len(file.Transfers))
+	customerCodes := make([]string, len(file.Transfers))
+	var total int64
+	for i, transfer := range file.Transfers {
+		field := fmt.Sprintf("transfers[%d].", i)
+		beneficiaries[i] = validateBankAccount(v, field+"beneficiary.", transfer.Beneficiary)
+
+		switch {
+		case transfer.Amount <= 0:
+			v.add(field+"amount", RuleMin, "振込金額は1円以上にしてください")
+		case transfer.Amount > zenginMaxAmount:
+			v.add(field+"amount", RuleMax, "振込金額は9,999,999,999円以内にしてください")
+		case math.Trunc(transfer.Amount) != transfer.Amount:
+			v.add(field+"amount", RuleFormat, "振込金額に1円未満の端数は指定できません")
+		default:
+			amounts[i] = int64(transfer.Amount)
+			total += amounts[i]
+		}
+
+		customerCodes[i] = strings.TrimSpace(transfer.CustomerCode)
+		if customerCodes[i] != "" && (!isDigits(customerCodes[i]) || len(customerCodes[i]) > 10) {
+			v.add(field+"customer_code", RuleFormat, "顧客コードは10桁以内の数字で入力してください")
+		}
+	}
+	if total > zenginMaxTotalAmount {
+		v.add("transfers", RuleMax, "振込金額の合計は999,999,999,999円以内にしてください")
+	}
+
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	// 検証済みの項目からレコードを作成（各項目は項目長以内であることが保証されている）
+	records := make([]string, 0, len(file.Transfers)+3)
+	records = append(records, zenginHeaderRecord(clientCode, remitter, file.TransferDate))
+	for i := range file.Transfers {
+		records = append(records, zenginDataRecord(beneficiaries[i], amounts[i], customerCodes[i]))
+	}
+	records = append(records, zenginTrailerRecord(len(file.Transfers), total), zenginEndRecord())
+	return encodeZenginRecords(records)
+}
+
+// zenginHeaderRecord ヘッダーレコード（依頼人の情報）を作成する
+func zenginHeaderRecord(clientCode string, remitter models.BankAccount, transferDate time.Time) string {
+	return "1" +
+		zenginTransferType +
+		zenginCodeShiftJIS +
+		zenginNumber(clientCode, 10) +
+		zenginText(remitter.AccountHolder, 40) +
+		transferDate.Format("0102") +
+		zenginNumber(remitter.BankCode, 4) +
+		zenginText(remitter.BankName, 15) +
+		zenginNumber(remitter.BranchCode, 3) +
+		zenginText(remitter.BranchName, 15) +
+		zenginAccountTypeCode(remitter.AccountType) +
+		zenginNumber(remitter.AccountNumber, 7) +
+		zenginText("", 17)
+}
+
+// zenginDataRecord データレコード（1件の振込先と振込金額）を作成する
+func zenginDataRecord(beneficiary models.BankAccount, amount int64, customerCode string) string {
+	customer := zenginText("", 10)
+	if customerCode != "" {
+		customer = zenginNumber(customerCode, 10)
+	}
+	return "2" +
+		zenginNumber(beneficiary.BankCode, 4) +
+		zenginText(beneficiary.BankName, 15) +
+		zenginNumber(beneficiary.BranchCode, 3) +
+		zenginText(beneficiary.BranchName, 15) +
+		zenginText("", 4) + // 手形交換所番号（未使用）
+		zenginAccountTypeCode(beneficiary.AccountType) +
+		zenginNumber(beneficiary.AccountNumber, 7) +
+		zenginText(beneficiary.AccountHolder, 30) +
+		zenginNumber(fmt.Sprint(amount), 10) +
+		"0" + // 新規コード
+		customer + // 顧客コード1
+		zenginText("", 10) + // 顧客コード2
+		zenginTransferMethod +
+		zenginText("", 1) + // 識別表示
+		zenginText("", 7)
+}
+
+// zenginTrailerRecord トレーラーレコード（合計件数・合計金額）を作成する
+func zenginTrailerRecord(count int, total int64) string {
+	return "8" +
+		zenginNumber(fmt.Sprint(count), 6) +
+		zenginNumber(fmt.Sprint(total), 12) +
+		zenginText("", 101)
+}
+
+// zenginEndRecord エンドレコードを作成する
+func zenginEndRecord() string {
+	return "9" + zenginText("", 119)
+}
+
+// zenginAccountTypeCode 預金種目を全銀フォーマットのコードに変換する
+func zenginAccountTypeCode(accountType string) string {
+	switch accountType {
+	case models.AccountTypeChecking:
+		return "2"
+	case models.AccountTypeSavings:
+		return "4"
+	default:
+		return "1"
+	}
+}
+
+// zenginNumber 数字項目を右詰め・0埋めで指定の桁数にする
+func zenginNumber(value string, length int) string {
+	return strings.Repeat("0", length-len(value)) + value
+}
+
+// zenginText 文字項目を左詰め・空白埋めで指定の文字数にする（文字は全て1バイトの半角文字）
+func zenginText(value string, length int) string {
+	return value + strings.Repeat(" ", length-utf8.RuneCountInString(value))
+}
+
+// encodeZenginRecords レコードをShift_JISに変換し、全てのレコードが120バイトであることを確認する
+func encodeZenginRecords(records []string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := japanese.ShiftJIS.NewEncoder()
+	for i, record := range records {
+		encoded, err := encoder.Bytes([]byte(record))
+		if err != nil {
+			return nil, fmt.Errorf("振込ファイルの%d行目をShift_JISに変換できません: %w", i+1, err)
+		}
+		if len(encoded) != zenginRecordLength {
+			return nil, fmt.Errorf("振込ファイルの%d行目が%dバイトです（%dバイト必要）", i+1, len(encoded), zenginRecordLength)
+		}
+		buf.Write(encoded)
+		buf.WriteString(zenginRecordSep)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+// ========================================
+// 全銀フォーマットの振込ファイル作成テスト
+// サンプルファイルとの一致と、項目ごとの検証を確認
+// ========================================
+
+package services
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"money_management/internal/models"
+)
+
+// sampleZenginTransferFile testdata/zengin_transfer_sample.txt と同じ内容の振込ファイル（入力は全角・ひらがなを含む）
+func sampleZenginTransferFile() ZenginTransferFile {
+	return ZenginTransferFile{
+		Remitter: models.BankAccount{
+			BankCode: "0001", BankName: "ミズホ", BranchCode: "001", BranchName: "トウキョウ",
+			AccountType: models.AccountTypeOrdinary, AccountNumber: "1234567", AccountHolder: "やまだ　はなこ",
+		},
+		TransferDate: time.Date(2024, 10, 25, 0, 0, 0, 0, time.Local),
+		Transfers: []ZenginTransfer{
+			{
+				Beneficiary: models.BankAccount{
+					BankCode: "0005", BankName: "ミツビシユーエフジェイ", BranchCode: "１２３", BranchName: "シンジュク",
+					AccountType: models.AccountTypeSavings, AccountNumber: "765", AccountHolder: "ｻﾄｳ ﾀﾛｳ",
+				},
+				Amount:       98000,
+				CustomerCode: "12",
+			},
+			{
+				Beneficiary: models.BankAccount{
+					BankCode: "0009", BankName: "ミツイスミトモ", BranchCode: "456", BranchName: "ウメダ",
+					AccountType: models.AccountTypeChecking, AccountNumber: "1111111", AccountHolder: "（カ）スズキ",
+				},
+				Amount: 1500,
+			},
+		},
+	}
+}
+
+// TestGenerateZenginTransferFile_MatchesSample 作成した振込ファイルがサンプルファイルとバイト単位で一致することを検証
+func TestGenerateZenginTransferFile_MatchesSample(t *testing.T) {
+	expected, err := os.ReadFile("testdata/zengin_transfer_sample.txt")
+	require.NoError(t, err)
+
+	data, err := GenerateZenginTransferFile(sampleZenginTransferFile())
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	// 全てのレコードが120バイトでCRLF区切り（ヘッダー・データ2件・トレーラー・エンド）
+	records := bytes.Split(bytes.TrimSuffix(data, []byte("\r\n")), []byte("\r\n"))
+	require.Len(t, records, 5)
+	for _, record := range records {
+		assert.Len(t, record, 120)
+	}
+	assert.Equal(t, "8000002000000099500", string(records[3][:19]))
+}
+
+// TestGenerateZenginTransferFile_Validation 項目長・文字種・金額の違反をフィールド単位で返すことを検証
+func TestGenerateZenginTransferFile_Validation(t *testing.T) {
+	file := sampleZenginTransferFile()
+	file.ClientCode = "12345678901"
+	file.Remitter.AccountType = models.AccountTypeSavings
+	file.Transfers[0].Beneficiary.AccountHolder = "佐藤 太郎"
+	file.Transfers[0].Beneficiary.BranchName = "シンジュクニシグチエキマエシュッチョウジョ"
+	file.Transfers[0].Amount = 1000.5
+	file.Transfers[1].Beneficiary.BankCode = "9"
+	file.Transfers[1].Amount = 0
+
+	_, err := GenerateZenginTransferFile(file)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	rules := map[string]string{}
+	for _, field := range validationErr.Fields {
+		rules[field.Field] = field.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"client_code":                             RuleFormat,
+		"remitter.account_type":                   RuleOneOf,
+		"transfers[0].beneficiary.account_holder": RuleFormat,
+		"transfers[0].beneficiary.branch_name":    RuleMaxLength,
+		"transfers[0].amount":                     RuleFormat,
+		"transfers[1].beneficiary.bank_code":      RuleFormat,
+		"transfers[1].amount":                     RuleMin,
+	}, rules)
+
+	// 振込明細がない場合
+	_, err = GenerateZenginTransferFile(ZenginTransferFile{Remitter: file.Remitter, TransferDate: file.TransferDate})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "transfers", validationErr.Fields[len(validationErr.Fields)-1].Field)
+}
+
+// TestNormalizeBankAccount 口座情報を振込ファイルの形式に正規化することを検証
+func TestNormalizeBankAccount(t *testing.T) {
+	account, err := NormalizeBankAccount(models.BankAccount{
+		BankCode: "０００１", BankName: "みずほ", BranchCode: "001", BranchName: "ﾄｳｷｮｳ",
+		AccountType: models.AccountTypeOrdinary, AccountNumber: "４５６", AccountHolder: "Yamada Hanako",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.BankAccount{
+		BankCode: "0001", BankName: "ﾐｽﾞﾎ", BranchCode: "001", BranchName: "ﾄｳｷﾖｳ",
+		AccountType: models.AccountTypeOrdinary, AccountNumber: "0000456", AccountHolder: "YAMADA HANAKO",
+	}, account)
+
+	_, err = NormalizeBankAccount(models.BankAccount{AccountType: "current"})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Fields, 7)
+}
+
+// TestFieldCipher 暗号化した口座情報は同じユーザーでのみ復号でき、改ざんや別のキーを検出することを検証
+func TestFieldCipher(t *testing.T) {
+	fieldCipher, err := NewFieldCipher([]byte("test-field-encryption-key-32-characters"))
+	require.NoError(t, err)
+
+	account := models.BankAccount{BankCode: "0001", BankName: "ﾐｽﾞﾎ", BranchCode: "001", BranchName: "ﾄｳｷﾖｳ",
+		AccountType: models.AccountTypeOrdinary, AccountNumber: "1234567", AccountHolder: "ﾔﾏﾀﾞ ﾊﾅｺ"}
+	user := models.User{ID: 1}
+	require.NoError(t, SealBankAccount(fieldCipher, &user, account))
+	assert.NotContains(t, user.EncryptedBankAccount, "1234567")
+
+	opened, err := OpenBankAccount(fieldCipher, user)
+	require.NoError(t, err)
+	assert.Equal(t, account, *opened)
+
+	// 他のユーザーに複製した暗号文は復号できない
+	_, err = OpenBankAccount(fieldCipher, models.User{ID: 2, EncryptedBankAccount: user.EncryptedBankAccount})
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	// 別のキーでは復号できない
+	otherCipher, err := NewFieldCipher([]byte("another-field-encryption-key-32-chars"))
+	require.NoError(t, err)
+	_, err = OpenBankAccount(otherCipher, user)
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	// 未登録の場合はnil
+	opened, err = OpenBankAccount(fieldCipher, models.User{ID: 3})
+	require.NoError(t, err)
+	assert.Nil(t, opened)
+}
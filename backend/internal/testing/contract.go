@@ -43,6 +43,8 @@ type FieldDefinition struct {
 	Format      string      `json:"format,omitempty"`
 	MinLength   *int        `json:"min_length,omitempty"`
 	MaxLength   *int        `json:"max_length,omitempty"`
+	Minimum     *float64    `json:"minimum,omitempty"`
+	Maximum     *float64    `json:"maximum,omitempty"`
 	Pattern     string      `json:"pattern,omitempty"`
 	Enum        []string    `json:"enum,omitempty"`
 	Default     interface{} `json:"default,omitempty"`
@@ -278,6 +280,25 @@ func (v *StandardContractVerifier) validateConstraints(fieldName string, value i
 		}
 	}
 
+	// 数値範囲制約
+	if num, ok := value.(float64); ok {
+		if definition.Minimum != nil && num < *definition.Minimum {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("値が小さすぎます。最小: %v, 実際: %v", *definition.Minimum, num),
+				Value:   value,
+			})
+		}
+
+		if definition.Maximum != nil && num > *definition.Maximum {
+			errors = append(errors, ValidationError{
+				Field:   fieldName,
+				Message: fmt.Sprintf("値が大きすぎます。最大: %v, 実際: %v", *definition.Maximum, num),
+				Value:   value,
+			})
+		}
+	}
+
 	return errors
 }
 
@@ -539,17 +560,22 @@ func GetCreateBillContract() Contract {
 			"year": FieldDefinition{
 				Type:        "integer",
 				Required:    true,
-				Description: "対象年",
+				Minimum:     &[]float64{2000}[0],
+				Maximum:     &[]float64{2100}[0],
+				Description: "対象年（2000-2100）",
 			},
 			"month": FieldDefinition{
 				Type:        "integer",
 				Required:    true,
+				Minimum:     &[]float64{1}[0],
+				Maximum:     &[]float64{12}[0],
 				Description: "対象月（1-12）",
 			},
 			"payer_id": FieldDefinition{
 				Type:        "integer",
 				Required:    true,
-				Description: "支払者のユーザーID",
+				Minimum:     &[]float64{1}[0],
+				Description: "支払者のユーザーID（存在するユーザー）",
 			},
 		},
 		ResponseSchema: map[string]interface{}{
@@ -571,7 +597,7 @@ func GetCreateBillContract() Contract {
 			"status": FieldDefinition{
 				Type:        "string",
 				Required:    true,
-				Enum:        []string{"pending", "requested", "paid"},
+				Enum:        []string{"pending", "requested", "acknowledged", "paid"},
 				Description: "家計簿の状態",
 			},
 			"created_at": FieldDefinition{
@@ -614,7 +640,7 @@ func GetBillContract() Contract {
 			"status": FieldDefinition{
 				Type:        "string",
 				Required:    true,
-				Enum:        []string{"pending", "requested", "paid"},
+				Enum:        []string{"pending", "requested", "acknowledged", "paid"},
 				Description: "家計簿の状態",
 			},
 			"total_amount": FieldDefinition{
@@ -665,7 +691,7 @@ func GetBillsContract() Contract {
 					"status": FieldDefinition{
 						Type:        "string",
 						Required:    true,
-						Enum:        []string{"pending", "requested", "paid"},
+						Enum:        []string{"pending", "requested", "acknowledged", "paid"},
 						Description: "家計簿の状態",
 					},
 					"total_amount": FieldDefinition{
@@ -681,3 +707,90 @@ func GetBillsContract() Contract {
 		Description: "家計簿一覧取得API",
 	}
 }
+
+// ========================================
+// 検証エラーレスポンス契約
+// ========================================
+
+// ValidationErrorCode 検証エラーのレスポンスのエラーコード
+const ValidationErrorCode = "VALIDATION_ERROR"
+
+// ValidationRuleCodes 検証エラーのルールコード一覧
+var ValidationRuleCodes = []string{
+	"required", "range", "min", "max", "max_length", "one_of", "format", "type", "not_found", "not_self", "after",
+}
+
+// GetValidationErrorContract 検証エラーレスポンス契約（全ての入力検証エラーで共通の400レスポンス）
+// fieldsの各要素はGetFieldErrorContractの契約に従う
+func GetValidationErrorContract() Contract {
+	return Contract{
+		Name: "Validation Error Response",
+		ResponseSchema: map[string]interface{}{
+			"error": FieldDefinition{
+				Type:        "string",
+				Required:    true,
+				Description: "最初の検証エラーのメッセージ",
+			},
+			"code": FieldDefinition{
+				Type:        "string",
+				Required:    true,
+				Enum:        []string{ValidationErrorCode},
+				Description: "エラーコード",
+			},
+			"fields": FieldDefinition{
+				Type:        "array",
+				Required:    true,
+				Description: "フィールド単位の検証エラー一覧",
+			},
+		},
+		StatusCode:  400,
+		Headers:     map[string]string{"Content-Type": "application/json"},
+		Description: "入力値の検証エラー。不正なフィールドとルールコードを全て列挙する",
+	}
+}
+
+// GetFieldErrorContract フィールド単位の検証エラー契約（検証エラーレスポンスのfieldsの要素）
+func GetFieldErrorContract() Contract {
+	return Contract{
+		Name: "Field Error",
+		ResponseSchema: map[string]interface{}{
+			"field": FieldDefinition{
+				Type:        "string",
+				Required:    true,
+				MinLength:   &[]int{1}[0],
+				Description: "フィールド名（配列要素は items[0].amount の形式）",
+			},
+			"rule": FieldDefinition{
+				Type:        "string",
+				Required:    true,
+				Enum:        ValidationRuleCodes,
+				Description: "違反した検証ルールのコード",
+			},
+			"message": FieldDefinition{
+				Type:        "string",
+				Required:    true,
+				MinLength:   &[]int{1}[0],
+				Description: "利用者向けメッセージ",
+			},
+		},
+		Description: "フィールド単位の検証エラー",
+	}
+}
+
+// ValidateValidationErrorResponse 検証エラーレスポンスを契約に照らして検証する（fieldsの各要素を含む）
+func ValidateValidationErrorResponse(verifier ContractVerifier, response map[string]interface{}) error {
+	if err := verifier.ValidateResponseSchema(response, GetValidationErrorContract()); err != nil {
+		return err
+	}
+
+	fields, ok := response["fields"].([]interface{})
+	if !ok || len(fields) == 0 {
+		return ValidationError{Field: "fields", Message: "検証エラーが1件以上必要です", Value: response["fields"]}
+	}
+	for i, field := range fields {
+		if err := verifier.ValidateResponseSchema(field, GetFieldErrorContract()); err != nil {
+			return fmt.Errorf("fields[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
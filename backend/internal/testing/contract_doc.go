@@ -96,6 +96,8 @@ type OpenAPISchema struct {
 	Enum        []string                 `json:"enum,omitempty"`
 	MinLength   *int                     `json:"minLength,omitempty"`
 	MaxLength   *int                     `json:"maxLength,omitempty"`
+	Minimum     *float64                 `json:"minimum,omitempty"`
+	Maximum     *float64                 `json:"maximum,omitempty"`
 }
 
 // OpenAPIComponents コンポーネント定義
@@ -211,22 +213,16 @@ func addContractToSpec(spec *OpenAPISpec, contract Contract) error {
 		},
 	}
 
-	// エラーレスポンスを追加
+	// エラーレスポンスを追加（入力値の検証エラー）
+	validationErrorSchema, err := createValidationErrorSchema()
+	if err != nil {
+		return err
+	}
 	operation.Responses["400"] = OpenAPIResponse{
 		Description: "リクエストエラー",
 		Content: map[string]OpenAPIContent{
 			"application/json": {
-				Schema: OpenAPISchema{
-					Type: "object",
-					Properties: map[string]OpenAPISchema{
-						"error": {
-							Type:        "string",
-							Description: "エラーメッセージ",
-							Example:     "リクエストの形式が不正です",
-						},
-					},
-					Required: []string{"error"},
-				},
+				Schema: validationErrorSchema,
 			},
 		},
 	}
@@ -319,6 +315,8 @@ func convertToOpenAPISchema(schema map[string]interface{}) (OpenAPISchema, error
 			Enum:        fieldDefinition.Enum,
 			MinLength:   fieldDefinition.MinLength,
 			MaxLength:   fieldDefinition.MaxLength,
+			Minimum:     fieldDefinition.Minimum,
+			Maximum:     fieldDefinition.Maximum,
 		}
 
 		// 例値を設定
@@ -340,6 +338,29 @@ func convertToOpenAPISchema(schema map[string]interface{}) (OpenAPISchema, error
 	return openAPISchema, nil
 }
 
+// createValidationErrorSchema 検証エラーレスポンスのスキーマを作成（fieldsの要素のスキーマを含む）
+func createValidationErrorSchema() (OpenAPISchema, error) {
+	schema, err := convertToOpenAPISchema(GetValidationErrorContract().ResponseSchema)
+	if err != nil {
+		return schema, err
+	}
+	fieldErrorSchema, err := convertToOpenAPISchema(GetFieldErrorContract().ResponseSchema)
+	if err != nil {
+		return schema, err
+	}
+
+	fields := schema.Properties["fields"]
+	fields.Items = &fieldErrorSchema
+	fields.Example = nil
+	schema.Properties["fields"] = fields
+
+	code := schema.Properties["code"]
+	code.Example = ValidationErrorCode
+	schema.Properties["code"] = code
+
+	return schema, nil
+}
+
 // mapTypeToOpenAPI 契約テストの型をOpenAPI型にマッピング
 func mapTypeToOpenAPI(contractType string) string {
 	switch contractType {
@@ -351,6 +372,8 @@ func mapTypeToOpenAPI(contractType string) string {
 		return "number"
 	case "boolean":
 		return "boolean"
+	case "array":
+		return "array"
 	default:
 		return "string"
 	}
@@ -411,6 +434,11 @@ func addCommonSchemas(spec *OpenAPISpec) {
 		Required: []string{"error"},
 	}
 
+	// 検証エラーレスポンススキーマ
+	if validationErrorSchema, err := createValidationErrorSchema(); err == nil {
+		spec.Components.Schemas["ValidationError"] = validationErrorSchema
+	}
+
 	// ユーザースキーマ
 	spec.Components.Schemas["User"] = OpenAPISchema{
 		Type: "object",
@@ -588,6 +616,21 @@ Authorization: Bearer <JWT_TOKEN>
 }
 ` + "```" + `
 
+入力値の検証エラー（HTTP 400）では、不正なフィールドとルールコードが全て列挙されます：
+
+` + "```json" + `
+{
+  "error": "月は1から12の範囲で指定してください",
+  "code": "` + ValidationErrorCode + `",
+  "fields": [
+    {"field": "month", "rule": "range", "message": "月は1から12の範囲で指定してください"},
+    {"field": "payer_id", "rule": "not_found", "message": "指定された支払者が見つかりません"}
+  ]
+}
+` + "```" + `
+
+ルールコード: ` + "`" + strings.Join(ValidationRuleCodes, "`, `") + "`" + `
+
 ### HTTPステータスコード
 
 | ステータスコード | 説明 |
@@ -15,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"money_management/internal/models"
@@ -243,10 +244,50 @@ func (suite *ContractTestSuite) TestCreateBillContract_InvalidMonth() {
 		"payer_id": 2,
 	}
 
-	// カスタムバリデーションが必要（基本的な型チェックは通過する）
+	// 年月の範囲は契約で検証する
 	err := suite.verifier.ValidateRequestSchema(invalidRequest, contract)
-	// 基本的な型チェックは通過するが、ビジネスロジックレベルでの検証が必要
-	assert.NoError(suite.T(), err, "基本的な型チェックは通過するはずです")
+	assert.Error(suite.T(), err, "範囲外の月が契約違反として検出されるべきです")
+
+	invalidRequest["month"] = 12
+	invalidRequest["year"] = -1
+	err = suite.verifier.ValidateRequestSchema(invalidRequest, contract)
+	assert.Error(suite.T(), err, "範囲外の年が契約違反として検出されるべきです")
+}
+
+// TestValidationErrorContract 検証エラーレスポンス契約テスト
+func (suite *ContractTestSuite) TestValidationErrorContract() {
+	validResponse := map[string]interface{}{
+		"error": "月は1から12の範囲で指定してください",
+		"code":  ValidationErrorCode,
+		"fields": []map[string]interface{}{
+			{"field": "month", "rule": "range", "message": "月は1から12の範囲で指定してください"},
+			{"field": "items[0].amount", "rule": "min", "message": "金額は0より大きい値を指定してください"},
+		},
+	}
+	var parsed map[string]interface{}
+	data, _ := json.Marshal(validResponse)
+	require.NoError(suite.T(), json.Unmarshal(data, &parsed))
+	assert.NoError(suite.T(), ValidateValidationErrorResponse(suite.verifier, parsed))
+
+	testCases := []struct {
+		name     string
+		response map[string]interface{}
+	}{
+		{"エラーコードなし", map[string]interface{}{"error": "不正", "fields": []interface{}{}}},
+		{"エラーコードが不正", map[string]interface{}{"error": "不正", "code": "INVALID", "fields": []interface{}{}}},
+		{"検証エラーが空", map[string]interface{}{"error": "不正", "code": ValidationErrorCode, "fields": []interface{}{}}},
+		{"未定義のルールコード", map[string]interface{}{"error": "不正", "code": ValidationErrorCode, "fields": []interface{}{
+			map[string]interface{}{"field": "month", "rule": "invalid_month", "message": "不正"},
+		}}},
+		{"フィールド名なし", map[string]interface{}{"error": "不正", "code": ValidationErrorCode, "fields": []interface{}{
+			map[string]interface{}{"rule": "range", "message": "不正"},
+		}}},
+	}
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			assert.Error(t, ValidateValidationErrorResponse(suite.verifier, tc.response))
+		})
+	}
 }
 
 // TestBillContract_ValidResponse 家計簿詳細API契約テスト
@@ -236,6 +236,79 @@ func (b *BillBuilder) MustBuild() (models.MonthlyBill, []models.BillItem) {
 	return bill, items
 }
 
+// ========================================
+// HouseholdBuilder - 世帯データのBuilder Pattern実装
+// ========================================
+
+// HouseholdBuilder 世帯作成のためのBuilder
+type HouseholdBuilder struct {
+	factory   *TestDataFactory
+	household models.Household
+	members   []models.HouseholdMember
+}
+
+// NewHousehold 新しいHouseholdBuilderを開始
+func (f *TestDataFactory) NewHousehold() *HouseholdBuilder {
+	return &HouseholdBuilder{
+		factory:   f,
+		household: models.Household{Name: "テスト世帯"},
+		members:   []models.HouseholdMember{},
+	}
+}
+
+// WithName 世帯名を設定
+func (b *HouseholdBuilder) WithName(name string) *HouseholdBuilder {
+	b.household.Name = name
+	return b
+}
+
+// WithOwner 世帯管理者を追加（最初の管理者が作成者となる）
+func (b *HouseholdBuilder) WithOwner(userID uint) *HouseholdBuilder {
+	if b.household.CreatedBy == 0 {
+		b.household.CreatedBy = userID
+	}
+	b.members = append(b.members, models.HouseholdMember{UserID: userID, Role: models.HouseholdRoleOwner})
+	return b
+}
+
+// WithMembers 一般メンバーを追加
+func (b *HouseholdBuilder) WithMembers(userIDs ...uint) *HouseholdBuilder {
+	for _, userID := range userIDs {
+		b.members = append(b.members, models.HouseholdMember{UserID: userID, Role: models.HouseholdRoleMember})
+	}
+	return b
+}
+
+// Build 世帯を構築
+func (b *HouseholdBuilder) Build() (models.Household, error) {
+	if b.household.CreatedBy == 0 && len(b.members) > 0 {
+		b.household.CreatedBy = b.members[0].UserID
+	}
+
+	if err := b.factory.db.Create(&b.household).Error; err != nil {
+		return models.Household{}, fmt.Errorf("世帯の作成に失敗しました: %w", err)
+	}
+
+	for i := range b.members {
+		b.members[i].HouseholdID = b.household.ID
+		if err := b.factory.db.Create(&b.members[i]).Error; err != nil {
+			return models.Household{}, fmt.Errorf("世帯メンバーの作成に失敗しました: %w", err)
+		}
+	}
+	b.household.Members = b.members
+
+	return b.household, nil
+}
+
+// MustBuild 世帯を構築（エラー時panic）
+func (b *HouseholdBuilder) MustBuild() models.Household {
+	household, err := b.Build()
+	if err != nil {
+		panic(fmt.Sprintf("世帯作成失敗: %v", err))
+	}
+	return household
+}
+
 // ========================================
 // 便利な型定義とヘルパー
 // ========================================
@@ -256,7 +329,7 @@ func Item(name string, amount float64) BillItemSpec {
 // ========================================
 
 // CreateStandardTestScenario 標準的なテストシナリオを作成
-// ユーザー3名、家計簿1件、項目2件の基本セット
+// ユーザー3名（同一世帯）、家計簿1件、項目2件の基本セット
 // 設定により軽量データまたは完全データを作成
 func (f *TestDataFactory) CreateStandardTestScenario() (*StandardTestData, error) {
 	config := GetGlobalConfig()
@@ -300,7 +373,16 @@ func (f *TestDataFactory) CreateLightweightTestScenario() (*StandardTestData, er
 		return nil, fmt.Errorf("軽量テスト: ユーザーIDが正しく設定されていません: User1.ID=%d, User2.ID=%d", data.User1.ID, data.User2.ID)
 	}
 
-	// Step 3: シンプルな家計簿（1つのアイテムのみ）
+	// Step 3: 3名が所属する世帯
+	data.Household, err = f.NewHousehold().
+		WithOwner(data.User1.ID).
+		WithMembers(data.User2.ID, data.User3.ID).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("軽量テスト 世帯作成失敗: %w", err)
+	}
+
+	// Step 4: シンプルな家計簿（1つのアイテムのみ）
 	data.Bill, data.Items, err = f.NewBill().
 		WithRequester(data.User1.ID).
 		WithPayer(data.User2.ID).
@@ -346,7 +428,17 @@ func (f *TestDataFactory) CreateFullTestScenario() (*StandardTestData, error) {
 		return nil, fmt.Errorf("ユーザーIDが正しく設定されていません: User1.ID=%d, User2.ID=%d", data.User1.ID, data.User2.ID)
 	}
 
-	// Step 3: 家計簿作成（User1が請求者、User2が支払者）
+	// Step 3: 世帯作成（User1が管理者、User2・User3がメンバー）
+	data.Household, err = f.NewHousehold().
+		WithName("山田家").
+		WithOwner(data.User1.ID).
+		WithMembers(data.User2.ID, data.User3.ID).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("世帯作成失敗: %w", err)
+	}
+
+	// Step 4: 家計簿作成（User1が請求者、User2が支払者）
 	data.Bill, data.Items, err = f.NewBill().
 		WithRequester(data.User1.ID).
 		WithPayer(data.User2.ID).
@@ -365,11 +457,12 @@ func (f *TestDataFactory) CreateFullTestScenario() (*StandardTestData, error) {
 
 // StandardTestData 標準的なテストデータセット
 type StandardTestData struct {
-	User1 models.User
-	User2 models.User
-	User3 models.User
-	Bill  models.MonthlyBill
-	Items []models.BillItem
+	User1     models.User
+	User2     models.User
+	User3     models.User
+	Household models.Household
+	Bill      models.MonthlyBill
+	Items     []models.BillItem
 }
 
 // ========================================
@@ -353,6 +353,7 @@ func (q *MockDBQuery) Where(query interface{}, args ...interface{}) DBInterface
 type PasswordHasherInterface interface {
 	HashPassword(password string) (string, error)
 	ComparePassword(hashedPassword, password string) error
+	NeedsRehash(hashedPassword string) bool // ハッシュを現在の設定で作り直す必要があるか
 }
 
 // MockPasswordHasher モックパスワードハッシャー
@@ -392,6 +393,11 @@ func (m *MockPasswordHasher) ComparePassword(hashedPassword, password string) er
 	return nil
 }
 
+// NeedsRehash ハッシュの作り直しが必要かのモック（モックのハッシュは作り直さない）
+func (m *MockPasswordHasher) NeedsRehash(hashedPassword string) bool {
+	return false
+}
+
 // ========================================
 // JWTトークンサービスのモック
 // ========================================
@@ -11,9 +11,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/utrack/gin-csrf"
 
+	"money_management/internal/config"
 	"money_management/internal/database"
 	"money_management/internal/handlers"
 	"money_management/internal/middleware"
+	"money_management/internal/services"
 )
 
 // main メイン関数
@@ -27,16 +29,60 @@ func main() {
 	}
 	time.Local = loc
 
-	// JWT秘密鍵の初期化確認
-	if _, err := middleware.GetJWTSecret(); err != nil {
-		log.Fatal("JWT秘密鍵の取得に失敗しました:", err)
+	// JWT署名鍵のキーリングを読み込み（JWT_KEYS_FILEがない場合はJWT_SECRET）
+	jwtKeys, err := middleware.NewJWTKeyManagerFromEnv()
+	if err != nil {
+		log.Fatal("JWT署名鍵の読み込みに失敗しました:", err)
 	}
+	middleware.SetJWTKeyManager(jwtKeys)
+	// 鍵のファイルの変更を定期的に確認して読み込み直す（JWT_KEYS_RELOAD_SECONDS、既定30秒）
+	go jwtKeys.Watch(time.Duration(config.GetIntEnv("JWT_KEYS_RELOAD_SECONDS", 30)) * time.Second)
 
 	// データベース接続を初期化
 	if err := database.Init(); err != nil {
 		log.Fatal("データベースに接続できませんでした:", err)
 	}
 
+	// トークンのブラックリストの保存先を設定（既定はデータベース、TOKEN_BLACKLIST_BACKENDで変更）
+	blacklistStore, err := middleware.NewTokenBlacklistStoreFromEnv(database.GetDB())
+	if err != nil {
+		log.Fatal("トークンのブラックリストを初期化できませんでした:", err)
+	}
+	middleware.SetTokenBlacklistStore(blacklistStore)
+
+	// セッションの最終利用日時を1分間隔でまとめて保存
+	stopSessionActivity := middleware.StartSessionActivityFlusher(services.NewSessionService(database.GetDB()).TouchSessions, time.Minute)
+	defer stopSessionActivity()
+
+	// 認証時にユーザーのトークン世代を検証（全デバイスログアウト・パスワード変更で旧トークンを無効化）
+	// 世代は30秒間（TOKEN_VERSION_CACHE_SECONDS）キャッシュし、世代を進めた時に破棄する
+	middleware.SetTokenVersionCacheTTL(time.Duration(config.GetIntEnv("TOKEN_VERSION_CACHE_SECONDS", 30)) * time.Second)
+	middleware.SetTokenVersionLookup(services.NewTokenVersionService(database.GetDB()).Current)
+
+	// ログイン失敗の古い記録を1時間間隔で削除
+	go func() {
+		attempts := services.NewLoginAttemptService(database.GetDB())
+		for range time.Tick(time.Hour) {
+			if _, err := attempts.PurgeStale(time.Now()); err != nil {
+				log.Printf("❌ Login attempts cleanup failed: %v", err)
+			}
+		}
+	}()
+
+	// パスワード再設定の案内の送信方法を設定（既定はログ出力、PASSWORD_RESET_SENDERで変更）
+	resetSender, err := services.NewPasswordResetSenderFromEnv()
+	if err != nil {
+		log.Fatal("パスワード再設定の送信方法を初期化できませんでした:", err)
+	}
+	services.SetPasswordResetSender(resetSender)
+
+	// パスワードのハッシュ化のアルゴリズムを設定（既定はArgon2id、PASSWORD_HASH_ALGORITHMで変更）
+	passwordHasher, err := services.NewPasswordHasherFromEnv()
+	if err != nil {
+		log.Fatal("パスワードハッシャーを初期化できませんでした:", err)
+	}
+	services.SetPasswordHasher(passwordHasher)
+
 	// Gin設定（本番環境ではリリースモードに設定）
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
@@ -56,8 +102,8 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://frontend:80"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -105,6 +151,11 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok", "message": "家計簿API稼働中"})
 	})
 
+	// JWKS（アクセストークンの検証に使用する公開鍵の一覧）
+	// 標準のパスに加えて、/api/以下のみを中継するリバースプロキシ経由でも取得できるようにする
+	r.GET("/.well-known/jwks.json", handlers.JWKSHandler)
+	r.GET("/api/.well-known/jwks.json", handlers.JWKSHandler)
+
 	// CSRFトークン取得エンドポイント
 	// フロントエンドがCSRFトークンを取得するためのエンドポイント
 	r.GET("/api/csrf-token", func(c *gin.Context) {
@@ -141,11 +192,29 @@ func setupRoutes(r *gin.Engine) {
 			auth.POST("/login", handlers.LoginHandler)                          // ログイン
 			auth.POST("/register", handlers.RegisterHandler)                    // ユーザー登録
 			auth.GET("/me", middleware.AuthMiddleware(), handlers.GetMeHandler) // 現在のユーザー情報取得
+			auth.POST("/refresh", handlers.RefreshTokenHandler)                 // アクセストークン再発行（リフレッシュトークンのローテーション）
+			auth.POST("/login/2fa", handlers.LoginTwoFactorHandler)             // 2段階認証のログイン（チャレンジトークンと認証コードを交換）
+
+			// パスワードの変更・再設定
+			auth.POST("/password/forgot", handlers.RequestPasswordResetHandler)                // パスワード再設定の依頼（再設定トークンを送信）
+			auth.POST("/password/reset", handlers.ResetPasswordHandler)                        // パスワード再設定（再設定トークンで新しいパスワードを設定）
+			auth.PUT("/password", middleware.AuthMiddleware(), handlers.ChangePasswordHandler) // パスワード変更（現在のパスワードが必要）
+			auth.GET("/password-policy", handlers.GetPasswordPolicyHandler)                    // パスワードの要件
+			auth.PUT("/email", middleware.AuthMiddleware(), handlers.UpdateEmailHandler)       // メールアドレス変更（再設定の案内の送信先、現在のパスワードが必要）
+
+			// 2段階認証（TOTP）の設定エンドポイント（認証が必要）
+			auth.GET("/2fa/status", middleware.AuthMiddleware(), handlers.GetTwoFactorStatusHandler)               // 本人の2段階認証が有効か
+			auth.POST("/2fa/setup", middleware.AuthMiddleware(), handlers.SetupTwoFactorHandler)                   // 設定開始（共有鍵とotpauth URI）
+			auth.POST("/2fa/enable", middleware.AuthMiddleware(), handlers.EnableTwoFactorHandler)                 // 認証コードを確認して有効化
+			auth.POST("/2fa/disable", middleware.AuthMiddleware(), handlers.DisableTwoFactorHandler)               // 無効化（パスワードと認証コード）
+			auth.POST("/2fa/recovery-codes", middleware.AuthMiddleware(), handlers.RegenerateRecoveryCodesHandler) // リカバリーコード再発行
 
 			// セッション管理エンドポイント（認証が必要）
-			auth.POST("/logout", middleware.AuthMiddleware(), handlers.LogoutHandler)              // ログアウト
-			auth.POST("/logout-all", middleware.AuthMiddleware(), handlers.LogoutAllHandler)       // 全デバイスログアウト
-			auth.GET("/token-status", middleware.AuthMiddleware(), handlers.GetTokenStatusHandler) // トークンステータス確認
+			auth.POST("/logout", middleware.AuthMiddleware(), handlers.LogoutHandler)                // ログアウト
+			auth.POST("/logout-all", middleware.AuthMiddleware(), handlers.LogoutAllHandler)         // 全デバイスログアウト（トークン世代を進める）
+			auth.GET("/token-status", middleware.AuthMiddleware(), handlers.GetTokenStatusHandler)   // トークンステータス確認
+			auth.GET("/sessions", middleware.AuthMiddleware(), handlers.ListSessionsHandler)         // ログイン中の端末一覧
+			auth.DELETE("/sessions/:id", middleware.AuthMiddleware(), handlers.RevokeSessionHandler) // 端末のセッション終了
 		}
 
 		// ユーザー関連のエンドポイント
@@ -153,24 +222,114 @@ func setupRoutes(r *gin.Engine) {
 		users.Use(middleware.AuthMiddleware())
 		{
 			users.GET("", handlers.GetUsersHandler) // ユーザー一覧取得
+
+			// 振込用の口座情報（暗号化して保存）
+			users.GET("/me/bank-account", handlers.GetBankAccountHandler)       // 口座情報取得
+			users.PUT("/me/bank-account", handlers.UpdateBankAccountHandler)    // 口座情報登録・更新
+			users.DELETE("/me/bank-account", handlers.DeleteBankAccountHandler) // 口座情報削除
 		}
 
 		// 家計簿関連のエンドポイント（認証が必要）
 		bills := api.Group("/bills")
 		bills.Use(middleware.AuthMiddleware())
+		bills.Use(middleware.IdempotencyMiddleware()) // 更新系リクエストのIdempotency-Key対応
 		{
-			bills.GET("", handlers.GetBillsListHandler)         // 家計簿一覧取得
-			bills.GET("/:year/:month", handlers.GetBillHandler) // 特定年月の家計簿取得
+			bills.GET("", handlers.GetBillsListHandler)           // 家計簿一覧取得
+			bills.GET("/:year/:month", handlers.GetBillHandler)   // 特定年月の家計簿取得
+			bills.GET("/detail/:id", handlers.GetBillByIDHandler) // ID指定の家計簿取得（任意期間を含む）
+
+			// 入金明細の照合結果（請求者のみ）
+			bills.GET("/reconciliations/:id", handlers.GetReconciliationHandler) // 照合結果取得
 
 			// 作成系操作には追加のレート制限
 			billsCreate := bills.Group("")
 			billsCreate.Use(middleware.CreateRateLimitMiddleware())
 			{
-				billsCreate.POST("", handlers.CreateBillHandler)             // 新規家計簿作成
-				billsCreate.PUT("/:id/items", handlers.UpdateItemsHandler)   // 家計簿項目更新
-				billsCreate.PUT("/:id/request", handlers.RequestBillHandler) // 家計簿請求
-				billsCreate.PUT("/:id/payment", handlers.PaymentBillHandler) // 家計簿支払い確認
-				billsCreate.DELETE("/:id", handlers.DeleteBillHandler)       // 家計簿削除
+				billsCreate.POST("", handlers.CreateBillHandler)                     // 新規家計簿作成
+				billsCreate.PUT("/:id/items", handlers.UpdateItemsHandler)           // 家計簿項目更新
+				billsCreate.PUT("/:id/request", handlers.RequestBillHandler)         // 家計簿請求
+				billsCreate.PUT("/:id/acknowledge", handlers.AcknowledgeBillHandler) // 家計簿内容確認（支払者）
+				billsCreate.PUT("/:id/payment", handlers.PaymentBillHandler)         // 家計簿支払い確認
+				billsCreate.DELETE("/:id", handlers.DeleteBillHandler)               // 家計簿削除
+				billsCreate.POST("/batch", handlers.BatchBillsHandler)               // 家計簿一括操作（請求・支払い・削除）
+
+				// 支払い取り消し（請求者・支払者の双方の合意で実行）
+				billsCreate.POST("/:id/reversal", handlers.RequestPaymentReversalHandler)        // 取り消し申請
+				billsCreate.PUT("/:id/reversal/confirm", handlers.ConfirmPaymentReversalHandler) // 取り消し確認
+				billsCreate.PUT("/:id/reversal/reject", handlers.RejectPaymentReversalHandler)   // 取り消し拒否
+				billsCreate.DELETE("/:id/reversal", handlers.CancelPaymentReversalHandler)       // 取り消し申請撤回
+
+				// 入金明細の照合（請求者が銀行の入金明細CSVを取り込み、入金と家計簿を突き合わせて支払い済みにする）
+				billsCreate.POST("/reconciliations", handlers.ImportBankStatementHandler)              // 入金明細取り込み・照合候補取得
+				billsCreate.PUT("/reconciliations/:id/confirm", handlers.ConfirmReconciliationHandler) // 照合確定（支払い済みに変更）
+
+				// 振込ファイル（支払者が請求中の家計簿を選択し、全銀フォーマットの振込ファイルをダウンロード）
+				billsCreate.POST("/transfer-file", handlers.GenerateTransferFileHandler) // 振込ファイル作成
+			}
+		}
+
+		// 検索エンドポイント（認証が必要）
+		api.GET("/search", middleware.AuthMiddleware(), handlers.SearchHandler) // 家計簿項目・コメント検索
+
+		// 予算関連のエンドポイント（認証が必要）
+		budgets := api.Group("/budgets")
+		budgets.Use(middleware.AuthMiddleware())
+		{
+			budgets.GET("", handlers.GetBudgetsHandler)             // 予算一覧取得
+			budgets.GET("/status", handlers.GetBudgetStatusHandler) // 予算消化状況取得
+			budgets.GET("/alerts", handlers.GetBudgetAlertsHandler) // 予算アラート一覧取得
+
+			// 作成系操作には追加のレート制限
+			budgetsCreate := budgets.Group("")
+			budgetsCreate.Use(middleware.CreateRateLimitMiddleware())
+			{
+				budgetsCreate.POST("", handlers.CreateBudgetHandler)       // 予算作成
+				budgetsCreate.PUT("/:id", handlers.UpdateBudgetHandler)    // 予算更新
+				budgetsCreate.DELETE("/:id", handlers.DeleteBudgetHandler) // 予算削除
+			}
+		}
+
+		// 支払者招待リンク関連のエンドポイント（認証が必要）
+		partnerInvitations := api.Group("/partner-invitations")
+		partnerInvitations.Use(middleware.AuthMiddleware())
+		{
+			partnerInvitations.GET("", handlers.GetPartnerInvitationsHandler) // 発行済み招待リンク一覧取得
+
+			// 作成系操作には追加のレート制限
+			partnerInvitationsCreate := partnerInvitations.Group("")
+			partnerInvitationsCreate.Use(middleware.CreateRateLimitMiddleware())
+			{
+				partnerInvitationsCreate.POST("", handlers.CreatePartnerInvitationHandler)       // 招待リンク発行
+				partnerInvitationsCreate.DELETE("/:id", handlers.RevokePartnerInvitationHandler) // 招待リンク取り消し
+			}
+		}
+
+		// 連携済みパートナー関連のエンドポイント（認証が必要）
+		partners := api.Group("/partners")
+		partners.Use(middleware.AuthMiddleware())
+		{
+			partners.GET("", handlers.GetPartnersHandler)               // 連携済みパートナー一覧取得
+			partners.PUT("/:id", handlers.UpdatePartnerSettingsHandler) // パートナー設定更新
+		}
+
+		// 世帯関連のエンドポイント（認証が必要）
+		households := api.Group("/households")
+		households.Use(middleware.AuthMiddleware())
+		{
+			households.GET("", handlers.GetHouseholdsHandler)                           // 所属世帯一覧取得
+			households.GET("/invitations", handlers.GetMyInvitationsHandler)            // 受信した招待一覧取得
+			households.GET("/:id/invitations", handlers.GetHouseholdInvitationsHandler) // 世帯の招待一覧取得
+
+			// 作成系操作には追加のレート制限
+			householdsCreate := households.Group("")
+			householdsCreate.Use(middleware.CreateRateLimitMiddleware())
+			{
+				householdsCreate.POST("", handlers.CreateHouseholdHandler)                                            // 世帯作成
+				householdsCreate.POST("/:id/invitations", handlers.CreateHouseholdInvitationHandler)                  // 世帯への招待
+				householdsCreate.DELETE("/:id/invitations/:invitation_id", handlers.RevokeHouseholdInvitationHandler) // 招待取り消し
+				householdsCreate.PUT("/invitations/:id/accept", handlers.AcceptHouseholdInvitationHandler)            // 招待承諾
+				householdsCreate.PUT("/invitations/:id/decline", handlers.DeclineHouseholdInvitationHandler)          // 招待辞退
+				householdsCreate.DELETE("/:id/members/:user_id", handlers.RemoveHouseholdMemberHandler)               // メンバー削除・脱退
 			}
 		}
 	}
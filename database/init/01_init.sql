@@ -8,7 +8,13 @@ CREATE TABLE users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     account_id VARCHAR(50) UNIQUE NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
     password_hash VARCHAR(255) NOT NULL,
+    token_version INT UNSIGNED NOT NULL DEFAULT 0,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_secret TEXT NULL,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    bank_account TEXT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 ) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
@@ -17,16 +23,27 @@ CREATE TABLE monthly_bills (
     id INT AUTO_INCREMENT PRIMARY KEY,
     year INT NOT NULL,
     month INT NOT NULL,
+    period_type VARCHAR(10) NOT NULL DEFAULT 'month',
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    period_label VARCHAR(100) NOT NULL DEFAULT '',
     requester_id INT NOT NULL,
     payer_id INT NOT NULL,
-    status ENUM('pending', 'requested', 'paid') DEFAULT 'pending',
+    status ENUM('pending', 'requested', 'acknowledged', 'paid') DEFAULT 'pending',
     request_date TIMESTAMP NULL,
+    require_acknowledgement BOOLEAN NOT NULL DEFAULT FALSE,
+    acknowledged_at TIMESTAMP NULL,
+    acknowledgement_comment VARCHAR(500) NOT NULL DEFAULT '',
     payment_date TIMESTAMP NULL,
+    comment VARCHAR(1000) NOT NULL DEFAULT '',
+    tax_rounding VARCHAR(10) NOT NULL DEFAULT 'floor',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (requester_id) REFERENCES users(id),
     FOREIGN KEY (payer_id) REFERENCES users(id),
-    UNIQUE KEY unique_month_requester (year, month, requester_id)
+    UNIQUE KEY unique_period_requester (requester_id, period_type, period_start, period_end),
+    INDEX idx_monthly_bills_year_month (year, month),
+    FULLTEXT INDEX ft_monthly_bills_comment (comment) WITH PARSER ngram
 ) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
 
 CREATE TABLE bill_items (
@@ -34,7 +51,246 @@ CREATE TABLE bill_items (
     bill_id INT NOT NULL,
     item_name VARCHAR(255) NOT NULL,
     amount DECIMAL(10, 2) NOT NULL,
+    tax_rate TINYINT NOT NULL DEFAULT 10,
+    tax_exclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    split_rule VARCHAR(10) NOT NULL DEFAULT 'payer',
+    payer_share TINYINT NOT NULL DEFAULT 100,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (bill_id) REFERENCES monthly_bills(id) ON DELETE CASCADE
+    FOREIGN KEY (bill_id) REFERENCES monthly_bills(id) ON DELETE CASCADE,
+    FULLTEXT INDEX ft_bill_items_item_name (item_name) WITH PARSER ngram
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE budgets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    monthly_limit DECIMAL(10, 2) NOT NULL,
+    alert_threshold INT NOT NULL DEFAULT 80,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE KEY unique_user_category (user_id, category)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE budget_alerts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    budget_id INT NOT NULL,
+    user_id INT NOT NULL,
+    bill_id INT NOT NULL,
+    year INT NOT NULL,
+    month INT NOT NULL,
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    threshold INT NOT NULL,
+    used_amount DECIMAL(10, 2) NOT NULL,
+    limit_amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (budget_id) REFERENCES budgets(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE KEY unique_budget_period_threshold (budget_id, year, month, threshold)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE households (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_by INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE household_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    household_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (household_id) REFERENCES households(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE KEY unique_household_user (household_id, user_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE household_invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    household_id INT NOT NULL,
+    inviter_id INT NOT NULL,
+    invitee_id INT NOT NULL,
+    invitee_account_id VARCHAR(50) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    responded_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (household_id) REFERENCES households(id) ON DELETE CASCADE,
+    FOREIGN KEY (inviter_id) REFERENCES users(id),
+    FOREIGN KEY (invitee_id) REFERENCES users(id),
+    INDEX idx_household_invitations_invitee (invitee_id, status)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE partner_invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    inviter_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    used_by INT NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (inviter_id) REFERENCES users(id),
+    FOREIGN KEY (used_by) REFERENCES users(id),
+    UNIQUE KEY unique_token_hash (token_hash)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE payer_relationships (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    requester_id INT NOT NULL,
+    payer_id INT NOT NULL,
+    invitation_id INT NULL,
+    require_acknowledgement BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (requester_id) REFERENCES users(id),
+    FOREIGN KEY (payer_id) REFERENCES users(id),
+    FOREIGN KEY (invitation_id) REFERENCES partner_invitations(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_requester_payer (requester_id, payer_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE payment_reversals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    bill_id INT NOT NULL,
+    initiator_id INT NOT NULL,
+    confirmer_id INT NULL,
+    reason VARCHAR(500) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    original_payment_date TIMESTAMP NULL,
+    resolved_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (bill_id) REFERENCES monthly_bills(id) ON DELETE CASCADE,
+    FOREIGN KEY (initiator_id) REFERENCES users(id),
+    FOREIGN KEY (confirmer_id) REFERENCES users(id),
+    INDEX idx_payment_reversals_bill (bill_id, status)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- 入金明細の取り込みテーブル
+CREATE TABLE bank_statement_imports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    requester_id INT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    deposit_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_bank_statement_imports_requester (requester_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- 入金明細の入金テーブル
+CREATE TABLE bank_deposits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    import_id INT NOT NULL,
+    line_number INT NOT NULL,
+    deposit_date DATE NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    sender_name VARCHAR(255) NOT NULL DEFAULT '',
+    matched_bill_id INT NULL,
+    confidence DECIMAL(3, 2) NOT NULL DEFAULT 0,
+    matched_at TIMESTAMP NULL,
+    FOREIGN KEY (import_id) REFERENCES bank_statement_imports(id) ON DELETE CASCADE,
+    FOREIGN KEY (matched_bill_id) REFERENCES monthly_bills(id) ON DELETE SET NULL,
+    INDEX idx_bank_deposits_import (import_id, matched_bill_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- リフレッシュトークンテーブル（トークン本体はSHA-256ハッシュのみ保存）
+CREATE TABLE refresh_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    family_id VARCHAR(32) NOT NULL,
+    parent_id INT NULL,
+    token_version INT UNSIGNED NOT NULL DEFAULT 0,
+    access_token_id VARCHAR(32) NOT NULL DEFAULT '',
+    access_expires_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    revoked_reason VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_refresh_token_hash (token_hash),
+    INDEX idx_refresh_tokens_family (family_id),
+    INDEX idx_refresh_tokens_user (user_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- トークンのブラックリストテーブル（ログアウト等で無効化したトークン、SHA-256ハッシュのみ保存）
+CREATE TABLE token_blacklist (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_token_blacklist_hash (token_hash),
+    INDEX idx_token_blacklist_expires (expires_at)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- ログインセッションテーブル（IDはリフレッシュトークンの系列IDと同じ）
+CREATE TABLE user_sessions (
+    id VARCHAR(32) PRIMARY KEY,
+    user_id INT NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    current_token_id VARCHAR(32) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    revoked_reason VARCHAR(50) NOT NULL DEFAULT '',
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_sessions_user (user_id, revoked_at)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- 2段階認証のリカバリーコード（ハッシュのみ保存、1回限り使用可能）
+CREATE TABLE recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_recovery_codes_user (user_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- 2段階認証のログインチャレンジ（パスワード認証後に発行する短時間有効なトークン）
+CREATE TABLE two_factor_challenges (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_two_factor_challenges_user (user_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- パスワード再設定トークン（ハッシュのみ保存、1回限り使用可能）
+CREATE TABLE password_reset_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_password_reset_tokens_user (user_id)
+) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+-- ログイン失敗の記録（アカウントIDのハッシュごと、存在しないアカウントIDも記録）
+CREATE TABLE login_attempts (
+    account_key VARCHAR(64) PRIMARY KEY,
+    failed_count INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_login_attempts_last_failed (last_failed_at)
 ) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
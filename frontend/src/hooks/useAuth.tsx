@@ -6,13 +6,21 @@ import React, {
   ReactNode,
 } from "react";
 import { useNavigate } from "react-router-dom";
-import { User, LoginRequest, RegisterRequest } from "../types";
+import {
+  User,
+  LoginRequest,
+  LoginResponse,
+  RegisterRequest,
+  TwoFactorChallengeResponse,
+} from "../types";
 import { api } from "../services/api";
 
 interface AuthContextType {
   user: User | null;
   loading: boolean;
-  login: (data: LoginRequest) => Promise<void>;
+  // 2段階認証が有効な場合はチャレンジを返す（loginTwoFactorで認証コードを送信する）
+  login: (data: LoginRequest) => Promise<TwoFactorChallengeResponse | void>;
+  loginTwoFactor: (challengeToken: string, code: string) => Promise<void>;
   register: (data: RegisterRequest) => Promise<void>;
   logout: () => void;
 }
@@ -32,6 +40,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         .then(setUser)
         .catch(() => {
           localStorage.removeItem("token");
+          localStorage.removeItem("refresh_token");
         })
         .finally(() => setLoading(false));
     } else {
@@ -39,17 +48,34 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   }, []);
 
-  const login = async (data: LoginRequest) => {
-    const response = await api.login(data);
+  const completeLogin = (response: LoginResponse) => {
     localStorage.setItem("token", response.token);
+    localStorage.setItem("refresh_token", response.refresh_token);
     setUser(response.user);
     // ログイン成功後は必ず家計簿一覧へリダイレクト
     navigate("/bills", { replace: true });
   };
 
+  const login = async (data: LoginRequest) => {
+    const response = await api.login(data);
+    if ("two_factor_required" in response) {
+      return response;
+    }
+    completeLogin(response);
+  };
+
+  const loginTwoFactor = async (challengeToken: string, code: string) => {
+    const response = await api.loginTwoFactor({
+      challenge_token: challengeToken,
+      code,
+    });
+    completeLogin(response);
+  };
+
   const register = async (data: RegisterRequest) => {
     const response = await api.register(data);
     localStorage.setItem("token", response.token);
+    localStorage.setItem("refresh_token", response.refresh_token);
     setUser(response.user);
     // 登録成功後も家計簿一覧へリダイレクト
     navigate("/bills", { replace: true });
@@ -57,13 +83,16 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
   const logout = () => {
     localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
     setUser(null);
     // ログアウト時にURLをルートにリセット
     navigate("/", { replace: true });
   };
 
   return (
-    <AuthContext.Provider value={{ user, loading, login, register, logout }}>
+    <AuthContext.Provider
+      value={{ user, loading, login, loginTwoFactor, register, logout }}
+    >
       {children}
     </AuthContext.Provider>
   );
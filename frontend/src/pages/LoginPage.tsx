@@ -1,15 +1,37 @@
-import React, { useState } from "react";
+import React, { useEffect, useState } from "react";
 import { useAuth } from "../hooks/useAuth";
+import { api, ApiError } from "../services/api";
+import { PasswordPolicy } from "../types";
+import { passwordErrorMessage } from "../utils/passwordPolicy";
 
 export default function LoginPage() {
-  const { login, register } = useAuth();
+  const { login, loginTwoFactor, register } = useAuth();
   const [isRegistering, setIsRegistering] = useState(false);
   const [name, setName] = useState("");
   const [accountId, setAccountId] = useState("");
   const [password, setPassword] = useState("");
   const [confirmPassword, setConfirmPassword] = useState("");
+  // 2段階認証のチャレンジトークン（パスワード認証後、認証コードの入力待ち）
+  const [challengeToken, setChallengeToken] = useState("");
+  const [twoFactorCode, setTwoFactorCode] = useState("");
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState("");
+  // パスワードの要件（登録時の入力欄の案内に使用し、検証はサーバーで行う）
+  const [passwordPolicy, setPasswordPolicy] = useState<PasswordPolicy | null>(
+    null,
+  );
+
+  useEffect(() => {
+    if (!isRegistering || passwordPolicy) {
+      return;
+    }
+    api
+      .getPasswordPolicy()
+      .then(({ policy }) => setPasswordPolicy(policy))
+      .catch(() => {
+        // 取得できない場合は案内を表示せず、登録時のサーバーの検証エラーを表示する
+      });
+  }, [isRegistering, passwordPolicy]);
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
@@ -21,20 +43,26 @@ export default function LoginPage() {
         if (password !== confirmPassword) {
           throw new Error("パスワードが一致しません");
         }
-        if (password.length < 6) {
-          throw new Error("パスワードは6文字以上で入力してください");
-        }
         await register({ name, account_id: accountId, password });
+      } else if (challengeToken) {
+        await loginTwoFactor(challengeToken, twoFactorCode);
       } else {
-        await login({ account_id: accountId, password });
+        const challenge = await login({ account_id: accountId, password });
+        if (challenge) {
+          setChallengeToken(challenge.challenge_token);
+        }
       }
     } catch (err) {
+      // パスワードポリシーの違反はルールごとのメッセージを表示する
+      const policyMessage =
+        err instanceof ApiError ? passwordErrorMessage(err.fields) : null;
       setError(
-        err instanceof Error
-          ? err.message
-          : isRegistering
-            ? "アカウント登録に失敗しました"
-            : "ログインに失敗しました",
+        policyMessage ??
+          (err instanceof Error
+            ? err.message
+            : isRegistering
+              ? "アカウント登録に失敗しました"
+              : "ログインに失敗しました"),
       );
     } finally {
       setLoading(false);
@@ -58,95 +86,121 @@ export default function LoginPage() {
 
         <form className="mt-8 space-y-6" onSubmit={handleSubmit}>
           {error && (
-            <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-md">
+            <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-md whitespace-pre-line">
               {error}
             </div>
           )}
 
-          <div className="space-y-4">
-            {isRegistering && (
-              <div>
-                <label
-                  htmlFor="name"
-                  className="block text-sm font-medium text-gray-700"
-                >
-                  お名前
-                </label>
-                <input
-                  id="name"
-                  name="name"
-                  type="text"
-                  required
-                  className="form-input mt-1"
-                  placeholder="山田太郎"
-                  value={name}
-                  onChange={(e) => setName(e.target.value)}
-                />
-              </div>
-            )}
-
+          {challengeToken ? (
             <div>
               <label
-                htmlFor="accountId"
+                htmlFor="twoFactorCode"
                 className="block text-sm font-medium text-gray-700"
               >
-                アカウントID
+                認証コード
               </label>
               <input
-                id="accountId"
-                name="accountId"
+                id="twoFactorCode"
+                name="twoFactorCode"
                 type="text"
                 required
+                autoComplete="one-time-code"
                 className="form-input mt-1"
-                placeholder="英数字とアンダースコア、3-20文字"
-                value={accountId}
-                onChange={(e) => setAccountId(e.target.value)}
-                minLength={3}
-                maxLength={20}
-                pattern="[a-zA-Z0-9_]+"
+                placeholder="認証アプリの6桁のコードまたはリカバリーコード"
+                value={twoFactorCode}
+                onChange={(e) => setTwoFactorCode(e.target.value)}
               />
             </div>
+          ) : (
+            <div className="space-y-4">
+              {isRegistering && (
+                <div>
+                  <label
+                    htmlFor="name"
+                    className="block text-sm font-medium text-gray-700"
+                  >
+                    お名前
+                  </label>
+                  <input
+                    id="name"
+                    name="name"
+                    type="text"
+                    required
+                    className="form-input mt-1"
+                    placeholder="山田太郎"
+                    value={name}
+                    onChange={(e) => setName(e.target.value)}
+                  />
+                </div>
+              )}
 
-            <div>
-              <label
-                htmlFor="password"
-                className="block text-sm font-medium text-gray-700"
-              >
-                パスワード
-              </label>
-              <input
-                id="password"
-                name="password"
-                type="password"
-                required
-                className="form-input mt-1"
-                placeholder="6文字以上"
-                value={password}
-                onChange={(e) => setPassword(e.target.value)}
-              />
-            </div>
+              <div>
+                <label
+                  htmlFor="accountId"
+                  className="block text-sm font-medium text-gray-700"
+                >
+                  アカウントID
+                </label>
+                <input
+                  id="accountId"
+                  name="accountId"
+                  type="text"
+                  required
+                  className="form-input mt-1"
+                  placeholder="英数字とアンダースコア、3-20文字"
+                  value={accountId}
+                  onChange={(e) => setAccountId(e.target.value)}
+                  minLength={3}
+                  maxLength={20}
+                  pattern="[a-zA-Z0-9_]+"
+                />
+              </div>
 
-            {isRegistering && (
               <div>
                 <label
-                  htmlFor="confirmPassword"
+                  htmlFor="password"
                   className="block text-sm font-medium text-gray-700"
                 >
-                  パスワード（確認）
+                  パスワード
                 </label>
                 <input
-                  id="confirmPassword"
-                  name="confirmPassword"
+                  id="password"
+                  name="password"
                   type="password"
                   required
                   className="form-input mt-1"
-                  placeholder="パスワードを再入力"
-                  value={confirmPassword}
-                  onChange={(e) => setConfirmPassword(e.target.value)}
+                  placeholder={
+                    isRegistering && passwordPolicy
+                      ? `${passwordPolicy.min_length}文字以上`
+                      : "パスワード"
+                  }
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
                 />
               </div>
-            )}
-          </div>
+
+              {isRegistering && (
+                <div>
+                  <label
+                    htmlFor="confirmPassword"
+                    className="block text-sm font-medium text-gray-700"
+                  >
+                    パスワード（確認）
+                  </label>
+                  <input
+                    id="confirmPassword"
+                    name="confirmPassword"
+                    type="password"
+                    required
+                    className="form-input mt-1"
+                    placeholder="パスワードを再入力"
+                    value={confirmPassword}
+                    onChange={(e) => setConfirmPassword(e.target.value)}
+                  />
+                </div>
+              )}
+            </div>
+          )}
 
           <button
             type="submit"
@@ -171,6 +225,8 @@ export default function LoginPage() {
               onClick={() => {
                 setIsRegistering(!isRegistering);
                 setError("");
+                setChallengeToken("");
+                setTwoFactorCode("");
                 setName("");
                 setAccountId("");
                 setPassword("");
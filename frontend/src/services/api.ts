@@ -2,25 +2,62 @@ import {
   LoginRequest,
   RegisterRequest,
   LoginResponse,
+  TwoFactorChallengeResponse,
+  TwoFactorLoginRequest,
+  ChangePasswordRequest,
+  PasswordResetConfirmRequest,
+  UpdateEmailRequest,
+  PasswordPolicy,
+  FieldError,
   BillResponse,
   User,
 } from "../types";
 
 const API_BASE = "/api";
 
-class ApiError extends Error {
+export class ApiError extends Error {
   constructor(
     public status: number,
     message: string,
+    public fields: FieldError[] = [],
   ) {
     super(message);
     this.name = "ApiError";
   }
 }
 
+// 同時に複数のリクエストが401になった場合も、トークンの再発行は1回だけ行う
+let refreshPromise: Promise<boolean> | null = null;
+
+// リフレッシュトークンでアクセストークンを再発行する（リフレッシュトークンは1回限りのため、新しいものに置き換える）
+async function refreshAccessToken(): Promise<boolean> {
+  const refreshToken = localStorage.getItem("refresh_token");
+  if (!refreshToken) {
+    return false;
+  }
+
+  const response = await fetch(`${API_BASE}/auth/refresh`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ refresh_token: refreshToken }),
+  }).catch(() => null);
+
+  if (!response || !response.ok) {
+    localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
+    return false;
+  }
+
+  const data: LoginResponse = await response.json();
+  localStorage.setItem("token", data.token);
+  localStorage.setItem("refresh_token", data.refresh_token);
+  return true;
+}
+
 async function apiRequest<T>(
   endpoint: string,
   options: RequestInit = {},
+  retry = true,
 ): Promise<T> {
   const token = localStorage.getItem("token");
 
@@ -33,6 +70,18 @@ async function apiRequest<T>(
     ...options,
   });
 
+  // アクセストークンの期限切れは、トークンを再発行して1回だけ再試行する
+  if (response.status === 401 && retry && token) {
+    if (!refreshPromise) {
+      refreshPromise = refreshAccessToken().finally(() => {
+        refreshPromise = null;
+      });
+    }
+    if (await refreshPromise) {
+      return apiRequest<T>(endpoint, options, false);
+    }
+  }
+
   if (!response.ok) {
     const errorData = await response
       .json()
@@ -40,6 +89,7 @@ async function apiRequest<T>(
     throw new ApiError(
       response.status,
       errorData.error || "リクエストが失敗しました",
+      errorData.fields ?? [],
     );
   }
 
@@ -48,12 +98,20 @@ async function apiRequest<T>(
 
 export const api = {
   // 認証
-  login: (data: LoginRequest): Promise<LoginResponse> =>
+  login: (
+    data: LoginRequest,
+  ): Promise<LoginResponse | TwoFactorChallengeResponse> =>
     apiRequest("/auth/login", {
       method: "POST",
       body: JSON.stringify(data),
     }),
 
+  loginTwoFactor: (data: TwoFactorLoginRequest): Promise<LoginResponse> =>
+    apiRequest("/auth/login/2fa", {
+      method: "POST",
+      body: JSON.stringify(data),
+    }),
+
   register: (data: RegisterRequest): Promise<LoginResponse> =>
     apiRequest("/auth/register", {
       method: "POST",
@@ -62,6 +120,41 @@ export const api = {
 
   getMe: (): Promise<User> => apiRequest("/auth/me"),
 
+  // パスワード変更（変更前のトークンは無効になるため、返されたトークンに置き換える）
+  changePassword: async (data: ChangePasswordRequest): Promise<User> => {
+    const response: LoginResponse = await apiRequest("/auth/password", {
+      method: "PUT",
+      body: JSON.stringify(data),
+    });
+    localStorage.setItem("token", response.token);
+    localStorage.setItem("refresh_token", response.refresh_token);
+    return response.user;
+  },
+
+  // メールアドレス変更（パスワード再設定の案内の送信先）
+  updateEmail: (data: UpdateEmailRequest): Promise<User> =>
+    apiRequest("/auth/email", {
+      method: "PUT",
+      body: JSON.stringify(data),
+    }),
+
+  requestPasswordReset: (accountId: string): Promise<{ message: string }> =>
+    apiRequest("/auth/password/forgot", {
+      method: "POST",
+      body: JSON.stringify({ account_id: accountId }),
+    }),
+
+  resetPassword: (
+    data: PasswordResetConfirmRequest,
+  ): Promise<{ message: string }> =>
+    apiRequest("/auth/password/reset", {
+      method: "POST",
+      body: JSON.stringify(data),
+    }),
+
+  getPasswordPolicy: (): Promise<{ policy: PasswordPolicy }> =>
+    apiRequest("/auth/password-policy"),
+
   // ユーザー
   getUsers: (): Promise<{ users: User[] }> => apiRequest("/users"),
 
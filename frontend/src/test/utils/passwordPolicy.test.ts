@@ -0,0 +1,55 @@
+import { describe, it, expect } from "vitest";
+import {
+  passwordRuleMessage,
+  passwordErrorMessage,
+} from "../../utils/passwordPolicy";
+import { FieldError } from "../../types";
+
+describe("passwordRuleMessage 関数", () => {
+  it("ルールの値を含むメッセージを返す", () => {
+    const error: FieldError = {
+      field: "password",
+      rule: "password_min_length",
+      message: "パスワードは12文字以上で入力してください",
+      params: { min: 12 },
+    };
+    expect(passwordRuleMessage(error)).toBe(
+      "パスワードは12文字以上で入力してください",
+    );
+  });
+
+  it("未知のルールはサーバーのメッセージを返す", () => {
+    const error: FieldError = {
+      field: "password",
+      rule: "password_future_rule",
+      message: "サーバーのメッセージ",
+    };
+    expect(passwordRuleMessage(error)).toBe("サーバーのメッセージ");
+  });
+});
+
+describe("passwordErrorMessage 関数", () => {
+  it("パスワードの違反のみを改行でまとめる", () => {
+    const fields: FieldError[] = [
+      { field: "name", rule: "required", message: "名前は必須です" },
+      {
+        field: "password",
+        rule: "password_breached",
+        message: "このパスワードは使用できません",
+      },
+      {
+        field: "password",
+        rule: "password_character_classes",
+        message: "文字種が不足しています",
+        params: { min: 2 },
+      },
+    ];
+    expect(passwordErrorMessage(fields)).toBe(
+      "よく使われているパスワードのため使用できません。推測されにくいパスワードにしてください\n英小文字・英大文字・数字・記号のうち2種類以上を含めてください",
+    );
+  });
+
+  it("パスワードの違反がない場合はnullを返す", () => {
+    expect(passwordErrorMessage([])).toBeNull();
+  });
+});
@@ -2,6 +2,7 @@ export interface User {
   id: number;
   name: string;
   account_id: string;
+  email?: string; // /auth/meなど本人向けのレスポンスのみ
   created_at: string;
   updated_at: string;
 }
@@ -44,9 +45,57 @@ export interface RegisterRequest {
   name: string;
   account_id: string;
   password: string;
+  // パスワード再設定の連絡先（任意）
+  email?: string;
+}
+
+export interface ChangePasswordRequest {
+  current_password: string;
+  new_password: string;
+}
+
+export interface UpdateEmailRequest {
+  current_password: string;
+  email: string;
+}
+
+export interface PasswordResetConfirmRequest {
+  token: string;
+  new_password: string;
+}
+
+// パスワードの要件（GET /api/auth/password-policy）
+export interface PasswordPolicy {
+  min_length: number;
+  max_bytes: number;
+  min_character_classes: number;
+  reject_breached: boolean;
+}
+
+// 入力値の検証エラー（ruleはフロントエンドで表示を切り替えるためのコード）
+export interface FieldError {
+  field: string;
+  rule: string;
+  message: string;
+  params?: Record<string, number>;
 }
 
 export interface LoginResponse {
   token: string;
+  expires_at: string;
+  refresh_token: string;
+  refresh_token_expires_at: string;
   user: User;
 }
+
+// 2段階認証が有効なユーザーのログイン応答（認証コードの入力が必要）
+export interface TwoFactorChallengeResponse {
+  two_factor_required: true;
+  challenge_token: string;
+  challenge_expires_at: string;
+}
+
+export interface TwoFactorLoginRequest {
+  challenge_token: string;
+  code: string;
+}
@@ -0,0 +1,37 @@
+import { FieldError } from "../types";
+
+/**
+ * パスワードポリシーの違反をルールのコードから表示用のメッセージにする
+ * 未知のルールはサーバーのメッセージをそのまま使用する
+ * @param error 検証エラー
+ * @returns 表示用のメッセージ
+ */
+export const passwordRuleMessage = (error: FieldError): string => {
+  const params = error.params ?? {};
+  switch (error.rule) {
+    case "password_min_length":
+      return `パスワードは${params.min}文字以上で入力してください`;
+    case "password_max_bytes":
+      return `パスワードが長すぎます（全角文字は1文字3バイトとして${params.max}バイト以内）`;
+    case "password_character_classes":
+      return `英小文字・英大文字・数字・記号のうち${params.min}種類以上を含めてください`;
+    case "password_breached":
+      return "よく使われているパスワードのため使用できません。推測されにくいパスワードにしてください";
+    case "password_invalid_characters":
+      return "パスワードに使用できない文字が含まれています";
+    default:
+      return error.message;
+  }
+};
+
+/**
+ * 検証エラーの一覧からパスワードの違反をまとめたメッセージを生成する
+ * @param fields 検証エラーの一覧
+ * @returns パスワードの違反がない場合はnull
+ */
+export const passwordErrorMessage = (fields: FieldError[]): string | null => {
+  const messages = fields
+    .filter((field) => field.rule.startsWith("password_"))
+    .map(passwordRuleMessage);
+  return messages.length > 0 ? messages.join("\n") : null;
+};
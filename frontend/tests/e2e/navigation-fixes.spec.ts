@@ -64,8 +64,8 @@ test.describe("ナビゲーション修正の検証", () => {
     const timestamp = Date.now();
     await page.fill("#name", "テストユーザー2");
     await page.fill("#accountId", `test_user_${timestamp}`);
-    await page.fill("#password", "password123");
-    await page.fill("#confirmPassword", "password123");
+    await page.fill("#password", "correct-horse-42");
+    await page.fill("#confirmPassword", "correct-horse-42");
 
     // 新規登録実行
     await page.getByRole("button", { name: "アカウントを作成" }).click();